@@ -3,9 +3,13 @@ package main
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/Andydev0/filmes-backend/internal/api"
+	"github.com/Andydev0/filmes-backend/internal/auth"
 	"github.com/Andydev0/filmes-backend/internal/database"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
 	"github.com/joho/godotenv"
 )
 
@@ -30,17 +34,82 @@ func main() {
 		log.Fatal("A variável de ambiente TMDB_API_KEY é obrigatória.")
 	}
 
-	// Lê o segredo do JWT do ambiente.
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		log.Fatal("A variável de ambiente JWT_SECRET é obrigatória.")
+	// Carrega as chaves de assinatura do JWT a partir do ambiente.
+	chaves, err := auth.CarregarDoAmbiente()
+	if err != nil {
+		log.Fatalf("Falha ao carregar as chaves JWT: %v", err)
+	}
+
+	// Carrega os provedores OpenID Connect habilitados para login social (opcional).
+	provedores, err := auth.CarregarProvedoresOIDCDoAmbiente()
+	if err != nil {
+		log.Fatalf("Falha ao configurar os provedores OIDC: %v", err)
+	}
+
+	// Carrega o limite de tamanho e o filtro de conteúdo das avaliações.
+	configAvaliacoes, err := servico.CarregarConfigAvaliacoesDoAmbiente()
+	if err != nil {
+		log.Fatalf("Falha ao configurar o filtro de avaliações: %v", err)
+	}
+
+	// Configura o envio dos e-mails de confirmação (SMTP ou apenas log).
+	configEmail, err := servico.CarregarConfigEmailDoAmbiente()
+	if err != nil {
+		log.Fatalf("Falha ao configurar o envio de e-mails: %v", err)
+	}
+
+	// Escolhe como as notificações novas chegam às conexões abertas (uma ou várias instâncias).
+	difusor, err := servico.CarregarDifusorDoAmbiente(repositorio.NovaNotificacaoRepositorio(db))
+	if err != nil {
+		log.Fatalf("Falha ao configurar o difusor de notificações: %v", err)
+	}
+
+	// Importações em andamento quando o servidor parou não são retomadas; o usuário pode reenviá-las.
+	interrompidas, err := repositorio.NovaImportacaoRepositorio(db).InterromperEmAndamento("importação interrompida pelo reinício do servidor; envie os arquivos novamente")
+	if err != nil {
+		log.Fatalf("Falha ao encerrar importações interrompidas: %v", err)
+	} else if interrompidas > 0 {
+		log.Printf("%d importação(ões) interrompida(s) pelo reinício do servidor.", interrompidas)
 	}
 
+	// Apaga periodicamente as contas cujo prazo de carência para exclusão terminou.
+	go excluirContasAgendadas(repositorio.NovoUsuarioRepositorio(db), time.Hour)
+
+	// Mantém título e pôster dos filmes guardados em listas de acordo com o catálogo do TMDB.
+	catalogoServico := servico.NovoCatalogoServico(repositorio.NovoCatalogoRepositorio(db), servico.NovoFilmeServico(chaveAPI))
+	go sincronizarCatalogo(catalogoServico, time.Hour)
+
 	// Passa as configurações e a conexão com o banco para o roteador.
-	roteador := api.SetupRouter(chaveAPI, db, jwtSecret)
+	roteador := api.SetupRouter(chaveAPI, db, chaves, provedores, configAvaliacoes, configEmail, difusor)
 
 	log.Println("Servidor iniciado na porta 8080")
 	if err := roteador.Run(":8080"); err != nil {
 		log.Fatalf("Falha ao iniciar o servidor: %v", err)
 	}
 }
+
+// excluirContasAgendadas remove, a cada intervalo, as contas com exclusão vencida.
+func excluirContasAgendadas(repo repositorio.UsuarioRepositorio, intervalo time.Duration) {
+	for {
+		excluidas, err := repo.ExcluirAgendados(time.Now())
+		if err != nil {
+			log.Printf("Falha ao excluir contas agendadas: %v", err)
+		} else if excluidas > 0 {
+			log.Printf("%d conta(s) excluída(s) após o prazo de carência.", excluidas)
+		}
+		time.Sleep(intervalo)
+	}
+}
+
+// sincronizarCatalogo atualiza, a cada intervalo, um lote de filmes com metadados antigos.
+func sincronizarCatalogo(catalogo servico.CatalogoServico, intervalo time.Duration) {
+	for {
+		alterados, err := catalogo.Sincronizar(200)
+		if err != nil {
+			log.Printf("Falha ao sincronizar o catálogo: %v", err)
+		} else if alterados > 0 {
+			log.Printf("%d item(ns) de lista atualizado(s) com os dados do catálogo.", alterados)
+		}
+		time.Sleep(intervalo)
+	}
+}
@@ -54,17 +54,56 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	// Chama o serviço para validar as credenciais e gerar o token.
-	token, err := h.servico.Login(input)
+	resposta, err := h.servico.Login(input, infoCliente(c))
 	if err != nil {
 		// Retorna 401 Unauthorized se as credenciais estiverem erradas.
 		if err == servico.ErrCredenciaisInvalidas {
 			c.JSON(http.StatusUnauthorized, gin.H{"erro": err.Error()})
 			return
 		}
+		if err == servico.ErrContaSuspensa {
+			c.JSON(http.StatusForbidden, gin.H{"erro": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao realizar login"})
 		return
 	}
 
-	// Se o login for bem-sucedido, retorna o token.
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	// Se o login for bem-sucedido, retorna o token (ou o desafio do 2FA).
+	c.JSON(http.StatusOK, resposta)
+}
+
+// Login2FA manipula o segundo passo do login para contas com 2FA ativo.
+func (h *AuthHandler) Login2FA(c *gin.Context) {
+	var input servico.Login2FAInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Dados de entrada inválidos"})
+		return
+	}
+
+	resposta, err := h.servico.ConcluirLogin2FA(input, infoCliente(c))
+	if err != nil {
+		switch err {
+		case servico.ErrDesafioInvalido, servico.ErrCodigoInvalido:
+			c.JSON(http.StatusUnauthorized, gin.H{"erro": err.Error()})
+		case servico.ErrMuitasTentativas:
+			c.JSON(http.StatusTooManyRequests, gin.H{"erro": err.Error()})
+		case servico.ErrContaSuspensa:
+			c.JSON(http.StatusForbidden, gin.H{"erro": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao realizar login"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, resposta)
+}
+
+// infoCliente extrai da requisição os dados do dispositivo registrados na sessão.
+func infoCliente(c *gin.Context) servico.InfoCliente {
+	return servico.InfoCliente{
+		Dispositivo: c.Request.UserAgent(),
+		IP:          c.ClientIP(),
+	}
 }
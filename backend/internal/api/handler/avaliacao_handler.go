@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -29,28 +30,299 @@ func (h *AvaliacaoHandler) Criar(c *gin.Context) {
 		return
 	}
 
-	if err := h.servico.Criar(usuarioID, filmeID, input); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao salvar avaliação"})
+	avaliacao, _, err := h.servico.Salvar(usuarioID, filmeID, input)
+	if err != nil {
+		responderErroAvaliacao(c, err, "Falha ao salvar avaliação")
+		return
+	}
+	if avaliacao.Situacao == dominio.SituacaoPendente {
+		c.Status(http.StatusAccepted)
 		return
 	}
 	c.Status(http.StatusCreated)
 }
 
+// BuscarMinha lida com a rota GET /filmes/:id/avaliacoes/minha.
+func (h *AvaliacaoHandler) BuscarMinha(c *gin.Context) {
+	filmeID, ok := parametroID(c, "id", "ID de filme inválido")
+	if !ok {
+		return
+	}
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	avaliacao, err := h.servico.BuscarDoUsuario(usuarioID, filmeID)
+	if err != nil {
+		responderErroAvaliacao(c, err, "Falha ao buscar avaliação")
+		return
+	}
+	c.JSON(http.StatusOK, avaliacao)
+}
+
+// SalvarMinha lida com a rota PUT /filmes/:id/avaliacoes/minha: cria (201) ou edita (200) a
+// avaliação do usuário, mantendo a data de criação. Responde 202 quando o filtro de conteúdo a
+// reteve para moderação.
+func (h *AvaliacaoHandler) SalvarMinha(c *gin.Context) {
+	filmeID, ok := parametroID(c, "id", "ID de filme inválido")
+	if !ok {
+		return
+	}
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	var input servico.AvaliacaoInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		return
+	}
+
+	avaliacao, criada, err := h.servico.Salvar(usuarioID, filmeID, input)
+	if err != nil {
+		responderErroAvaliacao(c, err, "Falha ao salvar avaliação")
+		return
+	}
+	if avaliacao.Situacao == dominio.SituacaoPendente {
+		c.JSON(http.StatusAccepted, avaliacao)
+		return
+	}
+	if criada {
+		c.JSON(http.StatusCreated, avaliacao)
+		return
+	}
+	c.JSON(http.StatusOK, avaliacao)
+}
+
+// ExcluirMinha lida com a rota DELETE /filmes/:id/avaliacoes/minha.
+func (h *AvaliacaoHandler) ExcluirMinha(c *gin.Context) {
+	filmeID, ok := parametroID(c, "id", "ID de filme inválido")
+	if !ok {
+		return
+	}
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	if err := h.servico.Excluir(usuarioID, filmeID); err != nil {
+		responderErroAvaliacao(c, err, "Falha ao excluir avaliação")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Historico lida com a rota de moderação GET /moderacao/avaliacoes/:id/historico.
+func (h *AvaliacaoHandler) Historico(c *gin.Context) {
+	avaliacaoID, ok := parametroID(c, "id", "ID de avaliação inválido")
+	if !ok {
+		return
+	}
+
+	historico, err := h.servico.Historico(avaliacaoID)
+	if err != nil {
+		responderErroAvaliacao(c, err, "Falha ao buscar o histórico da avaliação")
+		return
+	}
+	c.JSON(http.StatusOK, historico)
+}
+
+// ListarPendentes lida com a rota de moderação GET /moderacao/avaliacoes/pendentes.
+func (h *AvaliacaoHandler) ListarPendentes(c *gin.Context) {
+	pendentes, err := h.servico.ListarPendentes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao listar as avaliações pendentes"})
+		return
+	}
+	if pendentes == nil {
+		pendentes = make([]dominio.AvaliacaoPendente, 0)
+	}
+	c.JSON(http.StatusOK, pendentes)
+}
+
+// Aprovar lida com a rota de moderação POST /moderacao/avaliacoes/:id/aprovar.
+func (h *AvaliacaoHandler) Aprovar(c *gin.Context) {
+	avaliacaoID, ok := parametroID(c, "id", "ID de avaliação inválido")
+	if !ok {
+		return
+	}
+
+	if err := h.servico.Aprovar(avaliacaoID); err != nil {
+		responderErroAvaliacao(c, err, "Falha ao aprovar a avaliação")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Rejeitar lida com a rota de moderação POST /moderacao/avaliacoes/:id/rejeitar.
+func (h *AvaliacaoHandler) Rejeitar(c *gin.Context) {
+	avaliacaoID, ok := parametroID(c, "id", "ID de avaliação inválido")
+	if !ok {
+		return
+	}
+
+	if err := h.servico.Rejeitar(avaliacaoID); err != nil {
+		responderErroAvaliacao(c, err, "Falha ao rejeitar a avaliação")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Reagir lida com a rota PUT /avaliacoes/:id/reacao.
+func (h *AvaliacaoHandler) Reagir(c *gin.Context) {
+	avaliacaoID, ok := parametroID(c, "id", "ID de avaliação inválido")
+	if !ok {
+		return
+	}
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	var input servico.ReacaoInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Dados de entrada inválidos"})
+		return
+	}
+
+	if err := h.servico.Reagir(usuarioID, avaliacaoID, input); err != nil {
+		responderErroAvaliacao(c, err, "Falha ao registrar a reação")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RemoverReacao lida com a rota DELETE /avaliacoes/:id/reacao.
+func (h *AvaliacaoHandler) RemoverReacao(c *gin.Context) {
+	avaliacaoID, ok := parametroID(c, "id", "ID de avaliação inválido")
+	if !ok {
+		return
+	}
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	if err := h.servico.RemoverReacao(usuarioID, avaliacaoID); err != nil {
+		responderErroAvaliacao(c, err, "Falha ao remover a reação")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// responderErroAvaliacao traduz os erros do serviço de avaliações para o status HTTP adequado.
+func responderErroAvaliacao(c *gin.Context, err error, mensagem string) {
+	var rejeitada *servico.ErroAvaliacaoRejeitada
+	if errors.As(err, &rejeitada) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"erro": err.Error(), "motivos": rejeitada.Motivos})
+		return
+	}
+	var longo *servico.ErroComentarioLongo
+	if errors.As(err, &longo) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"erro": err.Error()})
+		return
+	}
+
+	switch err {
+	case servico.ErrAvaliacaoNaoEncontrada, servico.ErrReacaoNaoEncontrada:
+		c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+	case servico.ErrReacaoPropria:
+		c.JSON(http.StatusForbidden, gin.H{"erro": err.Error()})
+	case servico.ErrNotaInvalida:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"erro": err.Error()})
+	case servico.ErrAvaliacaoNaoPendente:
+		c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": mensagem})
+	}
+}
+
+// ListarPorFilme lida com a rota GET /filmes/:id/avaliacoes?ordenar=&mostrarSpoilers=&cursor=&limite=;
+// o cursor da próxima página vai no cabeçalho X-Proximo-Cursor, ausente na última.
 func (h *AvaliacaoHandler) ListarPorFilme(c *gin.Context) {
 	filmeID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"erro": "ID de filme inválido"})
 		return
 	}
+	limite, ok := consultaPositiva(c, "limite", "Limite inválido")
+	if !ok {
+		return
+	}
 
-	avaliacoes, err := h.servico.ListarPorFilme(filmeID)
+	consulta := servico.ConsultaAvaliacoes{
+		Ordenar:         c.Query("ordenar"),
+		MostrarSpoilers: c.Query("mostrarSpoilers") == "true",
+		Cursor:          c.Query("cursor"),
+		Limite:          limite,
+	}
+	avaliacoes, proximo, err := h.servico.ListarPorFilme(filmeID, consulta, c.GetInt64("usuarioID"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao buscar avaliações"})
+		switch err {
+		case servico.ErrOrdenacaoAvaliacoes, servico.ErrCursorAvaliacoesInvalido:
+			c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao buscar avaliações"})
+		}
 		return
 	}
 
 	if avaliacoes == nil {
 		avaliacoes = make([]dominio.AvaliacaoComUsuario, 0)
 	}
+	if proximo != "" {
+		c.Header("X-Proximo-Cursor", proximo)
+	}
 	c.JSON(http.StatusOK, avaliacoes)
 }
+
+// Estatisticas lida com a rota GET /filmes/:id/estatisticas. Com login, inclui a nota do usuário.
+func (h *AvaliacaoHandler) Estatisticas(c *gin.Context) {
+	filmeID, ok := parametroID(c, "id", "ID de filme inválido")
+	if !ok {
+		return
+	}
+	usuarioID := c.GetInt64("usuarioID")
+
+	estatisticas, err := h.servico.Estatisticas(filmeID, usuarioID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao calcular as estatísticas do filme"})
+		return
+	}
+	c.JSON(http.StatusOK, estatisticas)
+}
+
+// Ranking lida com a rota GET /ranking?genero=&decada=&votosMinimos=&limite=.
+func (h *AvaliacaoHandler) Ranking(c *gin.Context) {
+	var consulta servico.ConsultaRanking
+	var ok bool
+	if consulta.GeneroID, ok = consultaPositiva(c, "genero", "ID de gênero inválido"); !ok {
+		return
+	}
+	if consulta.Decada, ok = consultaPositiva(c, "decada", "Década inválida"); !ok {
+		return
+	}
+	if consulta.VotosMinimos, ok = consultaPositiva(c, "votosMinimos", "Quantidade mínima de votos inválida"); !ok {
+		return
+	}
+	if consulta.Limite, ok = consultaPositiva(c, "limite", "Limite inválido"); !ok {
+		return
+	}
+
+	ranking, err := h.servico.Ranking(consulta)
+	if err != nil {
+		switch err {
+		case servico.ErrDecadaInvalida:
+			c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao montar o ranking"})
+		}
+		return
+	}
+	if ranking == nil {
+		ranking = make([]dominio.ItemRanking, 0)
+	}
+	c.JSON(http.StatusOK, ranking)
+}
+
+// consultaPositiva lê um inteiro positivo da query string, respondendo 400 se ele for inválido.
+// Sem o parâmetro, retorna 0.
+func consultaPositiva(c *gin.Context, nome, mensagem string) (int, bool) {
+	valor := c.Query(nome)
+	if valor == "" {
+		return 0, true
+	}
+	numero, err := strconv.Atoi(valor)
+	if err != nil || numero <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": mensagem})
+		return 0, false
+	}
+	return numero, true
+}
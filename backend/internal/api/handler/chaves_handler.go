@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Andydev0/filmes-backend/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// ChavesHandler publica as chaves públicas usadas na assinatura dos tokens.
+type ChavesHandler struct {
+	chaves *auth.ConjuntoChaves
+}
+
+// NovoChavesHandler cria a instância do handler de chaves.
+func NovoChavesHandler(chaves *auth.ConjuntoChaves) *ChavesHandler {
+	return &ChavesHandler{chaves: chaves}
+}
+
+// JWKS lida com a rota GET /.well-known/jwks.json.
+// Outros serviços usam este documento para verificar nossos tokens sem conhecer segredos.
+func (h *ChavesHandler) JWKS(c *gin.Context) {
+	// Permite cache curto, para que uma nova chave apareça logo após a rotação.
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.chaves.JWKS())
+}
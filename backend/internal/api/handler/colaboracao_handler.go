@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/gin-gonic/gin"
+)
+
+// ColaboracaoHandler gerencia os membros e convites das listas colaborativas.
+type ColaboracaoHandler struct {
+	servico servico.ColaboracaoServico
+}
+
+// NovaColaboracaoHandler cria a instância do handler de colaboração.
+func NovaColaboracaoHandler(s servico.ColaboracaoServico) *ColaboracaoHandler {
+	return &ColaboracaoHandler{servico: s}
+}
+
+// CriarConvite lida com a rota POST /listas/:id/convites.
+func (h *ColaboracaoHandler) CriarConvite(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	listaID, ok := parametroID(c, "id", "ID de lista inválido")
+	if !ok {
+		return
+	}
+
+	var input servico.CriarConviteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Dados de entrada inválidos"})
+		return
+	}
+
+	convite, err := h.servico.CriarConvite(usuarioID, listaID, input)
+	if err != nil {
+		responderErroColaboracao(c, err, "Falha ao criar o convite")
+		return
+	}
+	c.JSON(http.StatusCreated, convite)
+}
+
+// ListarConvites lida com a rota GET /listas/:id/convites.
+func (h *ColaboracaoHandler) ListarConvites(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	listaID, ok := parametroID(c, "id", "ID de lista inválido")
+	if !ok {
+		return
+	}
+
+	convites, err := h.servico.ListarConvites(usuarioID, listaID)
+	if err != nil {
+		responderErroColaboracao(c, err, "Falha ao listar os convites")
+		return
+	}
+	if convites == nil {
+		convites = make([]dominio.ConviteLista, 0)
+	}
+	c.JSON(http.StatusOK, convites)
+}
+
+// RevogarConvite lida com a rota DELETE /listas/:id/convites/:conviteId.
+func (h *ColaboracaoHandler) RevogarConvite(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	listaID, ok := parametroID(c, "id", "ID de lista inválido")
+	if !ok {
+		return
+	}
+	conviteID, ok := parametroID(c, "conviteId", "ID de convite inválido")
+	if !ok {
+		return
+	}
+
+	if err := h.servico.RevogarConvite(usuarioID, listaID, conviteID); err != nil {
+		responderErroColaboracao(c, err, "Falha ao revogar o convite")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListarConvitesRecebidos lida com a rota GET /convites.
+func (h *ColaboracaoHandler) ListarConvitesRecebidos(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	convites, err := h.servico.ListarConvitesRecebidos(usuarioID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao listar os convites"})
+		return
+	}
+	if convites == nil {
+		convites = make([]dominio.ConviteLista, 0)
+	}
+	c.JSON(http.StatusOK, convites)
+}
+
+// AceitarConvite lida com a rota POST /convites/:codigo/aceitar.
+func (h *ColaboracaoHandler) AceitarConvite(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	lista, err := h.servico.AceitarConvite(usuarioID, c.Param("codigo"))
+	if err != nil {
+		responderErroColaboracao(c, err, "Falha ao aceitar o convite")
+		return
+	}
+	c.JSON(http.StatusOK, lista)
+}
+
+// ListarMembros lida com a rota GET /listas/:id/membros.
+func (h *ColaboracaoHandler) ListarMembros(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	listaID, ok := parametroID(c, "id", "ID de lista inválido")
+	if !ok {
+		return
+	}
+
+	membros, err := h.servico.ListarMembros(usuarioID, listaID)
+	if err != nil {
+		responderErroColaboracao(c, err, "Falha ao listar os membros")
+		return
+	}
+	c.JSON(http.StatusOK, membros)
+}
+
+// AlterarPapel lida com a rota PATCH /listas/:id/membros/:usuarioId.
+func (h *ColaboracaoHandler) AlterarPapel(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	listaID, ok := parametroID(c, "id", "ID de lista inválido")
+	if !ok {
+		return
+	}
+	membroID, ok := parametroID(c, "usuarioId", "ID de usuário inválido")
+	if !ok {
+		return
+	}
+
+	var input servico.AlterarPapelInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Dados de entrada inválidos"})
+		return
+	}
+
+	if err := h.servico.AlterarPapel(usuarioID, listaID, membroID, input); err != nil {
+		responderErroColaboracao(c, err, "Falha ao alterar o papel do membro")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RemoverMembro lida com a rota DELETE /listas/:id/membros/:usuarioId.
+func (h *ColaboracaoHandler) RemoverMembro(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	listaID, ok := parametroID(c, "id", "ID de lista inválido")
+	if !ok {
+		return
+	}
+	membroID, ok := parametroID(c, "usuarioId", "ID de usuário inválido")
+	if !ok {
+		return
+	}
+
+	if err := h.servico.RemoverMembro(usuarioID, listaID, membroID); err != nil {
+		responderErroColaboracao(c, err, "Falha ao remover o membro")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// responderErroColaboracao traduz os erros de colaboração; os demais seguem os das listas.
+func responderErroColaboracao(c *gin.Context, err error, mensagem string) {
+	switch err {
+	case servico.ErrConviteNaoEncontrado, servico.ErrConvidadoNaoEncontrado, servico.ErrMembroNaoEncontrado:
+		c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+	case servico.ErrListaNaoCompartilhavel, servico.ErrJaParticipaDaLista:
+		c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
+	default:
+		responderErroLista(c, err, mensagem)
+	}
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/gin-gonic/gin"
+)
+
+// ComentarioHandler gerencia os comentários nas avaliações.
+type ComentarioHandler struct {
+	servico servico.ComentarioServico
+}
+
+// NovoComentarioHandler cria a instância do handler de comentários.
+func NovoComentarioHandler(s servico.ComentarioServico) *ComentarioHandler {
+	return &ComentarioHandler{servico: s}
+}
+
+// Listar lida com a rota GET /avaliacoes/:id/comentarios?cursor=&limite=.
+func (h *ComentarioHandler) Listar(c *gin.Context) {
+	avaliacaoID, ok := parametroID(c, "id", "ID de avaliação inválido")
+	if !ok {
+		return
+	}
+	limite, ok := consultaPositiva(c, "limite", "Limite inválido")
+	if !ok {
+		return
+	}
+
+	comentarios, proximo, err := h.servico.Listar(avaliacaoID, c.Query("cursor"), limite)
+	if err != nil {
+		responderErroComentario(c, err, "Falha ao listar os comentários")
+		return
+	}
+	if comentarios == nil {
+		comentarios = make([]dominio.ComentarioAvaliacao, 0)
+	}
+	if proximo != "" {
+		c.Header("X-Proximo-Cursor", proximo)
+	}
+	c.JSON(http.StatusOK, comentarios)
+}
+
+// Comentar lida com a rota POST /avaliacoes/:id/comentarios.
+func (h *ComentarioHandler) Comentar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	avaliacaoID, ok := parametroID(c, "id", "ID de avaliação inválido")
+	if !ok {
+		return
+	}
+
+	var input servico.ComentarioInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Dados de entrada inválidos"})
+		return
+	}
+
+	comentario, err := h.servico.Comentar(usuarioID, avaliacaoID, input)
+	if err != nil {
+		responderErroComentario(c, err, "Falha ao publicar o comentário")
+		return
+	}
+	c.JSON(http.StatusCreated, comentario)
+}
+
+// Editar lida com a rota PATCH /comentarios/:id.
+func (h *ComentarioHandler) Editar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	comentarioID, ok := parametroID(c, "id", "ID de comentário inválido")
+	if !ok {
+		return
+	}
+
+	var input servico.EditarComentarioInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Dados de entrada inválidos"})
+		return
+	}
+
+	comentario, err := h.servico.Editar(usuarioID, comentarioID, input)
+	if err != nil {
+		responderErroComentario(c, err, "Falha ao editar o comentário")
+		return
+	}
+	c.JSON(http.StatusOK, comentario)
+}
+
+// Excluir lida com a rota DELETE /comentarios/:id.
+func (h *ComentarioHandler) Excluir(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	comentarioID, ok := parametroID(c, "id", "ID de comentário inválido")
+	if !ok {
+		return
+	}
+
+	if err := h.servico.Excluir(usuarioID, comentarioID); err != nil {
+		responderErroComentario(c, err, "Falha ao excluir o comentário")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// responderErroComentario traduz os erros do serviço de comentários para o status HTTP adequado.
+func responderErroComentario(c *gin.Context, err error, mensagem string) {
+	switch err {
+	case servico.ErrComentarioNaoEncontrado, servico.ErrAvaliacaoNaoEncontrada:
+		c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+	case servico.ErrComentarioVazio, servico.ErrCursorComentariosInvalido:
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+	case servico.ErrSemPermissaoComentario:
+		c.JSON(http.StatusForbidden, gin.H{"erro": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": mensagem})
+	}
+}
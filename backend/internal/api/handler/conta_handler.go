@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/gin-gonic/gin"
+)
+
+// ContaHandler gerencia a exportação de dados e a exclusão da conta do usuário.
+type ContaHandler struct {
+	servico servico.ContaServico
+}
+
+// NovoContaHandler cria a instância do handler de conta.
+func NovoContaHandler(s servico.ContaServico) *ContaHandler {
+	return &ContaHandler{servico: s}
+}
+
+// Exportar lida com a rota GET /usuarios/me/exportar?formato={zip|json}.
+// O formato padrão é um ZIP com um arquivo JSON por seção.
+func (h *ContaHandler) Exportar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	formato := c.DefaultQuery("formato", "zip")
+	if formato != "zip" && formato != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Formato inválido; use 'zip' ou 'json'"})
+		return
+	}
+
+	exportacao, err := h.servico.Exportar(usuarioID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao exportar os dados da conta"})
+		return
+	}
+
+	nomeArquivo := fmt.Sprintf("cinehub-dados-%d-%s.%s", usuarioID, exportacao.GeradoEm.Format("20060102"), formato)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", nomeArquivo))
+
+	if formato == "json" {
+		c.JSON(http.StatusOK, exportacao)
+		return
+	}
+
+	secoes := []struct {
+		nome  string
+		dados interface{}
+	}{
+		{"perfil.json", exportacao.Perfil},
+		{"favoritos.json", exportacao.Favoritos},
+		{"listas.json", exportacao.Listas},
+		{"listas_compartilhadas.json", exportacao.ListasCompartilhadas},
+		{"diario.json", exportacao.Diario},
+		{"avaliacoes.json", exportacao.Avaliacoes},
+		{"historico_avaliacoes.json", exportacao.HistoricoAvaliacoes},
+		{"reacoes.json", exportacao.Reacoes},
+		{"comentarios.json", exportacao.Comentarios},
+		{"decisoes_filtro.json", exportacao.DecisoesFiltro},
+		{"denuncias.json", exportacao.Denuncias},
+		{"advertencias.json", exportacao.Advertencias},
+		{"historico_quiz.json", exportacao.HistoricoQuiz},
+		{"importacoes.json", exportacao.Importacoes},
+		{"seguindo.json", exportacao.Seguindo},
+		{"seguidores.json", exportacao.Seguidores},
+		{"atividades.json", exportacao.Atividades},
+		{"notificacoes.json", exportacao.Notificacoes},
+		{"preferencias_notificacoes.json", exportacao.PreferenciasNotificacoes},
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+
+	// O ZIP é escrito diretamente na resposta; uma falha no meio do envio fecha a conexão.
+	arquivo := zip.NewWriter(c.Writer)
+	for _, secao := range secoes {
+		escritor, err := arquivo.CreateHeader(&zip.FileHeader{
+			Name:     secao.nome,
+			Method:   zip.Deflate,
+			Modified: exportacao.GeradoEm,
+		})
+		if err != nil {
+			interromperDownload(c, err, "Falha ao exportar os dados da conta")
+			return
+		}
+		codificador := json.NewEncoder(escritor)
+		codificador.SetIndent("", "  ")
+		if err := codificador.Encode(secao.dados); err != nil {
+			interromperDownload(c, err, "Falha ao exportar os dados da conta")
+			return
+		}
+	}
+	if err := arquivo.Close(); err != nil {
+		interromperDownload(c, err, "Falha ao exportar os dados da conta")
+	}
+}
+
+// Excluir lida com a rota DELETE /usuarios/me.
+// A conta é apagada ao fim do prazo de carência; até lá, entrar novamente cancela o pedido.
+func (h *ContaHandler) Excluir(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	var input servico.ExcluirContaInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Dados de entrada inválidos"})
+		return
+	}
+
+	data, err := h.servico.AgendarExclusao(usuarioID, input)
+	if err != nil {
+		switch err {
+		case servico.ErrCredenciaisInvalidas, servico.ErrCodigoInvalido:
+			c.JSON(http.StatusUnauthorized, gin.H{"erro": err.Error()})
+		case servico.ErrMuitasTentativas:
+			c.JSON(http.StatusTooManyRequests, gin.H{"erro": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao agendar a exclusão da conta"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"mensagem":             "Exclusão agendada. Entre novamente na conta antes da data para cancelar.",
+		"exclusaoAgendadaPara": data,
+	})
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/gin-gonic/gin"
+)
+
+// DiarioHandler gerencia o diário de filmes assistidos.
+type DiarioHandler struct {
+	servico servico.DiarioServico
+}
+
+// NovoDiarioHandler cria a instância do handler do diário.
+func NovoDiarioHandler(s servico.DiarioServico) *DiarioHandler {
+	return &DiarioHandler{servico: s}
+}
+
+// Registrar lida com a rota POST /diario.
+func (h *DiarioHandler) Registrar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	var input servico.RegistrarDiarioInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Dados de entrada inválidos"})
+		return
+	}
+
+	entrada, err := h.servico.Registrar(usuarioID, input)
+	if err != nil {
+		switch err {
+		case servico.ErrDataAssistidoInvalida, servico.ErrAvaliacaoNaoVinculavel:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"erro": err.Error()})
+		case servico.ErrFilmeInexistente:
+			c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+		case servico.ErrCatalogoIndisponivel:
+			c.JSON(http.StatusBadGateway, gin.H{"erro": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao registrar no diário"})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, entrada)
+}
+
+// Listar lida com a rota GET /diario?ano={ano}. Sem o parâmetro, usa o ano atual.
+func (h *DiarioHandler) Listar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	ano := time.Now().Year()
+	if valor := c.Query("ano"); valor != "" {
+		var err error
+		ano, err = strconv.Atoi(valor)
+		if err != nil || ano < 1 || ano > 9999 {
+			c.JSON(http.StatusBadRequest, gin.H{"erro": "Ano inválido"})
+			return
+		}
+	}
+
+	diario, err := h.servico.ListarAno(usuarioID, ano)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao buscar o diário"})
+		return
+	}
+	c.JSON(http.StatusOK, diario)
+}
+
+// Remover lida com a rota DELETE /diario/:id.
+func (h *DiarioHandler) Remover(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	entradaID, ok := parametroID(c, "id", "ID de entrada inválido")
+	if !ok {
+		return
+	}
+
+	if err := h.servico.Remover(usuarioID, entradaID); err != nil {
+		if err == servico.ErrEntradaDiarioNaoExiste {
+			c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao remover do diário"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
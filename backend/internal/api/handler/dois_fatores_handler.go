@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/gin-gonic/gin"
+)
+
+// DoisFatoresHandler gerencia o cadastro da autenticação em dois fatores (TOTP).
+type DoisFatoresHandler struct {
+	servico servico.DoisFatoresServico
+}
+
+// NovoDoisFatoresHandler cria a instância do handler de dois fatores.
+func NovoDoisFatoresHandler(s servico.DoisFatoresServico) *DoisFatoresHandler {
+	return &DoisFatoresHandler{servico: s}
+}
+
+// Status lida com a rota GET /usuarios/me/2fa.
+func (h *DoisFatoresHandler) Status(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	status, err := h.servico.Status(usuarioID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao consultar a autenticação em dois fatores"})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// Iniciar lida com a rota POST /usuarios/me/2fa.
+// Retorna o segredo e a URI otpauth:// para o aplicativo autenticador.
+func (h *DoisFatoresHandler) Iniciar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	configuracao, err := h.servico.IniciarCadastro(usuarioID)
+	if err != nil {
+		if err == servico.ErrDoisFatoresJaAtivo {
+			c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao iniciar a autenticação em dois fatores"})
+		return
+	}
+	c.JSON(http.StatusOK, configuracao)
+}
+
+// Confirmar lida com a rota POST /usuarios/me/2fa/confirmar.
+// Ativa o 2FA com o primeiro código e devolve os códigos de recuperação.
+func (h *DoisFatoresHandler) Confirmar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	var input servico.CodigoDoisFatoresInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Dados de entrada inválidos"})
+		return
+	}
+
+	codigos, err := h.servico.ConfirmarCadastro(usuarioID, input)
+	if err != nil {
+		switch err {
+		case servico.ErrDoisFatoresJaAtivo, servico.ErrDoisFatoresNaoIniciado:
+			c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
+		case servico.ErrCodigoInvalido:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"erro": err.Error()})
+		case servico.ErrMuitasTentativas:
+			c.JSON(http.StatusTooManyRequests, gin.H{"erro": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao confirmar a autenticação em dois fatores"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"codigosRecuperacao": codigos})
+}
+
+// Desativar lida com a rota POST /usuarios/me/2fa/desativar.
+func (h *DoisFatoresHandler) Desativar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	var input servico.DesativarDoisFatoresInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Dados de entrada inválidos"})
+		return
+	}
+
+	if err := h.servico.Desativar(usuarioID, input); err != nil {
+		switch err {
+		case servico.ErrDoisFatoresInativo:
+			c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
+		case servico.ErrCredenciaisInvalidas, servico.ErrCodigoInvalido:
+			c.JSON(http.StatusUnauthorized, gin.H{"erro": err.Error()})
+		case servico.ErrMuitasTentativas:
+			c.JSON(http.StatusTooManyRequests, gin.H{"erro": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao desativar a autenticação em dois fatores"})
+		}
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// interromperDownload registra o erro e avisa o cliente de que o arquivo não foi gerado por
+// inteiro. Se nada foi enviado ainda, a resposta vira um erro comum. Depois do início do envio o
+// status 200 já foi enviado, então a conexão é fechada sem o fim do envio em partes: o cliente
+// recebe um corpo incompleto, e não um arquivo truncado que parece válido.
+func interromperDownload(c *gin.Context, err error, mensagem string) {
+	c.Error(err)
+	if !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Disposition")
+		c.Writer.Header().Del("Content-Type")
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": mensagem})
+		return
+	}
+	// Sem suporte a Hijack (HTTP/2), resta o erro registrado em c.Error.
+	if conexao, _, err := c.Writer.Hijack(); err == nil {
+		conexao.Close()
+	}
+}
@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/gin-gonic/gin"
+)
+
+// Formatos aceitos por GET /exportar.
+const (
+	formatoExportacaoCSV        = "csv"
+	formatoExportacaoJSON       = "json"
+	formatoExportacaoLetterboxd = "letterboxd"
+)
+
+// ExportacaoHandler gerencia a exportação de favoritos, listas, diário e avaliações em arquivo.
+type ExportacaoHandler struct {
+	servico servico.ExportacaoServico
+}
+
+// NovaExportacaoHandler cria a instância do handler de exportação.
+func NovaExportacaoHandler(s servico.ExportacaoServico) *ExportacaoHandler {
+	return &ExportacaoHandler{servico: s}
+}
+
+// Exportar lida com a rota GET /exportar?formato={csv|json|letterboxd}&escopo={favoritos|avaliacoes|diario|lista:<id>}.
+// O arquivo é enviado em partes, à medida que as páginas são lidas do banco.
+func (h *ExportacaoHandler) Exportar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	formato := c.DefaultQuery("formato", formatoExportacaoCSV)
+	if formato != formatoExportacaoCSV && formato != formatoExportacaoJSON && formato != formatoExportacaoLetterboxd {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Formato inválido; use 'csv', 'json' ou 'letterboxd'"})
+		return
+	}
+
+	escopo, err := h.servico.ResolverEscopo(usuarioID, c.Query("escopo"))
+	if err != nil {
+		switch err {
+		case servico.ErrEscopoExportacaoInvalido:
+			c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		case servico.ErrListaNaoEncontrada:
+			c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao preparar a exportação"})
+		}
+		return
+	}
+
+	nomeArquivo := fmt.Sprintf("cinehub-%s-%s", escopo.Nome(), time.Now().UTC().Format("20060102"))
+	switch formato {
+	case formatoExportacaoJSON:
+		c.Header("Content-Type", "application/json; charset=utf-8")
+		nomeArquivo += ".json"
+	case formatoExportacaoLetterboxd:
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		nomeArquivo += "-letterboxd.csv"
+	default:
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		nomeArquivo += ".csv"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", nomeArquivo))
+	c.Status(http.StatusOK)
+
+	if formato == formatoExportacaoJSON {
+		err = h.escreverJSON(c, escopo)
+	} else {
+		err = h.escreverCSV(c, escopo, colunasExportacao(formato, escopo.Tipo))
+	}
+	if err != nil {
+		interromperDownload(c, err, "Falha ao gerar a exportação")
+	}
+}
+
+// escreverJSON envia um array JSON, um elemento por vez.
+func (h *ExportacaoHandler) escreverJSON(c *gin.Context, escopo *servico.EscopoExportacao) error {
+	if _, err := c.Writer.WriteString("["); err != nil {
+		return err
+	}
+
+	primeiro := true
+	err := h.servico.Percorrer(escopo, func(pagina []servico.ItemExportado) error {
+		for _, item := range pagina {
+			dados, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			if !primeiro {
+				if _, err := c.Writer.WriteString(","); err != nil {
+					return err
+				}
+			}
+			primeiro = false
+			if _, err := c.Writer.Write(dados); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Writer.WriteString("]")
+	return err
+}
+
+// escreverCSV envia o cabeçalho e as linhas de cada página assim que ela fica pronta.
+func (h *ExportacaoHandler) escreverCSV(c *gin.Context, escopo *servico.EscopoExportacao, colunas []colunaExportacao) error {
+	escritor := csv.NewWriter(c.Writer)
+
+	cabecalho := make([]string, len(colunas))
+	for i, coluna := range colunas {
+		cabecalho[i] = coluna.nome
+	}
+	if err := escritor.Write(cabecalho); err != nil {
+		return err
+	}
+
+	err := h.servico.Percorrer(escopo, func(pagina []servico.ItemExportado) error {
+		registro := make([]string, len(colunas))
+		for _, item := range pagina {
+			for i, coluna := range colunas {
+				registro[i] = coluna.valor(item)
+			}
+			if err := escritor.Write(registro); err != nil {
+				return err
+			}
+		}
+		escritor.Flush()
+		c.Writer.Flush()
+		return escritor.Error()
+	})
+	if err != nil {
+		return err
+	}
+
+	escritor.Flush()
+	return escritor.Error()
+}
+
+// colunaExportacao é uma coluna do CSV e a forma de obter seu valor.
+type colunaExportacao struct {
+	nome  string
+	valor func(item servico.ItemExportado) string
+}
+
+// colunasExportacao define as colunas de cada formato e escopo. No formato "letterboxd" os nomes
+// seguem o importador do Letterboxd (tmdbID, imdbID, Title, Year, Directors, Rating, ...).
+func colunasExportacao(formato, escopo string) []colunaExportacao {
+	ano := func(i servico.ItemExportado) string {
+		if i.Ano == 0 {
+			return ""
+		}
+		return strconv.Itoa(i.Ano)
+	}
+	nota := func(i servico.ItemExportado) string {
+		if i.Nota == 0 {
+			return ""
+		}
+		return strconv.FormatFloat(i.Nota.Estrelas(), 'f', -1, 64)
+	}
+	revisto := func(i servico.ItemExportado) string {
+		if i.Revisto == nil {
+			return ""
+		}
+		return strconv.FormatBool(*i.Revisto)
+	}
+	data := func(i servico.ItemExportado) string { return i.Data.Format("2006-01-02") }
+
+	if formato == formatoExportacaoLetterboxd {
+		colunas := []colunaExportacao{
+			{"tmdbID", func(i servico.ItemExportado) string { return strconv.FormatInt(i.FilmeID, 10) }},
+			{"imdbID", func(i servico.ItemExportado) string { return i.IMDbID }},
+			{"Title", func(i servico.ItemExportado) string { return i.Titulo }},
+			{"Year", ano},
+			{"Directors", func(i servico.ItemExportado) string { return i.Diretor }},
+		}
+		switch escopo {
+		case servico.EscopoExportacaoAvaliacoes:
+			colunas = append(colunas,
+				colunaExportacao{"Rating", nota},
+				colunaExportacao{"Review", func(i servico.ItemExportado) string { return i.Comentario }},
+			)
+		case servico.EscopoExportacaoDiario:
+			colunas = append(colunas,
+				colunaExportacao{"WatchedDate", func(i servico.ItemExportado) string { return i.DataAssistido }},
+				colunaExportacao{"Rewatch", revisto},
+				colunaExportacao{"Rating", nota},
+			)
+		}
+		return colunas
+	}
+
+	colunas := []colunaExportacao{
+		{"filme_id", func(i servico.ItemExportado) string { return strconv.FormatInt(i.FilmeID, 10) }},
+		{"titulo", func(i servico.ItemExportado) string { return i.Titulo }},
+		{"ano", ano},
+		{"imdb_id", func(i servico.ItemExportado) string { return i.IMDbID }},
+		{"diretor", func(i servico.ItemExportado) string { return i.Diretor }},
+	}
+	switch escopo {
+	case servico.EscopoExportacaoAvaliacoes:
+		colunas = append(colunas,
+			colunaExportacao{"nota", nota},
+			colunaExportacao{"comentario", func(i servico.ItemExportado) string { return i.Comentario }},
+			colunaExportacao{"data_avaliacao", data},
+		)
+	case servico.EscopoExportacaoDiario:
+		colunas = append(colunas,
+			colunaExportacao{"data_assistido", func(i servico.ItemExportado) string { return i.DataAssistido }},
+			colunaExportacao{"revisto", revisto},
+			colunaExportacao{"nota", nota},
+		)
+	default:
+		colunas = append(colunas,
+			colunaExportacao{"posicao", func(i servico.ItemExportado) string { return strconv.Itoa(i.Posicao) }},
+			colunaExportacao{"data_adicionado", data},
+		)
+	}
+	return colunas
+}
@@ -34,6 +34,18 @@ func (h *FavoritoHandler) Adicionar(c *gin.Context) {
 			c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
 			return
 		}
+		if err == servico.ErrAnotacaoMuitoLonga || err == servico.ErrTagsInvalidas {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"erro": err.Error()})
+			return
+		}
+		if err == servico.ErrFilmeInexistente {
+			c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+			return
+		}
+		if err == servico.ErrCatalogoIndisponivel {
+			c.JSON(http.StatusBadGateway, gin.H{"erro": err.Error()})
+			return
+		}
 		// Para outros erros, retorna 500.
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao adicionar favorito"})
 		return
@@ -42,23 +54,116 @@ func (h *FavoritoHandler) Adicionar(c *gin.Context) {
 	c.Status(http.StatusCreated)
 }
 
-// ... (Listar e Remover continuam os mesmos)
+// AplicarLote lida com a rota POST /favoritos/lote. As operações são gravadas juntas; a resposta
+// traz o resultado de cada uma, na ordem do pedido.
+func (h *FavoritoHandler) AplicarLote(c *gin.Context) {
+	var input servico.LoteFavoritosInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Input inválido; envie de 1 a 100 operações"})
+		return
+	}
+
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	resultados, err := h.servico.AplicarLote(usuarioID, input)
+	if err != nil {
+		switch err {
+		case servico.ErrAnotacaoMuitoLonga, servico.ErrTagsInvalidas:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"erro": err.Error()})
+		case servico.ErrCatalogoIndisponivel:
+			c.JSON(http.StatusBadGateway, gin.H{"erro": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao aplicar as operações"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"resultados": resultados})
+}
+
+// Listar lida com a rota GET /favoritos. Aceita ?ordenar={data|titulo|ano|nota}, ?ordem=desc,
+// ?tag=, ?genero=<id>, ?q=, ?limite= e ?cursor=; sem limite nem cursor, traz todos os favoritos.
+// O cursor da próxima página vai no cabeçalho X-Proximo-Cursor, ausente na última.
 func (h *FavoritoHandler) Listar(c *gin.Context) {
 	usuarioID := c.MustGet("usuarioID").(int64)
 
-	favoritos, err := h.servico.ListarFavoritos(usuarioID)
+	consulta := servico.ConsultaFavoritos{
+		Ordenar:     c.Query("ordenar"),
+		Decrescente: c.Query("ordem") == "desc",
+		Tag:         c.Query("tag"),
+		Busca:       c.Query("q"),
+		Cursor:      c.Query("cursor"),
+	}
+	if genero := c.Query("genero"); genero != "" {
+		id, err := strconv.Atoi(genero)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"erro": "ID de gênero inválido"})
+			return
+		}
+		consulta.GeneroID = id
+	}
+	if limite := c.Query("limite"); limite != "" {
+		valor, err := strconv.Atoi(limite)
+		if err != nil || valor <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"erro": "Limite inválido"})
+			return
+		}
+		consulta.Limite = valor
+	}
+
+	favoritos, proximo, err := h.servico.PesquisarFavoritos(usuarioID, consulta)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao listar favoritos"})
+		switch err {
+		case servico.ErrOrdenacaoFavoritos, servico.ErrCursorFavoritosInvalido:
+			c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao listar favoritos"})
+		}
 		return
 	}
 
 	if favoritos == nil {
 		favoritos = make([]dominio.FilmeFavorito, 0)
 	}
+	if proximo != "" {
+		c.Header("X-Proximo-Cursor", proximo)
+	}
 
 	c.JSON(http.StatusOK, favoritos)
 }
 
+// Atualizar lida com a rota PATCH /favoritos/:id, que edita a anotação e as tags do favorito.
+func (h *FavoritoHandler) Atualizar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	filmeID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "ID de filme inválido"})
+		return
+	}
+
+	var input servico.AtualizarFavoritoInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Input inválido"})
+		return
+	}
+
+	favorito, err := h.servico.AtualizarFavorito(usuarioID, filmeID, input)
+	if err != nil {
+		switch err {
+		case servico.ErrFavoritoNaoEncontrado:
+			c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+		case servico.ErrAnotacaoMuitoLonga, servico.ErrTagsInvalidas:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"erro": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao atualizar favorito"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, favorito)
+}
+
 func (h *FavoritoHandler) Remover(c *gin.Context) {
 	usuarioID := c.MustGet("usuarioID").(int64)
 
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// tamanhoMaximoArquivoImportacao limita cada CSV enviado (5 MB).
+	tamanhoMaximoArquivoImportacao = 5 << 20
+
+	// tamanhoMaximoEnvioImportacao limita o corpo inteiro da requisição (20 MB).
+	tamanhoMaximoEnvioImportacao = 20 << 20
+)
+
+// ImportacaoHandler gerencia a importação de histórico a partir de outros serviços.
+type ImportacaoHandler struct {
+	servico servico.ImportacaoServico
+}
+
+// NovaImportacaoHandler cria a instância do handler de importações.
+func NovaImportacaoHandler(s servico.ImportacaoServico) *ImportacaoHandler {
+	return &ImportacaoHandler{servico: s}
+}
+
+// Importar lida com a rota POST /importacoes (multipart/form-data, campo "arquivos").
+// Responde 202 com a importação criada; o processamento continua em segundo plano.
+func (h *ImportacaoHandler) Importar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, tamanhoMaximoEnvioImportacao)
+	formulario, err := c.MultipartForm()
+	if err != nil {
+		var erroTamanho *http.MaxBytesError
+		if errors.As(err, &erroTamanho) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"erro": "O envio excede o tamanho máximo de 20 MB"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Envie os arquivos CSV no campo 'arquivos' (multipart/form-data)"})
+		return
+	}
+
+	cabecalhos := formulario.File["arquivos"]
+	if len(cabecalhos) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Envie os arquivos CSV no campo 'arquivos' (multipart/form-data)"})
+		return
+	}
+
+	arquivos := make([]servico.ArquivoImportacao, 0, len(cabecalhos))
+	for _, cabecalho := range cabecalhos {
+		if cabecalho.Size > tamanhoMaximoArquivoImportacao {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"erro": "Cada arquivo pode ter no máximo 5 MB: " + cabecalho.Filename})
+			return
+		}
+		arquivo, err := cabecalho.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"erro": "Não foi possível ler o arquivo " + cabecalho.Filename})
+			return
+		}
+		defer arquivo.Close()
+		arquivos = append(arquivos, servico.ArquivoImportacao{Nome: cabecalho.Filename, Conteudo: arquivo})
+	}
+
+	importacao, err := h.servico.Iniciar(usuarioID, arquivos)
+	if err != nil {
+		switch {
+		case errors.Is(err, servico.ErrArquivoImportacaoInvalido),
+			errors.Is(err, servico.ErrImportacaoVazia),
+			errors.Is(err, servico.ErrImportacaoMuitoGrande):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"erro": err.Error()})
+		case errors.Is(err, servico.ErrImportacaoEmAndamento):
+			c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao iniciar a importação"})
+		}
+		return
+	}
+	c.JSON(http.StatusAccepted, importacao)
+}
+
+// Listar lida com a rota GET /importacoes.
+func (h *ImportacaoHandler) Listar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	importacoes, err := h.servico.Listar(usuarioID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao listar as importações"})
+		return
+	}
+
+	if importacoes == nil {
+		importacoes = make([]dominio.Importacao, 0)
+	}
+	c.JSON(http.StatusOK, importacoes)
+}
+
+// Buscar lida com a rota GET /importacoes/:id, com o andamento e as linhas não importadas.
+func (h *ImportacaoHandler) Buscar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	importacaoID, ok := parametroID(c, "id", "ID de importação inválido")
+	if !ok {
+		return
+	}
+
+	importacao, err := h.servico.Buscar(usuarioID, importacaoID)
+	if err != nil {
+		if err == servico.ErrImportacaoNaoEncontrada {
+			c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao buscar a importação"})
+		return
+	}
+	c.JSON(http.StatusOK, importacao)
+}
@@ -0,0 +1,353 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/gin-gonic/gin"
+)
+
+// ListaHandler gerencia as listas de filmes do usuário.
+type ListaHandler struct {
+	servico servico.ListaServico
+}
+
+// NovaListaHandler cria a instância do handler de listas.
+func NovaListaHandler(s servico.ListaServico) *ListaHandler {
+	return &ListaHandler{servico: s}
+}
+
+// Listar lida com a rota GET /listas.
+func (h *ListaHandler) Listar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	listas, err := h.servico.Listar(usuarioID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao listar listas"})
+		return
+	}
+
+	if listas == nil {
+		listas = make([]dominio.Lista, 0)
+	}
+	c.JSON(http.StatusOK, listas)
+}
+
+// Criar lida com a rota POST /listas.
+func (h *ListaHandler) Criar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	var input servico.CriarListaInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Dados de entrada inválidos"})
+		return
+	}
+
+	lista, err := h.servico.Criar(usuarioID, input)
+	if err != nil {
+		responderErroLista(c, err, "Falha ao criar lista")
+		return
+	}
+	c.JSON(http.StatusCreated, lista)
+}
+
+// Buscar lida com a rota GET /listas/:id.
+func (h *ListaHandler) Buscar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	listaID, ok := parametroID(c, "id", "ID de lista inválido")
+	if !ok {
+		return
+	}
+
+	lista, err := h.servico.Buscar(usuarioID, listaID)
+	if err != nil {
+		responderErroLista(c, err, "Falha ao buscar lista")
+		return
+	}
+	definirVersao(c, lista.Versao)
+	c.JSON(http.StatusOK, lista)
+}
+
+// BuscarCompartilhada lida com a rota pública GET /listas-compartilhadas/:slug.
+func (h *ListaHandler) BuscarCompartilhada(c *gin.Context) {
+	lista, err := h.servico.BuscarCompartilhada(c.Param("slug"))
+	if err != nil {
+		responderErroLista(c, err, "Falha ao buscar lista")
+		return
+	}
+	c.JSON(http.StatusOK, lista)
+}
+
+// Atualizar lida com a rota PATCH /listas/:id. Assim como nas rotas de itens, o cabeçalho
+// If-Match opcional informa a versão da lista em que a edição se baseia.
+func (h *ListaHandler) Atualizar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	listaID, ok := parametroID(c, "id", "ID de lista inválido")
+	if !ok {
+		return
+	}
+	versao, ok := versaoEsperada(c)
+	if !ok {
+		return
+	}
+
+	var input servico.AtualizarListaInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Dados de entrada inválidos"})
+		return
+	}
+
+	lista, err := h.servico.Atualizar(usuarioID, listaID, versao, input)
+	if err != nil {
+		responderErroLista(c, err, "Falha ao atualizar lista")
+		return
+	}
+	definirVersao(c, lista.Versao)
+	c.JSON(http.StatusOK, lista)
+}
+
+// Excluir lida com a rota DELETE /listas/:id.
+func (h *ListaHandler) Excluir(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	listaID, ok := parametroID(c, "id", "ID de lista inválido")
+	if !ok {
+		return
+	}
+
+	if err := h.servico.Excluir(usuarioID, listaID); err != nil {
+		responderErroLista(c, err, "Falha ao excluir lista")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// AdicionarItem lida com a rota POST /listas/:id/itens.
+func (h *ListaHandler) AdicionarItem(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	listaID, ok := parametroID(c, "id", "ID de lista inválido")
+	if !ok {
+		return
+	}
+	versao, ok := versaoEsperada(c)
+	if !ok {
+		return
+	}
+
+	var input servico.AdicionarItemListaInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Dados de entrada inválidos"})
+		return
+	}
+
+	item, novaVersao, err := h.servico.AdicionarItem(usuarioID, listaID, versao, input)
+	if err != nil {
+		responderErroLista(c, err, "Falha ao adicionar filme à lista")
+		return
+	}
+	definirVersao(c, novaVersao)
+	c.JSON(http.StatusCreated, item)
+}
+
+// RemoverItem lida com a rota DELETE /listas/:id/itens/:filmeId.
+func (h *ListaHandler) RemoverItem(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	listaID, ok := parametroID(c, "id", "ID de lista inválido")
+	if !ok {
+		return
+	}
+	filmeID, ok := parametroID(c, "filmeId", "ID de filme inválido")
+	if !ok {
+		return
+	}
+	versao, ok := versaoEsperada(c)
+	if !ok {
+		return
+	}
+
+	novaVersao, err := h.servico.RemoverItem(usuarioID, listaID, filmeID, versao)
+	if err != nil {
+		responderErroLista(c, err, "Falha ao remover filme da lista")
+		return
+	}
+	definirVersao(c, novaVersao)
+	c.Status(http.StatusNoContent)
+}
+
+// MoverItem lida com a rota PATCH /listas/:id/itens/:filmeId.
+func (h *ListaHandler) MoverItem(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	listaID, ok := parametroID(c, "id", "ID de lista inválido")
+	if !ok {
+		return
+	}
+	filmeID, ok := parametroID(c, "filmeId", "ID de filme inválido")
+	if !ok {
+		return
+	}
+	versao, ok := versaoEsperada(c)
+	if !ok {
+		return
+	}
+
+	var input servico.MoverItemListaInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Dados de entrada inválidos"})
+		return
+	}
+
+	novaVersao, err := h.servico.MoverItem(usuarioID, listaID, filmeID, versao, input)
+	if err != nil {
+		responderErroLista(c, err, "Falha ao reordenar a lista")
+		return
+	}
+	definirVersao(c, novaVersao)
+	c.Status(http.StatusNoContent)
+}
+
+// DefinirOrdem lida com a rota PUT /listas/:id/ordem.
+func (h *ListaHandler) DefinirOrdem(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	listaID, ok := parametroID(c, "id", "ID de lista inválido")
+	if !ok {
+		return
+	}
+	versao, ok := versaoEsperada(c)
+	if !ok {
+		return
+	}
+
+	var input servico.DefinirOrdemListaInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Dados de entrada inválidos"})
+		return
+	}
+
+	novaVersao, err := h.servico.DefinirOrdem(usuarioID, listaID, versao, input)
+	if err != nil {
+		responderErroLista(c, err, "Falha ao reordenar a lista")
+		return
+	}
+	definirVersao(c, novaVersao)
+	c.Status(http.StatusNoContent)
+}
+
+// ListarParaAssistir lida com a rota GET /assistir.
+func (h *ListaHandler) ListarParaAssistir(c *gin.Context) {
+	usuarioID, listaID, ok := h.listaParaAssistir(c)
+	if !ok {
+		return
+	}
+
+	lista, err := h.servico.Buscar(usuarioID, listaID)
+	if err != nil {
+		responderErroLista(c, err, "Falha ao buscar a lista para assistir")
+		return
+	}
+	c.JSON(http.StatusOK, lista)
+}
+
+// AdicionarParaAssistir lida com a rota POST /assistir.
+func (h *ListaHandler) AdicionarParaAssistir(c *gin.Context) {
+	var input servico.AdicionarItemListaInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Dados de entrada inválidos"})
+		return
+	}
+
+	usuarioID, listaID, ok := h.listaParaAssistir(c)
+	if !ok {
+		return
+	}
+
+	item, _, err := h.servico.AdicionarItem(usuarioID, listaID, 0, input)
+	if err != nil {
+		responderErroLista(c, err, "Falha ao adicionar filme à lista para assistir")
+		return
+	}
+	c.JSON(http.StatusCreated, item)
+}
+
+// RemoverParaAssistir lida com a rota DELETE /assistir/:filmeId.
+func (h *ListaHandler) RemoverParaAssistir(c *gin.Context) {
+	filmeID, ok := parametroID(c, "filmeId", "ID de filme inválido")
+	if !ok {
+		return
+	}
+
+	usuarioID, listaID, ok := h.listaParaAssistir(c)
+	if !ok {
+		return
+	}
+
+	if _, err := h.servico.RemoverItem(usuarioID, listaID, filmeID, 0); err != nil {
+		responderErroLista(c, err, "Falha ao remover filme da lista para assistir")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// listaParaAssistir resolve o ID da lista embutida "para assistir" do usuário logado.
+func (h *ListaHandler) listaParaAssistir(c *gin.Context) (int64, int64, bool) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	listaID, err := h.servico.IDEmbutida(usuarioID, dominio.TipoListaAssistir)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao buscar a lista para assistir"})
+		return 0, 0, false
+	}
+	return usuarioID, listaID, true
+}
+
+// parametroID lê um ID numérico da rota, respondendo 400 se ele for inválido.
+func parametroID(c *gin.Context, nome, mensagem string) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param(nome), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": mensagem})
+		return 0, false
+	}
+	return id, true
+}
+
+// versaoEsperada lê a versão da lista do cabeçalho If-Match (ex.: "7" ou W/"7"), respondendo 400
+// se ele for inválido. Sem o cabeçalho, retorna 0 e a alteração não é conferida.
+func versaoEsperada(c *gin.Context) (int, bool) {
+	valor := strings.TrimSpace(c.GetHeader("If-Match"))
+	if valor == "" {
+		return 0, true
+	}
+	valor = strings.Trim(strings.TrimPrefix(valor, "W/"), `"`)
+	versao, err := strconv.Atoi(valor)
+	if err != nil || versao < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Cabeçalho If-Match inválido"})
+		return 0, false
+	}
+	return versao, true
+}
+
+// definirVersao informa a versão atual da lista no cabeçalho ETag.
+func definirVersao(c *gin.Context, versao int) {
+	c.Header("ETag", `"`+strconv.Itoa(versao)+`"`)
+}
+
+// responderErroLista traduz os erros do serviço de listas para o status HTTP adequado.
+func responderErroLista(c *gin.Context, err error, mensagem string) {
+	switch err {
+	case servico.ErrListaNaoEncontrada, servico.ErrItemNaoEncontrado, servico.ErrFilmeInexistente:
+		c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+	case servico.ErrItemJaNaLista, servico.ErrListaEmbutida, servico.ErrListaDesatualizada, servico.ErrListaFavoritos:
+		c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
+	case servico.ErrCatalogoIndisponivel:
+		c.JSON(http.StatusBadGateway, gin.H{"erro": err.Error()})
+	case servico.ErrSemPermissaoLista:
+		c.JSON(http.StatusForbidden, gin.H{"erro": err.Error()})
+	case servico.ErrTituloListaInvalido:
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+	case servico.ErrOrdemListaInvalida:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"erro": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": mensagem})
+	}
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Andydev0/filmes-backend/internal/auth"
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/gin-gonic/gin"
+)
+
+// LoginExternoHandler gerencia o login com provedores OpenID Connect.
+type LoginExternoHandler struct {
+	servico servico.LoginExternoServico
+}
+
+// NovoLoginExternoHandler cria a instância do handler de login externo.
+func NovoLoginExternoHandler(s servico.LoginExternoServico) *LoginExternoHandler {
+	return &LoginExternoHandler{servico: s}
+}
+
+// cookieEstadoOIDC guarda o state no navegador que iniciou o fluxo; o callback só é aceito
+// quando o state recebido confere com ele, o que impede concluir o login de outra pessoa.
+const cookieEstadoOIDC = "cinehub_oidc_estado"
+
+// Iniciar lida com a rota GET /auth/oidc/:provedor/iniciar.
+// Retorna a URL do provedor para onde o frontend deve redirecionar o navegador.
+func (h *LoginExternoHandler) Iniciar(c *gin.Context) {
+	iniciado, err := h.servico.Iniciar(c.Param("provedor"))
+	h.responderInicio(c, iniciado, err)
+}
+
+// IniciarVinculo lida com a rota POST /usuarios/me/identidades/:provedor/iniciar.
+// Igual ao Iniciar, mas o retorno do provedor vincula a identidade à conta logada.
+func (h *LoginExternoHandler) IniciarVinculo(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	iniciado, err := h.servico.IniciarVinculo(c.Param("provedor"), usuarioID)
+	h.responderInicio(c, iniciado, err)
+}
+
+func (h *LoginExternoHandler) responderInicio(c *gin.Context, iniciado *servico.LoginExternoIniciado, err error) {
+	if err != nil {
+		if err == servico.ErrProvedorDesconhecido {
+			c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"erro": "Falha ao contatar o provedor de login"})
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(cookieEstadoOIDC, iniciado.Estado, int(servico.ValidadeLoginExterno.Seconds()), "/v1", "", c.Request.TLS != nil, true)
+	c.JSON(http.StatusOK, gin.H{"url": iniciado.URL})
+}
+
+// lerInputCallback lê o corpo do callback, junta o state do cookie e apaga o cookie,
+// que vale para uma única tentativa.
+func lerInputCallback(c *gin.Context) (servico.ConcluirLoginExternoInput, bool) {
+	var input servico.ConcluirLoginExternoInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Dados de entrada inválidos"})
+		return input, false
+	}
+	input.EstadoNavegador, _ = c.Cookie(cookieEstadoOIDC)
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(cookieEstadoOIDC, "", -1, "/v1", "", c.Request.TLS != nil, true)
+	return input, true
+}
+
+// Concluir lida com a rota POST /auth/oidc/:provedor/callback.
+// Recebe o 'code' e o 'state' devolvidos pelo provedor ao frontend e retorna o token
+// da aplicação (ou o desafio do 2FA, como no login por senha).
+func (h *LoginExternoHandler) Concluir(c *gin.Context) {
+	input, ok := lerInputCallback(c)
+	if !ok {
+		return
+	}
+
+	resposta, err := h.servico.Concluir(c.Param("provedor"), input, infoCliente(c))
+	if err != nil {
+		responderErroCallback(c, err, "Falha ao concluir o login com o provedor")
+		return
+	}
+
+	c.JSON(http.StatusOK, resposta)
+}
+
+// ConcluirVinculo lida com a rota POST /usuarios/me/identidades/:provedor/callback.
+// Recebe o 'code' e o 'state' como no login e retorna a identidade vinculada.
+func (h *LoginExternoHandler) ConcluirVinculo(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	input, ok := lerInputCallback(c)
+	if !ok {
+		return
+	}
+
+	identidade, err := h.servico.ConcluirVinculo(c.Param("provedor"), usuarioID, input)
+	if err != nil {
+		responderErroCallback(c, err, "Falha ao vincular o provedor")
+		return
+	}
+
+	c.JSON(http.StatusCreated, identidade)
+}
+
+func responderErroCallback(c *gin.Context, err error, mensagemPadrao string) {
+	switch {
+	case err == servico.ErrProvedorDesconhecido:
+		c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+	case err == servico.ErrContaSuspensa:
+		c.JSON(http.StatusForbidden, gin.H{"erro": err.Error()})
+	case err == servico.ErrContaJaExiste, err == servico.ErrIdentidadeEmUso:
+		c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
+	case err == servico.ErrEstadoInvalido, err == servico.ErrEmailNaoVerificado,
+		errors.Is(err, auth.ErrNonceInvalido):
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": err.Error()})
+	default:
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": mensagemPadrao})
+	}
+}
+
+// ListarIdentidades lida com a rota GET /usuarios/me/identidades.
+func (h *LoginExternoHandler) ListarIdentidades(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	identidades, err := h.servico.ListarIdentidades(usuarioID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao listar identidades"})
+		return
+	}
+
+	if identidades == nil {
+		identidades = make([]dominio.IdentidadeExterna, 0)
+	}
+	c.JSON(http.StatusOK, identidades)
+}
+
+// Desvincular lida com a rota DELETE /usuarios/me/identidades/:id.
+func (h *LoginExternoHandler) Desvincular(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	identidadeID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "ID de identidade inválido"})
+		return
+	}
+
+	if err := h.servico.Desvincular(usuarioID, identidadeID); err != nil {
+		switch err {
+		case servico.ErrIdentidadeNaoEncontrada:
+			c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+		case servico.ErrUltimoMetodoLogin:
+			c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao remover identidade"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
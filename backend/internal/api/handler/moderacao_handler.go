@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/gin-gonic/gin"
+)
+
+// ModeracaoHandler gerencia as denúncias e as ações da moderação.
+type ModeracaoHandler struct {
+	servico servico.ModeracaoServico
+}
+
+// NovoModeracaoHandler cria a instância do handler de moderação.
+func NovoModeracaoHandler(s servico.ModeracaoServico) *ModeracaoHandler {
+	return &ModeracaoHandler{servico: s}
+}
+
+// Denunciar lida com a rota POST /denuncias.
+func (h *ModeracaoHandler) Denunciar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	var input servico.DenunciaInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Dados de entrada inválidos"})
+		return
+	}
+
+	denuncia, err := h.servico.Denunciar(usuarioID, input)
+	if err != nil {
+		responderErroModeracao(c, err, "Falha ao registrar a denúncia")
+		return
+	}
+	c.JSON(http.StatusCreated, denuncia)
+}
+
+// ListarMinhas lida com a rota GET /denuncias: as denúncias do usuário e o resultado de cada uma.
+func (h *ModeracaoHandler) ListarMinhas(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	denuncias, err := h.servico.ListarMinhasDenuncias(usuarioID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao listar as denúncias"})
+		return
+	}
+	if denuncias == nil {
+		denuncias = make([]dominio.Denuncia, 0)
+	}
+	c.JSON(http.StatusOK, denuncias)
+}
+
+// ListarAdvertencias lida com a rota GET /usuarios/me/advertencias.
+func (h *ModeracaoHandler) ListarAdvertencias(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	acoes, err := h.servico.ListarAdvertencias(usuarioID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao listar as advertências"})
+		return
+	}
+	if acoes == nil {
+		acoes = make([]dominio.AcaoModeracao, 0)
+	}
+	c.JSON(http.StatusOK, acoes)
+}
+
+// Fila lida com a rota de moderação GET /moderacao/denuncias.
+func (h *ModeracaoHandler) Fila(c *gin.Context) {
+	fila, err := h.servico.Fila()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao montar a fila de denúncias"})
+		return
+	}
+	if fila == nil {
+		fila = make([]servico.GrupoDenuncias, 0)
+	}
+	c.JSON(http.StatusOK, fila)
+}
+
+// Resolver lida com a rota de moderação POST /moderacao/denuncias/:tipo/:id/resolver.
+func (h *ModeracaoHandler) Resolver(c *gin.Context) {
+	moderadorID := c.MustGet("usuarioID").(int64)
+	alvoID, ok := parametroID(c, "id", "ID de conteúdo inválido")
+	if !ok {
+		return
+	}
+
+	var input servico.ResolverDenunciasInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Dados de entrada inválidos"})
+		return
+	}
+
+	acao, err := h.servico.Resolver(moderadorID, c.Param("tipo"), alvoID, input)
+	if err != nil {
+		responderErroModeracao(c, err, "Falha ao resolver as denúncias")
+		return
+	}
+	c.JSON(http.StatusOK, acao)
+}
+
+// Auditoria lida com a rota de moderação GET /moderacao/auditoria?usuarioId=&cursor=&limite=.
+func (h *ModeracaoHandler) Auditoria(c *gin.Context) {
+	usuarioAlvoID, ok := consultaPositiva(c, "usuarioId", "ID de usuário inválido")
+	if !ok {
+		return
+	}
+	limite, ok := consultaPositiva(c, "limite", "Limite inválido")
+	if !ok {
+		return
+	}
+
+	acoes, proximo, err := h.servico.Auditoria(int64(usuarioAlvoID), c.Query("cursor"), limite)
+	if err != nil {
+		responderErroModeracao(c, err, "Falha ao listar as ações da moderação")
+		return
+	}
+	if acoes == nil {
+		acoes = make([]dominio.AcaoModeracao, 0)
+	}
+	if proximo != "" {
+		c.Header("X-Proximo-Cursor", proximo)
+	}
+	c.JSON(http.StatusOK, acoes)
+}
+
+// responderErroModeracao traduz os erros do serviço de moderação para o status HTTP adequado.
+func responderErroModeracao(c *gin.Context, err error, mensagem string) {
+	switch err {
+	case servico.ErrTipoDenunciaInvalido, servico.ErrCursorAuditoriaInvalido:
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+	case servico.ErrConteudoNaoEncontrado, servico.ErrSemDenunciasPendentes:
+		c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+	case servico.ErrDenunciaPropria:
+		c.JSON(http.StatusForbidden, gin.H{"erro": err.Error()})
+	case servico.ErrDenunciaDuplicada:
+		c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
+	case servico.ErrConteudoSemAutor:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"erro": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": mensagem})
+	}
+}
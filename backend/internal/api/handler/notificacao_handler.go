@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/gin-gonic/gin"
+)
+
+// intervaloBatimento é de quanto em quanto tempo o stream envia um comentário vazio, para que
+// proxies não fechem a conexão ociosa.
+const intervaloBatimento = 25 * time.Second
+
+// NotificacaoHandler gerencia as notificações do usuário autenticado.
+type NotificacaoHandler struct {
+	servico     servico.NotificacaoServico
+	authServico servico.AuthServico
+}
+
+// NovaNotificacaoHandler cria a instância do handler de notificações.
+func NovaNotificacaoHandler(s servico.NotificacaoServico, authServico servico.AuthServico) *NotificacaoHandler {
+	return &NotificacaoHandler{servico: s, authServico: authServico}
+}
+
+// MarcarLidaInput é o corpo de PATCH /notificacoes/:id.
+type MarcarLidaInput struct {
+	Lida *bool `json:"lida" binding:"required"`
+}
+
+// Listar lida com a rota GET /notificacoes?naoLidas=true&cursor=&limite=. O cursor da próxima
+// página vai no cabeçalho X-Proximo-Cursor e o total de não lidas em X-Nao-Lidas.
+func (h *NotificacaoHandler) Listar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	limite, ok := consultaPositiva(c, "limite", "Limite inválido")
+	if !ok {
+		return
+	}
+	consulta := servico.ConsultaNotificacoes{
+		ApenasNaoLidas: c.Query("naoLidas") == "true",
+		Cursor:         c.Query("cursor"),
+		Limite:         limite,
+	}
+
+	notificacoes, proximo, naoLidas, err := h.servico.Listar(usuarioID, consulta)
+	if err != nil {
+		responderErroNotificacao(c, err, "Falha ao listar as notificações")
+		return
+	}
+	if notificacoes == nil {
+		notificacoes = make([]dominio.Notificacao, 0)
+	}
+	if proximo != "" {
+		c.Header("X-Proximo-Cursor", proximo)
+	}
+	c.Header("X-Nao-Lidas", strconv.Itoa(naoLidas))
+	c.JSON(http.StatusOK, notificacoes)
+}
+
+// MarcarLida lida com a rota PATCH /notificacoes/:id.
+func (h *NotificacaoHandler) MarcarLida(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	notificacaoID, ok := parametroID(c, "id", "ID de notificação inválido")
+	if !ok {
+		return
+	}
+
+	var input MarcarLidaInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Dados de entrada inválidos"})
+		return
+	}
+
+	if err := h.servico.MarcarLida(usuarioID, notificacaoID, *input.Lida); err != nil {
+		responderErroNotificacao(c, err, "Falha ao marcar a notificação")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// MarcarTodasLidas lida com a rota POST /notificacoes/lidas.
+func (h *NotificacaoHandler) MarcarTodasLidas(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	marcadas, err := h.servico.MarcarTodasLidas(usuarioID)
+	if err != nil {
+		responderErroNotificacao(c, err, "Falha ao marcar as notificações como lidas")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"marcadas": marcadas})
+}
+
+// Preferencias lida com a rota GET /notificacoes/preferencias.
+func (h *NotificacaoHandler) Preferencias(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	preferencias, err := h.servico.Preferencias(usuarioID)
+	if err != nil {
+		responderErroNotificacao(c, err, "Falha ao buscar as preferências de notificação")
+		return
+	}
+	c.JSON(http.StatusOK, preferencias)
+}
+
+// AtualizarPreferencias lida com a rota PATCH /notificacoes/preferencias, cujo corpo liga ou
+// desliga cada tipo, como {"seguidor": false}.
+func (h *NotificacaoHandler) AtualizarPreferencias(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	var input map[string]bool
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Dados de entrada inválidos"})
+		return
+	}
+
+	preferencias, err := h.servico.AtualizarPreferencias(usuarioID, input)
+	if err != nil {
+		responderErroNotificacao(c, err, "Falha ao salvar as preferências de notificação")
+		return
+	}
+	c.JSON(http.StatusOK, preferencias)
+}
+
+// Stream lida com a rota GET /notificacoes/stream, que envia cada notificação nova como um
+// evento "notificacao" de Server-Sent Events. Ao reconectar com o cabeçalho Last-Event-ID (ou
+// ?ultimoId=), as notificações criadas nesse meio-tempo são enviadas primeiro.
+//
+// O stream termina quando o token de acesso expira e, a cada batimento, confere se a sessão e a
+// conta continuam ativas; sessões revogadas, contas suspensas ou agendadas para exclusão o encerram.
+// O cliente deve reconectar com um token novo.
+func (h *NotificacaoHandler) Stream(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	sessaoID := c.GetString("sessaoID")
+	expiraEm, ok := c.Get("tokenExpiraEm")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"erro": "Token sem expiração"})
+		return
+	}
+	expiracao := time.NewTimer(time.Until(expiraEm.(time.Time)))
+	defer expiracao.Stop()
+
+	ultimo := c.GetHeader("Last-Event-ID")
+	if ultimo == "" {
+		ultimo = c.Query("ultimoId")
+	}
+	var ultimoID int64
+	if ultimo != "" {
+		id, err := strconv.ParseInt(ultimo, 10, 64)
+		if err != nil || id < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"erro": "ID do último evento inválido"})
+			return
+		}
+		ultimoID = id
+	}
+
+	perdidas, canal, encerrar, err := h.servico.Assinar(usuarioID, ultimoID)
+	if err != nil {
+		responderErroNotificacao(c, err, "Falha ao abrir o stream de notificações")
+		return
+	}
+	defer encerrar()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	// A assinatura e a consulta das perdidas se sobrepõem; IDs já enviados são descartados.
+	enviar := func(notificacao dominio.Notificacao) bool {
+		if notificacao.ID <= ultimoID {
+			return true
+		}
+		dados, err := json.Marshal(notificacao)
+		if err != nil {
+			return false
+		}
+		if _, err := fmt.Fprintf(c.Writer, "id: %d\nevent: notificacao\ndata: %s\n\n", notificacao.ID, dados); err != nil {
+			return false
+		}
+		c.Writer.Flush()
+		ultimoID = notificacao.ID
+		return true
+	}
+
+	// Um comentário inicial entrega os cabeçalhos mesmo sem notificações pendentes.
+	if _, err := c.Writer.WriteString(": conectado\n\n"); err != nil {
+		return
+	}
+	c.Writer.Flush()
+	for _, notificacao := range perdidas {
+		if !enviar(notificacao) {
+			return
+		}
+	}
+
+	batimento := time.NewTicker(intervaloBatimento)
+	defer batimento.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-expiracao.C:
+			return
+		case notificacao, aberto := <-canal:
+			if !aberto || !enviar(notificacao) {
+				return
+			}
+		case <-batimento.C:
+			ativa, err := h.authServico.ConexaoAtiva(sessaoID, usuarioID)
+			if err != nil || !ativa {
+				return
+			}
+			if _, err := c.Writer.WriteString(": batimento\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// responderErroNotificacao traduz os erros do serviço de notificações para o status HTTP adequado.
+func responderErroNotificacao(c *gin.Context, err error, mensagem string) {
+	switch err {
+	case servico.ErrCursorNotificacoesInvalido:
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+	case servico.ErrNotificacaoNaoEncontrada:
+		c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+	case servico.ErrTipoNotificacaoInvalido:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"erro": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": mensagem})
+	}
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/gin-gonic/gin"
+)
+
+// PerfilHandler gerencia o perfil público e suas configurações de privacidade.
+type PerfilHandler struct {
+	servico servico.PerfilServico
+}
+
+// NovoPerfilHandler cria a instância do handler de perfis.
+func NovoPerfilHandler(s servico.PerfilServico) *PerfilHandler {
+	return &PerfilHandler{servico: s}
+}
+
+// Buscar lida com a rota GET /usuarios/me/perfil.
+func (h *PerfilHandler) Buscar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	perfil, err := h.servico.Buscar(usuarioID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao buscar o perfil"})
+		return
+	}
+	c.JSON(http.StatusOK, perfil)
+}
+
+// Atualizar lida com a rota PATCH /usuarios/me/perfil.
+func (h *PerfilHandler) Atualizar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	var input servico.AtualizarPerfilInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Dados de entrada inválidos"})
+		return
+	}
+
+	perfil, err := h.servico.Atualizar(usuarioID, input)
+	if err != nil {
+		switch err {
+		case servico.ErrSlugInvalido:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"erro": err.Error()})
+		case servico.ErrSlugEmUso:
+			c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao atualizar o perfil"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, perfil)
+}
+
+// BuscarPublico lida com a rota pública GET /perfis/:slug?mostrarSpoilers=true.
+func (h *PerfilHandler) BuscarPublico(c *gin.Context) {
+	perfil, err := h.servico.BuscarPublico(c.Param("slug"), c.Query("mostrarSpoilers") == "true")
+	if err != nil {
+		if err == servico.ErrPerfilNaoEncontrado {
+			c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao buscar o perfil"})
+		return
+	}
+	c.JSON(http.StatusOK, perfil)
+}
+
+// ListarAvaliacoes lida com a rota pública GET /usuarios/:id/avaliacoes?nota=&ano=&mostrarSpoilers=&cursor=&limite=;
+// o cursor da próxima página vai no cabeçalho X-Proximo-Cursor, ausente na última.
+func (h *PerfilHandler) ListarAvaliacoes(c *gin.Context) {
+	usuarioID, ok := parametroID(c, "id", "ID de usuário inválido")
+	if !ok {
+		return
+	}
+	var consulta servico.ConsultaAvaliacoesUsuario
+	if valor := c.Query("nota"); valor != "" {
+		nota, err := strconv.ParseFloat(valor, 64)
+		if err != nil || nota <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"erro": "Nota inválida"})
+			return
+		}
+		consulta.Nota = nota
+	}
+	if consulta.Ano, ok = consultaPositiva(c, "ano", "Ano inválido"); !ok {
+		return
+	}
+	if consulta.Limite, ok = consultaPositiva(c, "limite", "Limite inválido"); !ok {
+		return
+	}
+	consulta.MostrarSpoilers = c.Query("mostrarSpoilers") == "true"
+	consulta.Cursor = c.Query("cursor")
+
+	avaliacoes, proximo, err := h.servico.ListarAvaliacoes(usuarioID, consulta, c.GetInt64("usuarioID"))
+	if err != nil {
+		switch err {
+		case servico.ErrFiltroNotaInvalido, servico.ErrCursorAvaliacoesInvalido:
+			c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+		case servico.ErrPerfilNaoEncontrado:
+			c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao listar as avaliações do usuário"})
+		}
+		return
+	}
+
+	if avaliacoes == nil {
+		avaliacoes = make([]dominio.AvaliacaoComFilme, 0)
+	}
+	if proximo != "" {
+		c.Header("X-Proximo-Cursor", proximo)
+	}
+	c.JSON(http.StatusOK, avaliacoes)
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/gin-gonic/gin"
+)
+
+// SeguidorHandler gerencia quem segue quem e o feed de atividades.
+type SeguidorHandler struct {
+	servico servico.SeguidorServico
+}
+
+// NovoSeguidorHandler cria a instância do handler de seguidores.
+func NovoSeguidorHandler(s servico.SeguidorServico) *SeguidorHandler {
+	return &SeguidorHandler{servico: s}
+}
+
+// Seguir lida com a rota POST /usuarios/:id/seguir.
+func (h *SeguidorHandler) Seguir(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	seguidoID, ok := parametroID(c, "id", "ID de usuário inválido")
+	if !ok {
+		return
+	}
+
+	if err := h.servico.Seguir(usuarioID, seguidoID); err != nil {
+		responderErroSeguidor(c, err, "Falha ao seguir o usuário")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DeixarDeSeguir lida com a rota DELETE /usuarios/:id/seguir.
+func (h *SeguidorHandler) DeixarDeSeguir(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	seguidoID, ok := parametroID(c, "id", "ID de usuário inválido")
+	if !ok {
+		return
+	}
+
+	if err := h.servico.DeixarDeSeguir(usuarioID, seguidoID); err != nil {
+		responderErroSeguidor(c, err, "Falha ao deixar de seguir o usuário")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListarSeguidores lida com a rota pública GET /usuarios/:id/seguidores?cursor=&limite=.
+func (h *SeguidorHandler) ListarSeguidores(c *gin.Context) {
+	h.listar(c, h.servico.ListarSeguidores, "Falha ao listar os seguidores")
+}
+
+// ListarSeguindo lida com a rota pública GET /usuarios/:id/seguindo?cursor=&limite=.
+func (h *SeguidorHandler) ListarSeguindo(c *gin.Context) {
+	h.listar(c, h.servico.ListarSeguindo, "Falha ao listar quem o usuário segue")
+}
+
+// listar responde uma página de usuários; o cursor da próxima vai no cabeçalho X-Proximo-Cursor.
+func (h *SeguidorHandler) listar(
+	c *gin.Context,
+	buscar func(usuarioID int64, cursor string, limite int, leitorID int64) ([]dominio.UsuarioConexao, string, error),
+	mensagem string,
+) {
+	usuarioID, ok := parametroID(c, "id", "ID de usuário inválido")
+	if !ok {
+		return
+	}
+	limite, ok := consultaPositiva(c, "limite", "Limite inválido")
+	if !ok {
+		return
+	}
+
+	conexoes, proximo, err := buscar(usuarioID, c.Query("cursor"), limite, c.GetInt64("usuarioID"))
+	if err != nil {
+		responderErroSeguidor(c, err, mensagem)
+		return
+	}
+	if conexoes == nil {
+		conexoes = make([]dominio.UsuarioConexao, 0)
+	}
+	if proximo != "" {
+		c.Header("X-Proximo-Cursor", proximo)
+	}
+	c.JSON(http.StatusOK, conexoes)
+}
+
+// Feed lida com a rota GET /feed?mostrarSpoilers=&cursor=&limite=.
+func (h *SeguidorHandler) Feed(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	limite, ok := consultaPositiva(c, "limite", "Limite inválido")
+	if !ok {
+		return
+	}
+	consulta := servico.ConsultaFeed{
+		MostrarSpoilers: c.Query("mostrarSpoilers") == "true",
+		Cursor:          c.Query("cursor"),
+		Limite:          limite,
+	}
+
+	itens, proximo, err := h.servico.Feed(usuarioID, consulta)
+	if err != nil {
+		responderErroSeguidor(c, err, "Falha ao montar o feed")
+		return
+	}
+	if itens == nil {
+		itens = make([]dominio.ItemFeed, 0)
+	}
+	if proximo != "" {
+		c.Header("X-Proximo-Cursor", proximo)
+	}
+	c.JSON(http.StatusOK, itens)
+}
+
+// responderErroSeguidor traduz os erros do serviço de seguidores para o status HTTP adequado.
+func responderErroSeguidor(c *gin.Context, err error, mensagem string) {
+	switch err {
+	case servico.ErrCursorSeguidoresInvalido, servico.ErrCursorFeedInvalido:
+		c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+	case servico.ErrPerfilNaoEncontrado, servico.ErrNaoSegue:
+		c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+	case servico.ErrJaSegue:
+		c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
+	case servico.ErrSeguirASiMesmo:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"erro": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": mensagem})
+	}
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/gin-gonic/gin"
+)
+
+// SessaoHandler gerencia as sessões de login do usuário.
+type SessaoHandler struct {
+	servico servico.SessaoServico
+}
+
+// NovoSessaoHandler cria a instância do handler de sessões.
+func NovoSessaoHandler(s servico.SessaoServico) *SessaoHandler {
+	return &SessaoHandler{servico: s}
+}
+
+// Listar lida com a rota GET /usuarios/me/sessoes.
+func (h *SessaoHandler) Listar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+	sessaoAtual := c.GetString("sessaoID")
+
+	sessoes, err := h.servico.Listar(usuarioID, sessaoAtual)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao listar sessões"})
+		return
+	}
+
+	if sessoes == nil {
+		sessoes = make([]dominio.Sessao, 0)
+	}
+	c.JSON(http.StatusOK, sessoes)
+}
+
+// Revogar lida com a rota DELETE /usuarios/me/sessoes/:id.
+// Revogar a própria sessão equivale a fazer logout.
+func (h *SessaoHandler) Revogar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	if err := h.servico.Revogar(usuarioID, c.Param("id")); err != nil {
+		if err == servico.ErrSessaoNaoEncontrada {
+			c.JSON(http.StatusNotFound, gin.H{"erro": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao encerrar sessão"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RevogarOutras lida com a rota DELETE /usuarios/me/sessoes.
+// Encerra todas as sessões do usuário, exceto a da requisição atual.
+func (h *SessaoHandler) RevogarOutras(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	encerradas, err := h.servico.RevogarOutras(usuarioID, c.GetString("sessaoID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao encerrar sessões"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessoesEncerradas": encerradas})
+}
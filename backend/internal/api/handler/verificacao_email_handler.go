@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/gin-gonic/gin"
+)
+
+// VerificacaoEmailHandler gerencia a confirmação do e-mail das contas.
+type VerificacaoEmailHandler struct {
+	servico servico.VerificacaoEmailServico
+}
+
+// NovaVerificacaoEmailHandler cria a instância do handler de verificação de e-mail.
+func NovaVerificacaoEmailHandler(s servico.VerificacaoEmailServico) *VerificacaoEmailHandler {
+	return &VerificacaoEmailHandler{servico: s}
+}
+
+// confirmarEmailInput define o corpo de POST /auth/email/confirmar.
+type confirmarEmailInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// Solicitar lida com a rota POST /usuarios/me/email/verificacao.
+// Envia ao e-mail da conta o link de confirmação.
+func (h *VerificacaoEmailHandler) Solicitar(c *gin.Context) {
+	usuarioID := c.MustGet("usuarioID").(int64)
+
+	if err := h.servico.Solicitar(usuarioID); err != nil {
+		if err == servico.ErrEmailJaVerificado {
+			c.JSON(http.StatusConflict, gin.H{"erro": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"erro": "Falha ao enviar o e-mail de confirmação"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"mensagem": "Enviamos um link de confirmação para o seu e-mail"})
+}
+
+// Confirmar lida com a rota POST /auth/email/confirmar.
+// Recebe o token do link enviado por e-mail; não exige login.
+func (h *VerificacaoEmailHandler) Confirmar(c *gin.Context) {
+	var input confirmarEmailInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"erro": "Dados de entrada inválidos"})
+		return
+	}
+
+	if err := h.servico.Confirmar(input.Token); err != nil {
+		if err == servico.ErrTokenVerificacaoInvalido {
+			c.JSON(http.StatusBadRequest, gin.H{"erro": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao confirmar o e-mail"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"mensagem": "E-mail confirmado"})
+}
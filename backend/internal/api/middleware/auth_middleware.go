@@ -1,16 +1,22 @@
 package middleware
 
 import (
-	"fmt"
 	"net/http"
 	"strings"
 
+	"github.com/Andydev0/filmes-backend/internal/auth"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
+// VerificadorSessao confirma que a sessão referenciada por um token continua ativa.
+type VerificadorSessao interface {
+	SessaoAtiva(sessaoID string, usuarioID int64) (bool, error)
+}
+
 // AuthMiddleware cria um middleware do Gin para validar o token JWT.
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// O token é verificado pela chave indicada no 'kid', com emissor e audiência fixados,
+// e a sessão indicada no 'sid' não pode ter sido revogada.
+func AuthMiddleware(chaves *auth.ConjuntoChaves, sessoes VerificadorSessao) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Pega o header de autorização da requisição.
 		authHeader := c.GetHeader("Authorization")
@@ -28,34 +34,55 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 
 		tokenString := headerParts[1]
 
-		// Valida o token.
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Verifica se o método de assinatura é o esperado (HS256).
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("método de assinatura inesperado: %v", token.Header["alg"])
-			}
-			return []byte(jwtSecret), nil
-		})
-
+		// Valida o token (assinatura, expiração, emissor e audiência).
+		claims, err := chaves.Validar(tokenString)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"erro": "Token inválido: " + err.Error()})
 			return
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			// Extrai o ID do usuário (subject) do token.
-			usuarioID, ok := claims["sub"].(float64) // O parser JSON trata números como float64
-			if !ok {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"erro": "Claim de usuário inválida"})
-				return
-			}
+		// Extrai o ID do usuário (subject) do token.
+		usuarioID, err := claims.UsuarioID()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"erro": "Claim de usuário inválida"})
+			return
+		}
+
+		// Rejeita tokens cuja sessão foi encerrada (ex.: "sair de todos os dispositivos").
+		if claims.SessaoID == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"erro": "Token sem sessão associada"})
+			return
+		}
+		ativa, err := sessoes.SessaoAtiva(claims.SessaoID, usuarioID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao verificar a sessão"})
+			return
+		}
+		if !ativa {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"erro": "Sessão encerrada"})
+			return
+		}
+
+		// Adiciona o ID do usuário, o da sessão e a expiração do token ao contexto da requisição.
+		// As próximas funções (handlers) poderão acessar estes valores.
+		c.Set("usuarioID", usuarioID)
+		c.Set("sessaoID", claims.SessaoID)
+		if claims.ExpiresAt != nil {
+			c.Set("tokenExpiraEm", claims.ExpiresAt.Time)
+		}
+		c.Next() // Passa a requisição para o próximo handler.
+	}
+}
 
-			// Adiciona o ID do usuário ao contexto da requisição.
-			// As próximas funções (handlers) poderão acessar este valor.
-			c.Set("usuarioID", int64(usuarioID))
-			c.Next() // Passa a requisição para o próximo handler.
-		} else {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"erro": "Token ou claims inválidos"})
+// AuthOpcionalMiddleware autentica a requisição quando ela traz o header de autorização e a deixa
+// seguir anônima quando não traz. Um token enviado, mas inválido, continua sendo rejeitado.
+func AuthOpcionalMiddleware(chaves *auth.ConjuntoChaves, sessoes VerificadorSessao) gin.HandlerFunc {
+	autenticar := AuthMiddleware(chaves, sessoes)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
 		}
+		autenticar(c)
 	}
 }
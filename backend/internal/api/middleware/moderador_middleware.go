@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/gin-gonic/gin"
+)
+
+// BuscadorUsuario carrega a conta do usuário autenticado.
+type BuscadorUsuario interface {
+	BuscarPorID(id int64) (*dominio.Usuario, error)
+}
+
+// ModeradorMiddleware restringe a rota a contas com o papel de moderador. Deve ser usado depois
+// do AuthMiddleware; o papel é lido do banco a cada requisição, para que uma mudança valha na hora.
+func ModeradorMiddleware(usuarios BuscadorUsuario) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		usuario, err := usuarios.BuscarPorID(c.MustGet("usuarioID").(int64))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao verificar as permissões"})
+			return
+		}
+		if usuario.Papel != dominio.PapelModerador {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"erro": "Acesso restrito a moderadores"})
+			return
+		}
+		c.Next()
+	}
+}
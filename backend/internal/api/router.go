@@ -10,6 +10,7 @@ import (
 
 	"github.com/Andydev0/filmes-backend/internal/api/handler"
 	"github.com/Andydev0/filmes-backend/internal/api/middleware"
+	"github.com/Andydev0/filmes-backend/internal/auth"
 	"github.com/Andydev0/filmes-backend/internal/repositorio"
 	"github.com/Andydev0/filmes-backend/internal/servico"
 	"github.com/gin-contrib/cors"
@@ -24,11 +25,14 @@ import (
 // Parâmetros:
 //   - chaveAPI: Chave de API para o serviço TMDB
 //   - db: Conexão com o banco de dados
-//   - jwtSecret: Chave secreta para assinatura de tokens JWT
+//   - chaves: Conjunto de chaves usado para assinar e validar tokens JWT
+//   - provedores: Provedores OpenID Connect habilitados para login social
+//   - configAvaliacoes: Tamanho máximo e filtro de conteúdo dos comentários das avaliações
+//   - difusor: Difusor que entrega as notificações novas às conexões abertas (SSE)
 //
 // Retorno:
 //   - Engine do Gin configurado com todas as rotas e middlewares
-func SetupRouter(chaveAPI string, db *sqlx.DB, jwtSecret string) *gin.Engine {
+func SetupRouter(chaveAPI string, db *sqlx.DB, chaves *auth.ConjuntoChaves, provedores map[string]*auth.ProvedorOIDC, configAvaliacoes *servico.ConfigAvaliacoes, configEmail *servico.ConfigEmail, difusor servico.DifusorNotificacoes) *gin.Engine {
 	// Inicialização de todos os componentes da aplicação usando injeção de dependência
 	
 	// Componentes relacionados a filmes
@@ -37,29 +41,114 @@ func SetupRouter(chaveAPI string, db *sqlx.DB, jwtSecret string) *gin.Engine {
 	
 	// Componentes relacionados a usuários e autenticação
 	usuarioRepo := repositorio.NovoUsuarioRepositorio(db)
-	authServico := servico.NovoAuthServico(usuarioRepo, jwtSecret)
+	sessaoRepo := repositorio.NovoSessaoRepositorio(db)
+	sessaoServico := servico.NovoSessaoServico(sessaoRepo)
+	sessaoHandler := handler.NovoSessaoHandler(sessaoServico)
+	codigoRecuperacaoRepo := repositorio.NovoCodigoRecuperacaoRepositorio(db)
+	doisFatoresServico := servico.NovoDoisFatoresServico(usuarioRepo, codigoRecuperacaoRepo)
+	doisFatoresHandler := handler.NovoDoisFatoresHandler(doisFatoresServico)
+	authServico := servico.NovoAuthServico(usuarioRepo, chaves, doisFatoresServico, sessaoServico)
 	authHandler := handler.NovoAuthHandler(authServico)
+	chavesHandler := handler.NovoChavesHandler(chaves)
+	verificacaoEmailServico := servico.NovaVerificacaoEmailServico(usuarioRepo, configEmail)
+	verificacaoEmailHandler := handler.NovaVerificacaoEmailHandler(verificacaoEmailServico)
+
+	// Componentes relacionados ao login com provedores externos (OIDC)
+	identidadeRepo := repositorio.NovoIdentidadeRepositorio(db)
+	loginExternoServico := servico.NovoLoginExternoServico(provedores, identidadeRepo, usuarioRepo, authServico)
+	loginExternoHandler := handler.NovoLoginExternoHandler(loginExternoServico)
 	
+	// Registro de atividades que monta o feed dos seguidores (gravado pelos serviços abaixo)
+	atividadeRepo := repositorio.NovaAtividadeRepositorio(db)
+
+	// Componentes relacionados às notificações (criadas pelos serviços abaixo)
+	notificacaoRepo := repositorio.NovaNotificacaoRepositorio(db)
+	notificacaoServico := servico.NovaNotificacaoServico(notificacaoRepo, difusor)
+	notificacaoHandler := handler.NovaNotificacaoHandler(notificacaoServico, authServico)
+
 	// Componentes relacionados a favoritos
 	favoritoRepo := repositorio.NovoFavoritoRepositorio(db)
-	favoritoServico := servico.NovoFavoritoServico(favoritoRepo)
+	catalogoServico := servico.NovoCatalogoServico(repositorio.NovoCatalogoRepositorio(db), filmeServico)
+	favoritoServico := servico.NovoFavoritoServico(favoritoRepo, atividadeRepo, catalogoServico)
 	favoritoHandler := handler.NovoFavoritoHandler(favoritoServico)
+
+	// Componentes relacionados às listas (os favoritos são a lista embutida de cada usuário)
+	listaRepo := repositorio.NovoListaRepositorio(db)
+	colaboracaoRepo := repositorio.NovoColaboracaoRepositorio(db)
+	listaServico := servico.NovaListaServico(listaRepo, colaboracaoRepo, atividadeRepo, catalogoServico)
+	listaHandler := handler.NovaListaHandler(listaServico)
 	
 	// Componentes relacionados a recomendações
 	recomendacaoServico := servico.NovoRecomendacaoServico(favoritoRepo, filmeServico, chaveAPI)
 	recomendacaoHandler := handler.NovoRecomendacaoHandler(recomendacaoServico)
 	
 	// Componentes relacionados ao quiz
-	quizServico := servico.NovoQuizServico(chaveAPI, favoritoRepo, filmeServico)
+	quizRepo := repositorio.NovoQuizRepositorio(db)
+	quizServico := servico.NovoQuizServico(chaveAPI, favoritoRepo, quizRepo, filmeServico)
 	quizHandler := handler.NovoQuizHandler(quizServico)
 	
 	// Componentes relacionados a avaliações
 	avaliacaoRepo := repositorio.NovaAvaliacaoRepositorio(db)
-	avaliacaoServico := servico.NovaAvaliacaoServico(avaliacaoRepo)
+	avaliacaoServico := servico.NovaAvaliacaoServico(avaliacaoRepo, atividadeRepo, catalogoServico, configAvaliacoes)
 	avaliacaoHandler := handler.NovaAvaliacaoHandler(avaliacaoServico)
 
+	// Componentes relacionados aos comentários nas avaliações
+	comentarioRepo := repositorio.NovoComentarioRepositorio(db)
+	comentarioServico := servico.NovoComentarioServico(comentarioRepo, avaliacaoRepo, usuarioRepo, notificacaoServico)
+	comentarioHandler := handler.NovoComentarioHandler(comentarioServico)
+
+	// Componentes relacionados às denúncias e à moderação
+	moderacaoRepo := repositorio.NovoModeracaoRepositorio(db)
+	perfilRepo := repositorio.NovoPerfilRepositorio(db)
+	moderacaoServico := servico.NovoModeracaoServico(moderacaoRepo, avaliacaoRepo, comentarioRepo, perfilRepo, usuarioRepo, notificacaoServico)
+	moderacaoHandler := handler.NovoModeracaoHandler(moderacaoServico)
+
+	// Componentes relacionados ao diário de filmes assistidos
+	diarioRepo := repositorio.NovoDiarioRepositorio(db)
+	diarioServico := servico.NovoDiarioServico(diarioRepo, avaliacaoRepo, atividadeRepo, catalogoServico)
+	diarioHandler := handler.NovoDiarioHandler(diarioServico)
+
+	// Componentes relacionados à importação de histórico (Letterboxd e IMDb)
+	importacaoRepo := repositorio.NovaImportacaoRepositorio(db)
+	importacaoServico := servico.NovaImportacaoServico(importacaoRepo, filmeServico, avaliacaoRepo, diarioRepo, listaRepo)
+	importacaoHandler := handler.NovaImportacaoHandler(importacaoServico)
+
+	// Componentes relacionados à exportação de favoritos, listas, diário e avaliações em arquivo
+	exportacaoServico := servico.NovaExportacaoServico(listaServico, catalogoServico, listaRepo, avaliacaoRepo, diarioRepo)
+	exportacaoHandler := handler.NovaExportacaoHandler(exportacaoServico)
+
+	// Componentes relacionados aos perfis públicos
+	perfilServico := servico.NovoPerfilServico(perfilRepo, usuarioRepo, listaRepo, avaliacaoRepo, catalogoServico)
+	perfilHandler := handler.NovoPerfilHandler(perfilServico)
+
+	// Componentes relacionados aos seguidores e ao feed
+	seguidorRepo := repositorio.NovoSeguidorRepositorio(db)
+	seguidorServico := servico.NovoSeguidorServico(seguidorRepo, atividadeRepo, usuarioRepo, perfilRepo, catalogoServico, notificacaoServico)
+	seguidorHandler := handler.NovoSeguidorHandler(seguidorServico)
+
+	// Componentes relacionados às listas colaborativas (membros e convites)
+	colaboracaoServico := servico.NovaColaboracaoServico(listaServico, colaboracaoRepo, perfilRepo)
+	colaboracaoHandler := handler.NovaColaboracaoHandler(colaboracaoServico)
+
+	// Componentes relacionados à exportação de dados e exclusão da conta
+	contaServico := servico.NovaContaServico(usuarioRepo, perfilRepo, favoritoRepo, listaRepo, colaboracaoRepo, diarioRepo, avaliacaoRepo, comentarioRepo, moderacaoRepo, quizRepo, importacaoRepo, seguidorRepo, atividadeRepo, notificacaoRepo, identidadeRepo, sessaoRepo, doisFatoresServico)
+	contaHandler := handler.NovoContaHandler(contaServico)
+
 	// Inicialização do router Gin
 	router := gin.Default()
+
+	// Proxies confiáveis: só deles o Gin aceita o X-Forwarded-For ao calcular o IP do cliente
+	// (usado nas sessões). Sem a variável, nenhum proxy é confiável e vale o IP da conexão, pois o
+	// cabeçalho poderia ser forjado pelo próprio cliente.
+	var proxies []string
+	if trustedProxies := os.Getenv("TRUSTED_PROXIES"); trustedProxies != "" {
+		for _, proxy := range strings.Split(trustedProxies, ",") {
+			proxies = append(proxies, strings.TrimSpace(proxy))
+		}
+	}
+	if err := router.SetTrustedProxies(proxies); err != nil {
+		log.Fatalf("TRUSTED_PROXIES inválido: %v", err)
+	}
 	
 	// Configuração do middleware CORS
 	config := cors.DefaultConfig()
@@ -77,10 +166,16 @@ func SetupRouter(chaveAPI string, db *sqlx.DB, jwtSecret string) *gin.Engine {
 		log.Println("CORS configurado para permitir apenas localhost:5173")
 	}
 	
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization"}
+	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", "If-Match"}
+	config.ExposeHeaders = []string{"X-Proximo-Cursor", "X-Nao-Lidas", "ETag"}
+	// O cookie do state do login externo só é enviado pelo frontend com credenciais.
+	config.AllowCredentials = true
 	router.Use(cors.New(config))
 
+	// GET /.well-known/jwks.json - Publica as chaves públicas de verificação dos tokens
+	router.GET("/.well-known/jwks.json", chavesHandler.JWKS)
+
 	// Grupo de rotas com prefixo /v1 (versionamento da API)
 	apiV1 := router.Group("/v1")
 	{
@@ -94,6 +189,18 @@ func SetupRouter(chaveAPI string, db *sqlx.DB, jwtSecret string) *gin.Engine {
 			
 			// POST /v1/auth/login - Autentica um usuário existente
 			auth.POST("/login", authHandler.Login)
+
+			// POST /v1/auth/login/2fa - Segundo passo do login para contas com 2FA
+			auth.POST("/login/2fa", authHandler.Login2FA)
+
+			// POST /v1/auth/email/confirmar - Confirma o e-mail da conta com o token do link enviado
+			auth.POST("/email/confirmar", verificacaoEmailHandler.Confirmar)
+
+			// GET /v1/auth/oidc/:provedor/iniciar - Retorna a URL de login do provedor
+			auth.GET("/oidc/:provedor/iniciar", loginExternoHandler.Iniciar)
+
+			// POST /v1/auth/oidc/:provedor/callback - Conclui o login com o código do provedor
+			auth.POST("/oidc/:provedor/callback", loginExternoHandler.Concluir)
 		}
 
 		// Rotas de busca de filmes (públicas)
@@ -116,28 +223,163 @@ func SetupRouter(chaveAPI string, db *sqlx.DB, jwtSecret string) *gin.Engine {
 			// GET /v1/filmes/:id - Busca detalhes de um filme específico
 			filmePorId.GET("", filmeHandler.BuscarDetalhes)
 			
-			// GET /v1/filmes/:id/avaliacoes - Lista avaliações de um filme específico
-			filmePorId.GET("/avaliacoes", avaliacaoHandler.ListarPorFilme)
+			// GET /v1/filmes/:id/avaliacoes?ordenar={recentes|uteis|nota_alta|nota_baixa}&mostrarSpoilers=true&cursor={cursor}&limite={n}
+			// - Lista avaliações de um filme específico (com login, indica a reação do usuário em cada uma)
+			filmePorId.GET("/avaliacoes", middleware.AuthOpcionalMiddleware(chaves, sessaoServico), avaliacaoHandler.ListarPorFilme)
+
+			// GET /v1/filmes/:id/estatisticas - Total, média e distribuição das notas (com login, inclui a nota do usuário)
+			filmePorId.GET("/estatisticas", middleware.AuthOpcionalMiddleware(chaves, sessaoServico), avaliacaoHandler.Estatisticas)
 		}
 
+		// GET /v1/avaliacoes/:id/comentarios?cursor={cursor}&limite={n} - Comentários da avaliação, cada um com suas respostas
+		apiV1.GET("/avaliacoes/:id/comentarios", comentarioHandler.Listar)
+
+		// GET /v1/ranking?genero={id}&decada={ano}&votosMinimos={n}&limite={n} - Filmes mais bem avaliados pela comunidade
+		apiV1.GET("/ranking", avaliacaoHandler.Ranking)
+
+		// Perfis e listas compartilhadas (públicos; nunca expõem o email)
+
+		// GET /v1/perfis/:slug?mostrarSpoilers=true - Perfil público com as seções que o dono escolheu mostrar
+		apiV1.GET("/perfis/:slug", perfilHandler.BuscarPublico)
+
+		// GET /v1/usuarios/:id/avaliacoes?nota={n}&ano={ano}&mostrarSpoilers=true&cursor={cursor}&limite={n} - Histórico de avaliações
+		// do usuário com os dados do filme (respeita a privacidade do perfil, exceto para o próprio dono)
+		apiV1.GET("/usuarios/:id/avaliacoes", middleware.AuthOpcionalMiddleware(chaves, sessaoServico), perfilHandler.ListarAvaliacoes)
+
+		// GET /v1/usuarios/:id/seguidores?cursor={cursor}&limite={n} - Quem segue o usuário
+		apiV1.GET("/usuarios/:id/seguidores", middleware.AuthOpcionalMiddleware(chaves, sessaoServico), seguidorHandler.ListarSeguidores)
+
+		// GET /v1/usuarios/:id/seguindo?cursor={cursor}&limite={n} - Quem o usuário segue
+		apiV1.GET("/usuarios/:id/seguindo", middleware.AuthOpcionalMiddleware(chaves, sessaoServico), seguidorHandler.ListarSeguindo)
+
+		// GET /v1/listas-compartilhadas/:slug - Lista pública ou não listada pelo link de compartilhamento
+		apiV1.GET("/listas-compartilhadas/:slug", listaHandler.BuscarCompartilhada)
+
 		// ===== ROTAS PROTEGIDAS =====
 		// Todas as rotas abaixo requerem autenticação via JWT
 		autenticado := apiV1.Group("/")
-		autenticado.Use(middleware.AuthMiddleware(jwtSecret))
+		autenticado.Use(middleware.AuthMiddleware(chaves, sessaoServico))
 		{
 			// Rotas para gerenciamento de favoritos
 			favoritos := autenticado.Group("/favoritos")
 			{
 				// POST /v1/favoritos - Adiciona um filme aos favoritos
 				favoritos.POST("", favoritoHandler.Adicionar)
+
+				// POST /v1/favoritos/lote - Adiciona e remove vários favoritos em uma única transação
+				favoritos.POST("/lote", favoritoHandler.AplicarLote)
 				
-				// GET /v1/favoritos - Lista todos os filmes favoritos do usuário
+				// GET /v1/favoritos - Lista os favoritos do usuário, com filtros, ordenação e paginação por cursor
 				favoritos.GET("", favoritoHandler.Listar)
+
+				// PATCH /v1/favoritos/:id - Edita a anotação e as tags de um favorito
+				favoritos.PATCH("/:id", favoritoHandler.Atualizar)
 				
 				// DELETE /v1/favoritos/:id - Remove um filme dos favoritos
 				favoritos.DELETE("/:id", favoritoHandler.Remover)
 			}
 			
+			// Rotas para as listas de filmes do usuário
+			listas := autenticado.Group("/listas")
+			{
+				// GET /v1/listas - Lista as listas do usuário (incluindo a de favoritos) e as de que ele é membro
+				listas.GET("", listaHandler.Listar)
+
+				// POST /v1/listas - Cria uma lista
+				listas.POST("", listaHandler.Criar)
+
+				// GET /v1/listas/:id - Busca uma lista com seus filmes
+				listas.GET("/:id", listaHandler.Buscar)
+
+				// PATCH /v1/listas/:id - Altera título, descrição ou visibilidade (If-Match opcional com a versão)
+				listas.PATCH("/:id", listaHandler.Atualizar)
+
+				// DELETE /v1/listas/:id - Exclui uma lista
+				listas.DELETE("/:id", listaHandler.Excluir)
+
+				// POST /v1/listas/:id/itens - Adiciona um filme ao fim da lista ou na posição informada
+				listas.POST("/:id/itens", listaHandler.AdicionarItem)
+
+				// PATCH /v1/listas/:id/itens/:filmeId - Move o filme para outra posição
+				listas.PATCH("/:id/itens/:filmeId", listaHandler.MoverItem)
+
+				// DELETE /v1/listas/:id/itens/:filmeId - Remove um filme da lista
+				listas.DELETE("/:id/itens/:filmeId", listaHandler.RemoverItem)
+
+				// PUT /v1/listas/:id/ordem - Define a ordem de todos os filmes de uma vez
+				listas.PUT("/:id/ordem", listaHandler.DefinirOrdem)
+
+				// POST /v1/listas/:id/convites - Convida um usuário (pelo perfil) ou cria um link de convite
+				listas.POST("/:id/convites", colaboracaoHandler.CriarConvite)
+
+				// GET /v1/listas/:id/convites - Lista os convites ainda válidos
+				listas.GET("/:id/convites", colaboracaoHandler.ListarConvites)
+
+				// DELETE /v1/listas/:id/convites/:conviteId - Revoga um convite
+				listas.DELETE("/:id/convites/:conviteId", colaboracaoHandler.RevogarConvite)
+
+				// GET /v1/listas/:id/membros - Lista o dono e os membros da lista
+				listas.GET("/:id/membros", colaboracaoHandler.ListarMembros)
+
+				// PATCH /v1/listas/:id/membros/:usuarioId - Altera o papel de um membro
+				listas.PATCH("/:id/membros/:usuarioId", colaboracaoHandler.AlterarPapel)
+
+				// DELETE /v1/listas/:id/membros/:usuarioId - Remove um membro (ou sai da lista)
+				listas.DELETE("/:id/membros/:usuarioId", colaboracaoHandler.RemoverMembro)
+			}
+
+			// Convites recebidos para listas colaborativas
+			convites := autenticado.Group("/convites")
+			{
+				// GET /v1/convites - Lista os convites pessoais pendentes
+				convites.GET("", colaboracaoHandler.ListarConvitesRecebidos)
+
+				// POST /v1/convites/:codigo/aceitar - Aceita um convite pessoal ou de link
+				convites.POST("/:codigo/aceitar", colaboracaoHandler.AceitarConvite)
+			}
+
+			// Rotas da lista "para assistir" (lista embutida, também acessível por /v1/listas/:id)
+			assistir := autenticado.Group("/assistir")
+			{
+				// GET /v1/assistir - Lista os filmes que o usuário quer assistir
+				assistir.GET("", listaHandler.ListarParaAssistir)
+
+				// POST /v1/assistir - Adiciona um filme à lista
+				assistir.POST("", listaHandler.AdicionarParaAssistir)
+
+				// DELETE /v1/assistir/:filmeId - Remove um filme da lista
+				assistir.DELETE("/:filmeId", listaHandler.RemoverParaAssistir)
+			}
+
+			// Rotas do diário de filmes assistidos
+			diario := autenticado.Group("/diario")
+			{
+				// POST /v1/diario - Registra um filme assistido (e o tira da lista "para assistir")
+				diario.POST("", diarioHandler.Registrar)
+
+				// GET /v1/diario?ano={ano} - Entradas do ano agrupadas por mês
+				diario.GET("", diarioHandler.Listar)
+
+				// DELETE /v1/diario/:id - Remove uma entrada do diário
+				diario.DELETE("/:id", diarioHandler.Remover)
+			}
+
+			// GET /v1/exportar?formato={csv|json|letterboxd}&escopo={favoritos|avaliacoes|diario|lista:<id>}
+			autenticado.GET("/exportar", exportacaoHandler.Exportar)
+
+			// Rotas de importação de histórico de outros serviços
+			importacoes := autenticado.Group("/importacoes")
+			{
+				// POST /v1/importacoes - Envia CSVs do Letterboxd ou do IMDb para importação em segundo plano
+				importacoes.POST("", importacaoHandler.Importar)
+
+				// GET /v1/importacoes - Lista as importações do usuário
+				importacoes.GET("", importacaoHandler.Listar)
+
+				// GET /v1/importacoes/:id - Andamento da importação e linhas não importadas
+				importacoes.GET("/:id", importacaoHandler.Buscar)
+			}
+
 			// GET /v1/recomendacoes - Obtém recomendações personalizadas
 			autenticado.GET("/recomendacoes", recomendacaoHandler.ObterRecomendacoes)
 			
@@ -146,6 +388,144 @@ func SetupRouter(chaveAPI string, db *sqlx.DB, jwtSecret string) *gin.Engine {
 
 			// POST /v1/filmes/:id/avaliacoes - Cria uma nova avaliação para um filme
 			autenticado.POST("/filmes/:id/avaliacoes", avaliacaoHandler.Criar)
+
+			// GET /v1/filmes/:id/avaliacoes/minha - Avaliação do usuário logado para o filme
+			autenticado.GET("/filmes/:id/avaliacoes/minha", avaliacaoHandler.BuscarMinha)
+
+			// PUT /v1/filmes/:id/avaliacoes/minha - Cria ou edita a avaliação (guarda a versão anterior)
+			autenticado.PUT("/filmes/:id/avaliacoes/minha", avaliacaoHandler.SalvarMinha)
+
+			// DELETE /v1/filmes/:id/avaliacoes/minha - Exclui a avaliação
+			autenticado.DELETE("/filmes/:id/avaliacoes/minha", avaliacaoHandler.ExcluirMinha)
+
+			// PUT /v1/avaliacoes/:id/reacao - Marca a avaliação de outra pessoa como útil ou curtida
+			autenticado.PUT("/avaliacoes/:id/reacao", avaliacaoHandler.Reagir)
+
+			// DELETE /v1/avaliacoes/:id/reacao - Desfaz a reação
+			autenticado.DELETE("/avaliacoes/:id/reacao", avaliacaoHandler.RemoverReacao)
+
+			// POST /v1/avaliacoes/:id/comentarios - Comenta a avaliação ou responde a um comentário
+			autenticado.POST("/avaliacoes/:id/comentarios", comentarioHandler.Comentar)
+
+			// PATCH /v1/comentarios/:id - Edita um comentário próprio
+			autenticado.PATCH("/comentarios/:id", comentarioHandler.Editar)
+
+			// DELETE /v1/comentarios/:id - Apaga o comentário (autor, autor da avaliação ou moderador)
+			autenticado.DELETE("/comentarios/:id", comentarioHandler.Excluir)
+
+			// POST /v1/usuarios/:id/seguir - Passa a seguir o usuário
+			autenticado.POST("/usuarios/:id/seguir", seguidorHandler.Seguir)
+
+			// DELETE /v1/usuarios/:id/seguir - Deixa de seguir o usuário
+			autenticado.DELETE("/usuarios/:id/seguir", seguidorHandler.DeixarDeSeguir)
+
+			// GET /v1/feed?mostrarSpoilers=true&cursor={cursor}&limite={n} - Atividade recente de quem o usuário segue
+			autenticado.GET("/feed", seguidorHandler.Feed)
+
+			// GET /v1/notificacoes?naoLidas=true&cursor={cursor}&limite={n} - Notificações do usuário
+			autenticado.GET("/notificacoes", notificacaoHandler.Listar)
+
+			// GET /v1/notificacoes/stream - Notificações novas em tempo real (Server-Sent Events)
+			autenticado.GET("/notificacoes/stream", notificacaoHandler.Stream)
+
+			// POST /v1/notificacoes/lidas - Marca todas as notificações como lidas
+			autenticado.POST("/notificacoes/lidas", notificacaoHandler.MarcarTodasLidas)
+
+			// GET /v1/notificacoes/preferencias - Tipos de notificação ativos
+			autenticado.GET("/notificacoes/preferencias", notificacaoHandler.Preferencias)
+
+			// PATCH /v1/notificacoes/preferencias - Liga ou desliga tipos de notificação
+			autenticado.PATCH("/notificacoes/preferencias", notificacaoHandler.AtualizarPreferencias)
+
+			// PATCH /v1/notificacoes/:id - Marca uma notificação como lida ou não lida
+			autenticado.PATCH("/notificacoes/:id", notificacaoHandler.MarcarLida)
+
+			// POST /v1/denuncias - Denuncia uma avaliação, um comentário ou um perfil
+			autenticado.POST("/denuncias", moderacaoHandler.Denunciar)
+
+			// GET /v1/denuncias - Denúncias do usuário e o resultado de cada uma
+			autenticado.GET("/denuncias", moderacaoHandler.ListarMinhas)
+
+			// Rotas de moderação (apenas contas com o papel de moderador)
+			moderacao := autenticado.Group("/moderacao")
+			moderacao.Use(middleware.ModeradorMiddleware(usuarioRepo))
+			{
+				// GET /v1/moderacao/avaliacoes/:id/historico - Avaliação atual e versões anteriores
+				moderacao.GET("/avaliacoes/:id/historico", avaliacaoHandler.Historico)
+
+				// GET /v1/moderacao/avaliacoes/pendentes - Avaliações e edições retidas pelo filtro de conteúdo
+				moderacao.GET("/avaliacoes/pendentes", avaliacaoHandler.ListarPendentes)
+
+				// POST /v1/moderacao/avaliacoes/:id/aprovar - Publica uma avaliação ou edição retida
+				moderacao.POST("/avaliacoes/:id/aprovar", avaliacaoHandler.Aprovar)
+
+				// POST /v1/moderacao/avaliacoes/:id/rejeitar - Exclui uma avaliação retida ou descarta a edição retida
+				moderacao.POST("/avaliacoes/:id/rejeitar", avaliacaoHandler.Rejeitar)
+
+				// GET /v1/moderacao/denuncias - Denúncias pendentes agrupadas por conteúdo
+				moderacao.GET("/denuncias", moderacaoHandler.Fila)
+
+				// POST /v1/moderacao/denuncias/:tipo/:id/resolver - Descarta, oculta, adverte ou suspende
+				moderacao.POST("/denuncias/:tipo/:id/resolver", moderacaoHandler.Resolver)
+
+				// GET /v1/moderacao/auditoria?usuarioId={id}&cursor={cursor}&limite={n} - Registro das ações da moderação
+				moderacao.GET("/auditoria", moderacaoHandler.Auditoria)
+			}
+
+			// Rotas da conta do usuário logado
+			usuarioAtual := autenticado.Group("/usuarios/me")
+			{
+				// DELETE /v1/usuarios/me - Agenda a exclusão da conta (com prazo de carência)
+				usuarioAtual.DELETE("", contaHandler.Excluir)
+
+				// GET /v1/usuarios/me/perfil - Endereço do perfil público e privacidade de cada seção
+				usuarioAtual.GET("/perfil", perfilHandler.Buscar)
+
+				// PATCH /v1/usuarios/me/perfil - Altera o endereço e as seções visíveis do perfil
+				usuarioAtual.PATCH("/perfil", perfilHandler.Atualizar)
+
+				// GET /v1/usuarios/me/exportar?formato={zip|json} - Exporta todos os dados pessoais
+				usuarioAtual.GET("/exportar", contaHandler.Exportar)
+
+				// GET /v1/usuarios/me/advertencias - Ações da moderação contra o conteúdo ou a conta
+				usuarioAtual.GET("/advertencias", moderacaoHandler.ListarAdvertencias)
+
+				// POST /v1/usuarios/me/email/verificacao - Envia o link de confirmação ao e-mail da conta
+				usuarioAtual.POST("/email/verificacao", verificacaoEmailHandler.Solicitar)
+
+				// GET /v1/usuarios/me/identidades - Lista os provedores externos vinculados
+				usuarioAtual.GET("/identidades", loginExternoHandler.ListarIdentidades)
+
+				// POST /v1/usuarios/me/identidades/:provedor/iniciar - Retorna a URL do provedor para vinculá-lo à conta
+				usuarioAtual.POST("/identidades/:provedor/iniciar", loginExternoHandler.IniciarVinculo)
+
+				// POST /v1/usuarios/me/identidades/:provedor/callback - Conclui o vínculo com o código do provedor
+				usuarioAtual.POST("/identidades/:provedor/callback", loginExternoHandler.ConcluirVinculo)
+
+				// DELETE /v1/usuarios/me/identidades/:id - Desvincula um provedor externo
+				usuarioAtual.DELETE("/identidades/:id", loginExternoHandler.Desvincular)
+
+				// GET /v1/usuarios/me/2fa - Situação da autenticação em dois fatores
+				usuarioAtual.GET("/2fa", doisFatoresHandler.Status)
+
+				// POST /v1/usuarios/me/2fa - Gera o segredo TOTP e a URI otpauth://
+				usuarioAtual.POST("/2fa", doisFatoresHandler.Iniciar)
+
+				// POST /v1/usuarios/me/2fa/confirmar - Ativa o 2FA e retorna os códigos de recuperação
+				usuarioAtual.POST("/2fa/confirmar", doisFatoresHandler.Confirmar)
+
+				// POST /v1/usuarios/me/2fa/desativar - Desativa o 2FA (exige senha e código)
+				usuarioAtual.POST("/2fa/desativar", doisFatoresHandler.Desativar)
+
+				// GET /v1/usuarios/me/sessoes - Lista as sessões ativas por dispositivo
+				usuarioAtual.GET("/sessoes", sessaoHandler.Listar)
+
+				// DELETE /v1/usuarios/me/sessoes - Encerra todas as outras sessões
+				usuarioAtual.DELETE("/sessoes", sessaoHandler.RevogarOutras)
+
+				// DELETE /v1/usuarios/me/sessoes/:id - Encerra uma sessão específica
+				usuarioAtual.DELETE("/sessoes/:id", sessaoHandler.Revogar)
+			}
 		}
 	}
 	
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// CarregarDoAmbiente monta o conjunto de chaves a partir das variáveis de ambiente:
+//
+//   - JWT_ALGORITMO: HS256 (padrão), RS256 ou EdDSA
+//   - JWT_KID: identificador da chave ativa (padrão "principal")
+//   - JWT_SECRET: segredo da chave ativa quando o algoritmo é HS256
+//   - JWT_CHAVE_PRIVADA_ARQUIVO: caminho do PEM da chave ativa para RS256/EdDSA
+//   - JWT_CHAVES_ANTERIORES: chaves aceitas só na verificação, no formato
+//     "kid=segredo" ou "kid=arquivo:/caminho/chave.pem", separadas por vírgula
+//   - JWT_EMISSOR e JWT_AUDIENCIA: valores de 'iss' e 'aud' (padrão "cinehub" e "cinehub-api")
+func CarregarDoAmbiente() (*ConjuntoChaves, error) {
+	algoritmo := valorOuPadrao("JWT_ALGORITMO", "HS256")
+	kid := valorOuPadrao("JWT_KID", "principal")
+
+	var ativa *Chave
+	var err error
+	switch algoritmo {
+	case "HS256":
+		segredo := os.Getenv("JWT_SECRET")
+		if segredo == "" {
+			return nil, fmt.Errorf("a variável de ambiente JWT_SECRET é obrigatória para HS256")
+		}
+		ativa, err = NovaChaveHMAC(kid, []byte(segredo))
+	case "RS256", "EdDSA":
+		arquivo := os.Getenv("JWT_CHAVE_PRIVADA_ARQUIVO")
+		if arquivo == "" {
+			return nil, fmt.Errorf("a variável de ambiente JWT_CHAVE_PRIVADA_ARQUIVO é obrigatória para %s", algoritmo)
+		}
+		ativa, err = lerChavePEM(kid, arquivo)
+		if err == nil && ativa.Metodo.Alg() != algoritmo {
+			err = fmt.Errorf("a chave em %s não corresponde ao algoritmo %s", arquivo, algoritmo)
+		}
+	default:
+		return nil, fmt.Errorf("algoritmo JWT não suportado: %s", algoritmo)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	anteriores, err := lerChavesAnteriores(os.Getenv("JWT_CHAVES_ANTERIORES"))
+	if err != nil {
+		return nil, err
+	}
+
+	return NovoConjuntoChaves(
+		valorOuPadrao("JWT_EMISSOR", "cinehub"),
+		valorOuPadrao("JWT_AUDIENCIA", "cinehub-api"),
+		ativa,
+		anteriores...,
+	)
+}
+
+// lerChavesAnteriores interpreta a lista de chaves mantidas apenas para verificação.
+func lerChavesAnteriores(valor string) ([]*Chave, error) {
+	var chaves []*Chave
+	for _, entrada := range strings.Split(valor, ",") {
+		entrada = strings.TrimSpace(entrada)
+		if entrada == "" {
+			continue
+		}
+
+		kid, conteudo, ok := strings.Cut(entrada, "=")
+		if !ok || kid == "" || conteudo == "" {
+			return nil, fmt.Errorf("entrada inválida em JWT_CHAVES_ANTERIORES: '%s'", entrada)
+		}
+
+		var chave *Chave
+		var err error
+		if arquivo, ehArquivo := strings.CutPrefix(conteudo, "arquivo:"); ehArquivo {
+			chave, err = lerChavePEM(kid, arquivo)
+		} else {
+			chave, err = NovaChaveHMAC(kid, []byte(conteudo))
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Chaves anteriores nunca assinam, mesmo que o PEM contenha a parte privada.
+		chave.assinatura = nil
+		chaves = append(chaves, chave)
+	}
+	return chaves, nil
+}
+
+func lerChavePEM(kid, arquivo string) (*Chave, error) {
+	dados, err := os.ReadFile(arquivo)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao ler a chave '%s' em %s: %w", kid, arquivo, err)
+	}
+	return NovaChavePEM(kid, dados)
+}
+
+func valorOuPadrao(variavel, padrao string) string {
+	if valor := os.Getenv(variavel); valor != "" {
+		return valor
+	}
+	return padrao
+}
@@ -0,0 +1,274 @@
+// Package auth concentra a emissão e a validação dos tokens JWT da aplicação.
+// As chaves são identificadas por 'kid', o que permite manter várias chaves de
+// verificação ativas durante uma rotação e publicar as chaves públicas via JWKS.
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Erros retornados durante a validação de tokens.
+var (
+	ErrTokenSemKID       = errors.New("token sem identificador de chave (kid)")
+	ErrChaveDesconhecida = errors.New("chave de assinatura desconhecida")
+)
+
+// Claims são as informações carregadas nos tokens emitidos pela aplicação.
+type Claims struct {
+	jwt.RegisteredClaims
+	SessaoID string `json:"sid,omitempty"` // Sessão de login à qual o token pertence
+}
+
+// UsuarioID converte o 'sub' do token no ID numérico do usuário.
+func (c *Claims) UsuarioID() (int64, error) {
+	return strconv.ParseInt(c.Subject, 10, 64)
+}
+
+// Chave é uma chave de assinatura ou de verificação identificada por um 'kid'.
+type Chave struct {
+	KID    string
+	Metodo jwt.SigningMethod
+
+	// assinatura é nil para chaves que servem apenas para verificar tokens antigos.
+	assinatura  interface{}
+	verificacao interface{}
+}
+
+// NovaChaveHMAC cria uma chave simétrica HS256 a partir de um segredo.
+func NovaChaveHMAC(kid string, segredo []byte) (*Chave, error) {
+	if len(segredo) == 0 {
+		return nil, fmt.Errorf("o segredo da chave '%s' está vazio", kid)
+	}
+	return &Chave{KID: kid, Metodo: jwt.SigningMethodHS256, assinatura: segredo, verificacao: segredo}, nil
+}
+
+// NovaChavePEM cria uma chave RS256 ou EdDSA a partir de um bloco PEM.
+// Chaves privadas (PKCS#1 ou PKCS#8) podem assinar; chaves públicas (PKIX) apenas verificam.
+func NovaChavePEM(kid string, dadosPEM []byte) (*Chave, error) {
+	bloco, _ := pem.Decode(dadosPEM)
+	if bloco == nil {
+		return nil, fmt.Errorf("a chave '%s' não está no formato PEM", kid)
+	}
+
+	switch bloco.Type {
+	case "RSA PRIVATE KEY":
+		privada, err := x509.ParsePKCS1PrivateKey(bloco.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("falha ao ler a chave '%s': %w", kid, err)
+		}
+		return novaChaveAssimetrica(kid, privada, privada.Public())
+	case "PRIVATE KEY":
+		privada, err := x509.ParsePKCS8PrivateKey(bloco.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("falha ao ler a chave '%s': %w", kid, err)
+		}
+		assinante, ok := privada.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("a chave '%s' não pode ser usada para assinatura", kid)
+		}
+		return novaChaveAssimetrica(kid, privada, assinante.Public())
+	case "PUBLIC KEY":
+		publica, err := x509.ParsePKIXPublicKey(bloco.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("falha ao ler a chave '%s': %w", kid, err)
+		}
+		return novaChaveAssimetrica(kid, nil, publica)
+	default:
+		return nil, fmt.Errorf("tipo de bloco PEM '%s' não suportado na chave '%s'", bloco.Type, kid)
+	}
+}
+
+// novaChaveAssimetrica escolhe o método de assinatura de acordo com o tipo da chave pública.
+func novaChaveAssimetrica(kid string, privada, publica interface{}) (*Chave, error) {
+	chave := &Chave{KID: kid, assinatura: privada, verificacao: publica}
+	switch publica.(type) {
+	case *rsa.PublicKey:
+		chave.Metodo = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		chave.Metodo = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("tipo de chave não suportado em '%s' (use RSA ou Ed25519)", kid)
+	}
+	return chave, nil
+}
+
+// PodeAssinar informa se a chave contém a parte privada (ou o segredo).
+func (k *Chave) PodeAssinar() bool {
+	return k.assinatura != nil
+}
+
+// Simetrica informa se a chave é um segredo compartilhado, que nunca deve ser publicado.
+func (k *Chave) Simetrica() bool {
+	_, ok := k.verificacao.([]byte)
+	return ok
+}
+
+// ConjuntoChaves reúne a chave ativa, usada para assinar novos tokens, e as
+// chaves aceitas na verificação. Emissor e audiência são fixados na validação.
+type ConjuntoChaves struct {
+	Emissor   string
+	Audiencia string
+	Duracao   time.Duration
+
+	ativa       *Chave
+	verificacao map[string]*Chave
+	metodos     []string
+}
+
+// NovoConjuntoChaves monta o conjunto a partir da chave ativa e das chaves
+// anteriores, que continuam válidas para verificação até serem removidas.
+func NovoConjuntoChaves(emissor, audiencia string, ativa *Chave, anteriores ...*Chave) (*ConjuntoChaves, error) {
+	if ativa == nil || !ativa.PodeAssinar() {
+		return nil, errors.New("a chave ativa precisa ser capaz de assinar tokens")
+	}
+
+	conjunto := &ConjuntoChaves{
+		Emissor:     emissor,
+		Audiencia:   audiencia,
+		Duracao:     8 * time.Hour,
+		ativa:       ativa,
+		verificacao: make(map[string]*Chave),
+	}
+
+	for _, chave := range append([]*Chave{ativa}, anteriores...) {
+		if _, duplicada := conjunto.verificacao[chave.KID]; duplicada {
+			return nil, fmt.Errorf("kid duplicado no conjunto de chaves: '%s'", chave.KID)
+		}
+		conjunto.verificacao[chave.KID] = chave
+		conjunto.adicionarMetodo(chave.Metodo.Alg())
+	}
+
+	return conjunto, nil
+}
+
+func (c *ConjuntoChaves) adicionarMetodo(alg string) {
+	for _, existente := range c.metodos {
+		if existente == alg {
+			return
+		}
+	}
+	c.metodos = append(c.metodos, alg)
+}
+
+// Emitir cria um token de acesso para o usuário, vinculado à sessão informada
+// e assinado com a chave ativa.
+func (c *ConjuntoChaves) Emitir(usuarioID int64, sessaoID string, expiraEm time.Time) (string, error) {
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatInt(usuarioID, 10),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiraEm),
+		},
+		SessaoID: sessaoID,
+	}
+	return c.Assinar(claims)
+}
+
+// EmitirDesafio2FA cria o token de curta duração entregue no primeiro passo do login
+// quando o usuário tem 2FA ativo. Ele usa uma audiência própria, por isso nunca é
+// aceito como token de acesso.
+func (c *ConjuntoChaves) EmitirDesafio2FA(usuarioID int64, duracao time.Duration) (string, error) {
+	agora := time.Now()
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatInt(usuarioID, 10),
+			Audience:  jwt.ClaimStrings{c.audienciaDesafio2FA()},
+			IssuedAt:  jwt.NewNumericDate(agora),
+			ExpiresAt: jwt.NewNumericDate(agora.Add(duracao)),
+		},
+	}
+	return c.Assinar(claims)
+}
+
+// ValidarDesafio2FA valida um token emitido por EmitirDesafio2FA.
+func (c *ConjuntoChaves) ValidarDesafio2FA(tokenString string) (*Claims, error) {
+	return c.ValidarParaAudiencia(tokenString, c.audienciaDesafio2FA())
+}
+
+func (c *ConjuntoChaves) audienciaDesafio2FA() string {
+	return c.Audiencia + "/2fa"
+}
+
+// Assinar preenche emissor e audiência (quando ausentes) e assina as claims
+// com a chave ativa, identificando-a no cabeçalho 'kid'.
+func (c *ConjuntoChaves) Assinar(claims *Claims) (string, error) {
+	if claims.Issuer == "" {
+		claims.Issuer = c.Emissor
+	}
+	if len(claims.Audience) == 0 {
+		claims.Audience = jwt.ClaimStrings{c.Audiencia}
+	}
+
+	token := jwt.NewWithClaims(c.ativa.Metodo, claims)
+	token.Header["kid"] = c.ativa.KID
+	return token.SignedString(c.ativa.assinatura)
+}
+
+// Validar verifica assinatura, expiração, emissor e audiência de um token de acesso.
+func (c *ConjuntoChaves) Validar(tokenString string) (*Claims, error) {
+	return c.ValidarParaAudiencia(tokenString, c.Audiencia)
+}
+
+// ValidarParaAudiencia é como Validar, mas exige uma audiência específica.
+func (c *ConjuntoChaves) ValidarParaAudiencia(tokenString, audiencia string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, c.buscarChave,
+		jwt.WithValidMethods(c.metodos),
+		jwt.WithIssuer(c.Emissor),
+		jwt.WithAudience(audiencia),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// buscarChave localiza a chave de verificação pelo 'kid' do cabeçalho e confere o algoritmo.
+func (c *ConjuntoChaves) buscarChave(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrTokenSemKID
+	}
+
+	chave, ok := c.verificacao[kid]
+	if !ok {
+		return nil, ErrChaveDesconhecida
+	}
+
+	// Impede que um token troque o algoritmo esperado para aquela chave.
+	if token.Method.Alg() != chave.Metodo.Alg() {
+		return nil, fmt.Errorf("método de assinatura inesperado: %v", token.Header["alg"])
+	}
+	return chave.verificacao, nil
+}
+
+// JWKS retorna as chaves públicas do conjunto. Segredos HMAC nunca são publicados.
+func (c *ConjuntoChaves) JWKS() JWKS {
+	jwks := JWKS{Chaves: []JWK{}}
+	for _, chave := range c.verificacao {
+		if chave.Simetrica() {
+			continue
+		}
+		jwk, err := NovoJWK(chave.KID, chave.Metodo.Alg(), chave.verificacao)
+		if err != nil {
+			continue
+		}
+		jwks.Chaves = append(jwks.Chaves, jwk)
+	}
+
+	// Ordena por kid para que a resposta seja estável entre requisições.
+	sort.Slice(jwks.Chaves, func(i, j int) bool { return jwks.Chaves[i].KID < jwks.Chaves[j].KID })
+	return jwks
+}
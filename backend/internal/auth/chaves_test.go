@@ -0,0 +1,160 @@
+package auth_test
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chavePrivadaPEM cria a chave a partir do PEM PKCS#8 da chave privada, como o ambiente a carrega.
+func chavePrivadaPEM(t *testing.T, kid string, privada crypto.Signer) *auth.Chave {
+	t.Helper()
+
+	dados, err := x509.MarshalPKCS8PrivateKey(privada)
+	require.NoError(t, err)
+	chave, err := auth.NovaChavePEM(kid, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: dados}))
+	require.NoError(t, err)
+	return chave
+}
+
+// publicaPEM codifica a chave pública em PEM (PKIX), o formato publicado para quem verifica tokens.
+func publicaPEM(t *testing.T, publica crypto.PublicKey) []byte {
+	t.Helper()
+
+	dados, err := x509.MarshalPKIXPublicKey(publica)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: dados})
+}
+
+func novoConjunto(t *testing.T, emissor, audiencia string, ativa *auth.Chave, anteriores ...*auth.Chave) *auth.ConjuntoChaves {
+	t.Helper()
+
+	conjunto, err := auth.NovoConjuntoChaves(emissor, audiencia, ativa, anteriores...)
+	require.NoError(t, err)
+	return conjunto
+}
+
+// tokenHS256 assina as claims de acesso com HMAC usando o segredo e o kid informados.
+func tokenHS256(t *testing.T, kid string, segredo []byte) string {
+	t.Helper()
+
+	claims := &auth.Claims{RegisteredClaims: jwt.RegisteredClaims{
+		Subject:   "1",
+		Issuer:    "cinehub",
+		Audience:  jwt.ClaimStrings{"cinehub-api"},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	assinado, err := token.SignedString(segredo)
+	require.NoError(t, err)
+	return assinado
+}
+
+// Conjunto no meio de uma rotação de HS256 para EdDSA: a chave Ed25519 assina, e a HMAC e a RSA
+// anteriores continuam valendo para verificação.
+func TestValidarTokens(t *testing.T) {
+	_, privadaEd, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privadaRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	segredo := []byte("segredo-de-teste-com-tamanho-suficiente")
+
+	ed := chavePrivadaPEM(t, "ed", privadaEd)
+	rs := chavePrivadaPEM(t, "rsa", privadaRSA)
+	hs, err := auth.NovaChaveHMAC("hs", segredo)
+	require.NoError(t, err)
+	conjunto := novoConjunto(t, "cinehub", "cinehub-api", ed, hs, rs)
+
+	emitir := func(conjunto *auth.ConjuntoChaves) string {
+		token, err := conjunto.Emitir(1, "sessao", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		return token
+	}
+	desafio2FA, err := conjunto.EmitirDesafio2FA(1, time.Minute)
+	require.NoError(t, err)
+	outra, err := auth.NovaChaveHMAC("outra", segredo)
+	require.NoError(t, err)
+
+	casos := []struct {
+		nome  string
+		token string
+		erro  error
+	}{
+		{"chave ativa", emitir(conjunto), nil},
+		{"chave HMAC anterior", emitir(novoConjunto(t, "cinehub", "cinehub-api", hs)), nil},
+		{"chave RSA anterior", emitir(novoConjunto(t, "cinehub", "cinehub-api", rs)), nil},
+		{"sem kid", tokenHS256(t, "", segredo), auth.ErrTokenSemKID},
+		{"kid desconhecido", emitir(novoConjunto(t, "cinehub", "cinehub-api", outra)), auth.ErrChaveDesconhecida},
+		// A chave pública é conhecida por todos; aceitá-la como segredo HMAC permitiria forjar tokens.
+		{"HS256 com kid EdDSA", tokenHS256(t, "ed", privadaEd.Public().(ed25519.PublicKey)), jwt.ErrTokenUnverifiable},
+		{"HS256 com kid RS256", tokenHS256(t, "rsa", publicaPEM(t, privadaRSA.Public())), jwt.ErrTokenUnverifiable},
+		{"emissor errado", emitir(novoConjunto(t, "outro", "cinehub-api", ed)), jwt.ErrTokenInvalidIssuer},
+		{"audiência errada", emitir(novoConjunto(t, "cinehub", "outra-api", ed)), jwt.ErrTokenInvalidAudience},
+		{"desafio do 2FA como acesso", desafio2FA, jwt.ErrTokenInvalidAudience},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			claims, err := conjunto.Validar(caso.token)
+			if caso.erro == nil {
+				require.NoError(t, err)
+				assert.Equal(t, "sessao", claims.SessaoID)
+				return
+			}
+			assert.ErrorIs(t, err, caso.erro)
+			assert.Nil(t, claims)
+		})
+	}
+
+	// O desafio só vale na audiência própria dele.
+	claims, err := conjunto.ValidarDesafio2FA(desafio2FA)
+	require.NoError(t, err)
+	usuarioID, err := claims.UsuarioID()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), usuarioID)
+	_, err = conjunto.ValidarDesafio2FA(emitir(conjunto))
+	assert.ErrorIs(t, err, jwt.ErrTokenInvalidAudience)
+}
+
+func TestJWKSNuncaPublicaChavesHMAC(t *testing.T) {
+	_, privadaEd, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privadaRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	hs, err := auth.NovaChaveHMAC("hs", []byte("segredo-de-teste-com-tamanho-suficiente"))
+	require.NoError(t, err)
+	verificacao, err := auth.NovaChavePEM("rsa-publica", publicaPEM(t, privadaRSA.Public()))
+	require.NoError(t, err)
+
+	casos := []struct {
+		nome     string
+		conjunto *auth.ConjuntoChaves
+		kids     []string
+	}{
+		{"apenas HMAC", novoConjunto(t, "cinehub", "cinehub-api", hs), []string{}},
+		{"HMAC anterior", novoConjunto(t, "cinehub", "cinehub-api", chavePrivadaPEM(t, "ed", privadaEd), hs), []string{"ed"}},
+		{"HMAC ativa", novoConjunto(t, "cinehub", "cinehub-api", hs, verificacao), []string{"rsa-publica"}},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			kids := []string{}
+			for _, jwk := range caso.conjunto.JWKS().Chaves {
+				assert.NotEqual(t, "oct", jwk.Kty)
+				kids = append(kids, jwk.KID)
+			}
+			assert.Equal(t, caso.kids, kids)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+)
+
+// JWK representa uma chave pública no formato JSON Web Key (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	KID string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+
+	// Campos de chaves RSA.
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// Campos de chaves de curva elíptica (EC e OKP).
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS é o documento publicado em /.well-known/jwks.json.
+type JWKS struct {
+	Chaves []JWK `json:"keys"`
+}
+
+// NovoJWK converte uma chave pública RSA ou Ed25519 para o formato JWK.
+func NovoJWK(kid, alg string, publica interface{}) (JWK, error) {
+	jwk := JWK{KID: kid, Use: "sig", Alg: alg}
+
+	switch chave := publica.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = codificarBase64URL(chave.N.Bytes())
+		jwk.E = codificarBase64URL(big.NewInt(int64(chave.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = codificarBase64URL(chave)
+	default:
+		return JWK{}, fmt.Errorf("tipo de chave pública não suportado para o kid '%s'", kid)
+	}
+
+	return jwk, nil
+}
+
+// ChavePublica converte o JWK de volta em uma chave pública utilizável na verificação.
+// Suporta RSA, EC (P-256, P-384 e P-521) e OKP (Ed25519), que cobrem os provedores OIDC usuais.
+func (j JWK) ChavePublica() (interface{}, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodificarBase64URL(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodificarBase64URL(j.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curva elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curva = elliptic.P256()
+		case "P-384":
+			curva = elliptic.P384()
+		case "P-521":
+			curva = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("curva EC não suportada: %s", j.Crv)
+		}
+		x, err := decodificarBase64URL(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodificarBase64URL(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		chave := &ecdsa.PublicKey{Curve: curva, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curva.IsOnCurve(chave.X, chave.Y) {
+			return nil, fmt.Errorf("o ponto da chave '%s' não pertence à curva %s", j.KID, j.Crv)
+		}
+		return chave, nil
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, fmt.Errorf("curva OKP não suportada: %s", j.Crv)
+		}
+		x, err := decodificarBase64URL(j.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("tamanho inválido para a chave Ed25519 '%s'", j.KID)
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("tipo de chave JWK não suportado: %s", j.Kty)
+	}
+}
+
+func codificarBase64URL(dados []byte) string {
+	return base64.RawURLEncoding.EncodeToString(dados)
+}
+
+func decodificarBase64URL(valor string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(valor)
+}
@@ -0,0 +1,370 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Erros retornados durante o fluxo de login com um provedor OIDC.
+var (
+	ErrNonceInvalido          = errors.New("o nonce do id_token não corresponde ao login iniciado")
+	ErrRespostaProvedor       = errors.New("resposta inválida do provedor OIDC")
+	ErrIDTokenAusente         = errors.New("o provedor não retornou um id_token")
+	ErrEmissorDivergente      = errors.New("o emissor anunciado na descoberta difere do configurado")
+	ErrChaveOIDCNaoEncontrada = errors.New("chave do id_token não encontrada no JWKS do provedor")
+)
+
+// DocumentoDescoberta espelha os campos usados de /.well-known/openid-configuration.
+type DocumentoDescoberta struct {
+	Emissor               string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	MetodosAutenticacao   []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+// IdentidadeOIDC reúne as informações do usuário extraídas de um id_token validado.
+type IdentidadeOIDC struct {
+	Sujeito         string
+	Email           string
+	EmailVerificado bool
+	Nome            string
+}
+
+// claimsIDToken são as claims lidas do id_token do provedor.
+type claimsIDToken struct {
+	jwt.RegisteredClaims
+	Nonce           string      `json:"nonce"`
+	Email           string      `json:"email"`
+	EmailVerificado interface{} `json:"email_verified"` // Alguns provedores enviam "true" como string
+	Nome            string      `json:"name"`
+	AZP             string      `json:"azp"`
+}
+
+// ProvedorOIDC é um cliente do fluxo authorization code com PKCE para um provedor OpenID Connect.
+// Os endpoints são obtidos por descoberta a partir do emissor, o que permite apontar
+// o cliente para um provedor local em testes.
+type ProvedorOIDC struct {
+	Nome         string
+	Emissor      string
+	ClientID     string
+	ClientSecret string
+	RedirectURI  string
+	Escopos      []string
+	ClienteHTTP  *http.Client
+
+	mu         sync.Mutex
+	descoberta *DocumentoDescoberta
+	chaves     map[string]interface{}
+}
+
+// NovoProvedorOIDC cria um provedor com os escopos padrão e um cliente HTTP com timeout.
+func NovoProvedorOIDC(nome, emissor, clientID, clientSecret, redirectURI string) *ProvedorOIDC {
+	return &ProvedorOIDC{
+		Nome:         nome,
+		Emissor:      strings.TrimSuffix(emissor, "/"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURI:  redirectURI,
+		Escopos:      []string{"openid", "email", "profile"},
+		ClienteHTTP:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Descobrir busca (uma única vez) o documento de descoberta do provedor.
+func (p *ProvedorOIDC) Descobrir() (*DocumentoDescoberta, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.descoberta != nil {
+		return p.descoberta, nil
+	}
+
+	var documento DocumentoDescoberta
+	if err := p.buscarJSON(p.Emissor+"/.well-known/openid-configuration", &documento); err != nil {
+		return nil, fmt.Errorf("falha na descoberta do provedor '%s': %w", p.Nome, err)
+	}
+	if strings.TrimSuffix(documento.Emissor, "/") != p.Emissor {
+		return nil, ErrEmissorDivergente
+	}
+	if documento.AuthorizationEndpoint == "" || documento.TokenEndpoint == "" || documento.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: documento de descoberta incompleto", ErrRespostaProvedor)
+	}
+
+	p.descoberta = &documento
+	return p.descoberta, nil
+}
+
+// URLAutorizacao monta a URL para onde o navegador deve ser enviado para iniciar o login.
+func (p *ProvedorOIDC) URLAutorizacao(estado, nonce, desafioPKCE string) (string, error) {
+	documento, err := p.Descobrir()
+	if err != nil {
+		return "", err
+	}
+
+	parametros := url.Values{}
+	parametros.Set("response_type", "code")
+	parametros.Set("client_id", p.ClientID)
+	parametros.Set("redirect_uri", p.RedirectURI)
+	parametros.Set("scope", strings.Join(p.Escopos, " "))
+	parametros.Set("state", estado)
+	parametros.Set("nonce", nonce)
+	parametros.Set("code_challenge", desafioPKCE)
+	parametros.Set("code_challenge_method", "S256")
+
+	separador := "?"
+	if strings.Contains(documento.AuthorizationEndpoint, "?") {
+		separador = "&"
+	}
+	return documento.AuthorizationEndpoint + separador + parametros.Encode(), nil
+}
+
+// TrocarCodigo troca o código de autorização por tokens e valida o id_token
+// (assinatura, emissor, audiência, expiração e nonce).
+func (p *ProvedorOIDC) TrocarCodigo(codigo, verificadorPKCE, nonce string) (*IdentidadeOIDC, error) {
+	documento, err := p.Descobrir()
+	if err != nil {
+		return nil, err
+	}
+
+	formulario := url.Values{}
+	formulario.Set("grant_type", "authorization_code")
+	formulario.Set("code", codigo)
+	formulario.Set("redirect_uri", p.RedirectURI)
+	formulario.Set("code_verifier", verificadorPKCE)
+
+	// client_secret_basic é o padrão da especificação; client_secret_post só é
+	// usado quando o provedor não anuncia suporte ao basic.
+	usarBasic := p.ClientSecret != "" && p.suportaAutenticacao(documento, "client_secret_basic")
+	if !usarBasic {
+		formulario.Set("client_id", p.ClientID)
+		if p.ClientSecret != "" {
+			formulario.Set("client_secret", p.ClientSecret)
+		}
+	}
+
+	requisicao, err := http.NewRequest(http.MethodPost, documento.TokenEndpoint, strings.NewReader(formulario.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	requisicao.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	requisicao.Header.Set("Accept", "application/json")
+	if usarBasic {
+		requisicao.SetBasicAuth(url.QueryEscape(p.ClientID), url.QueryEscape(p.ClientSecret))
+	}
+
+	resposta, err := p.ClienteHTTP.Do(requisicao)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao contatar o endpoint de token: %w", err)
+	}
+	defer resposta.Body.Close()
+
+	if resposta.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: o endpoint de token retornou %s", ErrRespostaProvedor, resposta.Status)
+	}
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resposta.Body).Decode(&tokens); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRespostaProvedor, err)
+	}
+	if tokens.IDToken == "" {
+		return nil, ErrIDTokenAusente
+	}
+
+	return p.validarIDToken(tokens.IDToken, documento.Emissor, nonce)
+}
+
+// validarIDToken confere o id_token com as chaves publicadas pelo provedor. O emissor
+// é o anunciado na descoberta, que pode diferir do configurado apenas pela barra final.
+func (p *ProvedorOIDC) validarIDToken(idToken, emissor, nonce string) (*IdentidadeOIDC, error) {
+	claims := &claimsIDToken{}
+	_, err := jwt.ParseWithClaims(idToken, claims, p.buscarChave,
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithIssuer(emissor),
+		jwt.WithAudience(p.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("id_token inválido: %w", err)
+	}
+
+	// Com várias audiências, o 'azp' precisa identificar este cliente.
+	if len(claims.Audience) > 1 && claims.AZP != p.ClientID {
+		return nil, fmt.Errorf("id_token inválido: azp '%s' não corresponde ao cliente", claims.AZP)
+	}
+	if claims.Nonce == "" || claims.Nonce != nonce {
+		return nil, ErrNonceInvalido
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("id_token inválido: 'sub' ausente")
+	}
+
+	verificado := false
+	switch valor := claims.EmailVerificado.(type) {
+	case bool:
+		verificado = valor
+	case string:
+		verificado = valor == "true"
+	}
+
+	return &IdentidadeOIDC{
+		Sujeito:         claims.Subject,
+		Email:           strings.TrimSpace(claims.Email),
+		EmailVerificado: verificado,
+		Nome:            claims.Nome,
+	}, nil
+}
+
+// buscarChave localiza a chave pelo 'kid', recarregando o JWKS quando o kid é desconhecido
+// (o provedor pode ter rotacionado as chaves desde a última consulta).
+func (p *ProvedorOIDC) buscarChave(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	p.mu.Lock()
+	chave, ok := p.procurarChave(kid)
+	p.mu.Unlock()
+	if ok {
+		return chave, nil
+	}
+
+	if err := p.recarregarChaves(); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if chave, ok := p.procurarChave(kid); ok {
+		return chave, nil
+	}
+	return nil, ErrChaveOIDCNaoEncontrada
+}
+
+// procurarChave deve ser chamada com o mutex travado. Sem 'kid', só é aceita
+// quando o provedor publica uma única chave.
+func (p *ProvedorOIDC) procurarChave(kid string) (interface{}, bool) {
+	if kid == "" && len(p.chaves) == 1 {
+		for _, chave := range p.chaves {
+			return chave, true
+		}
+	}
+	chave, ok := p.chaves[kid]
+	return chave, ok
+}
+
+func (p *ProvedorOIDC) recarregarChaves() error {
+	documento, err := p.Descobrir()
+	if err != nil {
+		return err
+	}
+
+	var jwks JWKS
+	if err := p.buscarJSON(documento.JWKSURI, &jwks); err != nil {
+		return fmt.Errorf("falha ao buscar o JWKS do provedor '%s': %w", p.Nome, err)
+	}
+
+	chaves := make(map[string]interface{})
+	for _, jwk := range jwks.Chaves {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// Chaves em formatos não suportados são ignoradas em vez de invalidar o conjunto.
+		if chave, err := jwk.ChavePublica(); err == nil {
+			chaves[jwk.KID] = chave
+		}
+	}
+
+	p.mu.Lock()
+	p.chaves = chaves
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *ProvedorOIDC) suportaAutenticacao(documento *DocumentoDescoberta, metodo string) bool {
+	if len(documento.MetodosAutenticacao) == 0 {
+		return metodo == "client_secret_basic"
+	}
+	for _, suportado := range documento.MetodosAutenticacao {
+		if suportado == metodo {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *ProvedorOIDC) buscarJSON(endereco string, destino interface{}) error {
+	resposta, err := p.ClienteHTTP.Get(endereco)
+	if err != nil {
+		return err
+	}
+	defer resposta.Body.Close()
+
+	if resposta.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s retornou %s", ErrRespostaProvedor, endereco, resposta.Status)
+	}
+	return json.NewDecoder(resposta.Body).Decode(destino)
+}
+
+// NovoVerificadorPKCE gera o code_verifier e o code_challenge (S256) do PKCE.
+func NovoVerificadorPKCE() (verificador, desafio string, err error) {
+	verificador, err = TokenAleatorio(32)
+	if err != nil {
+		return "", "", err
+	}
+	soma := sha256.Sum256([]byte(verificador))
+	return verificador, base64.RawURLEncoding.EncodeToString(soma[:]), nil
+}
+
+// TokenAleatorio gera um valor aleatório seguro codificado em base64url.
+func TokenAleatorio(bytes int) (string, error) {
+	dados := make([]byte, bytes)
+	if _, err := rand.Read(dados); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(dados), nil
+}
+
+// CarregarProvedoresOIDCDoAmbiente lê os provedores listados em OIDC_PROVEDORES
+// (ex.: "google,keycloak"). Para cada nome, são usadas as variáveis
+// OIDC_<NOME>_EMISSOR, OIDC_<NOME>_CLIENT_ID, OIDC_<NOME>_CLIENT_SECRET,
+// OIDC_<NOME>_REDIRECT_URI e, opcionalmente, OIDC_<NOME>_ESCOPOS.
+func CarregarProvedoresOIDCDoAmbiente() (map[string]*ProvedorOIDC, error) {
+	provedores := make(map[string]*ProvedorOIDC)
+
+	for _, nome := range strings.Split(os.Getenv("OIDC_PROVEDORES"), ",") {
+		nome = strings.ToLower(strings.TrimSpace(nome))
+		if nome == "" {
+			continue
+		}
+
+		prefixo := "OIDC_" + strings.ToUpper(nome) + "_"
+		emissor := os.Getenv(prefixo + "EMISSOR")
+		clientID := os.Getenv(prefixo + "CLIENT_ID")
+		redirectURI := os.Getenv(prefixo + "REDIRECT_URI")
+		if emissor == "" || clientID == "" || redirectURI == "" {
+			return nil, fmt.Errorf("o provedor OIDC '%s' exige %sEMISSOR, %sCLIENT_ID e %sREDIRECT_URI", nome, prefixo, prefixo, prefixo)
+		}
+
+		provedor := NovoProvedorOIDC(nome, emissor, clientID, os.Getenv(prefixo+"CLIENT_SECRET"), redirectURI)
+		if escopos := os.Getenv(prefixo + "ESCOPOS"); escopos != "" {
+			provedor.Escopos = strings.Fields(strings.ReplaceAll(escopos, ",", " "))
+		}
+		provedores[nome] = provedor
+	}
+
+	return provedores, nil
+}
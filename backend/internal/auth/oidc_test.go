@@ -0,0 +1,92 @@
+package auth_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"testing"
+
+	"github.com/Andydev0/filmes-backend/internal/auth"
+	"github.com/Andydev0/filmes-backend/internal/auth/oidcteste"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func novoCliente(provedor *oidcteste.Provedor) *auth.ProvedorOIDC {
+	return auth.NovoProvedorOIDC("teste", provedor.Emissor()+"/", provedor.ClientID, "segredo", "http://localhost:5173/callback")
+}
+
+func desafio(verificador string) string {
+	soma := sha256.Sum256([]byte(verificador))
+	return base64.RawURLEncoding.EncodeToString(soma[:])
+}
+
+func TestDescobrirUsaEndpointsDoProvedor(t *testing.T) {
+	provedor := oidcteste.Novo(t, "cinehub")
+	cliente := novoCliente(provedor)
+
+	documento, err := cliente.Descobrir()
+	require.NoError(t, err)
+	assert.Equal(t, provedor.Emissor()+"/token", documento.TokenEndpoint)
+
+	endereco, err := cliente.URLAutorizacao("estado", "nonce", "desafio")
+	require.NoError(t, err)
+	destino, err := url.Parse(endereco)
+	require.NoError(t, err)
+	assert.Equal(t, "/autorizar", destino.Path)
+	assert.Equal(t, "estado", destino.Query().Get("state"))
+	assert.Equal(t, "nonce", destino.Query().Get("nonce"))
+	assert.Equal(t, "S256", destino.Query().Get("code_challenge_method"))
+}
+
+func TestDescobrirRejeitaEmissorDivergente(t *testing.T) {
+	provedor := oidcteste.Novo(t, "cinehub")
+	provedor.EmissorAnunciado = "https://outro.example"
+
+	_, err := novoCliente(provedor).Descobrir()
+	assert.ErrorIs(t, err, auth.ErrEmissorDivergente)
+}
+
+func TestTrocarCodigoValidaIDToken(t *testing.T) {
+	provedor := oidcteste.Novo(t, "cinehub")
+	cliente := novoCliente(provedor)
+
+	verificador, _, err := auth.NovoVerificadorPKCE()
+	require.NoError(t, err)
+	codigo := provedor.Autorizar(oidcteste.Autorizacao{
+		Sujeito: "sub-1", Email: "ana@example.com", EmailVerificado: true, Nome: "Ana",
+		Nonce: "nonce-1", DesafioPKCE: desafio(verificador),
+	})
+
+	identidade, err := cliente.TrocarCodigo(codigo, verificador, "nonce-1")
+	require.NoError(t, err)
+	assert.Equal(t, "sub-1", identidade.Sujeito)
+	assert.Equal(t, "ana@example.com", identidade.Email)
+	assert.True(t, identidade.EmailVerificado)
+	assert.Equal(t, "Ana", identidade.Nome)
+
+	// O código vale uma única vez.
+	_, err = cliente.TrocarCodigo(codigo, verificador, "nonce-1")
+	assert.ErrorIs(t, err, auth.ErrRespostaProvedor)
+}
+
+func TestTrocarCodigoRejeitaVerificadorPKCEErrado(t *testing.T) {
+	provedor := oidcteste.Novo(t, "cinehub")
+	verificador, _, err := auth.NovoVerificadorPKCE()
+	require.NoError(t, err)
+	codigo := provedor.Autorizar(oidcteste.Autorizacao{Sujeito: "sub-1", Nonce: "nonce-1", DesafioPKCE: desafio(verificador)})
+
+	_, err = novoCliente(provedor).TrocarCodigo(codigo, "outro-verificador", "nonce-1")
+	assert.ErrorIs(t, err, auth.ErrRespostaProvedor)
+}
+
+func TestTrocarCodigoRejeitaNonceDivergente(t *testing.T) {
+	provedor := oidcteste.Novo(t, "cinehub")
+	provedor.Nonce = "nonce-de-outro-login"
+	verificador, _, err := auth.NovoVerificadorPKCE()
+	require.NoError(t, err)
+	codigo := provedor.Autorizar(oidcteste.Autorizacao{Sujeito: "sub-1", Nonce: "nonce-1", DesafioPKCE: desafio(verificador)})
+
+	_, err = novoCliente(provedor).TrocarCodigo(codigo, verificador, "nonce-1")
+	assert.ErrorIs(t, err, auth.ErrNonceInvalido)
+}
@@ -0,0 +1,147 @@
+// Package oidcteste implementa um provedor OpenID Connect local, servido por httptest,
+// para exercitar o fluxo authorization code com PKCE nos testes sem depender da rede.
+package oidcteste
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/auth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Autorizacao descreve o login que o provedor aprova para um código: quem é o usuário
+// e os valores (nonce e desafio PKCE) enviados na URL de autorização.
+type Autorizacao struct {
+	Sujeito         string
+	Email           string
+	EmailVerificado bool
+	Nome            string
+	Nonce           string
+	DesafioPKCE     string
+}
+
+// Provedor é o provedor OIDC local. EmissorAnunciado e Nonce, quando preenchidos,
+// substituem o valor correto na descoberta e no id_token, para testar as rejeições.
+type Provedor struct {
+	Servidor *httptest.Server
+	ClientID string
+
+	EmissorAnunciado string
+	Nonce            string
+
+	chave   *rsa.PrivateKey
+	mu      sync.Mutex
+	codigos map[string]Autorizacao
+}
+
+// Novo sobe o provedor e o encerra ao fim do teste.
+func Novo(t testing.TB, clientID string) *Provedor {
+	t.Helper()
+
+	chave, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("falha ao gerar a chave do provedor: %v", err)
+	}
+
+	p := &Provedor{ClientID: clientID, chave: chave, codigos: make(map[string]Autorizacao)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.descoberta)
+	mux.HandleFunc("/jwks", p.jwks)
+	mux.HandleFunc("/token", p.token)
+	p.Servidor = httptest.NewServer(mux)
+	t.Cleanup(p.Servidor.Close)
+
+	return p
+}
+
+// Emissor é o endereço do provedor, usado como 'iss'.
+func (p *Provedor) Emissor() string {
+	return p.Servidor.URL
+}
+
+// Autorizar registra o login aprovado e retorna o código que o provedor devolveria ao navegador.
+func (p *Provedor) Autorizar(a Autorizacao) string {
+	codigo, _ := auth.TokenAleatorio(16)
+
+	p.mu.Lock()
+	p.codigos[codigo] = a
+	p.mu.Unlock()
+	return codigo
+}
+
+func (p *Provedor) descoberta(w http.ResponseWriter, r *http.Request) {
+	emissor := p.Emissor()
+	if p.EmissorAnunciado != "" {
+		emissor = p.EmissorAnunciado
+	}
+	json.NewEncoder(w).Encode(auth.DocumentoDescoberta{
+		Emissor:               emissor,
+		AuthorizationEndpoint: p.Emissor() + "/autorizar",
+		TokenEndpoint:         p.Emissor() + "/token",
+		JWKSURI:               p.Emissor() + "/jwks",
+	})
+}
+
+func (p *Provedor) jwks(w http.ResponseWriter, r *http.Request) {
+	jwk, _ := auth.NovoJWK("teste", "RS256", &p.chave.PublicKey)
+	json.NewEncoder(w).Encode(auth.JWKS{Chaves: []auth.JWK{jwk}})
+}
+
+// token confere o código, o client_id (client_secret_basic) e o code_verifier do PKCE.
+// Cada código vale uma única vez.
+func (p *Provedor) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	if clientID, _, ok := r.BasicAuth(); !ok || clientID != p.ClientID {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+
+	p.mu.Lock()
+	autorizacao, ok := p.codigos[r.PostForm.Get("code")]
+	delete(p.codigos, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	soma := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(soma[:]) != autorizacao.DesafioPKCE {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	nonce := autorizacao.Nonce
+	if p.Nonce != "" {
+		nonce = p.Nonce
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            p.Emissor(),
+		"aud":            p.ClientID,
+		"sub":            autorizacao.Sujeito,
+		"exp":            time.Now().Add(time.Minute).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          nonce,
+		"email":          autorizacao.Email,
+		"email_verified": autorizacao.EmailVerificado,
+		"name":           autorizacao.Nome,
+	})
+	token.Header["kid"] = "teste"
+	idToken, err := token.SignedString(p.chave)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"id_token": idToken, "token_type": "Bearer"})
+}
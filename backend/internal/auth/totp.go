@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Parâmetros do TOTP (RFC 6238) compatíveis com os aplicativos autenticadores comuns.
+const (
+	periodoTOTP = 30
+	digitosTOTP = 6
+
+	// toleranciaTOTP aceita o código do passo anterior e do seguinte, cobrindo relógios dessincronizados.
+	toleranciaTOTP = 1
+)
+
+var codificacaoBase32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GerarSegredoTOTP cria um segredo aleatório de 160 bits codificado em base32.
+func GerarSegredoTOTP() (string, error) {
+	segredo := make([]byte, 20)
+	if _, err := rand.Read(segredo); err != nil {
+		return "", err
+	}
+	return codificacaoBase32.EncodeToString(segredo), nil
+}
+
+// URIProvisionamentoTOTP monta a URI otpauth:// usada no QR code dos aplicativos autenticadores.
+func URIProvisionamentoTOTP(emissor, conta, segredo string) string {
+	parametros := url.Values{}
+	parametros.Set("secret", segredo)
+	parametros.Set("issuer", emissor)
+	parametros.Set("algorithm", "SHA1")
+	parametros.Set("digits", fmt.Sprint(digitosTOTP))
+	parametros.Set("period", fmt.Sprint(periodoTOTP))
+
+	rotulo := url.PathEscape(emissor + ":" + conta)
+	return "otpauth://totp/" + rotulo + "?" + parametros.Encode()
+}
+
+// ValidarCodigoTOTP confere o código dentro da janela de tolerância e retorna o
+// passo de tempo em que ele foi aceito, para que o chamador impeça sua reutilização.
+func ValidarCodigoTOTP(segredo, codigo string, agora time.Time) (int64, bool) {
+	codigo = strings.TrimSpace(codigo)
+	if len(codigo) != digitosTOTP {
+		return 0, false
+	}
+
+	chave, err := codificacaoBase32.DecodeString(strings.ToUpper(segredo))
+	if err != nil {
+		return 0, false
+	}
+
+	passoAtual := agora.Unix() / periodoTOTP
+	for deslocamento := int64(-toleranciaTOTP); deslocamento <= toleranciaTOTP; deslocamento++ {
+		passo := passoAtual + deslocamento
+		esperado := calcularCodigoTOTP(chave, passo)
+		if subtle.ConstantTimeCompare([]byte(esperado), []byte(codigo)) == 1 {
+			return passo, true
+		}
+	}
+	return 0, false
+}
+
+// calcularCodigoTOTP implementa o HOTP (RFC 4226) para o contador informado.
+func calcularCodigoTOTP(chave []byte, passo int64) string {
+	var contador [8]byte
+	binary.BigEndian.PutUint64(contador[:], uint64(passo))
+
+	mac := hmac.New(sha1.New, chave)
+	mac.Write(contador[:])
+	soma := mac.Sum(nil)
+
+	// Truncamento dinâmico: os 4 bits finais indicam de onde extrair 31 bits.
+	inicio := soma[len(soma)-1] & 0x0f
+	valor := binary.BigEndian.Uint32(soma[inicio:inicio+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < digitosTOTP; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", digitosTOTP, valor%modulo)
+}
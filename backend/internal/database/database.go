@@ -1,6 +1,7 @@
 package database
 
 import (
+	"fmt"
 	"log"
 
 	"github.com/jmoiron/sqlx"
@@ -11,17 +12,43 @@ import (
 func InitDB() *sqlx.DB {
 	log.Println("Tentando conectar ao banco de dados SQLite...")
 
-	db, err := sqlx.Connect("sqlite3", "./filmes.db")
+	db, err := Abrir("./filmes.db")
 	if err != nil {
-		log.Fatalf("Falha ao conectar ao banco de dados: %v", err)
+		log.Fatalf("Falha ao abrir o banco de dados: %v", err)
 	}
+	return db
+}
 
-	err = criarSchema(db)
+// Abrir conecta ao arquivo SQLite informado, cria o schema inicial se o banco for novo e
+// aplica as migrações pendentes. Os testes a usam com um arquivo em diretório temporário.
+func Abrir(caminho string) (*sqlx.DB, error) {
+	// O SQLite só aplica as chaves estrangeiras (e o ON DELETE) se elas forem habilitadas
+	// em cada conexão; o parâmetro na DSN garante isso para todas as conexões do pool.
+	db, err := sqlx.Connect("sqlite3", caminho+"?_foreign_keys=on&_busy_timeout=5000")
 	if err != nil {
-		log.Fatalf("Falha ao criar o schema do banco de dados: %v", err)
+		return nil, fmt.Errorf("falha ao conectar: %w", err)
 	}
 
-	return db
+	// O schema inicial corresponde à versão 0; a partir dela, só as migrações alteram
+	// o banco (algumas tabelas do schema inicial já foram substituídas por elas).
+	var versao int
+	if err := db.Get(&versao, "PRAGMA user_version"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("falha ao ler a versão: %w", err)
+	}
+	if versao == 0 {
+		if err := criarSchema(db); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("falha ao criar o schema: %w", err)
+		}
+	}
+
+	if err := aplicarMigracoes(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("falha ao aplicar as migrações: %w", err)
+	}
+
+	return db, nil
 }
 
 // criarSchema executa as instruções SQL para criar as tabelas da aplicação.
@@ -0,0 +1,905 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// migracoes lista, em ordem, as alterações aplicadas sobre o schema inicial.
+// A versão do banco (PRAGMA user_version) guarda quantas já foram executadas.
+// Migrações publicadas nunca devem ser editadas; novas mudanças entram no final.
+var migracoes = []string{
+	// 1: Identidades externas (login via OpenID Connect) e logins em andamento.
+	`
+	CREATE TABLE identidades_externas (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		usuario_id INTEGER NOT NULL,
+		provedor TEXT NOT NULL,
+		sujeito TEXT NOT NULL,
+		email TEXT NOT NULL,
+		data_criacao DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id),
+		-- Uma conta do provedor só pode estar vinculada a um usuário.
+		UNIQUE (provedor, sujeito)
+	);
+
+	CREATE INDEX idx_identidades_externas_usuario ON identidades_externas(usuario_id);
+
+	-- Guarda state, nonce e code_verifier entre o início do login e o callback.
+	CREATE TABLE logins_externos_pendentes (
+		estado TEXT PRIMARY KEY,
+		provedor TEXT NOT NULL,
+		nonce TEXT NOT NULL,
+		verificador_pkce TEXT NOT NULL,
+		expira_em DATETIME NOT NULL
+	);
+	`,
+
+	// 2: Autenticação em dois fatores (TOTP) e códigos de recuperação.
+	`
+	ALTER TABLE usuarios ADD COLUMN totp_segredo TEXT NOT NULL DEFAULT '';
+	ALTER TABLE usuarios ADD COLUMN totp_ativo BOOLEAN NOT NULL DEFAULT 0;
+	-- Último passo de tempo aceito, para impedir que o mesmo código seja usado duas vezes.
+	ALTER TABLE usuarios ADD COLUMN totp_ultimo_passo INTEGER NOT NULL DEFAULT 0;
+	-- Limita tentativas de adivinhar o código no segundo passo do login.
+	ALTER TABLE usuarios ADD COLUMN totp_falhas INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE usuarios ADD COLUMN totp_bloqueado_ate DATETIME;
+
+	CREATE TABLE codigos_recuperacao (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		usuario_id INTEGER NOT NULL,
+		codigo_hash TEXT NOT NULL,
+		usado_em DATETIME,
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id)
+	);
+
+	CREATE INDEX idx_codigos_recuperacao_usuario ON codigos_recuperacao(usuario_id);
+	`,
+
+	// 3: Sessões de login por dispositivo, referenciadas pelo 'sid' dos tokens.
+	`
+	CREATE TABLE sessoes (
+		id TEXT PRIMARY KEY,
+		usuario_id INTEGER NOT NULL,
+		dispositivo TEXT NOT NULL DEFAULT '',
+		ip TEXT NOT NULL DEFAULT '',
+		data_criacao DATETIME DEFAULT CURRENT_TIMESTAMP,
+		ultimo_acesso DATETIME DEFAULT CURRENT_TIMESTAMP,
+		expira_em DATETIME NOT NULL,
+		revogada_em DATETIME,
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id)
+	);
+
+	CREATE INDEX idx_sessoes_usuario ON sessoes(usuario_id, expira_em);
+	`,
+
+	// 4: Exclusão de contas. O SQLite não altera chaves estrangeiras existentes, então as
+	// tabelas que referenciam 'usuarios' são recriadas com o comportamento de ON DELETE.
+	// As avaliações são anonimizadas (usuario_id nulo) em vez de apagadas.
+	`
+	-- Data a partir da qual a conta será apagada definitivamente (fim do prazo de carência).
+	ALTER TABLE usuarios ADD COLUMN exclusao_agendada_em DATETIME;
+
+	CREATE TABLE filmes_favoritos_nova (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		usuario_id INTEGER NOT NULL,
+		filme_id INTEGER NOT NULL,
+		titulo TEXT NOT NULL,
+		caminho_poster TEXT,
+		data_adicionado DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+		UNIQUE (usuario_id, filme_id)
+	);
+	INSERT INTO filmes_favoritos_nova (id, usuario_id, filme_id, titulo, caminho_poster, data_adicionado)
+		SELECT id, usuario_id, filme_id, titulo, caminho_poster, data_adicionado FROM filmes_favoritos
+		WHERE usuario_id IN (SELECT id FROM usuarios);
+	DROP TABLE filmes_favoritos;
+	ALTER TABLE filmes_favoritos_nova RENAME TO filmes_favoritos;
+
+	CREATE TABLE avaliacoes_nova (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		-- Nulo quando o autor excluiu a conta; a avaliação aparece como "usuário removido".
+		usuario_id INTEGER,
+		filme_id INTEGER NOT NULL,
+		nota INTEGER NOT NULL CHECK(nota >= 1 AND nota <= 5),
+		comentario TEXT,
+		data_criacao DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE SET NULL,
+		UNIQUE (usuario_id, filme_id)
+	);
+	INSERT INTO avaliacoes_nova (id, usuario_id, filme_id, nota, comentario, data_criacao)
+		SELECT id, CASE WHEN usuario_id IN (SELECT id FROM usuarios) THEN usuario_id END,
+		       filme_id, nota, comentario, data_criacao FROM avaliacoes;
+	DROP TABLE avaliacoes;
+	ALTER TABLE avaliacoes_nova RENAME TO avaliacoes;
+
+	CREATE TABLE identidades_externas_nova (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		usuario_id INTEGER NOT NULL,
+		provedor TEXT NOT NULL,
+		sujeito TEXT NOT NULL,
+		email TEXT NOT NULL,
+		data_criacao DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+		UNIQUE (provedor, sujeito)
+	);
+	INSERT INTO identidades_externas_nova (id, usuario_id, provedor, sujeito, email, data_criacao)
+		SELECT id, usuario_id, provedor, sujeito, email, data_criacao FROM identidades_externas
+		WHERE usuario_id IN (SELECT id FROM usuarios);
+	DROP TABLE identidades_externas;
+	ALTER TABLE identidades_externas_nova RENAME TO identidades_externas;
+	CREATE INDEX idx_identidades_externas_usuario ON identidades_externas(usuario_id);
+
+	CREATE TABLE codigos_recuperacao_nova (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		usuario_id INTEGER NOT NULL,
+		codigo_hash TEXT NOT NULL,
+		usado_em DATETIME,
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE
+	);
+	INSERT INTO codigos_recuperacao_nova (id, usuario_id, codigo_hash, usado_em)
+		SELECT id, usuario_id, codigo_hash, usado_em FROM codigos_recuperacao
+		WHERE usuario_id IN (SELECT id FROM usuarios);
+	DROP TABLE codigos_recuperacao;
+	ALTER TABLE codigos_recuperacao_nova RENAME TO codigos_recuperacao;
+	CREATE INDEX idx_codigos_recuperacao_usuario ON codigos_recuperacao(usuario_id);
+
+	CREATE TABLE sessoes_nova (
+		id TEXT PRIMARY KEY,
+		usuario_id INTEGER NOT NULL,
+		dispositivo TEXT NOT NULL DEFAULT '',
+		ip TEXT NOT NULL DEFAULT '',
+		data_criacao DATETIME DEFAULT CURRENT_TIMESTAMP,
+		ultimo_acesso DATETIME DEFAULT CURRENT_TIMESTAMP,
+		expira_em DATETIME NOT NULL,
+		revogada_em DATETIME,
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE
+	);
+	INSERT INTO sessoes_nova (id, usuario_id, dispositivo, ip, data_criacao, ultimo_acesso, expira_em, revogada_em)
+		SELECT id, usuario_id, dispositivo, ip, data_criacao, ultimo_acesso, expira_em, revogada_em FROM sessoes
+		WHERE usuario_id IN (SELECT id FROM usuarios);
+	DROP TABLE sessoes;
+	ALTER TABLE sessoes_nova RENAME TO sessoes;
+	CREATE INDEX idx_sessoes_usuario ON sessoes(usuario_id, expira_em);
+
+	-- Perguntas do quiz apresentadas a cada usuário, incluídas na exportação de dados.
+	CREATE TABLE historico_quiz (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		usuario_id INTEGER NOT NULL,
+		filme_id INTEGER NOT NULL,
+		pergunta TEXT NOT NULL,
+		resposta_correta TEXT NOT NULL,
+		data_criacao DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX idx_historico_quiz_usuario ON historico_quiz(usuario_id, data_criacao);
+	`,
+
+	// 5: Listas nomeadas. Os favoritos passam a ser a lista embutida de tipo 'favoritos'
+	// de cada usuário, preservando os IDs e a ordem em que foram adicionados.
+	`
+	CREATE TABLE listas (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		usuario_id INTEGER NOT NULL,
+		-- 'personalizada' para as listas criadas pelo usuário; os demais tipos são embutidos.
+		tipo TEXT NOT NULL DEFAULT 'personalizada',
+		titulo TEXT NOT NULL,
+		descricao TEXT NOT NULL DEFAULT '',
+		visibilidade TEXT NOT NULL DEFAULT 'privada' CHECK(visibilidade IN ('privada', 'nao_listada', 'publica')),
+		data_criacao DATETIME DEFAULT CURRENT_TIMESTAMP,
+		data_atualizacao DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX idx_listas_usuario ON listas(usuario_id);
+	-- Cada usuário tem no máximo uma lista de cada tipo embutido.
+	CREATE UNIQUE INDEX idx_listas_embutidas ON listas(usuario_id, tipo) WHERE tipo != 'personalizada';
+
+	CREATE TABLE itens_lista (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		lista_id INTEGER NOT NULL,
+		filme_id INTEGER NOT NULL,
+		titulo TEXT NOT NULL,
+		caminho_poster TEXT NOT NULL DEFAULT '',
+		-- Ordem manual do item dentro da lista, começando em 1.
+		posicao INTEGER NOT NULL,
+		data_adicionado DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (lista_id) REFERENCES listas(id) ON DELETE CASCADE,
+		UNIQUE (lista_id, filme_id)
+	);
+
+	CREATE INDEX idx_itens_lista_posicao ON itens_lista(lista_id, posicao);
+
+	INSERT INTO listas (usuario_id, tipo, titulo)
+		SELECT DISTINCT usuario_id, 'favoritos', 'Favoritos' FROM filmes_favoritos;
+	INSERT INTO itens_lista (id, lista_id, filme_id, titulo, caminho_poster, posicao, data_adicionado)
+		SELECT f.id, l.id, f.filme_id, f.titulo, COALESCE(f.caminho_poster, ''),
+		       ROW_NUMBER() OVER (PARTITION BY f.usuario_id ORDER BY f.data_adicionado, f.id),
+		       f.data_adicionado
+		FROM filmes_favoritos f JOIN listas l ON l.usuario_id = f.usuario_id AND l.tipo = 'favoritos';
+	DROP TABLE filmes_favoritos;
+	`,
+
+	// 6: Diário de filmes assistidos. A lista "para assistir" é embutida e criada sob demanda.
+	`
+	CREATE TABLE diario (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		usuario_id INTEGER NOT NULL,
+		filme_id INTEGER NOT NULL,
+		titulo TEXT NOT NULL,
+		caminho_poster TEXT NOT NULL DEFAULT '',
+		-- Data em que o filme foi assistido, no formato AAAA-MM-DD.
+		data_assistido TEXT NOT NULL,
+		revisto BOOLEAN NOT NULL DEFAULT 0,
+		avaliacao_id INTEGER,
+		data_criacao DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+		FOREIGN KEY (avaliacao_id) REFERENCES avaliacoes(id) ON DELETE SET NULL
+	);
+
+	CREATE INDEX idx_diario_usuario_data ON diario(usuario_id, data_assistido);
+	CREATE INDEX idx_diario_avaliacao ON diario(avaliacao_id);
+	`,
+
+	// 7: Importações de histórico (Letterboxd/IMDb) e as linhas que não casaram com o catálogo.
+	`
+	CREATE TABLE importacoes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		usuario_id INTEGER NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pendente' CHECK (status IN ('pendente', 'processando', 'concluida', 'falhou')),
+		total_linhas INTEGER NOT NULL DEFAULT 0,
+		processadas INTEGER NOT NULL DEFAULT 0,
+		importadas INTEGER NOT NULL DEFAULT 0,
+		ja_existentes INTEGER NOT NULL DEFAULT 0,
+		nao_encontradas INTEGER NOT NULL DEFAULT 0,
+		erro TEXT NOT NULL DEFAULT '',
+		data_criacao DATETIME NOT NULL,
+		data_conclusao DATETIME,
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX idx_importacoes_usuario ON importacoes(usuario_id, data_criacao);
+
+	CREATE TABLE pendencias_importacao (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		importacao_id INTEGER NOT NULL,
+		arquivo TEXT NOT NULL,
+		linha INTEGER NOT NULL,
+		titulo TEXT NOT NULL DEFAULT '',
+		ano INTEGER NOT NULL DEFAULT 0,
+		imdb_id TEXT NOT NULL DEFAULT '',
+		motivo TEXT NOT NULL,
+		FOREIGN KEY (importacao_id) REFERENCES importacoes(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX idx_pendencias_importacao ON pendencias_importacao(importacao_id);
+	`,
+
+	// 8: Cache dos metadados do catálogo externo (ano, ID do IMDb, diretor) usados nas exportações.
+	`
+	CREATE TABLE catalogo_filmes (
+		filme_id INTEGER PRIMARY KEY,
+		titulo TEXT NOT NULL,
+		ano INTEGER NOT NULL DEFAULT 0,
+		imdb_id TEXT NOT NULL DEFAULT '',
+		diretor TEXT NOT NULL DEFAULT '',
+		caminho_poster TEXT NOT NULL DEFAULT '',
+		atualizado_em DATETIME NOT NULL
+	);
+	`,
+
+	// 9: Anotações e tags nos itens de lista (usadas nos favoritos), gêneros do catálogo e
+	// índices para ordenar e filtrar os favoritos.
+	`
+	ALTER TABLE itens_lista ADD COLUMN anotacao TEXT NOT NULL DEFAULT '';
+
+	CREATE TABLE tags_itens_lista (
+		item_id INTEGER NOT NULL,
+		tag TEXT NOT NULL,
+		PRIMARY KEY (item_id, tag),
+		FOREIGN KEY (item_id) REFERENCES itens_lista(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX idx_tags_itens_lista_tag ON tags_itens_lista(tag, item_id);
+
+	CREATE TABLE generos_filme (
+		filme_id INTEGER NOT NULL,
+		genero_id INTEGER NOT NULL,
+		nome TEXT NOT NULL,
+		PRIMARY KEY (filme_id, genero_id),
+		FOREIGN KEY (filme_id) REFERENCES catalogo_filmes(filme_id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX idx_generos_filme_genero ON generos_filme(genero_id, filme_id);
+	CREATE INDEX idx_itens_lista_data ON itens_lista(lista_id, data_adicionado);
+	CREATE INDEX idx_itens_lista_titulo ON itens_lista(lista_id, titulo COLLATE NOCASE);
+	`,
+
+	// 10: Perfis públicos (endereço e privacidade por seção) e links de compartilhamento das
+	// listas. As listas já visíveis recebem o link na própria migração.
+	`
+	CREATE TABLE perfis (
+		usuario_id INTEGER PRIMARY KEY,
+		slug TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		mostrar_favoritos BOOLEAN NOT NULL DEFAULT 0,
+		mostrar_listas BOOLEAN NOT NULL DEFAULT 1,
+		mostrar_avaliacoes BOOLEAN NOT NULL DEFAULT 1,
+		mostrar_estatisticas BOOLEAN NOT NULL DEFAULT 1,
+		data_atualizacao DATETIME NOT NULL,
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE
+	);
+
+	ALTER TABLE listas ADD COLUMN slug_compartilhamento TEXT;
+	UPDATE listas SET slug_compartilhamento = lower(hex(randomblob(16))) WHERE visibilidade != 'privada';
+	CREATE UNIQUE INDEX idx_listas_slug_compartilhamento ON listas(slug_compartilhamento)
+		WHERE slug_compartilhamento IS NOT NULL;
+	`,
+
+	// 11: Listas colaborativas: membros com papel, convites, autoria dos itens e versão da lista
+	// para detectar edições simultâneas. Até aqui só o dono adicionava itens.
+	`
+	CREATE TABLE membros_lista (
+		lista_id INTEGER NOT NULL,
+		usuario_id INTEGER NOT NULL,
+		papel TEXT NOT NULL CHECK (papel IN ('editor', 'leitor')),
+		data_entrada DATETIME NOT NULL,
+		PRIMARY KEY (lista_id, usuario_id),
+		FOREIGN KEY (lista_id) REFERENCES listas(id) ON DELETE CASCADE,
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE
+	);
+	CREATE INDEX idx_membros_lista_usuario ON membros_lista(usuario_id);
+
+	CREATE TABLE convites_lista (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		lista_id INTEGER NOT NULL,
+		codigo TEXT NOT NULL UNIQUE,
+		papel TEXT NOT NULL CHECK (papel IN ('editor', 'leitor')),
+		convidado_id INTEGER,
+		criado_por INTEGER NOT NULL,
+		data_criacao DATETIME NOT NULL,
+		expira_em DATETIME NOT NULL,
+		FOREIGN KEY (lista_id) REFERENCES listas(id) ON DELETE CASCADE,
+		FOREIGN KEY (convidado_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+		FOREIGN KEY (criado_por) REFERENCES usuarios(id) ON DELETE CASCADE
+	);
+	CREATE INDEX idx_convites_lista_lista ON convites_lista(lista_id);
+	CREATE INDEX idx_convites_lista_convidado ON convites_lista(convidado_id);
+
+	ALTER TABLE itens_lista ADD COLUMN adicionado_por INTEGER REFERENCES usuarios(id) ON DELETE SET NULL;
+	UPDATE itens_lista SET adicionado_por = (SELECT usuario_id FROM listas WHERE listas.id = itens_lista.lista_id);
+
+	ALTER TABLE listas ADD COLUMN versao INTEGER NOT NULL DEFAULT 1;
+	`,
+
+	// 12: Ordem com intervalos nos itens: mover, inserir e remover um filme só alteram a linha dele.
+	// A posição (1, 2, 3...) passa a ser calculada na leitura. Listas ranqueadas exibem a posição
+	// como classificação (ex.: "meu top 10").
+	`
+	ALTER TABLE itens_lista ADD COLUMN ordem INTEGER NOT NULL DEFAULT 0;
+	UPDATE itens_lista SET ordem = posicao * 1024;
+	DROP INDEX idx_itens_lista_posicao;
+	ALTER TABLE itens_lista DROP COLUMN posicao;
+	CREATE INDEX idx_itens_lista_ordem ON itens_lista(lista_id, ordem);
+
+	ALTER TABLE listas ADD COLUMN ranqueada BOOLEAN NOT NULL DEFAULT 0;
+	`,
+
+	// 13: Edição de avaliações sem perder a data de criação, histórico das versões anteriores
+	// (inclusive de avaliações excluídas) e papel da conta, para dar acesso aos moderadores.
+	`
+	ALTER TABLE avaliacoes ADD COLUMN data_atualizacao DATETIME;
+	UPDATE avaliacoes SET data_atualizacao = data_criacao, comentario = COALESCE(comentario, '');
+
+	CREATE TABLE historico_avaliacoes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		-- Sem chave estrangeira: o histórico continua disponível depois que a avaliação é excluída.
+		avaliacao_id INTEGER NOT NULL,
+		usuario_id INTEGER,
+		filme_id INTEGER NOT NULL,
+		nota INTEGER NOT NULL,
+		comentario TEXT NOT NULL DEFAULT '',
+		-- Quando esta versão foi escrita e quando deixou de valer.
+		data_versao DATETIME NOT NULL,
+		data_substituicao DATETIME NOT NULL,
+		acao TEXT NOT NULL CHECK (acao IN ('edicao', 'exclusao')),
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE SET NULL
+	);
+	CREATE INDEX idx_historico_avaliacoes_avaliacao ON historico_avaliacoes(avaliacao_id, data_substituicao);
+	CREATE INDEX idx_historico_avaliacoes_usuario ON historico_avaliacoes(usuario_id);
+
+	ALTER TABLE usuarios ADD COLUMN papel TEXT NOT NULL DEFAULT 'usuario' CHECK (papel IN ('usuario', 'moderador'));
+	`,
+
+	// 14: Agregados das avaliações por filme (total, soma e quantidade por nota), mantidos a cada
+	// avaliação gravada ou excluída, para as estatísticas e o ranking da comunidade.
+	`
+	CREATE TABLE estatisticas_filmes (
+		filme_id INTEGER PRIMARY KEY,
+		total INTEGER NOT NULL DEFAULT 0,
+		soma INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX idx_estatisticas_filmes_total ON estatisticas_filmes(total);
+
+	CREATE TABLE distribuicao_notas (
+		filme_id INTEGER NOT NULL,
+		nota INTEGER NOT NULL,
+		quantidade INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (filme_id, nota)
+	);
+
+	INSERT INTO estatisticas_filmes (filme_id, total, soma)
+	SELECT filme_id, COUNT(*), SUM(nota) FROM avaliacoes GROUP BY filme_id;
+
+	INSERT INTO distribuicao_notas (filme_id, nota, quantidade)
+	SELECT filme_id, nota, COUNT(*) FROM avaliacoes GROUP BY filme_id, nota;
+	`,
+
+	// 15: Reações às avaliações ("útil" ou "curtida"), uma por usuário em cada avaliação.
+	`
+	CREATE TABLE reacoes_avaliacoes (
+		avaliacao_id INTEGER NOT NULL,
+		usuario_id INTEGER NOT NULL,
+		tipo TEXT NOT NULL CHECK (tipo IN ('util', 'curtida')),
+		data_criacao DATETIME NOT NULL,
+		PRIMARY KEY (avaliacao_id, usuario_id),
+		FOREIGN KEY (avaliacao_id) REFERENCES avaliacoes(id) ON DELETE CASCADE,
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE
+	);
+	CREATE INDEX idx_reacoes_avaliacoes_usuario ON reacoes_avaliacoes(usuario_id);
+	`,
+
+	// 16: Comentários nas avaliações, com um nível de respostas. Apagar um comentário apaga as
+	// respostas a ele; os de contas excluídas ficam sem autor, como as avaliações.
+	`
+	CREATE TABLE comentarios_avaliacoes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		avaliacao_id INTEGER NOT NULL,
+		usuario_id INTEGER,
+		-- Comentário de primeiro nível ao qual este responde; nulo nos de primeiro nível.
+		resposta_a INTEGER,
+		texto TEXT NOT NULL,
+		data_criacao DATETIME NOT NULL,
+		data_atualizacao DATETIME NOT NULL,
+		FOREIGN KEY (avaliacao_id) REFERENCES avaliacoes(id) ON DELETE CASCADE,
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE SET NULL,
+		FOREIGN KEY (resposta_a) REFERENCES comentarios_avaliacoes(id) ON DELETE CASCADE
+	);
+	CREATE INDEX idx_comentarios_avaliacoes_avaliacao ON comentarios_avaliacoes(avaliacao_id, resposta_a, id);
+	CREATE INDEX idx_comentarios_avaliacoes_resposta ON comentarios_avaliacoes(resposta_a, id);
+	CREATE INDEX idx_comentarios_avaliacoes_usuario ON comentarios_avaliacoes(usuario_id);
+	`,
+
+	// 17: Marcação de spoiler e filtro de conteúdo. Avaliações retidas pelo filtro ficam pendentes
+	// até a moderação; as decisões de reter ou rejeitar ficam registradas.
+	`
+	ALTER TABLE avaliacoes ADD COLUMN spoiler BOOLEAN NOT NULL DEFAULT 0;
+	ALTER TABLE avaliacoes ADD COLUMN situacao TEXT NOT NULL DEFAULT 'publicada' CHECK (situacao IN ('publicada', 'pendente'));
+	CREATE INDEX idx_avaliacoes_situacao ON avaliacoes(situacao, data_atualizacao);
+
+	CREATE TABLE decisoes_filtro (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		-- Nulo quando a avaliação foi rejeitada e nem chegou a ser gravada.
+		avaliacao_id INTEGER,
+		usuario_id INTEGER,
+		filme_id INTEGER NOT NULL,
+		acao TEXT NOT NULL CHECK (acao IN ('reter', 'rejeitar')),
+		-- Códigos dos motivos separados por vírgula (ex.: 'palavra_bloqueada,link').
+		motivos TEXT NOT NULL,
+		texto TEXT NOT NULL,
+		data_criacao DATETIME NOT NULL,
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE SET NULL
+	);
+	CREATE INDEX idx_decisoes_filtro_avaliacao ON decisoes_filtro(avaliacao_id);
+	CREATE INDEX idx_decisoes_filtro_usuario ON decisoes_filtro(usuario_id);
+	`,
+
+	// 18: Denúncias de avaliações, comentários e perfis, e o registro das ações da moderação.
+	// Cada usuário tem no máximo uma denúncia pendente por conteúdo.
+	`
+	CREATE TABLE denuncias (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		denunciante_id INTEGER,
+		alvo_tipo TEXT NOT NULL CHECK (alvo_tipo IN ('avaliacao', 'comentario', 'perfil')),
+		alvo_id INTEGER NOT NULL,
+		motivo TEXT NOT NULL CHECK (motivo IN ('spam', 'ofensivo', 'assedio', 'spoiler', 'outro')),
+		detalhes TEXT NOT NULL DEFAULT '',
+		situacao TEXT NOT NULL DEFAULT 'pendente' CHECK (situacao IN ('pendente', 'procedente', 'descartada')),
+		-- Ação da moderação que encerrou a denúncia.
+		resultado TEXT,
+		data_criacao DATETIME NOT NULL,
+		data_resolucao DATETIME,
+		FOREIGN KEY (denunciante_id) REFERENCES usuarios(id) ON DELETE SET NULL
+	);
+	CREATE UNIQUE INDEX idx_denuncias_pendente_unica ON denuncias(denunciante_id, alvo_tipo, alvo_id)
+		WHERE situacao = 'pendente';
+	CREATE INDEX idx_denuncias_alvo ON denuncias(situacao, alvo_tipo, alvo_id);
+	CREATE INDEX idx_denuncias_denunciante ON denuncias(denunciante_id);
+
+	CREATE TABLE acoes_moderacao (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		moderador_id INTEGER,
+		acao TEXT NOT NULL CHECK (acao IN ('descartar', 'ocultar', 'advertir', 'suspender')),
+		alvo_tipo TEXT NOT NULL,
+		alvo_id INTEGER NOT NULL,
+		-- Autor do conteúdo, que recebe a advertência ou a suspensão.
+		usuario_alvo_id INTEGER,
+		observacao TEXT NOT NULL DEFAULT '',
+		-- Cópia do conteúdo no momento da ação, já que ocultar o remove.
+		conteudo TEXT NOT NULL DEFAULT '',
+		suspenso_ate DATETIME,
+		denuncias INTEGER NOT NULL,
+		data_criacao DATETIME NOT NULL,
+		FOREIGN KEY (moderador_id) REFERENCES usuarios(id) ON DELETE SET NULL,
+		FOREIGN KEY (usuario_alvo_id) REFERENCES usuarios(id) ON DELETE SET NULL
+	);
+	CREATE INDEX idx_acoes_moderacao_usuario_alvo ON acoes_moderacao(usuario_alvo_id);
+
+	ALTER TABLE usuarios ADD COLUMN suspenso_ate DATETIME;
+	ALTER TABLE perfis ADD COLUMN oculto BOOLEAN NOT NULL DEFAULT 0;
+	`,
+
+	// 19: Índices para paginar as avaliações de um filme e o histórico de avaliações de um usuário.
+	`
+	CREATE INDEX idx_avaliacoes_filme_data ON avaliacoes(filme_id, data_criacao);
+	CREATE INDEX idx_avaliacoes_usuario_data ON avaliacoes(usuario_id, data_criacao);
+	`,
+
+	// 20: Notas em meias estrelas. A nota passa a ser um REAL de 0.5 a 5 (as notas inteiras
+	// são copiadas sem alteração), assim como no histórico e nos agregados por filme. As tabelas
+	// são recriadas porque o SQLite não altera um CHECK; a sequência dos IDs é preservada, já
+	// que o histórico, as decisões do filtro e as denúncias guardam IDs de avaliações excluídas.
+	`
+	CREATE TABLE avaliacoes_nova (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		-- Nulo quando o autor excluiu a conta; a avaliação aparece como "usuário removido".
+		usuario_id INTEGER,
+		filme_id INTEGER NOT NULL,
+		nota REAL NOT NULL CHECK (nota >= 0.5 AND nota <= 5 AND nota * 2 = CAST(nota * 2 AS INTEGER)),
+		comentario TEXT,
+		data_criacao DATETIME DEFAULT CURRENT_TIMESTAMP,
+		data_atualizacao DATETIME,
+		spoiler BOOLEAN NOT NULL DEFAULT 0,
+		situacao TEXT NOT NULL DEFAULT 'publicada' CHECK (situacao IN ('publicada', 'pendente')),
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE SET NULL,
+		UNIQUE (usuario_id, filme_id)
+	);
+	INSERT INTO avaliacoes_nova (id, usuario_id, filme_id, nota, comentario, data_criacao, data_atualizacao, spoiler, situacao)
+		SELECT id, usuario_id, filme_id, nota, comentario, data_criacao, data_atualizacao, spoiler, situacao FROM avaliacoes;
+	DELETE FROM sqlite_sequence WHERE name = 'avaliacoes_nova';
+	UPDATE sqlite_sequence SET name = 'avaliacoes_nova' WHERE name = 'avaliacoes';
+	DROP TABLE avaliacoes;
+	ALTER TABLE avaliacoes_nova RENAME TO avaliacoes;
+	CREATE INDEX idx_avaliacoes_situacao ON avaliacoes(situacao, data_atualizacao);
+	CREATE INDEX idx_avaliacoes_filme_data ON avaliacoes(filme_id, data_criacao);
+	CREATE INDEX idx_avaliacoes_usuario_data ON avaliacoes(usuario_id, data_criacao);
+
+	CREATE TABLE historico_avaliacoes_nova (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		-- Sem chave estrangeira: o histórico continua disponível depois que a avaliação é excluída.
+		avaliacao_id INTEGER NOT NULL,
+		usuario_id INTEGER,
+		filme_id INTEGER NOT NULL,
+		nota REAL NOT NULL,
+		comentario TEXT NOT NULL DEFAULT '',
+		-- Quando esta versão foi escrita e quando deixou de valer.
+		data_versao DATETIME NOT NULL,
+		data_substituicao DATETIME NOT NULL,
+		acao TEXT NOT NULL CHECK (acao IN ('edicao', 'exclusao')),
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE SET NULL
+	);
+	INSERT INTO historico_avaliacoes_nova (id, avaliacao_id, usuario_id, filme_id, nota, comentario, data_versao, data_substituicao, acao)
+		SELECT id, avaliacao_id, usuario_id, filme_id, nota, comentario, data_versao, data_substituicao, acao FROM historico_avaliacoes;
+	DELETE FROM sqlite_sequence WHERE name = 'historico_avaliacoes_nova';
+	UPDATE sqlite_sequence SET name = 'historico_avaliacoes_nova' WHERE name = 'historico_avaliacoes';
+	DROP TABLE historico_avaliacoes;
+	ALTER TABLE historico_avaliacoes_nova RENAME TO historico_avaliacoes;
+	CREATE INDEX idx_historico_avaliacoes_avaliacao ON historico_avaliacoes(avaliacao_id, data_substituicao);
+	CREATE INDEX idx_historico_avaliacoes_usuario ON historico_avaliacoes(usuario_id);
+
+	-- Os agregados são recalculados a partir das avaliações publicadas.
+	DROP TABLE estatisticas_filmes;
+	CREATE TABLE estatisticas_filmes (
+		filme_id INTEGER PRIMARY KEY,
+		total INTEGER NOT NULL DEFAULT 0,
+		soma REAL NOT NULL DEFAULT 0
+	);
+	CREATE INDEX idx_estatisticas_filmes_total ON estatisticas_filmes(total);
+
+	DROP TABLE distribuicao_notas;
+	CREATE TABLE distribuicao_notas (
+		filme_id INTEGER NOT NULL,
+		nota REAL NOT NULL,
+		quantidade INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (filme_id, nota)
+	);
+
+	INSERT INTO estatisticas_filmes (filme_id, total, soma)
+	SELECT filme_id, COUNT(*), SUM(nota) FROM avaliacoes WHERE situacao = 'publicada' GROUP BY filme_id;
+
+	INSERT INTO distribuicao_notas (filme_id, nota, quantidade)
+	SELECT filme_id, nota, COUNT(*) FROM avaliacoes WHERE situacao = 'publicada' GROUP BY filme_id, nota;
+	`,
+
+	// 21: Seguidores e o registro de atividades que monta o feed. A atividade guarda só a
+	// referência ao conteúdo: a privacidade e o que ainda existe são conferidos na leitura, e
+	// excluir o conteúdo apaga a atividade. O diário ganha uma opção própria, oculta por padrão.
+	`
+	CREATE TABLE seguidores (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		seguidor_id INTEGER NOT NULL,
+		seguido_id INTEGER NOT NULL,
+		data_criacao DATETIME NOT NULL,
+		FOREIGN KEY (seguidor_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+		FOREIGN KEY (seguido_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+		UNIQUE (seguidor_id, seguido_id),
+		CHECK (seguidor_id != seguido_id)
+	);
+	CREATE INDEX idx_seguidores_seguido ON seguidores(seguido_id);
+
+	CREATE TABLE atividades (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		usuario_id INTEGER NOT NULL,
+		tipo TEXT NOT NULL CHECK (tipo IN ('avaliacao', 'favorito', 'lista', 'diario')),
+		-- Nulo só na criação de uma lista.
+		filme_id INTEGER,
+		titulo TEXT NOT NULL DEFAULT '',
+		caminho_poster TEXT NOT NULL DEFAULT '',
+		avaliacao_id INTEGER,
+		lista_id INTEGER,
+		diario_id INTEGER,
+		data_criacao DATETIME NOT NULL,
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+		FOREIGN KEY (avaliacao_id) REFERENCES avaliacoes(id) ON DELETE CASCADE,
+		FOREIGN KEY (lista_id) REFERENCES listas(id) ON DELETE CASCADE,
+		FOREIGN KEY (diario_id) REFERENCES diario(id) ON DELETE CASCADE
+	);
+	CREATE INDEX idx_atividades_usuario ON atividades(usuario_id, id);
+	-- Uma avaliação gera uma única atividade, mesmo se for editada ou voltar da moderação.
+	CREATE UNIQUE INDEX idx_atividades_avaliacao ON atividades(avaliacao_id) WHERE avaliacao_id IS NOT NULL;
+	CREATE INDEX idx_atividades_lista ON atividades(lista_id) WHERE lista_id IS NOT NULL;
+	CREATE INDEX idx_atividades_diario ON atividades(diario_id) WHERE diario_id IS NOT NULL;
+
+	ALTER TABLE perfis ADD COLUMN mostrar_diario BOOLEAN NOT NULL DEFAULT 0;
+	`,
+
+	// 22: Notificações e as preferências de cada tipo. Sem uma linha de preferência, o tipo
+	// fica ativo. Apagar o comentário ou a avaliação de origem apaga a notificação.
+	`
+	CREATE TABLE notificacoes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		usuario_id INTEGER NOT NULL,
+		tipo TEXT NOT NULL CHECK (tipo IN ('seguidor', 'comentario', 'resposta', 'moderacao')),
+		-- Quem causou a notificação; nulo nas da moderação ou se a conta foi excluída.
+		ator_id INTEGER,
+		filme_id INTEGER,
+		avaliacao_id INTEGER,
+		comentario_id INTEGER,
+		texto TEXT NOT NULL DEFAULT '',
+		lida BOOLEAN NOT NULL DEFAULT 0,
+		data_criacao DATETIME NOT NULL,
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE,
+		FOREIGN KEY (ator_id) REFERENCES usuarios(id) ON DELETE SET NULL,
+		FOREIGN KEY (avaliacao_id) REFERENCES avaliacoes(id) ON DELETE CASCADE,
+		FOREIGN KEY (comentario_id) REFERENCES comentarios_avaliacoes(id) ON DELETE CASCADE
+	);
+	CREATE INDEX idx_notificacoes_usuario ON notificacoes(usuario_id, id);
+	CREATE INDEX idx_notificacoes_nao_lidas ON notificacoes(usuario_id) WHERE lida = 0;
+	CREATE INDEX idx_notificacoes_avaliacao ON notificacoes(avaliacao_id) WHERE avaliacao_id IS NOT NULL;
+	CREATE INDEX idx_notificacoes_comentario ON notificacoes(comentario_id) WHERE comentario_id IS NOT NULL;
+
+	CREATE TABLE preferencias_notificacoes (
+		usuario_id INTEGER NOT NULL,
+		tipo TEXT NOT NULL,
+		ativa BOOLEAN NOT NULL,
+		PRIMARY KEY (usuario_id, tipo),
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE
+	);
+	`,
+
+	// 23: Vínculo de provedores a partir de uma sessão já autenticada. O login pendente guarda
+	// a conta que pediu o vínculo; nulo quando o fluxo é um login comum.
+	`
+	ALTER TABLE logins_externos_pendentes ADD COLUMN usuario_id INTEGER REFERENCES usuarios(id) ON DELETE CASCADE;
+	`,
+
+	// 24: Edições retidas pelo filtro de avaliações já publicadas. A versão publicada continua no
+	// ar, com comentários e reações, até a moderação aprovar ou rejeitar a edição.
+	`
+	CREATE TABLE edicoes_pendentes_avaliacoes (
+		avaliacao_id INTEGER PRIMARY KEY,
+		nota REAL NOT NULL CHECK (nota >= 0.5 AND nota <= 5),
+		comentario TEXT NOT NULL DEFAULT '',
+		spoiler BOOLEAN NOT NULL DEFAULT 0,
+		data_criacao DATETIME NOT NULL,
+		FOREIGN KEY (avaliacao_id) REFERENCES avaliacoes(id) ON DELETE CASCADE
+	);
+	`,
+
+	// 25: Notas como inteiros em meias estrelas (nota_meias, de 1 a 10) no lugar do REAL da
+	// migração 20, para que a distribuição e as comparações não dependam de ponto flutuante. A
+	// conversão para estrelas fica na API. As tabelas são recriadas como na migração 20.
+	`
+	CREATE TABLE avaliacoes_nova (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		-- Nulo quando o autor excluiu a conta; a avaliação aparece como "usuário removido".
+		usuario_id INTEGER,
+		filme_id INTEGER NOT NULL,
+		nota_meias INTEGER NOT NULL CHECK (nota_meias BETWEEN 1 AND 10),
+		comentario TEXT,
+		data_criacao DATETIME DEFAULT CURRENT_TIMESTAMP,
+		data_atualizacao DATETIME,
+		spoiler BOOLEAN NOT NULL DEFAULT 0,
+		situacao TEXT NOT NULL DEFAULT 'publicada' CHECK (situacao IN ('publicada', 'pendente')),
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE SET NULL,
+		UNIQUE (usuario_id, filme_id)
+	);
+	INSERT INTO avaliacoes_nova (id, usuario_id, filme_id, nota_meias, comentario, data_criacao, data_atualizacao, spoiler, situacao)
+		SELECT id, usuario_id, filme_id, CAST(ROUND(nota * 2) AS INTEGER), comentario, data_criacao, data_atualizacao, spoiler, situacao
+		FROM avaliacoes;
+	DELETE FROM sqlite_sequence WHERE name = 'avaliacoes_nova';
+	UPDATE sqlite_sequence SET name = 'avaliacoes_nova' WHERE name = 'avaliacoes';
+	DROP TABLE avaliacoes;
+	ALTER TABLE avaliacoes_nova RENAME TO avaliacoes;
+	CREATE INDEX idx_avaliacoes_situacao ON avaliacoes(situacao, data_atualizacao);
+	CREATE INDEX idx_avaliacoes_filme_data ON avaliacoes(filme_id, data_criacao);
+	CREATE INDEX idx_avaliacoes_usuario_data ON avaliacoes(usuario_id, data_criacao);
+
+	CREATE TABLE historico_avaliacoes_nova (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		-- Sem chave estrangeira: o histórico continua disponível depois que a avaliação é excluída.
+		avaliacao_id INTEGER NOT NULL,
+		usuario_id INTEGER,
+		filme_id INTEGER NOT NULL,
+		nota_meias INTEGER NOT NULL,
+		comentario TEXT NOT NULL DEFAULT '',
+		-- Quando esta versão foi escrita e quando deixou de valer.
+		data_versao DATETIME NOT NULL,
+		data_substituicao DATETIME NOT NULL,
+		acao TEXT NOT NULL CHECK (acao IN ('edicao', 'exclusao')),
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE SET NULL
+	);
+	INSERT INTO historico_avaliacoes_nova (id, avaliacao_id, usuario_id, filme_id, nota_meias, comentario, data_versao, data_substituicao, acao)
+		SELECT id, avaliacao_id, usuario_id, filme_id, CAST(ROUND(nota * 2) AS INTEGER), comentario, data_versao, data_substituicao, acao
+		FROM historico_avaliacoes;
+	DELETE FROM sqlite_sequence WHERE name = 'historico_avaliacoes_nova';
+	UPDATE sqlite_sequence SET name = 'historico_avaliacoes_nova' WHERE name = 'historico_avaliacoes';
+	DROP TABLE historico_avaliacoes;
+	ALTER TABLE historico_avaliacoes_nova RENAME TO historico_avaliacoes;
+	CREATE INDEX idx_historico_avaliacoes_avaliacao ON historico_avaliacoes(avaliacao_id, data_substituicao);
+	CREATE INDEX idx_historico_avaliacoes_usuario ON historico_avaliacoes(usuario_id);
+
+	CREATE TABLE edicoes_pendentes_avaliacoes_nova (
+		avaliacao_id INTEGER PRIMARY KEY,
+		nota_meias INTEGER NOT NULL CHECK (nota_meias BETWEEN 1 AND 10),
+		comentario TEXT NOT NULL DEFAULT '',
+		spoiler BOOLEAN NOT NULL DEFAULT 0,
+		data_criacao DATETIME NOT NULL,
+		FOREIGN KEY (avaliacao_id) REFERENCES avaliacoes(id) ON DELETE CASCADE
+	);
+	INSERT INTO edicoes_pendentes_avaliacoes_nova (avaliacao_id, nota_meias, comentario, spoiler, data_criacao)
+		SELECT avaliacao_id, CAST(ROUND(nota * 2) AS INTEGER), comentario, spoiler, data_criacao FROM edicoes_pendentes_avaliacoes;
+	DROP TABLE edicoes_pendentes_avaliacoes;
+	ALTER TABLE edicoes_pendentes_avaliacoes_nova RENAME TO edicoes_pendentes_avaliacoes;
+
+	-- Os agregados são recalculados a partir das avaliações publicadas.
+	DROP TABLE estatisticas_filmes;
+	CREATE TABLE estatisticas_filmes (
+		filme_id INTEGER PRIMARY KEY,
+		total INTEGER NOT NULL DEFAULT 0,
+		soma_meias INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX idx_estatisticas_filmes_total ON estatisticas_filmes(total);
+
+	DROP TABLE distribuicao_notas;
+	CREATE TABLE distribuicao_notas (
+		filme_id INTEGER NOT NULL,
+		nota_meias INTEGER NOT NULL,
+		quantidade INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (filme_id, nota_meias)
+	);
+
+	INSERT INTO estatisticas_filmes (filme_id, total, soma_meias)
+	SELECT filme_id, COUNT(*), SUM(nota_meias) FROM avaliacoes WHERE situacao = 'publicada' GROUP BY filme_id;
+
+	INSERT INTO distribuicao_notas (filme_id, nota_meias, quantidade)
+	SELECT filme_id, nota_meias, COUNT(*) FROM avaliacoes WHERE situacao = 'publicada' GROUP BY filme_id, nota_meias;
+	`,
+
+	// 26: No máximo uma importação em andamento por usuário. Se já houver mais de uma, só a mais
+	// recente continua; as outras são encerradas como falhas.
+	`
+	UPDATE importacoes SET status = 'falhou', erro = 'importação substituída por outra em andamento',
+		data_conclusao = CURRENT_TIMESTAMP
+	WHERE status IN ('pendente', 'processando')
+		AND id NOT IN (SELECT MAX(id) FROM importacoes WHERE status IN ('pendente', 'processando') GROUP BY usuario_id);
+	CREATE UNIQUE INDEX idx_importacoes_em_andamento ON importacoes(usuario_id) WHERE status IN ('pendente', 'processando');
+	`,
+
+	// 27: Verificação do e-mail das contas. Contas criadas pelo login externo já chegam com o
+	// e-mail confirmado pelo provedor; as demais precisam confirmar pelo link enviado ao endereço.
+	`
+	ALTER TABLE usuarios ADD COLUMN email_verificado BOOLEAN NOT NULL DEFAULT 0;
+	UPDATE usuarios SET email_verificado = 1
+	WHERE senha_hash = '' AND EXISTS (
+		SELECT 1 FROM identidades_externas i WHERE i.usuario_id = usuarios.id AND i.email = usuarios.email
+	);
+
+	CREATE TABLE verificacoes_email (
+		token_hash TEXT PRIMARY KEY,
+		usuario_id INTEGER NOT NULL,
+		email TEXT NOT NULL,
+		expira_em DATETIME NOT NULL,
+		FOREIGN KEY (usuario_id) REFERENCES usuarios(id) ON DELETE CASCADE
+	);
+	CREATE INDEX idx_verificacoes_email_usuario ON verificacoes_email(usuario_id);
+	`,
+}
+
+// aplicarMigracoes executa, cada uma em sua transação, as migrações ainda não aplicadas.
+// As chaves estrangeiras ficam desligadas enquanto isso: com elas ligadas, recriar uma tabela
+// referenciada por outras (DROP TABLE) dispararia o ON DELETE das tabelas dependentes. Por isso a
+// integridade é conferida com foreign_key_check antes de cada commit.
+func aplicarMigracoes(db *sqlx.DB) error {
+	return migrarAte(db, len(migracoes))
+}
+
+// migrarAte aplica as migrações pendentes até a versão informada. Os testes a usam para
+// preparar um banco em uma versão antiga.
+func migrarAte(db *sqlx.DB, versaoFinal int) error {
+	ctx := context.Background()
+
+	// O PRAGMA vale só para a conexão em que é executado e é ignorado dentro de transações.
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+
+	var versao int
+	if err := conn.GetContext(ctx, &versao, "PRAGMA user_version"); err != nil {
+		return err
+	}
+
+	for i := versao; i < versaoFinal; i++ {
+		tx, err := conn.BeginTxx(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(migracoes[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migração %d: %w", i+1, err)
+		}
+
+		var violacoes int
+		if err := tx.Get(&violacoes, "SELECT COUNT(*) FROM pragma_foreign_key_check"); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migração %d: %w", i+1, err)
+		}
+		if violacoes > 0 {
+			tx.Rollback()
+			return fmt.Errorf("migração %d: %d linhas violam chaves estrangeiras", i+1, violacoes)
+		}
+
+		// PRAGMA não aceita parâmetros, por isso a versão é formatada na instrução.
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migração %d: %w", i+1, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migração %d: %w", i+1, err)
+		}
+		log.Printf("Migração %d aplicada ao banco de dados.", i+1)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bancoNaVersao cria um banco com o schema inicial e as migrações até a versão informada.
+func bancoNaVersao(t *testing.T, versao int) *sqlx.DB {
+	t.Helper()
+
+	db, err := sqlx.Connect("sqlite3", filepath.Join(t.TempDir(), "teste.db")+"?_foreign_keys=on")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, criarSchema(db))
+	require.NoError(t, migrarAte(db, versao))
+	return db
+}
+
+// As notas inteiras de antes da migração 20 e as meias estrelas gravadas depois dela chegam à
+// migração 25 como meias estrelas inteiras, com os agregados recalculados.
+func TestMigracoesConvertemNotasEmMeiasEstrelas(t *testing.T) {
+	db := bancoNaVersao(t, 19)
+	db.MustExec("INSERT INTO usuarios (id, nome, email, senha_hash) VALUES (1, 'Ana', 'ana@example.com', 'x'), (2, 'Bia', 'bia@example.com', 'x'), (3, 'Caio', 'caio@example.com', 'x')")
+	db.MustExec("INSERT INTO avaliacoes (id, usuario_id, filme_id, nota) VALUES (1, 1, 603, 4), (2, 2, 603, 4)")
+
+	require.NoError(t, migrarAte(db, 24))
+	db.MustExec("INSERT INTO avaliacoes (id, usuario_id, filme_id, nota, situacao) VALUES (3, 3, 603, 3.5, 'publicada')")
+	db.MustExec("INSERT INTO edicoes_pendentes_avaliacoes (avaliacao_id, nota, data_criacao) VALUES (1, 0.5, CURRENT_TIMESTAMP)")
+	db.MustExec("INSERT INTO historico_avaliacoes (avaliacao_id, usuario_id, filme_id, nota, data_versao, data_substituicao, acao) VALUES (2, 2, 603, 2.5, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'edicao')")
+
+	require.NoError(t, aplicarMigracoes(db))
+
+	var notas []int
+	require.NoError(t, db.Select(&notas, "SELECT nota_meias FROM avaliacoes ORDER BY id"))
+	assert.Equal(t, []int{8, 8, 7}, notas)
+
+	var pendente, historico int
+	require.NoError(t, db.Get(&pendente, "SELECT nota_meias FROM edicoes_pendentes_avaliacoes WHERE avaliacao_id = 1"))
+	assert.Equal(t, 1, pendente)
+	require.NoError(t, db.Get(&historico, "SELECT nota_meias FROM historico_avaliacoes WHERE avaliacao_id = 2"))
+	assert.Equal(t, 5, historico)
+
+	var agregado struct {
+		Total     int `db:"total"`
+		SomaMeias int `db:"soma_meias"`
+	}
+	require.NoError(t, db.Get(&agregado, "SELECT total, soma_meias FROM estatisticas_filmes WHERE filme_id = 603"))
+	assert.Equal(t, 3, agregado.Total)
+	assert.Equal(t, 23, agregado.SomaMeias)
+
+	var distribuicao []struct {
+		NotaMeias  int `db:"nota_meias"`
+		Quantidade int `db:"quantidade"`
+	}
+	require.NoError(t, db.Select(&distribuicao, "SELECT nota_meias, quantidade FROM distribuicao_notas WHERE filme_id = 603 ORDER BY nota_meias"))
+	require.Len(t, distribuicao, 2)
+	assert.Equal(t, 7, distribuicao[0].NotaMeias)
+	assert.Equal(t, 1, distribuicao[0].Quantidade)
+	assert.Equal(t, 8, distribuicao[1].NotaMeias)
+	assert.Equal(t, 2, distribuicao[1].Quantidade)
+
+	// A sequência dos IDs continua de onde parou.
+	db.MustExec("DELETE FROM avaliacoes WHERE id = 3")
+	resultado := db.MustExec("INSERT INTO avaliacoes (usuario_id, filme_id, nota_meias) VALUES (3, 604, 10)")
+	id, err := resultado.LastInsertId()
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), id)
+}
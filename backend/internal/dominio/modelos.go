@@ -1,6 +1,11 @@
 package dominio
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
 
 // Genero representa a estrutura de um gênero retornada pela API do TMDB.
 type Genero struct {
@@ -23,6 +28,11 @@ type RespostaBuscaTMDB struct {
 	Resultados []TMDBMovieResult `json:"results"`
 }
 
+// RespostaFindTMDB espelha a resposta da busca por ID externo (como o do IMDb) da API externa.
+type RespostaFindTMDB struct {
+	Filmes []TMDBMovieResult `json:"movie_results"`
+}
+
 // TMDBMovieResult representa um filme na resposta da API externa.
 type TMDBMovieResult struct {
 	ID             int      `json:"id"`
@@ -34,7 +44,26 @@ type TMDBMovieResult struct {
 	Generos        []Genero `json:"genres"`
 }
 
-// FilmeFavorito representa a tabela 'filmes_favoritos' no nosso banco de dados.
+// DetalhesCatalogoTMDB espelha os detalhes de um filme com os créditos anexados na mesma resposta.
+type DetalhesCatalogoTMDB struct {
+	TMDBMovieResult
+	IMDbID   string       `json:"imdb_id"`
+	Creditos CreditosTMDB `json:"credits"`
+}
+
+// MetadadosFilme representa a tabela 'catalogo_filmes': dados do catálogo externo guardados em cache.
+type MetadadosFilme struct {
+	FilmeID       int64     `db:"filme_id" json:"filmeId"`
+	Titulo        string    `db:"titulo" json:"titulo"`
+	Ano           int       `db:"ano" json:"ano,omitempty"`
+	IMDbID        string    `db:"imdb_id" json:"imdbId,omitempty"`
+	Diretor       string    `db:"diretor" json:"diretor,omitempty"`
+	CaminhoPoster string    `db:"caminho_poster" json:"caminhoPoster"`
+	AtualizadoEm  time.Time `db:"atualizado_em" json:"-"`
+	Generos       []Genero  `db:"-" json:"generos,omitempty"`
+}
+
+// FilmeFavorito é um item da lista embutida de favoritos do usuário.
 type FilmeFavorito struct {
 	ID             int64     `db:"id" json:"id"`
 	UsuarioID      int64     `db:"usuario_id" json:"usuarioId"`
@@ -42,6 +71,176 @@ type FilmeFavorito struct {
 	Titulo         string    `db:"titulo" json:"titulo"`
 	CaminhoPoster  string    `db:"caminho_poster" json:"caminhoPoster"`
 	DataAdicionado time.Time `db:"data_adicionado" json:"dataAdicionado"`
+	Anotacao       string    `db:"anotacao" json:"anotacao"` // Visível só para o dono
+	Ano            int       `db:"ano" json:"ano,omitempty"`
+	Nota           *Nota     `db:"nota_meias" json:"nota,omitempty"` // Nota da avaliação do próprio usuário
+	Tags           []string  `db:"-" json:"tags"`
+	Generos        []Genero  `db:"-" json:"generos"`
+}
+
+// Tipos de lista. Os tipos embutidos existem uma única vez por usuário.
+const (
+	TipoListaPersonalizada = "personalizada"
+	TipoListaFavoritos     = "favoritos"
+	TipoListaAssistir      = "assistir"
+)
+
+// Visibilidades de uma lista.
+const (
+	VisibilidadePrivada    = "privada"
+	VisibilidadeNaoListada = "nao_listada"
+	VisibilidadePublica    = "publica"
+)
+
+// Lista representa a tabela 'listas': uma coleção nomeada e ordenada de filmes.
+type Lista struct {
+	ID              int64     `db:"id" json:"id"`
+	UsuarioID       int64     `db:"usuario_id" json:"usuarioId"`
+	Tipo            string    `db:"tipo" json:"tipo"`
+	Titulo          string    `db:"titulo" json:"titulo"`
+	Descricao       string    `db:"descricao" json:"descricao"`
+	Visibilidade    string    `db:"visibilidade" json:"visibilidade"`
+	Ranqueada       bool      `db:"ranqueada" json:"ranqueada"` // A posição dos filmes é uma classificação
+	DataCriacao     time.Time `db:"data_criacao" json:"dataCriacao"`
+	DataAtualizacao time.Time `db:"data_atualizacao" json:"dataAtualizacao"`
+	QuantidadeItens int       `db:"quantidade_itens" json:"quantidadeItens"` // Preenchido apenas nas listagens
+
+	// Identificador do link de compartilhamento, gerado quando a lista deixa de ser privada.
+	SlugCompartilhamento *string `db:"slug_compartilhamento" json:"slugCompartilhamento,omitempty"`
+
+	// Incrementada a cada alteração; usada para recusar edições feitas sobre uma versão antiga.
+	Versao int `db:"versao" json:"versao"`
+
+	// Papel de quem consulta a lista (dono, editor ou leitor); preenchido apenas para o usuário logado.
+	Papel string `db:"papel" json:"papel,omitempty"`
+}
+
+// ItemLista representa a tabela 'itens_lista': um filme dentro de uma lista.
+type ItemLista struct {
+	ID             int64     `db:"id" json:"id"`
+	ListaID        int64     `db:"lista_id" json:"-"`
+	FilmeID        int64     `db:"filme_id" json:"filmeId"`
+	Titulo         string    `db:"titulo" json:"titulo"`
+	CaminhoPoster  string    `db:"caminho_poster" json:"caminhoPoster"`
+	Posicao        int       `db:"posicao" json:"posicao"` // Calculada na leitura a partir de Ordem, começando em 1
+	Ordem          int64     `db:"ordem" json:"-"`
+	DataAdicionado time.Time `db:"data_adicionado" json:"dataAdicionado"`
+	Anotacao       string    `db:"anotacao" json:"-"` // Privada; exposta só nos favoritos do próprio usuário
+	AdicionadoPor  *int64    `db:"adicionado_por" json:"adicionadoPor,omitempty"`
+	NomeAutor      string    `db:"nome_autor" json:"adicionadoPorNome,omitempty"` // Preenchido ao listar os itens
+}
+
+// Papéis de um usuário em uma lista. O dono é o criador da lista e não fica em 'membros_lista'.
+const (
+	PapelDono   = "dono"
+	PapelEditor = "editor"
+	PapelLeitor = "leitor"
+)
+
+// MembroLista representa a tabela 'membros_lista': um usuário convidado para uma lista.
+type MembroLista struct {
+	ListaID     int64     `db:"lista_id" json:"listaId"`
+	UsuarioID   int64     `db:"usuario_id" json:"usuarioId"`
+	Nome        string    `db:"nome" json:"nome"`
+	Papel       string    `db:"papel" json:"papel"`
+	DataEntrada time.Time `db:"data_entrada" json:"dataEntrada"`
+}
+
+// ConviteLista representa a tabela 'convites_lista'. Sem ConvidadoID, o convite é um link que
+// qualquer usuário com o código pode aceitar até expirar.
+type ConviteLista struct {
+	ID          int64     `db:"id" json:"id"`
+	ListaID     int64     `db:"lista_id" json:"listaId"`
+	Codigo      string    `db:"codigo" json:"codigo"`
+	Papel       string    `db:"papel" json:"papel"`
+	ConvidadoID *int64    `db:"convidado_id" json:"convidadoId,omitempty"`
+	CriadoPor   int64     `db:"criado_por" json:"criadoPor"`
+	DataCriacao time.Time `db:"data_criacao" json:"dataCriacao"`
+	ExpiraEm    time.Time `db:"expira_em" json:"expiraEm"`
+	TituloLista string    `db:"titulo_lista" json:"tituloLista,omitempty"` // Preenchido nos convites recebidos
+}
+
+// ListaComItens é a resposta de uma lista junto com seus filmes, na ordem definida pelo dono.
+type ListaComItens struct {
+	Lista
+	Itens []ItemLista `json:"itens"`
+}
+
+// EntradaDiario representa a tabela 'diario': uma vez em que o usuário assistiu a um filme.
+type EntradaDiario struct {
+	ID            int64     `db:"id" json:"id"`
+	UsuarioID     int64     `db:"usuario_id" json:"-"`
+	FilmeID       int64     `db:"filme_id" json:"filmeId"`
+	Titulo        string    `db:"titulo" json:"titulo"`
+	CaminhoPoster string    `db:"caminho_poster" json:"caminhoPoster"`
+	DataAssistido string    `db:"data_assistido" json:"dataAssistido"` // AAAA-MM-DD
+	Revisto       bool      `db:"revisto" json:"revisto"`
+	AvaliacaoID   *int64    `db:"avaliacao_id" json:"avaliacaoId,omitempty"`
+	DataCriacao   time.Time `db:"data_criacao" json:"dataCriacao"`
+}
+
+// Situações de uma importação de histórico.
+const (
+	StatusImportacaoPendente    = "pendente"
+	StatusImportacaoProcessando = "processando"
+	StatusImportacaoConcluida   = "concluida"
+	StatusImportacaoFalhou      = "falhou"
+)
+
+// Importacao representa a tabela 'importacoes': um envio de arquivos CSV processado em segundo plano.
+type Importacao struct {
+	ID             int64      `db:"id" json:"id"`
+	UsuarioID      int64      `db:"usuario_id" json:"-"`
+	Status         string     `db:"status" json:"status"`
+	TotalLinhas    int        `db:"total_linhas" json:"totalLinhas"`
+	Processadas    int        `db:"processadas" json:"processadas"`
+	Importadas     int        `db:"importadas" json:"importadas"`
+	JaExistentes   int        `db:"ja_existentes" json:"jaExistentes"`
+	NaoEncontradas int        `db:"nao_encontradas" json:"naoEncontradas"`
+	Erro           string     `db:"erro" json:"erro,omitempty"`
+	DataCriacao    time.Time  `db:"data_criacao" json:"dataCriacao"`
+	DataConclusao  *time.Time `db:"data_conclusao" json:"dataConclusao,omitempty"`
+}
+
+// PendenciaImportacao é uma linha importada que não pôde ser associada a um filme do catálogo.
+type PendenciaImportacao struct {
+	ID           int64  `db:"id" json:"-"`
+	ImportacaoID int64  `db:"importacao_id" json:"-"`
+	Arquivo      string `db:"arquivo" json:"arquivo"`
+	Linha        int    `db:"linha" json:"linha"`
+	Titulo       string `db:"titulo" json:"titulo,omitempty"`
+	Ano          int    `db:"ano" json:"ano,omitempty"`
+	IMDbID       string `db:"imdb_id" json:"imdbId,omitempty"`
+	Motivo       string `db:"motivo" json:"motivo"`
+}
+
+// EntradaDiarioComNota é uma entrada do diário com a nota da avaliação vinculada, se houver.
+type EntradaDiarioComNota struct {
+	EntradaDiario
+	Nota *Nota `db:"nota_meias" json:"nota,omitempty"`
+}
+
+// Perfil representa a tabela 'perfis': o endereço público do usuário e as seções que ele mostra.
+type Perfil struct {
+	UsuarioID           int64     `db:"usuario_id" json:"-"`
+	Slug                string    `db:"slug" json:"slug"`
+	MostrarFavoritos    bool      `db:"mostrar_favoritos" json:"mostrarFavoritos"`
+	MostrarListas       bool      `db:"mostrar_listas" json:"mostrarListas"`
+	MostrarAvaliacoes   bool      `db:"mostrar_avaliacoes" json:"mostrarAvaliacoes"`
+	MostrarEstatisticas bool      `db:"mostrar_estatisticas" json:"mostrarEstatisticas"`
+	MostrarDiario       bool      `db:"mostrar_diario" json:"mostrarDiario"` // Diário no feed dos seguidores e filmes assistidos nas estatísticas
+	DataAtualizacao     time.Time `db:"data_atualizacao" json:"dataAtualizacao"`
+	Oculto              bool      `db:"oculto" json:"oculto"` // Ocultado pela moderação; o endereço público deixa de funcionar
+}
+
+// EstatisticasPerfil resume a atividade do usuário no perfil público. FilmesAssistidos vem do
+// diário e é nulo quando o dono não o mostra.
+type EstatisticasPerfil struct {
+	Favoritos        int     `db:"favoritos" json:"favoritos"`
+	ListasPublicas   int     `db:"listas_publicas" json:"listasPublicas"`
+	Avaliacoes       int     `db:"avaliacoes" json:"avaliacoes"`
+	MediaNotas       float64 `db:"media_notas" json:"mediaNotas"`
+	FilmesAssistidos *int    `db:"filmes_assistidos" json:"filmesAssistidos"`
 }
 
 // Usuario representa a tabela 'usuarios' no nosso banco de dados.
@@ -50,6 +249,69 @@ type Usuario struct {
 	Nome      string `db:"nome"`
 	Email     string `db:"email"`
 	SenhaHash string `db:"senha_hash"`
+
+	// Indica que o dono provou ter acesso ao e-mail (pelo link de confirmação ou pelo provedor
+	// externo que criou a conta). Só contas verificadas são vinculadas por e-mail no login externo.
+	EmailVerificado bool `db:"email_verificado"`
+
+	// Campos da autenticação em dois fatores (TOTP).
+	TOTPSegredo      string     `db:"totp_segredo"`
+	TOTPAtivo        bool       `db:"totp_ativo"`
+	TOTPUltimoPasso  int64      `db:"totp_ultimo_passo"`
+	TOTPFalhas       int        `db:"totp_falhas"`
+	TOTPBloqueadoAte *time.Time `db:"totp_bloqueado_ate"`
+
+	// Preenchido quando o usuário pede a exclusão da conta: data em que ela será apagada.
+	ExclusaoAgendadaEm *time.Time `db:"exclusao_agendada_em"`
+
+	// Papel da conta: 'usuario' ou 'moderador'.
+	Papel string `db:"papel"`
+
+	// Preenchido quando a moderação suspende a conta: até quando o login fica bloqueado.
+	SuspensoAte *time.Time `db:"suspenso_ate"`
+}
+
+// Papéis de uma conta. Moderadores têm acesso às rotas de moderação.
+const (
+	PapelUsuario   = "usuario"
+	PapelModerador = "moderador"
+)
+
+// IdentidadeExterna representa a tabela 'identidades_externas': o vínculo entre
+// um usuário e sua conta em um provedor OpenID Connect.
+type IdentidadeExterna struct {
+	ID          int64     `db:"id" json:"id"`
+	UsuarioID   int64     `db:"usuario_id" json:"-"`
+	Provedor    string    `db:"provedor" json:"provedor"`
+	Sujeito     string    `db:"sujeito" json:"-"`
+	Email       string    `db:"email" json:"email"`
+	DataCriacao time.Time `db:"data_criacao" json:"dataCriacao"`
+}
+
+// LoginExternoPendente representa a tabela 'logins_externos_pendentes'.
+// Guarda os valores que o callback do provedor precisa conferir.
+type LoginExternoPendente struct {
+	Estado          string    `db:"estado"`
+	Provedor        string    `db:"provedor"`
+	Nonce           string    `db:"nonce"`
+	VerificadorPKCE string    `db:"verificador_pkce"`
+	ExpiraEm        time.Time `db:"expira_em"`
+
+	// Conta que iniciou o vínculo de um novo provedor; nulo no login comum.
+	UsuarioID *int64 `db:"usuario_id"`
+}
+
+// Sessao representa a tabela 'sessoes': um login ativo em um dispositivo.
+type Sessao struct {
+	ID           string     `db:"id" json:"id"`
+	UsuarioID    int64      `db:"usuario_id" json:"-"`
+	Dispositivo  string     `db:"dispositivo" json:"dispositivo"`
+	IP           string     `db:"ip" json:"ip"`
+	DataCriacao  time.Time  `db:"data_criacao" json:"dataCriacao"`
+	UltimoAcesso time.Time  `db:"ultimo_acesso" json:"ultimoAcesso"`
+	ExpiraEm     time.Time  `db:"expira_em" json:"expiraEm"`
+	RevogadaEm   *time.Time `db:"revogada_em" json:"-"`
+	Atual        bool       `db:"-" json:"atual"` // Indica a sessão da própria requisição
 }
 
 // OpcaoQuiz representa uma única opção de resposta em uma pergunta.
@@ -65,23 +327,231 @@ type PerguntaQuiz struct {
 	RespostaCorretaID int         `json:"respostaCorretaId"`
 }
 
+// RegistroQuiz representa a tabela 'historico_quiz': uma pergunta apresentada ao usuário.
+type RegistroQuiz struct {
+	ID              int64     `db:"id" json:"id"`
+	UsuarioID       int64     `db:"usuario_id" json:"-"`
+	FilmeID         int64     `db:"filme_id" json:"filmeId"`
+	Pergunta        string    `db:"pergunta" json:"pergunta"`
+	RespostaCorreta string    `db:"resposta_correta" json:"respostaCorreta"`
+	DataCriacao     time.Time `db:"data_criacao" json:"dataCriacao"`
+}
+
 // Avaliacao representa a tabela 'avaliacoes' no nosso banco de dados.
 type Avaliacao struct {
 	ID          int64     `db:"id" json:"id"`
 	UsuarioID   int64     `db:"usuario_id" json:"usuarioId"`
 	FilmeID     int64     `db:"filme_id" json:"filmeId"`
-	Nota        int       `db:"nota" json:"nota"`
+	Nota        Nota      `db:"nota_meias" json:"nota"`
 	Comentario  string    `db:"comentario" json:"comentario"`
 	DataCriacao time.Time `db:"data_criacao" json:"dataCriacao"`
+
+	// Data da última edição; igual à de criação enquanto a avaliação não for editada.
+	DataAtualizacao time.Time `db:"data_atualizacao" json:"dataAtualizacao"`
+
+	Spoiler  bool   `db:"spoiler" json:"spoiler"`   // O comentário só é mostrado a quem pedir
+	Situacao string `db:"situacao" json:"situacao"` // Pendentes só aparecem para o autor e a moderação
+}
+
+// Nota é a nota de uma avaliação em meias estrelas, de 1 (meia estrela) a 10 (cinco estrelas).
+// O banco e os agregados usam o inteiro; o JSON usa estrelas, de 0.5 a 5.
+type Nota int
+
+// NotaMinima e NotaMaxima delimitam a escala em meias estrelas.
+const (
+	NotaMinima Nota = 1
+	NotaMaxima Nota = 10
+)
+
+// NotaDeEstrelas converte uma nota em estrelas (0.5 a 5, de meia em meia) e informa se ela está
+// na escala.
+func NotaDeEstrelas(estrelas float64) (Nota, bool) {
+	meias := estrelas * 2
+	if meias != math.Trunc(meias) || meias < float64(NotaMinima) || meias > float64(NotaMaxima) {
+		return 0, false
+	}
+	return Nota(meias), true
+}
+
+// Estrelas é a nota na escala exibida ao usuário, de 0.5 a 5.
+func (n Nota) Estrelas() float64 {
+	return float64(n) / 2
+}
+
+// MarshalJSON escreve a nota em estrelas.
+func (n Nota) MarshalJSON() ([]byte, error) {
+	return json.Marshal(n.Estrelas())
+}
+
+// UnmarshalJSON lê a nota em estrelas e rejeita valores fora da escala.
+func (n *Nota) UnmarshalJSON(dados []byte) error {
+	var estrelas float64
+	if err := json.Unmarshal(dados, &estrelas); err != nil {
+		return err
+	}
+	nota, ok := NotaDeEstrelas(estrelas)
+	if !ok {
+		return fmt.Errorf("nota fora da escala: %v", estrelas)
+	}
+	*n = nota
+	return nil
+}
+
+// Situações de uma avaliação. Só as publicadas entram nas listagens e nas estatísticas.
+const (
+	SituacaoPublicada = "publicada"
+	SituacaoPendente  = "pendente"
+)
+
+// Ações do filtro de conteúdo sobre o texto de uma avaliação.
+const (
+	AcaoFiltroAprovar  = "aprovar"
+	AcaoFiltroReter    = "reter"
+	AcaoFiltroRejeitar = "rejeitar"
+)
+
+// DecisaoFiltro representa a tabela 'decisoes_filtro': uma avaliação que o filtro de conteúdo
+// reteve para moderação ou rejeitou.
+type DecisaoFiltro struct {
+	ID          int64     `db:"id" json:"id"`
+	AvaliacaoID *int64    `db:"avaliacao_id" json:"avaliacaoId"`
+	UsuarioID   *int64    `db:"usuario_id" json:"usuarioId"`
+	FilmeID     int64     `db:"filme_id" json:"filmeId"`
+	Acao        string    `db:"acao" json:"acao"`
+	Motivos     string    `db:"motivos" json:"motivos"`
+	Texto       string    `db:"texto" json:"texto"`
+	DataCriacao time.Time `db:"data_criacao" json:"dataCriacao"`
+}
+
+// AvaliacaoPendente é uma avaliação retida, com os motivos da última decisão do filtro. Com
+// Edicao, é a edição retida de uma avaliação publicada, que continua no ar até a moderação decidir.
+type AvaliacaoPendente struct {
+	Avaliacao
+	Motivos string `db:"motivos" json:"motivos"`
+	Edicao  bool   `db:"edicao" json:"edicao"`
+}
+
+// EdicaoPendente representa a tabela 'edicoes_pendentes_avaliacoes': a edição de uma avaliação
+// publicada que o filtro de conteúdo reteve.
+type EdicaoPendente struct {
+	AvaliacaoID int64     `db:"avaliacao_id"`
+	Nota        Nota      `db:"nota_meias"`
+	Comentario  string    `db:"comentario"`
+	Spoiler     bool      `db:"spoiler"`
+	DataCriacao time.Time `db:"data_criacao"`
+}
+
+// Ações que arquivam uma versão de avaliação no histórico.
+const (
+	AcaoHistoricoEdicao   = "edicao"
+	AcaoHistoricoExclusao = "exclusao"
+)
+
+// VersaoAvaliacao representa a tabela 'historico_avaliacoes': uma versão anterior de uma
+// avaliação, guardada quando ela foi editada ou excluída.
+type VersaoAvaliacao struct {
+	ID               int64     `db:"id" json:"id"`
+	AvaliacaoID      int64     `db:"avaliacao_id" json:"avaliacaoId"`
+	UsuarioID        *int64    `db:"usuario_id" json:"usuarioId"`
+	FilmeID          int64     `db:"filme_id" json:"filmeId"`
+	Nota             Nota      `db:"nota_meias" json:"nota"`
+	Comentario       string    `db:"comentario" json:"comentario"`
+	DataVersao       time.Time `db:"data_versao" json:"dataVersao"`
+	DataSubstituicao time.Time `db:"data_substituicao" json:"dataSubstituicao"`
+	Acao             string    `db:"acao" json:"acao"`
+}
+
+// EstatisticasFilme resume as avaliações da comunidade para um filme. MinhaNota é a nota do
+// usuário logado, quando houver.
+type EstatisticasFilme struct {
+	FilmeID      int64            `json:"filmeId"`
+	Total        int              `json:"total"`
+	Media        float64          `json:"media"`
+	Distribuicao []QuantidadeNota `json:"distribuicao"`
+	MinhaNota    *Nota            `json:"minhaNota"`
+}
+
+// QuantidadeNota é quantas avaliações de um filme deram uma certa nota.
+type QuantidadeNota struct {
+	Nota       Nota `db:"nota_meias" json:"nota"`
+	Quantidade int  `db:"quantidade" json:"quantidade"`
+}
+
+// ItemRanking é um filme do ranking da comunidade. Pontuacao é a média bayesiana usada na
+// ordenação; Media é a média simples das notas.
+type ItemRanking struct {
+	Posicao       int     `db:"-" json:"posicao"`
+	FilmeID       int64   `db:"filme_id" json:"filmeId"`
+	Titulo        string  `db:"titulo" json:"titulo"`
+	CaminhoPoster string  `db:"caminho_poster" json:"caminhoPoster"`
+	Ano           int     `db:"ano" json:"ano,omitempty"`
+	Total         int     `db:"total" json:"total"`
+	Media         float64 `db:"media" json:"media"`
+	Pontuacao     float64 `db:"pontuacao" json:"pontuacao"`
 }
 
 // AvaliacaoComUsuario é uma struct para enviar uma avaliação junto com o nome de quem a fez.
 type AvaliacaoComUsuario struct {
 	ID          int64     `db:"id" json:"id"`
-	Nota        int       `db:"nota" json:"nota"`
+	Nota        Nota      `db:"nota_meias" json:"nota"`
 	Comentario  string    `db:"comentario" json:"comentario"`
 	DataCriacao time.Time `db:"data_criacao" json:"dataCriacao"`
 	NomeUsuario string    `db:"nome" json:"nomeUsuario"` // Vem da tabela 'usuarios'
+
+	DataAtualizacao time.Time `db:"data_atualizacao" json:"dataAtualizacao"`
+
+	// Contagem das reações e a reação de quem está vendo (nula sem login ou sem reação).
+	Uteis       int     `db:"uteis" json:"uteis"`
+	Curtidas    int     `db:"curtidas" json:"curtidas"`
+	MinhaReacao *string `db:"minha_reacao" json:"minhaReacao"`
+
+	Comentarios int `db:"comentarios" json:"comentarios"` // Inclui as respostas
+
+	// Com spoiler, o comentário vem vazio a menos que o cliente peça para mostrá-lo.
+	Spoiler bool `db:"spoiler" json:"spoiler"`
+}
+
+// AvaliacaoComFilme é uma avaliação do histórico de um usuário, com o título, o pôster e o ano
+// do filme vindos do catálogo.
+type AvaliacaoComFilme struct {
+	ID              int64     `db:"id" json:"id"`
+	FilmeID         int64     `db:"filme_id" json:"filmeId"`
+	Titulo          string    `db:"titulo" json:"titulo"`
+	CaminhoPoster   string    `db:"caminho_poster" json:"caminhoPoster"`
+	Ano             int       `db:"ano" json:"ano,omitempty"`
+	Nota            Nota      `db:"nota_meias" json:"nota"`
+	Comentario      string    `db:"comentario" json:"comentario"` // Vazio quando é spoiler e o leitor não pediu para vê-lo
+	Spoiler         bool      `db:"spoiler" json:"spoiler"`
+	DataCriacao     time.Time `db:"data_criacao" json:"dataCriacao"`
+	DataAtualizacao time.Time `db:"data_atualizacao" json:"dataAtualizacao"`
+}
+
+// Tipos de reação a uma avaliação.
+const (
+	ReacaoUtil    = "util"
+	ReacaoCurtida = "curtida"
+)
+
+// ComentarioAvaliacao representa a tabela 'comentarios_avaliacoes'. Respostas só existem nos
+// comentários de primeiro nível, que são os que têm RespostaA nulo.
+type ComentarioAvaliacao struct {
+	ID              int64                 `db:"id" json:"id"`
+	AvaliacaoID     int64                 `db:"avaliacao_id" json:"avaliacaoId"`
+	UsuarioID       *int64                `db:"usuario_id" json:"usuarioId"` // Nulo se o autor excluiu a conta
+	NomeUsuario     string                `db:"nome" json:"nomeUsuario"`     // Vem da tabela 'usuarios'
+	RespostaA       *int64                `db:"resposta_a" json:"respostaA,omitempty"`
+	Texto           string                `db:"texto" json:"texto"`
+	DataCriacao     time.Time             `db:"data_criacao" json:"dataCriacao"`
+	DataAtualizacao time.Time             `db:"data_atualizacao" json:"dataAtualizacao"`
+	Respostas       []ComentarioAvaliacao `db:"-" json:"respostas,omitempty"`
+}
+
+// ReacaoAvaliacao representa a tabela 'reacoes_avaliacoes': a reação de um usuário a uma avaliação.
+type ReacaoAvaliacao struct {
+	AvaliacaoID int64     `db:"avaliacao_id" json:"avaliacaoId"`
+	UsuarioID   int64     `db:"usuario_id" json:"usuarioId"`
+	Tipo        string    `db:"tipo" json:"tipo"`
+	DataCriacao time.Time `db:"data_criacao" json:"dataCriacao"`
 }
 
 // MembroElenco representa um ator/atriz no elenco de um filme.
@@ -123,3 +593,125 @@ type DetalhesFilmeCompleto struct {
 	Escritores       []string       `json:"escritores"`
 	TrailerKey       string         `json:"trailerKey"`
 }
+
+// Tipos de conteúdo que podem ser denunciados. Perfis são identificados pelo ID do dono.
+const (
+	AlvoDenunciaAvaliacao  = "avaliacao"
+	AlvoDenunciaComentario = "comentario"
+	AlvoDenunciaPerfil     = "perfil"
+)
+
+// Situações de uma denúncia. A moderação resolve juntas todas as pendentes do mesmo conteúdo.
+const (
+	SituacaoDenunciaPendente   = "pendente"
+	SituacaoDenunciaProcedente = "procedente"
+	SituacaoDenunciaDescartada = "descartada"
+)
+
+// Ações da moderação sobre um conteúdo denunciado.
+const (
+	AcaoModeracaoDescartar = "descartar"
+	AcaoModeracaoOcultar   = "ocultar"
+	AcaoModeracaoAdvertir  = "advertir"
+	AcaoModeracaoSuspender = "suspender"
+)
+
+// Denuncia representa a tabela 'denuncias'.
+type Denuncia struct {
+	ID            int64      `db:"id" json:"id"`
+	DenuncianteID *int64     `db:"denunciante_id" json:"denuncianteId"`
+	AlvoTipo      string     `db:"alvo_tipo" json:"tipo"`
+	AlvoID        int64      `db:"alvo_id" json:"alvoId"`
+	Motivo        string     `db:"motivo" json:"motivo"`
+	Detalhes      string     `db:"detalhes" json:"detalhes"`
+	Situacao      string     `db:"situacao" json:"situacao"`
+	Resultado     *string    `db:"resultado" json:"resultado"` // Ação tomada pela moderação
+	DataCriacao   time.Time  `db:"data_criacao" json:"dataCriacao"`
+	DataResolucao *time.Time `db:"data_resolucao" json:"dataResolucao"`
+}
+
+// AcaoModeracao representa a tabela 'acoes_moderacao', o registro de auditoria da moderação.
+type AcaoModeracao struct {
+	ID            int64      `db:"id" json:"id"`
+	ModeradorID   *int64     `db:"moderador_id" json:"moderadorId"`
+	Acao          string     `db:"acao" json:"acao"`
+	AlvoTipo      string     `db:"alvo_tipo" json:"tipo"`
+	AlvoID        int64      `db:"alvo_id" json:"alvoId"`
+	UsuarioAlvoID *int64     `db:"usuario_alvo_id" json:"usuarioAlvoId"`
+	Observacao    string     `db:"observacao" json:"observacao"`
+	Conteudo      string     `db:"conteudo" json:"conteudo"`
+	SuspensoAte   *time.Time `db:"suspenso_ate" json:"suspensoAte"`
+	Denuncias     int        `db:"denuncias" json:"denuncias"` // Quantas denúncias a ação encerrou
+	DataCriacao   time.Time  `db:"data_criacao" json:"dataCriacao"`
+}
+
+// UsuarioConexao é um usuário nas listas de seguidores e de seguidos (tabela 'seguidores'),
+// com a data em que passou a seguir ou a ser seguido.
+type UsuarioConexao struct {
+	ID          int64     `db:"id" json:"-"` // Da tabela 'seguidores'; usado como cursor
+	UsuarioID   int64     `db:"usuario_id" json:"usuarioId"`
+	Nome        string    `db:"nome" json:"nome"`
+	Slug        *string   `db:"slug" json:"slug"` // Nulo enquanto o usuário não tiver perfil público
+	DataCriacao time.Time `db:"data_criacao" json:"dataCriacao"`
+}
+
+// Tipos de atividade que aparecem no feed dos seguidores.
+const (
+	AtividadeAvaliacao = "avaliacao"
+	AtividadeFavorito  = "favorito"
+	AtividadeLista     = "lista"
+	AtividadeDiario    = "diario"
+)
+
+// Atividade representa a tabela 'atividades': uma ação do usuário que aparece no feed de quem
+// o segue. Só um dos IDs de conteúdo é preenchido, conforme o tipo.
+type Atividade struct {
+	ID            int64     `db:"id" json:"id"`
+	UsuarioID     int64     `db:"usuario_id" json:"usuarioId"`
+	Tipo          string    `db:"tipo" json:"tipo"`
+	FilmeID       *int64    `db:"filme_id" json:"filmeId,omitempty"` // Nulo na criação de uma lista
+	Titulo        string    `db:"titulo" json:"titulo,omitempty"`
+	CaminhoPoster string    `db:"caminho_poster" json:"caminhoPoster,omitempty"`
+	AvaliacaoID   *int64    `db:"avaliacao_id" json:"avaliacaoId,omitempty"`
+	ListaID       *int64    `db:"lista_id" json:"listaId,omitempty"`
+	DiarioID      *int64    `db:"diario_id" json:"diarioId,omitempty"`
+	DataCriacao   time.Time `db:"data_criacao" json:"dataCriacao"`
+}
+
+// ItemFeed é uma atividade do feed com o autor e os dados atuais do conteúdo.
+type ItemFeed struct {
+	Atividade
+	NomeUsuario   string  `db:"nome_usuario" json:"nomeUsuario"`
+	SlugUsuario   *string `db:"slug_usuario" json:"slugUsuario"`
+	Nota          *Nota   `db:"nota_meias" json:"nota,omitempty"`
+	Comentario    string  `db:"comentario" json:"comentario,omitempty"` // Vazio quando é spoiler e o leitor não pediu para vê-lo
+	Spoiler       bool    `db:"spoiler" json:"spoiler,omitempty"`
+	TituloLista   string  `db:"titulo_lista" json:"tituloLista,omitempty"`
+	SlugLista     *string `db:"slug_lista" json:"slugLista,omitempty"` // Link de compartilhamento da lista
+	DataAssistido string  `db:"data_assistido" json:"dataAssistido,omitempty"`
+	Revisto       bool    `db:"revisto" json:"revisto,omitempty"`
+}
+
+// Tipos de notificação. Cada um pode ser desligado nas preferências do usuário.
+const (
+	NotificacaoSeguidor   = "seguidor"
+	NotificacaoComentario = "comentario"
+	NotificacaoResposta   = "resposta"
+	NotificacaoModeracao  = "moderacao"
+)
+
+// Notificacao representa a tabela 'notificacoes': um aviso para o usuário sobre algo que
+// aconteceu com ele ou com o seu conteúdo.
+type Notificacao struct {
+	ID           int64     `db:"id" json:"id"`
+	UsuarioID    int64     `db:"usuario_id" json:"-"`
+	Tipo         string    `db:"tipo" json:"tipo"`
+	AtorID       *int64    `db:"ator_id" json:"atorId"`
+	NomeAtor     *string   `db:"nome_ator" json:"nomeAtor"` // Vem da tabela 'usuarios'
+	FilmeID      *int64    `db:"filme_id" json:"filmeId,omitempty"`
+	AvaliacaoID  *int64    `db:"avaliacao_id" json:"avaliacaoId,omitempty"`
+	ComentarioID *int64    `db:"comentario_id" json:"comentarioId,omitempty"`
+	Texto        string    `db:"texto" json:"texto"`
+	Lida         bool      `db:"lida" json:"lida"`
+	DataCriacao  time.Time `db:"data_criacao" json:"dataCriacao"`
+}
@@ -0,0 +1,49 @@
+package dominio_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotaDeEstrelas(t *testing.T) {
+	casos := []struct {
+		estrelas float64
+		nota     dominio.Nota
+		ok       bool
+	}{
+		{0.5, 1, true},
+		{3.5, 7, true},
+		{5, 10, true},
+		{0, 0, false},
+		{5.5, 0, false},
+		{3.3, 0, false},
+		{-1, 0, false},
+	}
+	for _, caso := range casos {
+		nota, ok := dominio.NotaDeEstrelas(caso.estrelas)
+		assert.Equal(t, caso.ok, ok, "estrelas %v", caso.estrelas)
+		assert.Equal(t, caso.nota, nota, "estrelas %v", caso.estrelas)
+	}
+}
+
+func TestNotaEmJSONUsaEstrelas(t *testing.T) {
+	for nota := dominio.NotaMinima; nota <= dominio.NotaMaxima; nota++ {
+		dados, err := json.Marshal(nota)
+		require.NoError(t, err)
+
+		var lida dominio.Nota
+		require.NoError(t, json.Unmarshal(dados, &lida))
+		assert.Equal(t, nota, lida)
+	}
+
+	dados, err := json.Marshal(dominio.QuantidadeNota{Nota: 7, Quantidade: 2})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"nota": 3.5, "quantidade": 2}`, string(dados))
+
+	var nota dominio.Nota
+	assert.Error(t, json.Unmarshal([]byte("4.2"), &nota))
+}
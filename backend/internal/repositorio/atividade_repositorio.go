@@ -0,0 +1,87 @@
+package repositorio
+
+import (
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/jmoiron/sqlx"
+)
+
+// AtividadeRepositorio define a persistência das atividades que formam o feed dos seguidores.
+type AtividadeRepositorio interface {
+	Registrar(atividade *dominio.Atividade) error
+	ListarFeed(seguidorID, antesDeID int64, limite int) ([]dominio.ItemFeed, error)
+	ListarPorUsuarioID(usuarioID int64) ([]dominio.Atividade, error)
+}
+
+type atividadeRepositorioSqlx struct {
+	db *sqlx.DB
+}
+
+// NovaAtividadeRepositorio cria uma nova instância do repositório de atividades.
+func NovaAtividadeRepositorio(db *sqlx.DB) AtividadeRepositorio {
+	return &atividadeRepositorioSqlx{db: db}
+}
+
+// Registrar grava a atividade e preenche o ID gerado. Uma avaliação que já tem atividade é
+// ignorada, e o ID fica zerado.
+func (r *atividadeRepositorioSqlx) Registrar(a *dominio.Atividade) error {
+	a.DataCriacao = time.Now().UTC()
+	query := `INSERT OR IGNORE INTO atividades (usuario_id, tipo, filme_id, titulo, caminho_poster,
+	              avaliacao_id, lista_id, diario_id, data_criacao)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	resultado, err := r.db.Exec(query, a.UsuarioID, a.Tipo, a.FilmeID, a.Titulo, a.CaminhoPoster,
+		a.AvaliacaoID, a.ListaID, a.DiarioID, a.DataCriacao)
+	if err != nil {
+		return err
+	}
+	if linhas, err := resultado.RowsAffected(); err != nil || linhas == 0 {
+		return err
+	}
+	a.ID, err = resultado.LastInsertId()
+	return err
+}
+
+// ListarFeed retorna as atividades de quem seguidorID segue, das mais novas para as mais
+// antigas, a partir do cursor antesDeID (0 para o início). A privacidade é conferida aqui, com
+// os padrões de quem ainda não configurou o perfil: avaliações e listas públicas aparecem,
+// favoritos e diário não. Favoritos e filmes já tirados da lista também somem do feed.
+func (r *atividadeRepositorioSqlx) ListarFeed(seguidorID, antesDeID int64, limite int) ([]dominio.ItemFeed, error) {
+	var itens []dominio.ItemFeed
+	query := `SELECT t.*, u.nome AS nome_usuario, p.slug AS slug_usuario,
+	              a.nota_meias, COALESCE(a.comentario, '') AS comentario, COALESCE(a.spoiler, 0) AS spoiler,
+	              COALESCE(l.titulo, '') AS titulo_lista, l.slug_compartilhamento AS slug_lista,
+	              COALESCE(d.data_assistido, '') AS data_assistido, COALESCE(d.revisto, 0) AS revisto
+	          FROM atividades t
+	          JOIN seguidores s ON s.seguido_id = t.usuario_id AND s.seguidor_id = ?
+	          JOIN usuarios u ON u.id = t.usuario_id
+	          LEFT JOIN perfis p ON p.usuario_id = t.usuario_id
+	          LEFT JOIN avaliacoes a ON a.id = t.avaliacao_id
+	          LEFT JOIN listas l ON l.id = t.lista_id
+	          LEFT JOIN diario d ON d.id = t.diario_id
+	          WHERE (? = 0 OR t.id < ?)
+	              AND u.exclusao_agendada_em IS NULL AND COALESCE(p.oculto, 0) = 0
+	              AND CASE t.tipo
+	                  WHEN ? THEN COALESCE(p.mostrar_avaliacoes, 1) AND a.situacao = ?
+	                  WHEN ? THEN COALESCE(p.mostrar_favoritos, 0) AND EXISTS (
+	                      SELECT 1 FROM itens_lista i JOIN listas f ON f.id = i.lista_id
+	                      WHERE f.usuario_id = t.usuario_id AND f.tipo = ? AND i.filme_id = t.filme_id)
+	                  WHEN ? THEN COALESCE(p.mostrar_listas, 1) AND l.visibilidade = ? AND (t.filme_id IS NULL OR EXISTS (
+	                      SELECT 1 FROM itens_lista i WHERE i.lista_id = t.lista_id AND i.filme_id = t.filme_id))
+	                  WHEN ? THEN COALESCE(p.mostrar_diario, 0)
+	                  ELSE 0 END
+	          ORDER BY t.id DESC LIMIT ?`
+	err := r.db.Select(&itens, query, seguidorID, antesDeID, antesDeID,
+		dominio.AtividadeAvaliacao, dominio.SituacaoPublicada,
+		dominio.AtividadeFavorito, dominio.TipoListaFavoritos,
+		dominio.AtividadeLista, dominio.VisibilidadePublica,
+		dominio.AtividadeDiario, limite)
+	return itens, err
+}
+
+// ListarPorUsuarioID retorna as atividades do usuário em ordem cronológica.
+func (r *atividadeRepositorioSqlx) ListarPorUsuarioID(usuarioID int64) ([]dominio.Atividade, error) {
+	var atividades []dominio.Atividade
+	err := r.db.Select(&atividades, "SELECT * FROM atividades WHERE usuario_id = ? ORDER BY id", usuarioID)
+	return atividades, err
+}
@@ -1,13 +1,99 @@
 package repositorio
 
 import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
 	"github.com/Andydev0/filmes-backend/internal/dominio"
 	"github.com/jmoiron/sqlx"
 )
 
 type AvaliacaoRepositorio interface {
-	Salvar(avaliacao *dominio.Avaliacao) error
-	BuscarPorFilmeID(filmeID int64) ([]dominio.AvaliacaoComUsuario, error)
+	Salvar(avaliacao *dominio.Avaliacao) (bool, error)
+	SalvarNota(usuarioID, filmeID int64, nota dominio.Nota) (bool, error)
+	BuscarPorFilmeDoUsuario(usuarioID, filmeID int64) (*dominio.Avaliacao, error)
+	BuscarPorID(id int64) (*dominio.Avaliacao, error)
+	Deletar(usuarioID, filmeID int64) (bool, error)
+	DeletarPorID(id int64) (bool, error)
+	Publicar(id int64) (bool, error)
+	SalvarEdicaoPendente(edicao *dominio.EdicaoPendente) error
+	AplicarEdicaoPendente(avaliacaoID int64) (bool, error)
+	DescartarEdicaoPendente(avaliacaoID int64) (bool, error)
+	ListarPendentes() ([]dominio.AvaliacaoPendente, error)
+	RegistrarDecisaoFiltro(decisao *dominio.DecisaoFiltro) error
+	ListarDecisoesFiltroDoUsuario(usuarioID int64) ([]dominio.DecisaoFiltro, error)
+	ListarHistorico(avaliacaoID int64) ([]dominio.VersaoAvaliacao, error)
+	ListarHistoricoDoUsuario(usuarioID int64) ([]dominio.VersaoAvaliacao, error)
+	BuscarPorFilmeID(filmeID int64, filtro FiltroAvaliacoes, leitorID int64) ([]dominio.AvaliacaoComUsuario, *CursorAvaliacoes, error)
+	ListarComFilmesDoUsuario(usuarioID int64, filtro FiltroAvaliacoesUsuario) ([]dominio.AvaliacaoComFilme, *CursorAvaliacoes, error)
+	ListarPorUsuarioID(usuarioID int64) ([]dominio.Avaliacao, error)
+	ListarPorUsuarioApos(usuarioID, aposID int64, limite int) ([]dominio.Avaliacao, error)
+	BuscarDoUsuario(usuarioID, id int64) (*dominio.Avaliacao, error)
+	ListarRecentesDoUsuario(usuarioID int64, limite int) ([]dominio.Avaliacao, error)
+	BuscarEstatisticas(filmeID int64) (*dominio.EstatisticasFilme, error)
+	ListarRanking(filtro FiltroRanking) ([]dominio.ItemRanking, error)
+	Reagir(reacao *dominio.ReacaoAvaliacao) error
+	RemoverReacao(avaliacaoID, usuarioID int64) (bool, error)
+	ListarReacoesDoUsuario(usuarioID int64) ([]dominio.ReacaoAvaliacao, error)
+}
+
+// Critérios de ordenação aceitos por BuscarPorFilmeID.
+const (
+	OrdenarAvaliacoesRecentes  = "recentes"
+	OrdenarAvaliacoesUteis     = "uteis"
+	OrdenarAvaliacoesNotaAlta  = "nota_alta"
+	OrdenarAvaliacoesNotaBaixa = "nota_baixa"
+)
+
+// contagemUteis conta as reações "útil" da avaliação; é ordenável e usada no cursor.
+const contagemUteis = "(SELECT COUNT(*) FROM reacoes_avaliacoes WHERE avaliacao_id = a.id AND tipo = 'util')"
+
+// ordenacoesAvaliacoes associa cada critério à expressão numérica ordenada antes da data; as
+// mais recentes não têm expressão. Empates sempre saem das mais recentes.
+var ordenacoesAvaliacoes = map[string]struct {
+	expressao string
+	crescente bool
+}{
+	OrdenarAvaliacoesRecentes:  {"", false},
+	OrdenarAvaliacoesUteis:     {contagemUteis, false},
+	OrdenarAvaliacoesNotaAlta:  {"a.nota_meias", false},
+	OrdenarAvaliacoesNotaBaixa: {"a.nota_meias", true},
+}
+
+// CursorAvaliacoes marca a última avaliação de uma página: o valor da ordenação (vazio nas mais
+// recentes), a data de criação como gravada no banco e o ID.
+type CursorAvaliacoes struct {
+	Chave string
+	Data  string
+	ID    int64
+}
+
+// FiltroAvaliacoes define a ordenação e a página das avaliações de um filme.
+type FiltroAvaliacoes struct {
+	Ordenar string
+	Apos    *CursorAvaliacoes
+	Limite  int
+}
+
+// FiltroAvaliacoesUsuario define os filtros e a página do histórico de avaliações de um usuário.
+// Nota e ano zerados não filtram; o ano é o de lançamento do filme, vindo do catálogo.
+type FiltroAvaliacoesUsuario struct {
+	Nota   dominio.Nota
+	Ano    int
+	Apos   *CursorAvaliacoes
+	Limite int
+}
+
+// FiltroRanking define o recorte do ranking da comunidade. VotosMinimos é tanto o corte de
+// avaliações quanto o peso da média geral na média bayesiana; anos zerados não filtram.
+type FiltroRanking struct {
+	VotosMinimos int
+	GeneroID     int
+	AnoInicial   int
+	AnoFinal     int
+	Limite       int
 }
 
 type avaliacaoRepoSqlx struct{ db *sqlx.DB }
@@ -16,19 +102,618 @@ func NovaAvaliacaoRepositorio(db *sqlx.DB) AvaliacaoRepositorio {
 	return &avaliacaoRepoSqlx{db: db}
 }
 
-func (r *avaliacaoRepoSqlx) Salvar(a *dominio.Avaliacao) error {
-	// Usamos "upsert" (INSERT OR REPLACE) para que um usuário só possa ter uma avaliação por filme.
-	query := `INSERT OR REPLACE INTO avaliacoes (id, usuario_id, filme_id, nota, comentario) 
-	           VALUES ((SELECT id FROM avaliacoes WHERE usuario_id = ? AND filme_id = ?), ?, ?, ?, ?)`
-	_, err := r.db.Exec(query, a.UsuarioID, a.FilmeID, a.UsuarioID, a.FilmeID, a.Nota, a.Comentario)
+// colunasAvaliacao seleciona uma avaliação de qualquer usuário; as de contas excluídas vêm com
+// usuario_id 0.
+const colunasAvaliacao = `a.id, COALESCE(a.usuario_id, 0) AS usuario_id, a.filme_id, a.nota_meias, a.comentario,
+	          a.data_criacao, a.data_atualizacao, a.spoiler, a.situacao`
+
+// Salvar cria a avaliação do usuário para o filme ou atualiza a existente com a nota, o
+// comentário, a marcação de spoiler e a situação de a, mantendo a data de criação e guardando a
+// versão anterior no histórico. Preenche a com a avaliação gravada e retorna true se ela foi criada.
+func (r *avaliacaoRepoSqlx) Salvar(a *dominio.Avaliacao) (bool, error) {
+	gravada, criada, _, err := r.gravar(a, false)
+	if err != nil {
+		return false, err
+	}
+	*a = *gravada
+	return criada, nil
+}
+
+// SalvarNota cria ou atualiza apenas a nota do usuário para o filme, mantendo o comentário, a
+// marcação de spoiler e a situação. Retorna false quando a avaliação já existia com a mesma nota.
+func (r *avaliacaoRepoSqlx) SalvarNota(usuarioID, filmeID int64, nota dominio.Nota) (bool, error) {
+	nova := &dominio.Avaliacao{UsuarioID: usuarioID, FilmeID: filmeID, Nota: nota, Situacao: dominio.SituacaoPublicada}
+	_, _, alterada, err := r.gravar(nova, true)
+	return alterada, err
+}
+
+// gravar cria a avaliação ou aplica os dados de nova sobre a existente (só a nota, com
+// apenasNota), em uma transação própria.
+func (r *avaliacaoRepoSqlx) gravar(nova *dominio.Avaliacao, apenasNota bool) (*dominio.Avaliacao, bool, bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, false, false, err
+	}
+	defer tx.Rollback()
+
+	gravada, criada, alterada, err := gravarNaTransacao(tx, nova, apenasNota)
+	if err != nil {
+		return nil, false, false, err
+	}
+	return gravada, criada, alterada, tx.Commit()
+}
+
+// gravarNaTransacao cria a avaliação ou aplica os dados de nova sobre a existente. Uma avaliação
+// só pode existir uma por filme para cada usuário; a versão substituída vai para o histórico, e
+// uma edição completa descarta a edição retida pelo filtro, que ficou superada. Retorna a
+// avaliação gravada, se ela foi criada e se algo mudou.
+func gravarNaTransacao(tx *sqlx.Tx, nova *dominio.Avaliacao, apenasNota bool) (*dominio.Avaliacao, bool, bool, error) {
+	agora := time.Now().UTC()
+	var atual dominio.Avaliacao
+	err := tx.Get(&atual, "SELECT * FROM avaliacoes WHERE usuario_id = ? AND filme_id = ?", nova.UsuarioID, nova.FilmeID)
+	if err == sql.ErrNoRows {
+		criada := *nova
+		criada.DataCriacao, criada.DataAtualizacao = agora, agora
+		if criada.Situacao == "" {
+			criada.Situacao = dominio.SituacaoPublicada
+		}
+		query := `INSERT INTO avaliacoes (usuario_id, filme_id, nota_meias, comentario, spoiler, situacao, data_criacao, data_atualizacao)
+		          VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+		resultado, err := tx.Exec(query, criada.UsuarioID, criada.FilmeID, criada.Nota, criada.Comentario,
+			criada.Spoiler, criada.Situacao, agora, agora)
+		if err != nil {
+			return nil, false, false, err
+		}
+		if criada.ID, err = resultado.LastInsertId(); err != nil {
+			return nil, false, false, err
+		}
+		if err := ajustarEstatisticas(tx, criada.FilmeID, 0, notaPublicada(&criada)); err != nil {
+			return nil, false, false, err
+		}
+		return &criada, true, true, nil
+	}
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	gravada := atual
+	gravada.Nota = nova.Nota
+	if !apenasNota {
+		gravada.Comentario, gravada.Spoiler, gravada.Situacao = nova.Comentario, nova.Spoiler, nova.Situacao
+		if _, err := tx.Exec("DELETE FROM edicoes_pendentes_avaliacoes WHERE avaliacao_id = ?", atual.ID); err != nil {
+			return nil, false, false, err
+		}
+	}
+	if gravada.Nota == atual.Nota && gravada.Comentario == atual.Comentario &&
+		gravada.Spoiler == atual.Spoiler && gravada.Situacao == atual.Situacao {
+		return &atual, false, false, nil
+	}
+
+	if err := arquivarVersao(tx, &atual, dominio.AcaoHistoricoEdicao, agora); err != nil {
+		return nil, false, false, err
+	}
+	gravada.DataAtualizacao = agora
+	query := "UPDATE avaliacoes SET nota_meias = ?, comentario = ?, spoiler = ?, situacao = ?, data_atualizacao = ? WHERE id = ?"
+	if _, err := tx.Exec(query, gravada.Nota, gravada.Comentario, gravada.Spoiler, gravada.Situacao, agora, atual.ID); err != nil {
+		return nil, false, false, err
+	}
+	if err := ajustarEstatisticas(tx, atual.FilmeID, notaPublicada(&atual), notaPublicada(&gravada)); err != nil {
+		return nil, false, false, err
+	}
+	return &gravada, false, true, nil
+}
+
+// notaPublicada é a nota com que a avaliação entra nas estatísticas: zero enquanto ela estiver pendente.
+func notaPublicada(a *dominio.Avaliacao) dominio.Nota {
+	if a.Situacao != dominio.SituacaoPublicada {
+		return 0
+	}
+	return a.Nota
+}
+
+// BuscarPorFilmeDoUsuario encontra a avaliação que o usuário fez do filme.
+func (r *avaliacaoRepoSqlx) BuscarPorFilmeDoUsuario(usuarioID, filmeID int64) (*dominio.Avaliacao, error) {
+	var avaliacao dominio.Avaliacao
+	query := "SELECT * FROM avaliacoes WHERE usuario_id = ? AND filme_id = ?"
+	if err := r.db.Get(&avaliacao, query, usuarioID, filmeID); err != nil {
+		return nil, err
+	}
+	return &avaliacao, nil
+}
+
+// BuscarPorID encontra uma avaliação de qualquer usuário; as de contas excluídas vêm com usuarioId 0.
+func (r *avaliacaoRepoSqlx) BuscarPorID(id int64) (*dominio.Avaliacao, error) {
+	var avaliacao dominio.Avaliacao
+	if err := r.db.Get(&avaliacao, "SELECT "+colunasAvaliacao+" FROM avaliacoes a WHERE a.id = ?", id); err != nil {
+		return nil, err
+	}
+	return &avaliacao, nil
+}
+
+// Deletar exclui a avaliação do usuário para o filme, guardando-a no histórico. Entradas do
+// diário que apontavam para ela perdem o vínculo. Retorna false se ela não existir.
+func (r *avaliacaoRepoSqlx) Deletar(usuarioID, filmeID int64) (bool, error) {
+	return r.excluir("a.usuario_id = ? AND a.filme_id = ?", usuarioID, filmeID)
+}
+
+// DeletarPorID exclui uma avaliação de qualquer usuário, como Deletar. Usado pela moderação.
+func (r *avaliacaoRepoSqlx) DeletarPorID(id int64) (bool, error) {
+	return r.excluir("a.id = ?", id)
+}
+
+// excluir apaga a avaliação que atende à condição, guardando-a no histórico e tirando a nota
+// dela das estatísticas.
+func (r *avaliacaoRepoSqlx) excluir(condicao string, args ...interface{}) (bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	excluida, err := excluirAvaliacao(tx, condicao, args...)
+	if err != nil || !excluida {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// excluirAvaliacao faz o trabalho de excluir dentro de uma transação já aberta; a moderação a usa
+// para ocultar uma avaliação junto com o encerramento das denúncias.
+func excluirAvaliacao(tx *sqlx.Tx, condicao string, args ...interface{}) (bool, error) {
+	var atual dominio.Avaliacao
+	err := tx.Get(&atual, "SELECT "+colunasAvaliacao+" FROM avaliacoes a WHERE "+condicao, args...)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := arquivarVersao(tx, &atual, dominio.AcaoHistoricoExclusao, time.Now().UTC()); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec("DELETE FROM avaliacoes WHERE id = ?", atual.ID); err != nil {
+		return false, err
+	}
+	if err := ajustarEstatisticas(tx, atual.FilmeID, notaPublicada(&atual), 0); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Publicar libera uma avaliação pendente, que passa a contar nas listagens e nas estatísticas.
+// Retorna false se ela não existir ou não estiver pendente.
+func (r *avaliacaoRepoSqlx) Publicar(id int64) (bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var atual dominio.Avaliacao
+	query := "SELECT " + colunasAvaliacao + " FROM avaliacoes a WHERE a.id = ? AND a.situacao = ?"
+	err = tx.Get(&atual, query, id, dominio.SituacaoPendente)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := tx.Exec("UPDATE avaliacoes SET situacao = ? WHERE id = ?", dominio.SituacaoPublicada, id); err != nil {
+		return false, err
+	}
+	if err := ajustarEstatisticas(tx, atual.FilmeID, 0, atual.Nota); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// SalvarEdicaoPendente guarda a edição retida pelo filtro de uma avaliação publicada, que
+// continua no ar sem alteração. Uma nova edição retida substitui a anterior.
+func (r *avaliacaoRepoSqlx) SalvarEdicaoPendente(e *dominio.EdicaoPendente) error {
+	query := `INSERT INTO edicoes_pendentes_avaliacoes (avaliacao_id, nota_meias, comentario, spoiler, data_criacao)
+	          VALUES (?, ?, ?, ?, ?)
+	          ON CONFLICT (avaliacao_id) DO UPDATE SET nota_meias = excluded.nota_meias, comentario = excluded.comentario,
+	              spoiler = excluded.spoiler, data_criacao = excluded.data_criacao`
+	_, err := r.db.Exec(query, e.AvaliacaoID, e.Nota, e.Comentario, e.Spoiler, e.DataCriacao.UTC())
+	return err
+}
+
+// AplicarEdicaoPendente publica a edição retida sobre a avaliação, como uma edição comum (a
+// versão substituída vai para o histórico). Retorna false se não houver edição retida.
+func (r *avaliacaoRepoSqlx) AplicarEdicaoPendente(avaliacaoID int64) (bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var nova dominio.Avaliacao
+	query := `SELECT a.usuario_id, a.filme_id, e.nota_meias, e.comentario, e.spoiler
+	          FROM edicoes_pendentes_avaliacoes e JOIN avaliacoes a ON a.id = e.avaliacao_id
+	          WHERE e.avaliacao_id = ? AND a.usuario_id IS NOT NULL`
+	err = tx.Get(&nova, query, avaliacaoID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	nova.Situacao = dominio.SituacaoPublicada
+	if _, _, _, err := gravarNaTransacao(tx, &nova, false); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// DescartarEdicaoPendente apaga a edição retida, mantendo a versão publicada da avaliação.
+// Retorna false se não houver edição retida.
+func (r *avaliacaoRepoSqlx) DescartarEdicaoPendente(avaliacaoID int64) (bool, error) {
+	resultado, err := r.db.Exec("DELETE FROM edicoes_pendentes_avaliacoes WHERE avaliacao_id = ?", avaliacaoID)
+	if err != nil {
+		return false, err
+	}
+	linhas, err := resultado.RowsAffected()
+	return linhas > 0, err
+}
+
+// ListarPendentes retorna as avaliações retidas pelo filtro e as edições retidas de avaliações
+// publicadas (com edicao verdadeiro e o texto da edição), das mais antigas para as mais novas,
+// com os motivos da última decisão sobre cada uma.
+func (r *avaliacaoRepoSqlx) ListarPendentes() ([]dominio.AvaliacaoPendente, error) {
+	var pendentes []dominio.AvaliacaoPendente
+	const motivos = "COALESCE((SELECT d.motivos FROM decisoes_filtro d WHERE d.avaliacao_id = a.id ORDER BY d.id DESC LIMIT 1), '') AS motivos"
+	query := `SELECT ` + colunasAvaliacao + `, ` + motivos + `, 0 AS edicao
+	          FROM avaliacoes a WHERE a.situacao = ?
+	          UNION ALL
+	          SELECT a.id, COALESCE(a.usuario_id, 0) AS usuario_id, a.filme_id, e.nota_meias, e.comentario,
+	                 a.data_criacao, e.data_criacao AS data_atualizacao, e.spoiler, ? AS situacao, ` + motivos + `, 1 AS edicao
+	          FROM edicoes_pendentes_avaliacoes e JOIN avaliacoes a ON a.id = e.avaliacao_id
+	          ORDER BY data_atualizacao, id`
+	err := r.db.Select(&pendentes, query, dominio.SituacaoPendente, dominio.SituacaoPendente)
+	return pendentes, err
+}
+
+// RegistrarDecisaoFiltro guarda a decisão do filtro de conteúdo de reter ou rejeitar uma avaliação.
+func (r *avaliacaoRepoSqlx) RegistrarDecisaoFiltro(d *dominio.DecisaoFiltro) error {
+	query := `INSERT INTO decisoes_filtro (avaliacao_id, usuario_id, filme_id, acao, motivos, texto, data_criacao)
+	          VALUES (?, ?, ?, ?, ?, ?, ?)`
+	resultado, err := r.db.Exec(query, d.AvaliacaoID, d.UsuarioID, d.FilmeID, d.Acao, d.Motivos, d.Texto, d.DataCriacao)
+	if err != nil {
+		return err
+	}
+	d.ID, err = resultado.LastInsertId()
+	return err
+}
+
+// ListarDecisoesFiltroDoUsuario retorna as decisões do filtro sobre as avaliações do usuário.
+func (r *avaliacaoRepoSqlx) ListarDecisoesFiltroDoUsuario(usuarioID int64) ([]dominio.DecisaoFiltro, error) {
+	var decisoes []dominio.DecisaoFiltro
+	err := r.db.Select(&decisoes, "SELECT * FROM decisoes_filtro WHERE usuario_id = ? ORDER BY id", usuarioID)
+	return decisoes, err
+}
+
+// ListarHistorico retorna as versões anteriores da avaliação, da mais antiga para a mais recente.
+func (r *avaliacaoRepoSqlx) ListarHistorico(avaliacaoID int64) ([]dominio.VersaoAvaliacao, error) {
+	var versoes []dominio.VersaoAvaliacao
+	query := "SELECT * FROM historico_avaliacoes WHERE avaliacao_id = ? ORDER BY data_substituicao, id"
+	err := r.db.Select(&versoes, query, avaliacaoID)
+	return versoes, err
+}
+
+// ListarHistoricoDoUsuario retorna as versões anteriores de todas as avaliações do usuário.
+func (r *avaliacaoRepoSqlx) ListarHistoricoDoUsuario(usuarioID int64) ([]dominio.VersaoAvaliacao, error) {
+	var versoes []dominio.VersaoAvaliacao
+	query := "SELECT * FROM historico_avaliacoes WHERE usuario_id = ? ORDER BY avaliacao_id, data_substituicao, id"
+	err := r.db.Select(&versoes, query, usuarioID)
+	return versoes, err
+}
+
+// arquivarVersao guarda no histórico a versão da avaliação que está sendo editada ou excluída.
+// Avaliações de contas excluídas (usuário 0) ficam sem autor também no histórico.
+func arquivarVersao(tx *sqlx.Tx, a *dominio.Avaliacao, acao string, agora time.Time) error {
+	query := `INSERT INTO historico_avaliacoes
+	              (avaliacao_id, usuario_id, filme_id, nota_meias, comentario, data_versao, data_substituicao, acao)
+	          VALUES (?, NULLIF(?, 0), ?, ?, ?, ?, ?, ?)`
+	_, err := tx.Exec(query, a.ID, a.UsuarioID, a.FilmeID, a.Nota, a.Comentario, a.DataAtualizacao, agora, acao)
+	return err
+}
+
+// ajustarEstatisticas aplica aos agregados do filme a troca de notaAnterior por notaNova; zero
+// indica que a avaliação não existia antes ou deixou de existir.
+func ajustarEstatisticas(tx *sqlx.Tx, filmeID int64, notaAnterior, notaNova dominio.Nota) error {
+	if notaAnterior == notaNova {
+		return nil
+	}
+
+	total := 0
+	if notaAnterior == 0 {
+		total++
+	}
+	if notaNova == 0 {
+		total--
+	}
+	query := `INSERT INTO estatisticas_filmes (filme_id, total, soma_meias) VALUES (?, ?, ?)
+	          ON CONFLICT (filme_id) DO UPDATE SET total = total + excluded.total, soma_meias = soma_meias + excluded.soma_meias`
+	if _, err := tx.Exec(query, filmeID, total, notaNova-notaAnterior); err != nil {
+		return err
+	}
+
+	query = `INSERT INTO distribuicao_notas (filme_id, nota_meias, quantidade) VALUES (?, ?, ?)
+	         ON CONFLICT (filme_id, nota_meias) DO UPDATE SET quantidade = quantidade + excluded.quantidade`
+	if notaAnterior != 0 {
+		if _, err := tx.Exec(query, filmeID, notaAnterior, -1); err != nil {
+			return err
+		}
+	}
+	if notaNova != 0 {
+		if _, err := tx.Exec(query, filmeID, notaNova, 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BuscarEstatisticas retorna os agregados das avaliações do filme. A distribuição traz só as
+// notas que já foram dadas; um filme sem avaliações volta com total zero.
+func (r *avaliacaoRepoSqlx) BuscarEstatisticas(filmeID int64) (*dominio.EstatisticasFilme, error) {
+	estatisticas := &dominio.EstatisticasFilme{FilmeID: filmeID}
+
+	var agregado struct {
+		Total     int `db:"total"`
+		SomaMeias int `db:"soma_meias"`
+	}
+	err := r.db.Get(&agregado, "SELECT total, soma_meias FROM estatisticas_filmes WHERE filme_id = ?", filmeID)
+	if err == sql.ErrNoRows {
+		return estatisticas, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	estatisticas.Total = agregado.Total
+	if agregado.Total > 0 {
+		estatisticas.Media = float64(agregado.SomaMeias) / 2 / float64(agregado.Total)
+	}
+
+	query := "SELECT nota_meias, quantidade FROM distribuicao_notas WHERE filme_id = ? AND quantidade > 0 ORDER BY nota_meias"
+	if err := r.db.Select(&estatisticas.Distribuicao, query, filmeID); err != nil {
+		return nil, err
+	}
+	return estatisticas, nil
+}
+
+// ListarRanking ordena os filmes com ao menos filtro.VotosMinimos avaliações pela média
+// bayesiana (soma + m*C) / (total + m), em que C é a média de todas as notas do site. Gênero e
+// ano vêm do catálogo local, então filmes ainda fora dele não aparecem nos recortes.
+func (r *avaliacaoRepoSqlx) ListarRanking(filtro FiltroRanking) ([]dominio.ItemRanking, error) {
+	query := `WITH geral AS (
+	              SELECT COALESCE(SUM(soma_meias) / 2.0 / NULLIF(SUM(total), 0), 0) AS media FROM estatisticas_filmes
+	          )
+	          SELECT e.filme_id, COALESCE(c.titulo, '') AS titulo, COALESCE(c.caminho_poster, '') AS caminho_poster,
+	                 COALESCE(c.ano, 0) AS ano, e.total, e.soma_meias / 2.0 / e.total AS media,
+	                 (e.soma_meias / 2.0 + ? * geral.media) / (e.total + ?) AS pontuacao
+	          FROM estatisticas_filmes e CROSS JOIN geral
+	          LEFT JOIN catalogo_filmes c ON c.filme_id = e.filme_id
+	          WHERE e.total >= ?`
+	args := []interface{}{filtro.VotosMinimos, filtro.VotosMinimos, filtro.VotosMinimos}
+
+	if filtro.GeneroID != 0 {
+		query += " AND EXISTS (SELECT 1 FROM generos_filme g WHERE g.filme_id = e.filme_id AND g.genero_id = ?)"
+		args = append(args, filtro.GeneroID)
+	}
+	if filtro.AnoInicial != 0 {
+		query += " AND c.ano >= ?"
+		args = append(args, filtro.AnoInicial)
+	}
+	if filtro.AnoFinal != 0 {
+		query += " AND c.ano <= ?"
+		args = append(args, filtro.AnoFinal)
+	}
+	query += " ORDER BY pontuacao DESC, e.total DESC, e.filme_id LIMIT ?"
+	args = append(args, filtro.Limite)
+
+	var ranking []dominio.ItemRanking
+	err := r.db.Select(&ranking, query, args...)
+	return ranking, err
+}
+
+// BuscarPorFilmeID retorna uma página das avaliações publicadas do filme na ordem pedida, com a
+// contagem das reações e dos comentários e a reação de leitorID (zero para visitantes sem login).
+// O cursor da próxima página é nulo na última.
+func (r *avaliacaoRepoSqlx) BuscarPorFilmeID(filmeID int64, filtro FiltroAvaliacoes, leitorID int64) ([]dominio.AvaliacaoComUsuario, *CursorAvaliacoes, error) {
+	ordenacao, ok := ordenacoesAvaliacoes[filtro.Ordenar]
+	if !ok {
+		return nil, nil, fmt.Errorf("ordenação de avaliações desconhecida: %q", filtro.Ordenar)
+	}
+	chave := "''"
+	if ordenacao.expressao != "" {
+		chave = ordenacao.expressao
+	}
+
+	// Avaliações de contas excluídas ficam sem autor e aparecem como "usuário removido".
+	query := `SELECT a.id, a.nota_meias, a.comentario, a.spoiler, a.data_criacao, a.data_atualizacao, COALESCE(u.nome, 'usuário removido') AS nome,
+	                 ` + contagemUteis + ` AS uteis,
+	                 (SELECT COUNT(*) FROM reacoes_avaliacoes WHERE avaliacao_id = a.id AND tipo = 'curtida') AS curtidas,
+	                 m.tipo AS minha_reacao,
+	                 (SELECT COUNT(*) FROM comentarios_avaliacoes WHERE avaliacao_id = a.id) AS comentarios,
+	                 CAST(` + chave + ` AS TEXT) AS chave, CAST(a.data_criacao AS TEXT) AS data_chave
+	          FROM avaliacoes a LEFT JOIN usuarios u ON a.usuario_id = u.id
+	          LEFT JOIN reacoes_avaliacoes m ON m.avaliacao_id = a.id AND m.usuario_id = ?
+	          WHERE a.filme_id = ? AND a.situacao = ?`
+	args := []interface{}{leitorID, filmeID, dominio.SituacaoPublicada}
+
+	if filtro.Apos != nil {
+		condicao, argsCursor, err := condicaoCursorAvaliacoes(ordenacao.expressao, ordenacao.crescente, filtro.Apos)
+		if err != nil {
+			return nil, nil, err
+		}
+		query += " AND " + condicao
+		args = append(args, argsCursor...)
+	}
+
+	ordem := "a.data_criacao DESC, a.id DESC"
+	if ordenacao.expressao != "" {
+		direcao := "DESC"
+		if ordenacao.crescente {
+			direcao = "ASC"
+		}
+		ordem = ordenacao.expressao + " " + direcao + ", " + ordem
+	}
+	// Um item a mais indica se existe uma próxima página.
+	query += " ORDER BY " + ordem + " LIMIT ?"
+	args = append(args, filtro.Limite+1)
+
+	var linhas []struct {
+		dominio.AvaliacaoComUsuario
+		Chave     string `db:"chave"`
+		DataChave string `db:"data_chave"`
+	}
+	if err := r.db.Select(&linhas, query, args...); err != nil {
+		return nil, nil, err
+	}
+
+	var proximo *CursorAvaliacoes
+	if len(linhas) > filtro.Limite {
+		linhas = linhas[:filtro.Limite]
+		ultima := linhas[len(linhas)-1]
+		proximo = &CursorAvaliacoes{Chave: ultima.Chave, Data: ultima.DataChave, ID: ultima.ID}
+	}
+	avaliacoes := make([]dominio.AvaliacaoComUsuario, 0, len(linhas))
+	for _, linha := range linhas {
+		avaliacoes = append(avaliacoes, linha.AvaliacaoComUsuario)
+	}
+	return avaliacoes, proximo, nil
+}
+
+// ListarComFilmesDoUsuario retorna uma página das avaliações publicadas do usuário, das mais
+// recentes para as mais antigas, com título, pôster e ano do catálogo (vazios para filmes que
+// ainda não estão nele). O cursor da próxima página é nulo na última.
+func (r *avaliacaoRepoSqlx) ListarComFilmesDoUsuario(usuarioID int64, filtro FiltroAvaliacoesUsuario) ([]dominio.AvaliacaoComFilme, *CursorAvaliacoes, error) {
+	query := `SELECT a.id, a.filme_id, COALESCE(c.titulo, '') AS titulo, COALESCE(c.caminho_poster, '') AS caminho_poster,
+	                 COALESCE(c.ano, 0) AS ano, a.nota_meias, a.comentario, a.spoiler, a.data_criacao, a.data_atualizacao,
+	                 CAST(a.data_criacao AS TEXT) AS data_chave
+	          FROM avaliacoes a LEFT JOIN catalogo_filmes c ON c.filme_id = a.filme_id
+	          WHERE a.usuario_id = ? AND a.situacao = ?`
+	args := []interface{}{usuarioID, dominio.SituacaoPublicada}
+
+	if filtro.Nota != 0 {
+		query += " AND a.nota_meias = ?"
+		args = append(args, filtro.Nota)
+	}
+	if filtro.Ano != 0 {
+		query += " AND c.ano = ?"
+		args = append(args, filtro.Ano)
+	}
+	if filtro.Apos != nil {
+		condicao, argsCursor, err := condicaoCursorAvaliacoes("", false, filtro.Apos)
+		if err != nil {
+			return nil, nil, err
+		}
+		query += " AND " + condicao
+		args = append(args, argsCursor...)
+	}
+	query += " ORDER BY a.data_criacao DESC, a.id DESC LIMIT ?"
+	args = append(args, filtro.Limite+1)
+
+	var linhas []struct {
+		dominio.AvaliacaoComFilme
+		DataChave string `db:"data_chave"`
+	}
+	if err := r.db.Select(&linhas, query, args...); err != nil {
+		return nil, nil, err
+	}
+
+	var proximo *CursorAvaliacoes
+	if len(linhas) > filtro.Limite {
+		linhas = linhas[:filtro.Limite]
+		ultima := linhas[len(linhas)-1]
+		proximo = &CursorAvaliacoes{Data: ultima.DataChave, ID: ultima.ID}
+	}
+	avaliacoes := make([]dominio.AvaliacaoComFilme, 0, len(linhas))
+	for _, linha := range linhas {
+		avaliacoes = append(avaliacoes, linha.AvaliacaoComFilme)
+	}
+	return avaliacoes, proximo, nil
+}
+
+// condicaoCursorAvaliacoes monta o WHERE que continua depois do cursor: primeiro pela expressão
+// da ordenação (quando houver) e, nos empates, pelas mais antigas que a última da página.
+func condicaoCursorAvaliacoes(expressao string, crescente bool, cursor *CursorAvaliacoes) (string, []interface{}, error) {
+	recentes := "(a.data_criacao, a.id) < (?, ?)"
+	if expressao == "" {
+		return recentes, []interface{}{cursor.Data, cursor.ID}, nil
+	}
+
+	chave, err := strconv.ParseFloat(cursor.Chave, 64)
+	if err != nil {
+		return "", nil, fmt.Errorf("cursor de avaliações inválido: %w", err)
+	}
+	comparacao := "<"
+	if crescente {
+		comparacao = ">"
+	}
+	condicao := fmt.Sprintf("(%s %s ? OR (%s = ? AND %s))", expressao, comparacao, expressao, recentes)
+	return condicao, []interface{}{chave, chave, cursor.Data, cursor.ID}, nil
+}
+
+// Reagir grava a reação do usuário à avaliação, trocando a anterior se ele já tinha reagido.
+func (r *avaliacaoRepoSqlx) Reagir(reacao *dominio.ReacaoAvaliacao) error {
+	query := `INSERT INTO reacoes_avaliacoes (avaliacao_id, usuario_id, tipo, data_criacao) VALUES (?, ?, ?, ?)
+	          ON CONFLICT (avaliacao_id, usuario_id) DO UPDATE SET tipo = excluded.tipo, data_criacao = excluded.data_criacao`
+	_, err := r.db.Exec(query, reacao.AvaliacaoID, reacao.UsuarioID, reacao.Tipo, reacao.DataCriacao)
 	return err
 }
 
-func (r *avaliacaoRepoSqlx) BuscarPorFilmeID(filmeID int64) ([]dominio.AvaliacaoComUsuario, error) {
-	var avaliacoes []dominio.AvaliacaoComUsuario
-	query := `SELECT a.id, a.nota, a.comentario, a.data_criacao, u.nome 
-	          FROM avaliacoes a JOIN usuarios u ON a.usuario_id = u.id 
-	          WHERE a.filme_id = ? ORDER BY a.data_criacao DESC`
-	err := r.db.Select(&avaliacoes, query, filmeID)
+// RemoverReacao apaga a reação do usuário à avaliação. Retorna false se ele não tinha reagido.
+func (r *avaliacaoRepoSqlx) RemoverReacao(avaliacaoID, usuarioID int64) (bool, error) {
+	resultado, err := r.db.Exec("DELETE FROM reacoes_avaliacoes WHERE avaliacao_id = ? AND usuario_id = ?", avaliacaoID, usuarioID)
+	if err != nil {
+		return false, err
+	}
+	linhas, err := resultado.RowsAffected()
+	return linhas > 0, err
+}
+
+// ListarReacoesDoUsuario retorna as reações que o usuário deu às avaliações de outras pessoas.
+func (r *avaliacaoRepoSqlx) ListarReacoesDoUsuario(usuarioID int64) ([]dominio.ReacaoAvaliacao, error) {
+	var reacoes []dominio.ReacaoAvaliacao
+	query := "SELECT * FROM reacoes_avaliacoes WHERE usuario_id = ? ORDER BY data_criacao"
+	err := r.db.Select(&reacoes, query, usuarioID)
+	return reacoes, err
+}
+
+// ListarPorUsuarioID retorna todas as avaliações escritas pelo usuário.
+func (r *avaliacaoRepoSqlx) ListarPorUsuarioID(usuarioID int64) ([]dominio.Avaliacao, error) {
+	var avaliacoes []dominio.Avaliacao
+	query := "SELECT * FROM avaliacoes WHERE usuario_id = ? ORDER BY data_criacao"
+	err := r.db.Select(&avaliacoes, query, usuarioID)
+	return avaliacoes, err
+}
+
+// ListarPorUsuarioApos retorna até limite avaliações do usuário com ID maior que aposID.
+func (r *avaliacaoRepoSqlx) ListarPorUsuarioApos(usuarioID, aposID int64, limite int) ([]dominio.Avaliacao, error) {
+	var avaliacoes []dominio.Avaliacao
+	query := "SELECT * FROM avaliacoes WHERE usuario_id = ? AND id > ? ORDER BY id LIMIT ?"
+	err := r.db.Select(&avaliacoes, query, usuarioID, aposID, limite)
+	return avaliacoes, err
+}
+
+// BuscarDoUsuario encontra uma avaliação pelo ID, desde que seja do usuário informado.
+func (r *avaliacaoRepoSqlx) BuscarDoUsuario(usuarioID, id int64) (*dominio.Avaliacao, error) {
+	var avaliacao dominio.Avaliacao
+	query := "SELECT * FROM avaliacoes WHERE id = ? AND usuario_id = ?"
+	if err := r.db.Get(&avaliacao, query, id, usuarioID); err != nil {
+		return nil, err
+	}
+	return &avaliacao, nil
+}
+
+// ListarRecentesDoUsuario retorna as últimas avaliações publicadas do usuário, das mais novas para as mais antigas.
+func (r *avaliacaoRepoSqlx) ListarRecentesDoUsuario(usuarioID int64, limite int) ([]dominio.Avaliacao, error) {
+	var avaliacoes []dominio.Avaliacao
+	query := "SELECT * FROM avaliacoes WHERE usuario_id = ? AND situacao = ? ORDER BY data_criacao DESC, id DESC LIMIT ?"
+	err := r.db.Select(&avaliacoes, query, usuarioID, dominio.SituacaoPublicada, limite)
 	return avaliacoes, err
 }
@@ -0,0 +1,34 @@
+package repositorio_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Andydev0/filmes-backend/internal/database"
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+	"github.com/jmoiron/sqlx"
+)
+
+// novoBanco abre um banco novo, com todas as migrações, em um diretório temporário do teste.
+func novoBanco(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	db, err := database.Abrir(filepath.Join(t.TempDir(), "teste.db"))
+	if err != nil {
+		t.Fatalf("falha ao abrir o banco de teste: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// novoUsuario cadastra um usuário com senha e retorna o registro salvo.
+func novoUsuario(t *testing.T, db *sqlx.DB, nome, email string) *dominio.Usuario {
+	t.Helper()
+
+	usuario := &dominio.Usuario{Nome: nome, Email: email, SenhaHash: "hash"}
+	if err := repositorio.NovoUsuarioRepositorio(db).Salvar(usuario); err != nil {
+		t.Fatalf("falha ao cadastrar o usuário de teste: %v", err)
+	}
+	return usuario
+}
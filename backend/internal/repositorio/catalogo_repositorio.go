@@ -0,0 +1,137 @@
+package repositorio
+
+import (
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/jmoiron/sqlx"
+)
+
+// CatalogoRepositorio define o cache local dos metadados do catálogo externo.
+type CatalogoRepositorio interface {
+	BuscarPorIDs(filmeIDs []int64) ([]dominio.MetadadosFilme, error)
+	Salvar(metadados *dominio.MetadadosFilme) error
+	ListarReferenciadosDesatualizados(antesDe time.Time, limite int) ([]int64, error)
+	SincronizarItensListas() (int64, error)
+}
+
+type catalogoRepositorioSqlx struct {
+	db *sqlx.DB
+}
+
+// NovoCatalogoRepositorio cria uma nova instância do repositório do catálogo.
+func NovoCatalogoRepositorio(db *sqlx.DB) CatalogoRepositorio {
+	return &catalogoRepositorioSqlx{db: db}
+}
+
+// BuscarPorIDs retorna os metadados em cache dos filmes informados; os ausentes são omitidos.
+func (r *catalogoRepositorioSqlx) BuscarPorIDs(filmeIDs []int64) ([]dominio.MetadadosFilme, error) {
+	if len(filmeIDs) == 0 {
+		return nil, nil
+	}
+
+	query, args, err := sqlx.In("SELECT * FROM catalogo_filmes WHERE filme_id IN (?)", filmeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var metadados []dominio.MetadadosFilme
+	if err := r.db.Select(&metadados, r.db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+
+	generos, err := buscarGenerosFilmes(r.db, filmeIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range metadados {
+		metadados[i].Generos = generos[metadados[i].FilmeID]
+	}
+	return metadados, nil
+}
+
+// Salvar grava ou substitui os metadados de um filme, incluindo os gêneros.
+func (r *catalogoRepositorioSqlx) Salvar(m *dominio.MetadadosFilme) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO catalogo_filmes (filme_id, titulo, ano, imdb_id, diretor, caminho_poster, atualizado_em)
+	          VALUES (?, ?, ?, ?, ?, ?, ?)
+	          ON CONFLICT (filme_id) DO UPDATE SET
+	              titulo = excluded.titulo, ano = excluded.ano, imdb_id = excluded.imdb_id,
+	              diretor = excluded.diretor, caminho_poster = excluded.caminho_poster,
+	              atualizado_em = excluded.atualizado_em`
+	if _, err := tx.Exec(query, m.FilmeID, m.Titulo, m.Ano, m.IMDbID, m.Diretor, m.CaminhoPoster, m.AtualizadoEm); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM generos_filme WHERE filme_id = ?", m.FilmeID); err != nil {
+		return err
+	}
+	for _, genero := range m.Generos {
+		query := "INSERT INTO generos_filme (filme_id, genero_id, nome) VALUES (?, ?, ?)"
+		if _, err := tx.Exec(query, m.FilmeID, genero.ID, genero.Nome); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListarReferenciadosDesatualizados retorna até limite filmes presentes em alguma lista ou
+// avaliados que não estão no cache ou foram atualizados antes de antesDe. A ordem aleatória
+// evita que filmes que a API externa não conhece ocupem sempre as mesmas vagas.
+func (r *catalogoRepositorioSqlx) ListarReferenciadosDesatualizados(antesDe time.Time, limite int) ([]int64, error) {
+	var ids []int64
+	query := `SELECT r.filme_id FROM (
+	              SELECT filme_id FROM itens_lista
+	              UNION SELECT filme_id FROM estatisticas_filmes WHERE total > 0
+	          ) r
+	          LEFT JOIN catalogo_filmes c ON c.filme_id = r.filme_id
+	          WHERE c.filme_id IS NULL OR c.atualizado_em < ?
+	          ORDER BY RANDOM() LIMIT ?`
+	err := r.db.Select(&ids, query, antesDe, limite)
+	return ids, err
+}
+
+// SincronizarItensListas copia o título e o pôster do cache para os itens de lista que
+// estão diferentes. Retorna quantos itens foram alterados.
+func (r *catalogoRepositorioSqlx) SincronizarItensListas() (int64, error) {
+	query := `UPDATE itens_lista SET titulo = c.titulo, caminho_poster = c.caminho_poster
+	          FROM catalogo_filmes c
+	          WHERE c.filme_id = itens_lista.filme_id AND c.titulo != ''
+	            AND (itens_lista.titulo != c.titulo OR itens_lista.caminho_poster != c.caminho_poster)`
+	resultado, err := r.db.Exec(query)
+	if err != nil {
+		return 0, err
+	}
+	return resultado.RowsAffected()
+}
+
+// buscarGenerosFilmes retorna os gêneros em cache de cada filme informado.
+func buscarGenerosFilmes(db sqlx.Queryer, filmeIDs []int64) (map[int64][]dominio.Genero, error) {
+	generos := make(map[int64][]dominio.Genero)
+	if len(filmeIDs) == 0 {
+		return generos, nil
+	}
+
+	query, args, err := sqlx.In("SELECT filme_id, genero_id, nome FROM generos_filme WHERE filme_id IN (?) ORDER BY nome", filmeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var linhas []struct {
+		FilmeID  int64  `db:"filme_id"`
+		GeneroID int    `db:"genero_id"`
+		Nome     string `db:"nome"`
+	}
+	if err := sqlx.Select(db, &linhas, query, args...); err != nil {
+		return nil, err
+	}
+	for _, linha := range linhas {
+		generos[linha.FilmeID] = append(generos[linha.FilmeID], dominio.Genero{ID: linha.GeneroID, Nome: linha.Nome})
+	}
+	return generos, nil
+}
@@ -0,0 +1,62 @@
+package repositorio
+
+import (
+	"github.com/jmoiron/sqlx"
+)
+
+// CodigoRecuperacaoRepositorio define a persistência dos códigos de recuperação do 2FA.
+// Apenas o hash de cada código é armazenado.
+type CodigoRecuperacaoRepositorio interface {
+	Substituir(usuarioID int64, hashes []string) error
+	Usar(usuarioID int64, hash string) (bool, error)
+	ContarDisponiveis(usuarioID int64) (int, error)
+}
+
+type codigoRecuperacaoRepositorioSqlx struct {
+	db *sqlx.DB
+}
+
+// NovoCodigoRecuperacaoRepositorio cria uma nova instância do repositório de códigos de recuperação.
+func NovoCodigoRecuperacaoRepositorio(db *sqlx.DB) CodigoRecuperacaoRepositorio {
+	return &codigoRecuperacaoRepositorioSqlx{db: db}
+}
+
+// Substituir apaga os códigos anteriores e grava o novo conjunto na mesma transação.
+func (r *codigoRecuperacaoRepositorioSqlx) Substituir(usuarioID int64, hashes []string) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM codigos_recuperacao WHERE usuario_id = ?", usuarioID); err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		if _, err := tx.Exec("INSERT INTO codigos_recuperacao (usuario_id, codigo_hash) VALUES (?, ?)", usuarioID, hash); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Usar marca o código como utilizado. O UPDATE condicional garante o uso único
+// mesmo com requisições concorrentes.
+func (r *codigoRecuperacaoRepositorioSqlx) Usar(usuarioID int64, hash string) (bool, error) {
+	query := `UPDATE codigos_recuperacao SET usado_em = CURRENT_TIMESTAMP
+	          WHERE usuario_id = ? AND codigo_hash = ? AND usado_em IS NULL`
+	resultado, err := r.db.Exec(query, usuarioID, hash)
+	if err != nil {
+		return false, err
+	}
+	linhas, err := resultado.RowsAffected()
+	return linhas > 0, err
+}
+
+// ContarDisponiveis retorna quantos códigos ainda não foram usados.
+func (r *codigoRecuperacaoRepositorioSqlx) ContarDisponiveis(usuarioID int64) (int, error) {
+	var total int
+	query := "SELECT COUNT(*) FROM codigos_recuperacao WHERE usuario_id = ? AND usado_em IS NULL"
+	err := r.db.Get(&total, query, usuarioID)
+	return total, err
+}
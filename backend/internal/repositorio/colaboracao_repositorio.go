@@ -0,0 +1,210 @@
+package repositorio
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/jmoiron/sqlx"
+)
+
+// ColaboracaoRepositorio define a persistência dos membros e convites das listas colaborativas.
+type ColaboracaoRepositorio interface {
+	BuscarPapel(listaID, usuarioID int64) (string, error)
+	ListarMembros(listaID int64) ([]dominio.MembroLista, error)
+	AlterarPapel(listaID, usuarioID int64, papel string) (bool, error)
+	RemoverMembro(listaID, usuarioID int64, revogarLinks bool) (bool, error)
+	ListarListasDoMembro(usuarioID int64) ([]dominio.Lista, error)
+	CriarConvite(convite *dominio.ConviteLista) error
+	BuscarConvite(codigo string) (*dominio.ConviteLista, error)
+	ListarConvitesDaLista(listaID int64) ([]dominio.ConviteLista, error)
+	ListarConvitesRecebidos(usuarioID int64) ([]dominio.ConviteLista, error)
+	AceitarConvite(convite *dominio.ConviteLista, usuarioID int64) (bool, error)
+	RevogarConvite(listaID, conviteID int64) (bool, error)
+}
+
+type colaboracaoRepositorioSqlx struct {
+	db *sqlx.DB
+}
+
+// NovoColaboracaoRepositorio cria uma nova instância do repositório de colaboração.
+func NovoColaboracaoRepositorio(db *sqlx.DB) ColaboracaoRepositorio {
+	return &colaboracaoRepositorioSqlx{db: db}
+}
+
+// BuscarPapel retorna o papel do usuário na lista como membro convidado, ou "" se ele não for membro.
+func (r *colaboracaoRepositorioSqlx) BuscarPapel(listaID, usuarioID int64) (string, error) {
+	var papel string
+	query := "SELECT papel FROM membros_lista WHERE lista_id = ? AND usuario_id = ?"
+	err := r.db.Get(&papel, query, listaID, usuarioID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return papel, err
+}
+
+// ListarMembros retorna o dono da lista seguido dos membros convidados, na ordem em que entraram.
+func (r *colaboracaoRepositorioSqlx) ListarMembros(listaID int64) ([]dominio.MembroLista, error) {
+	var membros []dominio.MembroLista
+	query := `SELECT * FROM (
+	              SELECT l.id AS lista_id, l.usuario_id, u.nome, 'dono' AS papel, l.data_criacao AS data_entrada
+	              FROM listas l JOIN usuarios u ON u.id = l.usuario_id WHERE l.id = ?
+	              UNION ALL
+	              SELECT m.lista_id, m.usuario_id, u.nome, m.papel, m.data_entrada
+	              FROM membros_lista m JOIN usuarios u ON u.id = m.usuario_id WHERE m.lista_id = ?
+	          ) ORDER BY papel = 'dono' DESC, data_entrada, usuario_id`
+	err := r.db.Select(&membros, query, listaID, listaID)
+	return membros, err
+}
+
+// AlterarPapel troca o papel de um membro. Retorna false se ele não for membro da lista. Ao rebaixar
+// um editor a leitor, os convites de link da lista são revogados, para que ele não recupere o papel
+// aceitando um deles.
+func (r *colaboracaoRepositorioSqlx) AlterarPapel(listaID, usuarioID int64, papel string) (bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var anterior string
+	err = tx.Get(&anterior, "SELECT papel FROM membros_lista WHERE lista_id = ? AND usuario_id = ?", listaID, usuarioID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	query := "UPDATE membros_lista SET papel = ? WHERE lista_id = ? AND usuario_id = ?"
+	if _, err := tx.Exec(query, papel, listaID, usuarioID); err != nil {
+		return false, err
+	}
+	if anterior == dominio.PapelEditor && papel == dominio.PapelLeitor {
+		if err := revogarConvitesDeLink(tx, listaID); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+// RemoverMembro tira o usuário da lista; os filmes que ele adicionou continuam nela. Com revogarLinks,
+// os convites de link da lista também são revogados, para que o membro removido não volte por eles.
+func (r *colaboracaoRepositorioSqlx) RemoverMembro(listaID, usuarioID int64, revogarLinks bool) (bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	resultado, err := tx.Exec("DELETE FROM membros_lista WHERE lista_id = ? AND usuario_id = ?", listaID, usuarioID)
+	if err != nil {
+		return false, err
+	}
+	linhas, err := resultado.RowsAffected()
+	if err != nil || linhas == 0 {
+		return false, err
+	}
+	if revogarLinks {
+		if err := revogarConvitesDeLink(tx, listaID); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+// revogarConvitesDeLink apaga os convites sem convidado da lista; os pessoais continuam valendo.
+func revogarConvitesDeLink(tx *sqlx.Tx, listaID int64) error {
+	_, err := tx.Exec("DELETE FROM convites_lista WHERE lista_id = ? AND convidado_id IS NULL", listaID)
+	return err
+}
+
+// ListarListasDoMembro retorna as listas de outros usuários das quais o usuário é membro,
+// com o papel dele e a quantidade de itens.
+func (r *colaboracaoRepositorioSqlx) ListarListasDoMembro(usuarioID int64) ([]dominio.Lista, error) {
+	var listas []dominio.Lista
+	query := `SELECT l.*, m.papel, (SELECT COUNT(*) FROM itens_lista i WHERE i.lista_id = l.id) AS quantidade_itens
+	          FROM listas l JOIN membros_lista m ON m.lista_id = l.id
+	          WHERE m.usuario_id = ? ORDER BY m.data_entrada DESC, l.id DESC`
+	err := r.db.Select(&listas, query, usuarioID)
+	return listas, err
+}
+
+// CriarConvite insere o convite e preenche o ID gerado.
+func (r *colaboracaoRepositorioSqlx) CriarConvite(c *dominio.ConviteLista) error {
+	c.DataCriacao = time.Now().UTC()
+	query := `INSERT INTO convites_lista (lista_id, codigo, papel, convidado_id, criado_por, data_criacao, expira_em)
+	          VALUES (?, ?, ?, ?, ?, ?, ?)`
+	resultado, err := r.db.Exec(query, c.ListaID, c.Codigo, c.Papel, c.ConvidadoID, c.CriadoPor, c.DataCriacao, c.ExpiraEm)
+	if err != nil {
+		return err
+	}
+	c.ID, err = resultado.LastInsertId()
+	return err
+}
+
+// BuscarConvite encontra um convite ainda válido pelo código.
+func (r *colaboracaoRepositorioSqlx) BuscarConvite(codigo string) (*dominio.ConviteLista, error) {
+	var convite dominio.ConviteLista
+	query := `SELECT c.*, l.titulo AS titulo_lista FROM convites_lista c JOIN listas l ON l.id = c.lista_id
+	          WHERE c.codigo = ? AND c.expira_em > ?`
+	if err := r.db.Get(&convite, query, codigo, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	return &convite, nil
+}
+
+// ListarConvitesDaLista retorna os convites ainda válidos da lista.
+func (r *colaboracaoRepositorioSqlx) ListarConvitesDaLista(listaID int64) ([]dominio.ConviteLista, error) {
+	var convites []dominio.ConviteLista
+	query := "SELECT * FROM convites_lista WHERE lista_id = ? AND expira_em > ? ORDER BY data_criacao DESC, id DESC"
+	err := r.db.Select(&convites, query, listaID, time.Now().UTC())
+	return convites, err
+}
+
+// ListarConvitesRecebidos retorna os convites ainda válidos enviados diretamente ao usuário.
+func (r *colaboracaoRepositorioSqlx) ListarConvitesRecebidos(usuarioID int64) ([]dominio.ConviteLista, error) {
+	var convites []dominio.ConviteLista
+	query := `SELECT c.*, l.titulo AS titulo_lista FROM convites_lista c JOIN listas l ON l.id = c.lista_id
+	          WHERE c.convidado_id = ? AND c.expira_em > ? ORDER BY c.data_criacao DESC, c.id DESC`
+	err := r.db.Select(&convites, query, usuarioID, time.Now().UTC())
+	return convites, err
+}
+
+// AceitarConvite torna o usuário membro da lista com o papel do convite e retorna false, sem alterar
+// nada, se ele já for membro: um convite nunca muda o papel de quem já participa. Convites pessoais
+// são consumidos; os de link continuam valendo para outras pessoas até expirarem ou serem revogados.
+func (r *colaboracaoRepositorioSqlx) AceitarConvite(convite *dominio.ConviteLista, usuarioID int64) (bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO membros_lista (lista_id, usuario_id, papel, data_entrada) VALUES (?, ?, ?, ?)
+	          ON CONFLICT (lista_id, usuario_id) DO NOTHING`
+	resultado, err := tx.Exec(query, convite.ListaID, usuarioID, convite.Papel, time.Now().UTC())
+	if err != nil {
+		return false, err
+	}
+	linhas, err := resultado.RowsAffected()
+	if err != nil || linhas == 0 {
+		return false, err
+	}
+	if convite.ConvidadoID != nil {
+		if _, err := tx.Exec("DELETE FROM convites_lista WHERE id = ?", convite.ID); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+// RevogarConvite apaga um convite da lista. Retorna false se ele não existir.
+func (r *colaboracaoRepositorioSqlx) RevogarConvite(listaID, conviteID int64) (bool, error) {
+	resultado, err := r.db.Exec("DELETE FROM convites_lista WHERE id = ? AND lista_id = ?", conviteID, listaID)
+	if err != nil {
+		return false, err
+	}
+	linhas, err := resultado.RowsAffected()
+	return linhas > 0, err
+}
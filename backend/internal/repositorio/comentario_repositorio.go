@@ -0,0 +1,99 @@
+package repositorio
+
+import (
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/jmoiron/sqlx"
+)
+
+// ComentarioRepositorio define a persistência dos comentários nas avaliações.
+type ComentarioRepositorio interface {
+	Criar(comentario *dominio.ComentarioAvaliacao) error
+	BuscarPorID(id int64) (*dominio.ComentarioAvaliacao, error)
+	AtualizarTexto(id int64, texto string, data time.Time) error
+	Deletar(id int64) error
+	ListarPrimeiroNivel(avaliacaoID, aposID int64, limite int) ([]dominio.ComentarioAvaliacao, error)
+	ListarRespostas(comentarioIDs []int64) ([]dominio.ComentarioAvaliacao, error)
+	ListarPorUsuarioID(usuarioID int64) ([]dominio.ComentarioAvaliacao, error)
+}
+
+type comentarioRepositorioSqlx struct {
+	db *sqlx.DB
+}
+
+// NovoComentarioRepositorio cria uma nova instância do repositório de comentários.
+func NovoComentarioRepositorio(db *sqlx.DB) ComentarioRepositorio {
+	return &comentarioRepositorioSqlx{db: db}
+}
+
+// consultaComentarios seleciona os comentários com o nome do autor; os de contas excluídas
+// aparecem como "usuário removido".
+const consultaComentarios = `SELECT c.id, c.avaliacao_id, c.usuario_id, COALESCE(u.nome, 'usuário removido') AS nome,
+	          c.resposta_a, c.texto, c.data_criacao, c.data_atualizacao
+	          FROM comentarios_avaliacoes c LEFT JOIN usuarios u ON u.id = c.usuario_id`
+
+// Criar insere o comentário e preenche o ID gerado.
+func (r *comentarioRepositorioSqlx) Criar(c *dominio.ComentarioAvaliacao) error {
+	query := `INSERT INTO comentarios_avaliacoes (avaliacao_id, usuario_id, resposta_a, texto, data_criacao, data_atualizacao)
+	          VALUES (?, ?, ?, ?, ?, ?)`
+	resultado, err := r.db.Exec(query, c.AvaliacaoID, c.UsuarioID, c.RespostaA, c.Texto, c.DataCriacao, c.DataAtualizacao)
+	if err != nil {
+		return err
+	}
+	c.ID, err = resultado.LastInsertId()
+	return err
+}
+
+// BuscarPorID encontra um comentário pelo ID.
+func (r *comentarioRepositorioSqlx) BuscarPorID(id int64) (*dominio.ComentarioAvaliacao, error) {
+	var comentario dominio.ComentarioAvaliacao
+	if err := r.db.Get(&comentario, consultaComentarios+" WHERE c.id = ?", id); err != nil {
+		return nil, err
+	}
+	return &comentario, nil
+}
+
+// AtualizarTexto troca o texto do comentário e a data da última edição.
+func (r *comentarioRepositorioSqlx) AtualizarTexto(id int64, texto string, data time.Time) error {
+	_, err := r.db.Exec("UPDATE comentarios_avaliacoes SET texto = ?, data_atualizacao = ? WHERE id = ?", texto, data, id)
+	return err
+}
+
+// Deletar apaga o comentário e, se ele for de primeiro nível, as respostas a ele.
+func (r *comentarioRepositorioSqlx) Deletar(id int64) error {
+	_, err := r.db.Exec("DELETE FROM comentarios_avaliacoes WHERE id = ?", id)
+	return err
+}
+
+// ListarPrimeiroNivel retorna até limite comentários de primeiro nível da avaliação com ID maior
+// que aposID, dos mais antigos para os mais novos.
+func (r *comentarioRepositorioSqlx) ListarPrimeiroNivel(avaliacaoID, aposID int64, limite int) ([]dominio.ComentarioAvaliacao, error) {
+	var comentarios []dominio.ComentarioAvaliacao
+	query := consultaComentarios + " WHERE c.avaliacao_id = ? AND c.resposta_a IS NULL AND c.id > ? ORDER BY c.id LIMIT ?"
+	err := r.db.Select(&comentarios, query, avaliacaoID, aposID, limite)
+	return comentarios, err
+}
+
+// ListarRespostas retorna as respostas aos comentários informados, em ordem cronológica.
+func (r *comentarioRepositorioSqlx) ListarRespostas(comentarioIDs []int64) ([]dominio.ComentarioAvaliacao, error) {
+	if len(comentarioIDs) == 0 {
+		return nil, nil
+	}
+
+	query, args, err := sqlx.In(consultaComentarios+" WHERE c.resposta_a IN (?) ORDER BY c.id", comentarioIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var respostas []dominio.ComentarioAvaliacao
+	err = r.db.Select(&respostas, r.db.Rebind(query), args...)
+	return respostas, err
+}
+
+// ListarPorUsuarioID retorna todos os comentários escritos pelo usuário.
+func (r *comentarioRepositorioSqlx) ListarPorUsuarioID(usuarioID int64) ([]dominio.ComentarioAvaliacao, error) {
+	var comentarios []dominio.ComentarioAvaliacao
+	err := r.db.Select(&comentarios, consultaComentarios+" WHERE c.usuario_id = ? ORDER BY c.id", usuarioID)
+	return comentarios, err
+}
@@ -0,0 +1,120 @@
+package repositorio
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/jmoiron/sqlx"
+)
+
+// DiarioRepositorio define a persistência do diário de filmes assistidos.
+type DiarioRepositorio interface {
+	Criar(entrada *dominio.EntradaDiario) error
+	ListarPorPeriodo(usuarioID int64, inicio, fim string) ([]dominio.EntradaDiario, error)
+	ListarPorUsuarioID(usuarioID int64) ([]dominio.EntradaDiario, error)
+	ListarComNotaApos(usuarioID int64, aposData string, aposID int64, limite int) ([]dominio.EntradaDiarioComNota, error)
+	JaAssistido(usuarioID, filmeID int64, ate string) (bool, error)
+	AssistidoEm(usuarioID, filmeID int64, data string) (bool, error)
+	Deletar(usuarioID, id int64) (bool, error)
+}
+
+type diarioRepositorioSqlx struct {
+	db *sqlx.DB
+}
+
+// NovoDiarioRepositorio cria uma nova instância do repositório do diário.
+func NovoDiarioRepositorio(db *sqlx.DB) DiarioRepositorio {
+	return &diarioRepositorioSqlx{db: db}
+}
+
+// Criar grava a entrada e, na mesma transação, tira o filme da lista "para assistir".
+func (r *diarioRepositorioSqlx) Criar(e *dominio.EntradaDiario) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	e.DataCriacao = time.Now().UTC()
+	query := `INSERT INTO diario (usuario_id, filme_id, titulo, caminho_poster, data_assistido, revisto, avaliacao_id, data_criacao)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	resultado, err := tx.Exec(query, e.UsuarioID, e.FilmeID, e.Titulo, e.CaminhoPoster, e.DataAssistido, e.Revisto, e.AvaliacaoID, e.DataCriacao)
+	if err != nil {
+		return err
+	}
+	if e.ID, err = resultado.LastInsertId(); err != nil {
+		return err
+	}
+
+	var listaID int64
+	query = "SELECT id FROM listas WHERE usuario_id = ? AND tipo = ?"
+	err = tx.Get(&listaID, query, e.UsuarioID, dominio.TipoListaAssistir)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if err == nil {
+		if _, err := removerItemLista(tx, listaID, e.FilmeID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListarPorPeriodo retorna as entradas com data entre inicio (inclusive) e fim (exclusive),
+// da mais recente para a mais antiga.
+func (r *diarioRepositorioSqlx) ListarPorPeriodo(usuarioID int64, inicio, fim string) ([]dominio.EntradaDiario, error) {
+	var entradas []dominio.EntradaDiario
+	query := `SELECT * FROM diario WHERE usuario_id = ? AND data_assistido >= ? AND data_assistido < ?
+	          ORDER BY data_assistido DESC, id DESC`
+	err := r.db.Select(&entradas, query, usuarioID, inicio, fim)
+	return entradas, err
+}
+
+// ListarPorUsuarioID retorna todo o diário do usuário em ordem cronológica.
+func (r *diarioRepositorioSqlx) ListarPorUsuarioID(usuarioID int64) ([]dominio.EntradaDiario, error) {
+	var entradas []dominio.EntradaDiario
+	query := "SELECT * FROM diario WHERE usuario_id = ? ORDER BY data_assistido, id"
+	err := r.db.Select(&entradas, query, usuarioID)
+	return entradas, err
+}
+
+// ListarComNotaApos retorna até limite entradas em ordem cronológica, posteriores à entrada
+// (aposData, aposID), junto com a nota da avaliação vinculada.
+func (r *diarioRepositorioSqlx) ListarComNotaApos(usuarioID int64, aposData string, aposID int64, limite int) ([]dominio.EntradaDiarioComNota, error) {
+	var entradas []dominio.EntradaDiarioComNota
+	query := `SELECT d.*, a.nota_meias FROM diario d LEFT JOIN avaliacoes a ON a.id = d.avaliacao_id
+	          WHERE d.usuario_id = ? AND (d.data_assistido, d.id) > (?, ?)
+	          ORDER BY d.data_assistido, d.id LIMIT ?`
+	err := r.db.Select(&entradas, query, usuarioID, aposData, aposID, limite)
+	return entradas, err
+}
+
+// JaAssistido informa se o filme tem alguma entrada no diário do usuário com data anterior a ate
+// (AAAA-MM-DD). Com ate vazio, considera o diário inteiro.
+func (r *diarioRepositorioSqlx) JaAssistido(usuarioID, filmeID int64, ate string) (bool, error) {
+	var existe bool
+	query := `SELECT EXISTS(SELECT 1 FROM diario
+	              WHERE usuario_id = ? AND filme_id = ? AND (? = '' OR data_assistido < ?))`
+	err := r.db.Get(&existe, query, usuarioID, filmeID, ate, ate)
+	return existe, err
+}
+
+// AssistidoEm informa se o filme já tem uma entrada no diário do usuário exatamente na data.
+func (r *diarioRepositorioSqlx) AssistidoEm(usuarioID, filmeID int64, data string) (bool, error) {
+	var existe bool
+	query := "SELECT EXISTS(SELECT 1 FROM diario WHERE usuario_id = ? AND filme_id = ? AND data_assistido = ?)"
+	err := r.db.Get(&existe, query, usuarioID, filmeID, data)
+	return existe, err
+}
+
+// Deletar remove uma entrada do diário do usuário e informa se ela existia.
+func (r *diarioRepositorioSqlx) Deletar(usuarioID, id int64) (bool, error) {
+	resultado, err := r.db.Exec("DELETE FROM diario WHERE id = ? AND usuario_id = ?", id, usuarioID)
+	if err != nil {
+		return false, err
+	}
+	linhas, err := resultado.RowsAffected()
+	return linhas > 0, err
+}
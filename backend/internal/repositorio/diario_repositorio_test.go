@@ -0,0 +1,51 @@
+package repositorio_test
+
+import (
+	"testing"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJaAssistidoConsideraSoDatasAnteriores(t *testing.T) {
+	db := novoBanco(t)
+	ana := novoUsuario(t, db, "Ana", "ana@example.com")
+	repo := repositorio.NovoDiarioRepositorio(db)
+	require.NoError(t, repo.Criar(&dominio.EntradaDiario{UsuarioID: ana.ID, FilmeID: 603, Titulo: "Matrix", DataAssistido: "2024-03-10"}))
+
+	casos := []struct {
+		ate      string
+		esperado bool
+	}{
+		{"2024-03-09", false},
+		{"2024-03-10", false},
+		{"2024-03-11", true},
+		{"", true},
+	}
+	for _, caso := range casos {
+		assistido, err := repo.JaAssistido(ana.ID, 603, caso.ate)
+		require.NoError(t, err)
+		assert.Equal(t, caso.esperado, assistido, "ate = %q", caso.ate)
+	}
+
+	assistido, err := repo.JaAssistido(ana.ID, 604, "")
+	require.NoError(t, err)
+	assert.False(t, assistido)
+}
+
+func TestAssistidoEmConsideraSoAMesmaData(t *testing.T) {
+	db := novoBanco(t)
+	ana := novoUsuario(t, db, "Ana", "ana@example.com")
+	repo := repositorio.NovoDiarioRepositorio(db)
+	require.NoError(t, repo.Criar(&dominio.EntradaDiario{UsuarioID: ana.ID, FilmeID: 603, Titulo: "Matrix", DataAssistido: "2024-03-10"}))
+
+	mesmaData, err := repo.AssistidoEm(ana.ID, 603, "2024-03-10")
+	require.NoError(t, err)
+	assert.True(t, mesmaData)
+
+	outraData, err := repo.AssistidoEm(ana.ID, 603, "2024-03-11")
+	require.NoError(t, err)
+	assert.False(t, outraData)
+}
@@ -2,18 +2,84 @@ package repositorio
 
 import (
 	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/Andydev0/filmes-backend/internal/dominio"
 	"github.com/jmoiron/sqlx"
 )
 
 type FavoritoRepositorio interface {
-	Salvar(favorito *dominio.FilmeFavorito) error
+	Salvar(favorito *dominio.FilmeFavorito) (bool, error)
+	AplicarLote(usuarioID int64, operacoes []OperacaoFavorito) ([]bool, error)
 	ListarPorUsuarioID(usuarioID int64) ([]dominio.FilmeFavorito, error)
+	Pesquisar(usuarioID int64, filtro FiltroFavoritos) ([]dominio.FilmeFavorito, *CursorFavoritos, error)
+	Buscar(usuarioID, filmeID int64) (*dominio.FilmeFavorito, error)
+	AtualizarDetalhes(usuarioID, filmeID int64, anotacao *string, tags []string) (bool, error)
 	Deletar(usuarioID, filmeID int64) error
-	VerificarExistencia(usuarioID, filmeID int64) (bool, error)
 }
 
+// Critérios de ordenação aceitos por Pesquisar.
+const (
+	OrdenarFavoritosPosicao = "posicao"
+	OrdenarFavoritosData    = "data"
+	OrdenarFavoritosTitulo  = "titulo"
+	OrdenarFavoritosAno     = "ano"
+	OrdenarFavoritosNota    = "nota"
+)
+
+// ordenacoesFavoritos associa cada critério à expressão SQL usada para ordenar e paginar.
+// Ano e nota vêm do catálogo e da avaliação do usuário; quem não os tem conta como 0.
+var ordenacoesFavoritos = map[string]struct {
+	expressao string
+	numerica  bool
+}{
+	OrdenarFavoritosPosicao: {"i.ordem", true},
+	OrdenarFavoritosData:    {"i.data_adicionado", false},
+	OrdenarFavoritosTitulo:  {"i.titulo COLLATE NOCASE", false},
+	OrdenarFavoritosAno:     {"COALESCE(c.ano, 0)", true},
+	OrdenarFavoritosNota:    {"COALESCE(a.nota_meias, 0)", true},
+}
+
+// OperacaoFavorito é uma adição ou remoção de um lote. Nas remoções só o FilmeID é usado.
+type OperacaoFavorito struct {
+	Remover  bool
+	Favorito dominio.FilmeFavorito
+}
+
+// CursorFavoritos marca o último favorito de uma página: o valor da ordenação e o ID do item.
+type CursorFavoritos struct {
+	Chave string
+	ID    int64
+}
+
+// FiltroFavoritos define a ordenação, os filtros e a página de uma pesquisa nos favoritos.
+type FiltroFavoritos struct {
+	Ordenar     string
+	Decrescente bool
+	Tag         string
+	GeneroID    int
+	Busca       string // Procura no título e na anotação
+	Apos        *CursorFavoritos
+	Limite      int
+}
+
+// favoritoComChave é um favorito lido junto com o valor da ordenação, para montar o cursor.
+type favoritoComChave struct {
+	dominio.FilmeFavorito
+	Chave string `db:"chave"`
+}
+
+// consultaFavoritos seleciona os favoritos com o ano do catálogo e a nota do próprio usuário.
+const consultaFavoritos = `SELECT i.id, l.usuario_id, i.filme_id, i.titulo, i.caminho_poster, i.data_adicionado,
+	          i.anotacao, COALESCE(c.ano, 0) AS ano, a.nota_meias, CAST(%s AS TEXT) AS chave
+	          FROM itens_lista i JOIN listas l ON l.id = i.lista_id
+	          LEFT JOIN catalogo_filmes c ON c.filme_id = i.filme_id
+	          LEFT JOIN avaliacoes a ON a.usuario_id = l.usuario_id AND a.filme_id = i.filme_id
+	          WHERE l.usuario_id = ? AND l.tipo = ?`
+
+// favoritoRepositorioSqlx guarda os favoritos como itens da lista embutida 'favoritos'.
 type favoritoRepositorioSqlx struct {
 	db *sqlx.DB
 }
@@ -22,32 +88,287 @@ func NovoFavoritoRepositorio(db *sqlx.DB) FavoritoRepositorio {
 	return &favoritoRepositorioSqlx{db: db}
 }
 
-func (r *favoritoRepositorioSqlx) Salvar(favorito *dominio.FilmeFavorito) error {
-	query := "INSERT INTO filmes_favoritos (usuario_id, filme_id, titulo, caminho_poster) VALUES (?, ?, ?, ?)"
-	_, err := r.db.Exec(query, favorito.UsuarioID, favorito.FilmeID, favorito.Titulo, favorito.CaminhoPoster)
-	return err
+// Salvar adiciona o filme ao fim da lista de favoritos, criando-a se ainda não existir.
+// Retorna false se o filme já era favorito; a restrição única da lista decide, sem consulta prévia.
+func (r *favoritoRepositorioSqlx) Salvar(favorito *dominio.FilmeFavorito) (bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	listaID, err := garantirListaEmbutida(tx, favorito.UsuarioID, dominio.TipoListaFavoritos)
+	if err != nil {
+		return false, err
+	}
+
+	inserido, err := inserirFavorito(tx, listaID, favorito)
+	if err != nil || !inserido {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// AplicarLote executa as operações em ordem, em uma única transação: ou todas são gravadas,
+// ou nenhuma. Para cada operação, retorna se ela teve efeito (false quando o filme já era
+// favorito, na adição, ou não era, na remoção).
+func (r *favoritoRepositorioSqlx) AplicarLote(usuarioID int64, operacoes []OperacaoFavorito) ([]bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	listaID, err := garantirListaEmbutida(tx, usuarioID, dominio.TipoListaFavoritos)
+	if err != nil {
+		return nil, err
+	}
+
+	aplicadas := make([]bool, len(operacoes))
+	for i, operacao := range operacoes {
+		if operacao.Remover {
+			aplicadas[i], err = removerItemLista(tx, listaID, operacao.Favorito.FilmeID)
+		} else {
+			favorito := operacao.Favorito
+			favorito.UsuarioID = usuarioID
+			aplicadas[i], err = inserirFavorito(tx, listaID, &favorito)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return aplicadas, tx.Commit()
 }
 
+// ListarPorUsuarioID retorna todos os favoritos na ordem manual da lista.
 func (r *favoritoRepositorioSqlx) ListarPorUsuarioID(usuarioID int64) ([]dominio.FilmeFavorito, error) {
-	var favoritos []dominio.FilmeFavorito
-	query := "SELECT * FROM filmes_favoritos WHERE usuario_id = ?"
-	err := r.db.Select(&favoritos, query, usuarioID)
-	return favoritos, err
+	var linhas []favoritoComChave
+	query := fmt.Sprintf(consultaFavoritos, "i.ordem") + " ORDER BY i.ordem, i.id"
+	if err := r.db.Select(&linhas, query, usuarioID, dominio.TipoListaFavoritos); err != nil {
+		return nil, err
+	}
+	return r.completar(linhas)
+}
+
+// Pesquisar retorna uma página de favoritos filtrada e ordenada, e o cursor da próxima página
+// (nil quando não há mais resultados). Com limite zero, retorna todos os favoritos do filtro.
+func (r *favoritoRepositorioSqlx) Pesquisar(usuarioID int64, filtro FiltroFavoritos) ([]dominio.FilmeFavorito, *CursorFavoritos, error) {
+	ordenacao, ok := ordenacoesFavoritos[filtro.Ordenar]
+	if !ok {
+		return nil, nil, fmt.Errorf("ordenação de favoritos desconhecida: %q", filtro.Ordenar)
+	}
+
+	query := fmt.Sprintf(consultaFavoritos, ordenacao.expressao)
+	args := []interface{}{usuarioID, dominio.TipoListaFavoritos}
+
+	if filtro.Tag != "" {
+		query += " AND EXISTS (SELECT 1 FROM tags_itens_lista t WHERE t.item_id = i.id AND t.tag = ?)"
+		args = append(args, filtro.Tag)
+	}
+	if filtro.GeneroID != 0 {
+		query += " AND EXISTS (SELECT 1 FROM generos_filme g WHERE g.filme_id = i.filme_id AND g.genero_id = ?)"
+		args = append(args, filtro.GeneroID)
+	}
+	if filtro.Busca != "" {
+		// O ESCAPE permite buscar literalmente por % e _.
+		padrao := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(filtro.Busca) + "%"
+		query += ` AND (i.titulo LIKE ? ESCAPE '\' OR i.anotacao LIKE ? ESCAPE '\')`
+		args = append(args, padrao, padrao)
+	}
+
+	direcao, comparacao := "ASC", ">"
+	if filtro.Decrescente {
+		direcao, comparacao = "DESC", "<"
+	}
+	if filtro.Apos != nil {
+		var chave interface{} = filtro.Apos.Chave
+		if ordenacao.numerica {
+			numero, err := strconv.ParseFloat(filtro.Apos.Chave, 64)
+			if err != nil {
+				return nil, nil, fmt.Errorf("cursor de favoritos inválido: %w", err)
+			}
+			chave = numero
+		}
+		query += fmt.Sprintf(" AND (%s, i.id) %s (?, ?)", ordenacao.expressao, comparacao)
+		args = append(args, chave, filtro.Apos.ID)
+	}
+
+	// Um item a mais indica se existe uma próxima página; LIMIT -1 não limita.
+	limite := -1
+	if filtro.Limite > 0 {
+		limite = filtro.Limite + 1
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s, i.id %s LIMIT ?", ordenacao.expressao, direcao, direcao)
+	args = append(args, limite)
+
+	var linhas []favoritoComChave
+	if err := r.db.Select(&linhas, query, args...); err != nil {
+		return nil, nil, err
+	}
+
+	var proximo *CursorFavoritos
+	if filtro.Limite > 0 && len(linhas) > filtro.Limite {
+		linhas = linhas[:filtro.Limite]
+		ultima := linhas[len(linhas)-1]
+		proximo = &CursorFavoritos{Chave: ultima.Chave, ID: ultima.ID}
+	}
+
+	favoritos, err := r.completar(linhas)
+	return favoritos, proximo, err
+}
+
+// Buscar retorna um favorito do usuário pelo ID do filme.
+func (r *favoritoRepositorioSqlx) Buscar(usuarioID, filmeID int64) (*dominio.FilmeFavorito, error) {
+	var linhas []favoritoComChave
+	query := fmt.Sprintf(consultaFavoritos, "i.ordem") + " AND i.filme_id = ?"
+	if err := r.db.Select(&linhas, query, usuarioID, dominio.TipoListaFavoritos, filmeID); err != nil {
+		return nil, err
+	}
+	if len(linhas) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	favoritos, err := r.completar(linhas)
+	if err != nil {
+		return nil, err
+	}
+	return &favoritos[0], nil
+}
+
+// AtualizarDetalhes altera a anotação e/ou substitui as tags do favorito; argumentos nil não
+// mudam. Retorna false se o filme não estiver nos favoritos do usuário.
+func (r *favoritoRepositorioSqlx) AtualizarDetalhes(usuarioID, filmeID int64, anotacao *string, tags []string) (bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var itemID int64
+	query := `SELECT i.id FROM itens_lista i JOIN listas l ON l.id = i.lista_id
+	          WHERE l.usuario_id = ? AND l.tipo = ? AND i.filme_id = ?`
+	if err := tx.Get(&itemID, query, usuarioID, dominio.TipoListaFavoritos, filmeID); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if anotacao != nil {
+		if _, err := tx.Exec("UPDATE itens_lista SET anotacao = ? WHERE id = ?", *anotacao, itemID); err != nil {
+			return false, err
+		}
+	}
+	if tags != nil {
+		if _, err := tx.Exec("DELETE FROM tags_itens_lista WHERE item_id = ?", itemID); err != nil {
+			return false, err
+		}
+		if err := gravarTagsItem(tx, itemID, tags); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
 }
 
 func (r *favoritoRepositorioSqlx) Deletar(usuarioID, filmeID int64) error {
-	query := "DELETE FROM filmes_favoritos WHERE usuario_id = ? AND filme_id = ?"
-	_, err := r.db.Exec(query, usuarioID, filmeID)
-	return err
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var listaID int64
+	query := "SELECT id FROM listas WHERE usuario_id = ? AND tipo = ?"
+	if err := tx.Get(&listaID, query, usuarioID, dominio.TipoListaFavoritos); err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+
+	if _, err := removerItemLista(tx, listaID, filmeID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// completar carrega as tags e os gêneros dos favoritos lidos, com duas consultas por página.
+func (r *favoritoRepositorioSqlx) completar(linhas []favoritoComChave) ([]dominio.FilmeFavorito, error) {
+	favoritos := make([]dominio.FilmeFavorito, len(linhas))
+	if len(linhas) == 0 {
+		return favoritos, nil
+	}
+
+	itemIDs := make([]int64, len(linhas))
+	filmeIDs := make([]int64, len(linhas))
+	for i, linha := range linhas {
+		itemIDs[i] = linha.ID
+		filmeIDs[i] = linha.FilmeID
+	}
+
+	query, args, err := sqlx.In("SELECT item_id, tag FROM tags_itens_lista WHERE item_id IN (?) ORDER BY tag", itemIDs)
+	if err != nil {
+		return nil, err
+	}
+	var tags []struct {
+		ItemID int64  `db:"item_id"`
+		Tag    string `db:"tag"`
+	}
+	if err := r.db.Select(&tags, query, args...); err != nil {
+		return nil, err
+	}
+	tagsPorItem := make(map[int64][]string)
+	for _, t := range tags {
+		tagsPorItem[t.ItemID] = append(tagsPorItem[t.ItemID], t.Tag)
+	}
+
+	generos, err := buscarGenerosFilmes(r.db, filmeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, linha := range linhas {
+		favoritos[i] = linha.FilmeFavorito
+		favoritos[i].Tags = tagsPorItem[linha.ID]
+		favoritos[i].Generos = generos[linha.FilmeID]
+		if favoritos[i].Tags == nil {
+			favoritos[i].Tags = make([]string, 0)
+		}
+		if favoritos[i].Generos == nil {
+			favoritos[i].Generos = make([]dominio.Genero, 0)
+		}
+	}
+	return favoritos, nil
 }
 
-// Implementação do novo método para verificar a existência de um favorito.
-func (r *favoritoRepositorioSqlx) VerificarExistencia(usuarioID, filmeID int64) (bool, error) {
-	var existe bool
-	query := "SELECT EXISTS(SELECT 1 FROM filmes_favoritos WHERE usuario_id = ? AND filme_id = ?)"
-	err := r.db.Get(&existe, query, usuarioID, filmeID)
-	if err != nil && err != sql.ErrNoRows {
+// inserirFavorito adiciona o filme ao fim da lista de favoritos, com anotação e tags.
+func inserirFavorito(tx *sqlx.Tx, listaID int64, favorito *dominio.FilmeFavorito) (bool, error) {
+	item := &dominio.ItemLista{
+		ListaID:       listaID,
+		FilmeID:       favorito.FilmeID,
+		Titulo:        favorito.Titulo,
+		CaminhoPoster: favorito.CaminhoPoster,
+		Anotacao:      favorito.Anotacao,
+		AdicionadoPor: &favorito.UsuarioID,
+	}
+	inserido, err := inserirItemLista(tx, item)
+	if err != nil || !inserido {
 		return false, err
 	}
-	return existe, nil
+	if err := gravarTagsItem(tx, item.ID, favorito.Tags); err != nil {
+		return false, err
+	}
+	favorito.ID, favorito.DataAdicionado = item.ID, item.DataAdicionado
+	return true, nil
+}
+
+// gravarTagsItem associa as tags ao item; as repetidas são ignoradas.
+func gravarTagsItem(tx *sqlx.Tx, itemID int64, tags []string) error {
+	for _, tag := range tags {
+		query := "INSERT INTO tags_itens_lista (item_id, tag) VALUES (?, ?) ON CONFLICT DO NOTHING"
+		if _, err := tx.Exec(query, itemID, tag); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,129 @@
+package repositorio
+
+import (
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/jmoiron/sqlx"
+)
+
+// IdentidadeRepositorio define a persistência das identidades externas (OIDC)
+// e dos logins externos em andamento.
+type IdentidadeRepositorio interface {
+	BuscarPorProvedor(provedor, sujeito string) (*dominio.IdentidadeExterna, error)
+	Vincular(identidade *dominio.IdentidadeExterna) error
+	CriarUsuarioComIdentidade(usuario *dominio.Usuario, identidade *dominio.IdentidadeExterna) error
+	ListarPorUsuarioID(usuarioID int64) ([]dominio.IdentidadeExterna, error)
+	Desvincular(usuarioID, identidadeID int64) (bool, error)
+	SalvarLoginPendente(pendente *dominio.LoginExternoPendente) error
+	ConsumirLoginPendente(estado string) (*dominio.LoginExternoPendente, error)
+}
+
+type identidadeRepositorioSqlx struct {
+	db *sqlx.DB
+}
+
+// NovoIdentidadeRepositorio cria uma nova instância do repositório de identidades externas.
+func NovoIdentidadeRepositorio(db *sqlx.DB) IdentidadeRepositorio {
+	return &identidadeRepositorioSqlx{db: db}
+}
+
+// BuscarPorProvedor encontra a identidade pelo par provedor + 'sub'.
+func (r *identidadeRepositorioSqlx) BuscarPorProvedor(provedor, sujeito string) (*dominio.IdentidadeExterna, error) {
+	var identidade dominio.IdentidadeExterna
+	query := "SELECT * FROM identidades_externas WHERE provedor = ? AND sujeito = ?"
+	if err := r.db.Get(&identidade, query, provedor, sujeito); err != nil {
+		return nil, err
+	}
+	return &identidade, nil
+}
+
+// Vincular associa uma identidade externa a um usuário já existente.
+func (r *identidadeRepositorioSqlx) Vincular(identidade *dominio.IdentidadeExterna) error {
+	query := "INSERT INTO identidades_externas (usuario_id, provedor, sujeito, email) VALUES (?, ?, ?, ?)"
+	resultado, err := r.db.Exec(query, identidade.UsuarioID, identidade.Provedor, identidade.Sujeito, identidade.Email)
+	if err != nil {
+		return err
+	}
+	identidade.ID, err = resultado.LastInsertId()
+	return err
+}
+
+// CriarUsuarioComIdentidade cria o usuário e o vínculo na mesma transação,
+// para que não sobre uma conta sem forma de login se o vínculo falhar.
+func (r *identidadeRepositorioSqlx) CriarUsuarioComIdentidade(usuario *dominio.Usuario, identidade *dominio.IdentidadeExterna) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	resultado, err := tx.Exec("INSERT INTO usuarios (nome, email, senha_hash, email_verificado) VALUES (?, ?, ?, ?)",
+		usuario.Nome, usuario.Email, usuario.SenhaHash, usuario.EmailVerificado)
+	if err != nil {
+		return err
+	}
+	if usuario.ID, err = resultado.LastInsertId(); err != nil {
+		return err
+	}
+
+	identidade.UsuarioID = usuario.ID
+	resultado, err = tx.Exec("INSERT INTO identidades_externas (usuario_id, provedor, sujeito, email) VALUES (?, ?, ?, ?)",
+		identidade.UsuarioID, identidade.Provedor, identidade.Sujeito, identidade.Email)
+	if err != nil {
+		return err
+	}
+	if identidade.ID, err = resultado.LastInsertId(); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ListarPorUsuarioID retorna as identidades externas vinculadas ao usuário.
+func (r *identidadeRepositorioSqlx) ListarPorUsuarioID(usuarioID int64) ([]dominio.IdentidadeExterna, error) {
+	var identidades []dominio.IdentidadeExterna
+	query := "SELECT * FROM identidades_externas WHERE usuario_id = ? ORDER BY data_criacao"
+	err := r.db.Select(&identidades, query, usuarioID)
+	return identidades, err
+}
+
+// Desvincular remove a identidade do usuário e informa se algo foi removido.
+func (r *identidadeRepositorioSqlx) Desvincular(usuarioID, identidadeID int64) (bool, error) {
+	resultado, err := r.db.Exec("DELETE FROM identidades_externas WHERE id = ? AND usuario_id = ?", identidadeID, usuarioID)
+	if err != nil {
+		return false, err
+	}
+	linhas, err := resultado.RowsAffected()
+	return linhas > 0, err
+}
+
+// SalvarLoginPendente registra um login iniciado e aproveita para limpar os expirados.
+func (r *identidadeRepositorioSqlx) SalvarLoginPendente(p *dominio.LoginExternoPendente) error {
+	if _, err := r.db.Exec("DELETE FROM logins_externos_pendentes WHERE expira_em < ?", time.Now().UTC()); err != nil {
+		return err
+	}
+	query := `INSERT INTO logins_externos_pendentes (estado, provedor, nonce, verificador_pkce, expira_em, usuario_id)
+	          VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Exec(query, p.Estado, p.Provedor, p.Nonce, p.VerificadorPKCE, p.ExpiraEm.UTC(), p.UsuarioID)
+	return err
+}
+
+// ConsumirLoginPendente busca e apaga o login pendente, garantindo que cada 'state' seja usado uma única vez.
+func (r *identidadeRepositorioSqlx) ConsumirLoginPendente(estado string) (*dominio.LoginExternoPendente, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var pendente dominio.LoginExternoPendente
+	if err := tx.Get(&pendente, "SELECT * FROM logins_externos_pendentes WHERE estado = ?", estado); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("DELETE FROM logins_externos_pendentes WHERE estado = ?", estado); err != nil {
+		return nil, err
+	}
+
+	return &pendente, tx.Commit()
+}
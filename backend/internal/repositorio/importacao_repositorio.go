@@ -0,0 +1,106 @@
+package repositorio
+
+import (
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/jmoiron/sqlx"
+)
+
+// ImportacaoRepositorio define a persistência das importações de histórico e de suas pendências.
+type ImportacaoRepositorio interface {
+	Criar(importacao *dominio.Importacao) (bool, error)
+	Atualizar(importacao *dominio.Importacao) error
+	BuscarDoUsuario(usuarioID, id int64) (*dominio.Importacao, error)
+	ListarPorUsuarioID(usuarioID int64) ([]dominio.Importacao, error)
+	InterromperEmAndamento(motivo string) (int64, error)
+	AdicionarPendencia(pendencia *dominio.PendenciaImportacao) error
+	ListarPendencias(importacaoID int64) ([]dominio.PendenciaImportacao, error)
+}
+
+type importacaoRepositorioSqlx struct {
+	db *sqlx.DB
+}
+
+// NovaImportacaoRepositorio cria uma nova instância do repositório de importações.
+func NovaImportacaoRepositorio(db *sqlx.DB) ImportacaoRepositorio {
+	return &importacaoRepositorioSqlx{db: db}
+}
+
+// Criar grava uma nova importação e preenche o ID e a data de criação. Retorna false, sem
+// gravar nada, se o usuário já tiver uma importação em andamento: o índice único parcial sobre
+// as importações pendentes e em processamento garante uma por usuário.
+func (r *importacaoRepositorioSqlx) Criar(i *dominio.Importacao) (bool, error) {
+	i.DataCriacao = time.Now().UTC()
+	query := `INSERT INTO importacoes (usuario_id, status, total_linhas, data_criacao) VALUES (?, ?, ?, ?)
+	          ON CONFLICT DO NOTHING`
+	resultado, err := r.db.Exec(query, i.UsuarioID, i.Status, i.TotalLinhas, i.DataCriacao)
+	if err != nil {
+		return false, err
+	}
+	if afetadas, err := resultado.RowsAffected(); err != nil || afetadas == 0 {
+		return false, err
+	}
+	i.ID, err = resultado.LastInsertId()
+	return true, err
+}
+
+// Atualizar grava a situação e os contadores de progresso da importação.
+func (r *importacaoRepositorioSqlx) Atualizar(i *dominio.Importacao) error {
+	query := `UPDATE importacoes SET status = ?, processadas = ?, importadas = ?, ja_existentes = ?,
+	              nao_encontradas = ?, erro = ?, data_conclusao = ?
+	          WHERE id = ?`
+	_, err := r.db.Exec(query, i.Status, i.Processadas, i.Importadas, i.JaExistentes,
+		i.NaoEncontradas, i.Erro, i.DataConclusao, i.ID)
+	return err
+}
+
+// BuscarDoUsuario encontra uma importação pelo ID, desde que seja do usuário informado.
+func (r *importacaoRepositorioSqlx) BuscarDoUsuario(usuarioID, id int64) (*dominio.Importacao, error) {
+	var importacao dominio.Importacao
+	query := "SELECT * FROM importacoes WHERE id = ? AND usuario_id = ?"
+	if err := r.db.Get(&importacao, query, id, usuarioID); err != nil {
+		return nil, err
+	}
+	return &importacao, nil
+}
+
+// ListarPorUsuarioID retorna as importações do usuário, das mais recentes para as mais antigas.
+func (r *importacaoRepositorioSqlx) ListarPorUsuarioID(usuarioID int64) ([]dominio.Importacao, error) {
+	var importacoes []dominio.Importacao
+	query := "SELECT * FROM importacoes WHERE usuario_id = ? ORDER BY data_criacao DESC, id DESC"
+	err := r.db.Select(&importacoes, query, usuarioID)
+	return importacoes, err
+}
+
+// InterromperEmAndamento marca como falhas as importações que não terminaram (por exemplo,
+// porque o servidor foi reiniciado) e retorna quantas foram afetadas.
+func (r *importacaoRepositorioSqlx) InterromperEmAndamento(motivo string) (int64, error) {
+	query := `UPDATE importacoes SET status = ?, erro = ?, data_conclusao = ? WHERE status IN (?, ?)`
+	resultado, err := r.db.Exec(query, dominio.StatusImportacaoFalhou, motivo, time.Now().UTC(),
+		dominio.StatusImportacaoPendente, dominio.StatusImportacaoProcessando)
+	if err != nil {
+		return 0, err
+	}
+	return resultado.RowsAffected()
+}
+
+// AdicionarPendencia registra uma linha que não pôde ser importada.
+func (r *importacaoRepositorioSqlx) AdicionarPendencia(p *dominio.PendenciaImportacao) error {
+	query := `INSERT INTO pendencias_importacao (importacao_id, arquivo, linha, titulo, ano, imdb_id, motivo)
+	          VALUES (?, ?, ?, ?, ?, ?, ?)`
+	resultado, err := r.db.Exec(query, p.ImportacaoID, p.Arquivo, p.Linha, p.Titulo, p.Ano, p.IMDbID, p.Motivo)
+	if err != nil {
+		return err
+	}
+	p.ID, err = resultado.LastInsertId()
+	return err
+}
+
+// ListarPendencias retorna as linhas não importadas, na ordem dos arquivos enviados.
+func (r *importacaoRepositorioSqlx) ListarPendencias(importacaoID int64) ([]dominio.PendenciaImportacao, error) {
+	var pendencias []dominio.PendenciaImportacao
+	query := "SELECT * FROM pendencias_importacao WHERE importacao_id = ? ORDER BY id"
+	err := r.db.Select(&pendencias, query, importacaoID)
+	return pendencias, err
+}
@@ -0,0 +1,448 @@
+package repositorio
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/jmoiron/sqlx"
+)
+
+// ListaRepositorio define a persistência das listas de filmes e de seus itens.
+type ListaRepositorio interface {
+	Criar(lista *dominio.Lista) error
+	BuscarPorID(id int64) (*dominio.Lista, error)
+	BuscarPorSlug(slug string) (*dominio.Lista, error)
+	ListarPorUsuarioID(usuarioID int64) ([]dominio.Lista, error)
+	Atualizar(lista *dominio.Lista, versaoEsperada int) error
+	Deletar(id int64) error
+	GarantirEmbutidas(usuarioID int64) error
+	BuscarIDEmbutida(usuarioID int64, tipo string) (int64, error)
+	ListarItens(listaID int64) ([]dominio.ItemLista, error)
+	ListarItensApos(listaID int64, aposPosicao, limite int) ([]dominio.ItemLista, error)
+	AdicionarItem(item *dominio.ItemLista, versaoEsperada int) (int, error)
+	RemoverItem(listaID, filmeID int64, versaoEsperada int) (int, error)
+	MoverItem(listaID, filmeID int64, posicao, versaoEsperada int) (int, error)
+	DefinirOrdem(listaID int64, filmeIDs []int64, versaoEsperada int) (int, error)
+}
+
+// Erros que o repositório de listas pode retornar.
+var (
+	// ErrVersaoDesatualizada indica que a lista mudou desde a versão em que a alteração se baseou.
+	ErrVersaoDesatualizada = errors.New("versão da lista desatualizada")
+
+	// ErrOrdemDivergente indica que a nova ordem não traz exatamente os filmes da lista.
+	ErrOrdemDivergente = errors.New("a ordem informada não corresponde aos filmes da lista")
+)
+
+// intervaloOrdem é a distância entre as ordens de itens vizinhos quando a lista é numerada. Um
+// item inserido ou movido recebe a ordem do meio entre seus vizinhos; as demais linhas só são
+// renumeradas quando não sobra espaço entre eles.
+const intervaloOrdem = 1024
+
+// titulosListasEmbutidas define os tipos de lista embutidos e o título com que são criados.
+var titulosListasEmbutidas = map[string]string{
+	dominio.TipoListaFavoritos: "Favoritos",
+	dominio.TipoListaAssistir:  "Para assistir",
+}
+
+type listaRepositorioSqlx struct {
+	db *sqlx.DB
+}
+
+// NovoListaRepositorio cria uma nova instância do repositório de listas.
+func NovoListaRepositorio(db *sqlx.DB) ListaRepositorio {
+	return &listaRepositorioSqlx{db: db}
+}
+
+// Criar insere uma lista e preenche o ID gerado.
+func (r *listaRepositorioSqlx) Criar(l *dominio.Lista) error {
+	agora := time.Now().UTC()
+	query := `INSERT INTO listas (usuario_id, tipo, titulo, descricao, visibilidade, ranqueada, slug_compartilhamento, data_criacao, data_atualizacao)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	resultado, err := r.db.Exec(query, l.UsuarioID, l.Tipo, l.Titulo, l.Descricao, l.Visibilidade, l.Ranqueada, l.SlugCompartilhamento, agora, agora)
+	if err != nil {
+		return err
+	}
+	l.DataCriacao, l.DataAtualizacao = agora, agora
+	l.Versao = 1
+	l.ID, err = resultado.LastInsertId()
+	return err
+}
+
+// BuscarPorID encontra uma lista pelo seu ID.
+func (r *listaRepositorioSqlx) BuscarPorID(id int64) (*dominio.Lista, error) {
+	var lista dominio.Lista
+	if err := r.db.Get(&lista, "SELECT * FROM listas WHERE id = ?", id); err != nil {
+		return nil, err
+	}
+	return &lista, nil
+}
+
+// BuscarPorSlug encontra uma lista pelo identificador do link de compartilhamento. Listas de
+// contas com exclusão agendada ou com o perfil ocultado pela moderação não aparecem.
+func (r *listaRepositorioSqlx) BuscarPorSlug(slug string) (*dominio.Lista, error) {
+	var lista dominio.Lista
+	query := `SELECT l.* FROM listas l
+	          JOIN usuarios u ON u.id = l.usuario_id
+	          LEFT JOIN perfis p ON p.usuario_id = l.usuario_id
+	          WHERE l.slug_compartilhamento = ? AND u.exclusao_agendada_em IS NULL AND COALESCE(p.oculto, 0) = 0`
+	if err := r.db.Get(&lista, query, slug); err != nil {
+		return nil, err
+	}
+	return &lista, nil
+}
+
+// ListarPorUsuarioID retorna as listas do usuário com a quantidade de itens de cada uma.
+// As listas embutidas aparecem primeiro; as demais, da mais recente para a mais antiga.
+func (r *listaRepositorioSqlx) ListarPorUsuarioID(usuarioID int64) ([]dominio.Lista, error) {
+	var listas []dominio.Lista
+	query := `SELECT l.*, (SELECT COUNT(*) FROM itens_lista i WHERE i.lista_id = l.id) AS quantidade_itens
+	          FROM listas l WHERE l.usuario_id = ?
+	          ORDER BY l.tipo = 'personalizada', l.data_criacao DESC, l.id DESC`
+	err := r.db.Select(&listas, query, usuarioID)
+	return listas, err
+}
+
+// Atualizar grava título, descrição, visibilidade, classificação e link de compartilhamento da lista.
+// Com versaoEsperada diferente de 0, retorna ErrVersaoDesatualizada se a lista já tiver mudado.
+func (r *listaRepositorioSqlx) Atualizar(l *dominio.Lista, versaoEsperada int) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := conferirVersao(tx, l.ID, versaoEsperada); err != nil {
+		return err
+	}
+	query := "UPDATE listas SET titulo = ?, descricao = ?, visibilidade = ?, ranqueada = ?, slug_compartilhamento = ? WHERE id = ?"
+	if _, err := tx.Exec(query, l.Titulo, l.Descricao, l.Visibilidade, l.Ranqueada, l.SlugCompartilhamento, l.ID); err != nil {
+		return err
+	}
+	if err := tocarLista(tx, l.ID); err != nil {
+		return err
+	}
+	if err := tx.Get(l, "SELECT * FROM listas WHERE id = ?", l.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Deletar apaga a lista; os itens são removidos em cascata.
+func (r *listaRepositorioSqlx) Deletar(id int64) error {
+	_, err := r.db.Exec("DELETE FROM listas WHERE id = ?", id)
+	return err
+}
+
+// GarantirEmbutidas cria as listas embutidas que o usuário ainda não tem.
+func (r *listaRepositorioSqlx) GarantirEmbutidas(usuarioID int64) error {
+	for tipo := range titulosListasEmbutidas {
+		if _, err := garantirListaEmbutida(r.db, usuarioID, tipo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BuscarIDEmbutida retorna o ID da lista embutida do tipo informado, criando-a se preciso.
+func (r *listaRepositorioSqlx) BuscarIDEmbutida(usuarioID int64, tipo string) (int64, error) {
+	return garantirListaEmbutida(r.db, usuarioID, tipo)
+}
+
+// ListarItens retorna os filmes da lista na ordem manual, com a posição de cada um e o nome de
+// quem o adicionou.
+func (r *listaRepositorioSqlx) ListarItens(listaID int64) ([]dominio.ItemLista, error) {
+	var itens []dominio.ItemLista
+	query := `SELECT i.*, ROW_NUMBER() OVER (ORDER BY i.ordem, i.id) AS posicao, COALESCE(u.nome, '') AS nome_autor
+	          FROM itens_lista i LEFT JOIN usuarios u ON u.id = i.adicionado_por
+	          WHERE i.lista_id = ? ORDER BY i.ordem, i.id`
+	err := r.db.Select(&itens, query, listaID)
+	return itens, err
+}
+
+// ListarItensApos retorna até limite itens com posição maior que aposPosicao, para percorrer
+// listas grandes em páginas.
+func (r *listaRepositorioSqlx) ListarItensApos(listaID int64, aposPosicao, limite int) ([]dominio.ItemLista, error) {
+	var itens []dominio.ItemLista
+	query := `SELECT * FROM (
+	              SELECT i.*, ROW_NUMBER() OVER (ORDER BY i.ordem, i.id) AS posicao FROM itens_lista i WHERE i.lista_id = ?
+	          ) WHERE posicao > ? ORDER BY posicao LIMIT ?`
+	err := r.db.Select(&itens, query, listaID, aposPosicao, limite)
+	return itens, err
+}
+
+// AdicionarItem insere o filme na posição item.Posicao (ou no fim, se ela for 0) e retorna a nova versão da lista, ou 0 se o
+// filme já estava nela. Com versaoEsperada diferente de 0, retorna ErrVersaoDesatualizada se a
+// lista já tiver mudado; o mesmo vale para RemoverItem e MoverItem.
+func (r *listaRepositorioSqlx) AdicionarItem(item *dominio.ItemLista, versaoEsperada int) (int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := conferirVersao(tx, item.ListaID, versaoEsperada); err != nil {
+		return 0, err
+	}
+	inserido, err := inserirItemLista(tx, item)
+	if err != nil || !inserido {
+		return 0, err
+	}
+	return concluirAlteracao(tx, item.ListaID)
+}
+
+// RemoverItem tira o filme da lista. Retorna a nova versão da lista, ou 0 se o filme não estava nela.
+func (r *listaRepositorioSqlx) RemoverItem(listaID, filmeID int64, versaoEsperada int) (int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := conferirVersao(tx, listaID, versaoEsperada); err != nil {
+		return 0, err
+	}
+	removido, err := removerItemLista(tx, listaID, filmeID)
+	if err != nil || !removido {
+		return 0, err
+	}
+	return concluirAlteracao(tx, listaID)
+}
+
+// MoverItem coloca o filme na posição informada, alterando apenas a ordem dele. Posições além
+// do fim da lista levam o item para o último lugar. Retorna a nova versão da lista, ou 0 se o
+// filme não estava nela.
+func (r *listaRepositorioSqlx) MoverItem(listaID, filmeID int64, posicao, versaoEsperada int) (int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := conferirVersao(tx, listaID, versaoEsperada); err != nil {
+		return 0, err
+	}
+
+	var existe bool
+	query := "SELECT EXISTS (SELECT 1 FROM itens_lista WHERE lista_id = ? AND filme_id = ?)"
+	if err := tx.Get(&existe, query, listaID, filmeID); err != nil || !existe {
+		return 0, err
+	}
+
+	ordem, err := ordemParaPosicao(tx, listaID, filmeID, posicao)
+	if err != nil {
+		return 0, err
+	}
+	query = "UPDATE itens_lista SET ordem = ? WHERE lista_id = ? AND filme_id = ?"
+	if _, err := tx.Exec(query, ordem, listaID, filmeID); err != nil {
+		return 0, err
+	}
+	if err := tocarLista(tx, listaID); err != nil {
+		return 0, err
+	}
+	return concluirAlteracao(tx, listaID)
+}
+
+// DefinirOrdem reordena a lista inteira de uma vez. filmeIDs deve conter cada filme da lista
+// exatamente uma vez; caso contrário, retorna ErrOrdemDivergente sem alterar nada.
+func (r *listaRepositorioSqlx) DefinirOrdem(listaID int64, filmeIDs []int64, versaoEsperada int) (int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := conferirVersao(tx, listaID, versaoEsperada); err != nil {
+		return 0, err
+	}
+
+	var atuais []int64
+	if err := tx.Select(&atuais, "SELECT filme_id FROM itens_lista WHERE lista_id = ?", listaID); err != nil {
+		return 0, err
+	}
+	if len(atuais) != len(filmeIDs) {
+		return 0, ErrOrdemDivergente
+	}
+	pendentes := make(map[int64]bool, len(atuais))
+	for _, filmeID := range atuais {
+		pendentes[filmeID] = true
+	}
+	for _, filmeID := range filmeIDs {
+		if !pendentes[filmeID] {
+			return 0, ErrOrdemDivergente
+		}
+		delete(pendentes, filmeID)
+	}
+
+	stmt, err := tx.Preparex("UPDATE itens_lista SET ordem = ? WHERE lista_id = ? AND filme_id = ?")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	for i, filmeID := range filmeIDs {
+		if _, err := stmt.Exec(int64(i+1)*intervaloOrdem, listaID, filmeID); err != nil {
+			return 0, err
+		}
+	}
+	if err := tocarLista(tx, listaID); err != nil {
+		return 0, err
+	}
+	return concluirAlteracao(tx, listaID)
+}
+
+// garantirListaEmbutida cria (se preciso) e retorna o ID da lista embutida do usuário.
+// O índice único parcial sobre (usuario_id, tipo) torna a criação segura sob concorrência.
+func garantirListaEmbutida(db sqlx.Ext, usuarioID int64, tipo string) (int64, error) {
+	agora := time.Now().UTC()
+	// O NOT EXISTS evita consumir IDs a cada chamada; o OR IGNORE cobre a corrida entre duas criações.
+	query := `INSERT OR IGNORE INTO listas (usuario_id, tipo, titulo, data_criacao, data_atualizacao)
+	          SELECT ?, ?, ?, ?, ? WHERE NOT EXISTS (SELECT 1 FROM listas WHERE usuario_id = ? AND tipo = ?)`
+	if _, err := db.Exec(query, usuarioID, tipo, titulosListasEmbutidas[tipo], agora, agora, usuarioID, tipo); err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err := sqlx.Get(db, &id, "SELECT id FROM listas WHERE usuario_id = ? AND tipo = ?", usuarioID, tipo)
+	return id, err
+}
+
+// inserirItemLista adiciona o item na posição item.Posicao, ou na última se ela for 0, e
+// preenche a posição final. Retorna false se o filme já estava na lista.
+func inserirItemLista(tx *sqlx.Tx, item *dominio.ItemLista) (bool, error) {
+	var err error
+	if item.Posicao > 0 {
+		item.Ordem, err = ordemParaPosicao(tx, item.ListaID, item.FilmeID, item.Posicao)
+	} else {
+		err = tx.Get(&item.Ordem, "SELECT COALESCE(MAX(ordem), 0) + ? FROM itens_lista WHERE lista_id = ?", intervaloOrdem, item.ListaID)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	agora := time.Now().UTC()
+	query := `INSERT INTO itens_lista (lista_id, filme_id, titulo, caminho_poster, anotacao, adicionado_por, ordem, data_adicionado)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	          ON CONFLICT (lista_id, filme_id) DO NOTHING`
+	resultado, err := tx.Exec(query, item.ListaID, item.FilmeID, item.Titulo, item.CaminhoPoster, item.Anotacao,
+		item.AdicionadoPor, item.Ordem, agora)
+	if err != nil {
+		return false, err
+	}
+	if linhas, err := resultado.RowsAffected(); err != nil || linhas == 0 {
+		return false, err
+	}
+
+	item.ID, err = resultado.LastInsertId()
+	if err != nil {
+		return false, err
+	}
+	item.DataAdicionado = agora
+	query = "SELECT COUNT(*) FROM itens_lista WHERE lista_id = ? AND (ordem, id) <= (?, ?)"
+	if err := tx.Get(&item.Posicao, query, item.ListaID, item.Ordem, item.ID); err != nil {
+		return false, err
+	}
+	return true, tocarLista(tx, item.ListaID)
+}
+
+// removerItemLista apaga o item; os demais mantêm a ordem, e suas posições se ajustam na leitura.
+func removerItemLista(tx *sqlx.Tx, listaID, filmeID int64) (bool, error) {
+	resultado, err := tx.Exec("DELETE FROM itens_lista WHERE lista_id = ? AND filme_id = ?", listaID, filmeID)
+	if err != nil {
+		return false, err
+	}
+	if linhas, err := resultado.RowsAffected(); err != nil || linhas == 0 {
+		return false, err
+	}
+	return true, tocarLista(tx, listaID)
+}
+
+// ordemParaPosicao calcula a ordem que coloca o filme na posição informada (a partir de 1),
+// entre os vizinhos que ele terá, sem contar ele mesmo. Sem espaço entre os vizinhos, a lista é
+// renumerada antes.
+func ordemParaPosicao(tx *sqlx.Tx, listaID, filmeID int64, posicao int) (int64, error) {
+	// Lê o item que ficará antes (se houver) e o que ficará depois.
+	inicio := posicao - 2
+	if inicio < 0 {
+		inicio = 0
+	}
+	var vizinhos []int64
+	query := "SELECT ordem FROM itens_lista WHERE lista_id = ? AND filme_id != ? ORDER BY ordem, id LIMIT ? OFFSET ?"
+	if err := tx.Select(&vizinhos, query, listaID, filmeID, posicao-inicio, inicio); err != nil {
+		return 0, err
+	}
+
+	var anterior, seguinte int64
+	switch {
+	case posicao <= 1 && len(vizinhos) > 0:
+		seguinte = vizinhos[0]
+	case len(vizinhos) == 2:
+		anterior, seguinte = vizinhos[0], vizinhos[1]
+	case len(vizinhos) == 1:
+		anterior = vizinhos[0]
+	}
+
+	if seguinte == 0 {
+		if anterior == 0 && posicao > 1 {
+			// A posição pedida está além do fim: o item vai para o último lugar.
+			if err := tx.Get(&anterior, "SELECT COALESCE(MAX(ordem), 0) FROM itens_lista WHERE lista_id = ? AND filme_id != ?", listaID, filmeID); err != nil {
+				return 0, err
+			}
+		}
+		return anterior + intervaloOrdem, nil
+	}
+	if seguinte-anterior > 1 {
+		return anterior + (seguinte-anterior)/2, nil
+	}
+
+	if err := renumerarItens(tx, listaID); err != nil {
+		return 0, err
+	}
+	return ordemParaPosicao(tx, listaID, filmeID, posicao)
+}
+
+// renumerarItens redistribui as ordens da lista com o intervalo padrão, mantendo a sequência.
+func renumerarItens(tx *sqlx.Tx, listaID int64) error {
+	query := `UPDATE itens_lista SET ordem = n.ordem
+	          FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY ordem, id) * ? AS ordem FROM itens_lista WHERE lista_id = ?) AS n
+	          WHERE itens_lista.id = n.id`
+	_, err := tx.Exec(query, intervaloOrdem, listaID)
+	return err
+}
+
+// tocarLista registra que a lista mudou, avançando sua versão.
+func tocarLista(tx *sqlx.Tx, listaID int64) error {
+	query := "UPDATE listas SET data_atualizacao = ?, versao = versao + 1 WHERE id = ?"
+	_, err := tx.Exec(query, time.Now().UTC(), listaID)
+	return err
+}
+
+// conferirVersao retorna ErrVersaoDesatualizada se a lista não estiver na versão esperada
+// (0 dispensa a verificação). Por ser uma escrita, a consulta já reserva o banco para a
+// transação, de modo que outra edição não consegue mudar a versão antes do commit.
+func conferirVersao(tx *sqlx.Tx, listaID int64, versaoEsperada int) error {
+	if versaoEsperada == 0 {
+		return nil
+	}
+	resultado, err := tx.Exec("UPDATE listas SET versao = versao WHERE id = ? AND versao = ?", listaID, versaoEsperada)
+	if err != nil {
+		return err
+	}
+	linhas, err := resultado.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if linhas == 0 {
+		return ErrVersaoDesatualizada
+	}
+	return nil
+}
+
+// concluirAlteracao confirma a transação e retorna a nova versão da lista.
+func concluirAlteracao(tx *sqlx.Tx, listaID int64) (int, error) {
+	var versao int
+	if err := tx.Get(&versao, "SELECT versao FROM listas WHERE id = ?", listaID); err != nil {
+		return 0, err
+	}
+	return versao, tx.Commit()
+}
@@ -0,0 +1,184 @@
+package repositorio
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrAlvoRemovido indica que o conteúdo a ocultar deixou de existir antes da ação da moderação.
+var ErrAlvoRemovido = errors.New("o conteúdo denunciado não existe mais")
+
+// ModeracaoRepositorio define a persistência das denúncias e do registro de ações da moderação.
+type ModeracaoRepositorio interface {
+	CriarDenuncia(denuncia *dominio.Denuncia) (bool, error)
+	ListarDenunciasPendentes() ([]dominio.Denuncia, error)
+	ContarDenunciasPendentes(alvoTipo string, alvoID int64) (int, error)
+	ListarDenunciasDoUsuario(usuarioID int64) ([]dominio.Denuncia, error)
+	Resolver(acao *dominio.AcaoModeracao, situacao string) ([]int64, error)
+	ListarAcoes(usuarioAlvoID, antesDeID int64, limite int) ([]dominio.AcaoModeracao, error)
+	ListarAcoesContraUsuario(usuarioID int64) ([]dominio.AcaoModeracao, error)
+}
+
+type moderacaoRepositorioSqlx struct {
+	db *sqlx.DB
+}
+
+// NovoModeracaoRepositorio cria uma nova instância do repositório de moderação.
+func NovoModeracaoRepositorio(db *sqlx.DB) ModeracaoRepositorio {
+	return &moderacaoRepositorioSqlx{db: db}
+}
+
+// CriarDenuncia insere a denúncia e preenche o ID gerado. Retorna false se o denunciante já
+// tiver uma denúncia pendente para o mesmo conteúdo.
+func (r *moderacaoRepositorioSqlx) CriarDenuncia(d *dominio.Denuncia) (bool, error) {
+	query := `INSERT OR IGNORE INTO denuncias (denunciante_id, alvo_tipo, alvo_id, motivo, detalhes, situacao, data_criacao)
+	          VALUES (?, ?, ?, ?, ?, ?, ?)`
+	resultado, err := r.db.Exec(query, d.DenuncianteID, d.AlvoTipo, d.AlvoID, d.Motivo, d.Detalhes, d.Situacao, d.DataCriacao)
+	if err != nil {
+		return false, err
+	}
+	if linhas, err := resultado.RowsAffected(); err != nil || linhas == 0 {
+		return false, err
+	}
+	d.ID, err = resultado.LastInsertId()
+	return true, err
+}
+
+// ListarDenunciasPendentes retorna as denúncias ainda não resolvidas, agrupadas por conteúdo e
+// das mais antigas para as mais novas.
+func (r *moderacaoRepositorioSqlx) ListarDenunciasPendentes() ([]dominio.Denuncia, error) {
+	var denuncias []dominio.Denuncia
+	query := "SELECT * FROM denuncias WHERE situacao = ? ORDER BY alvo_tipo, alvo_id, id"
+	err := r.db.Select(&denuncias, query, dominio.SituacaoDenunciaPendente)
+	return denuncias, err
+}
+
+// ContarDenunciasPendentes conta as denúncias não resolvidas de um conteúdo.
+func (r *moderacaoRepositorioSqlx) ContarDenunciasPendentes(alvoTipo string, alvoID int64) (int, error) {
+	var total int
+	query := "SELECT COUNT(*) FROM denuncias WHERE situacao = ? AND alvo_tipo = ? AND alvo_id = ?"
+	err := r.db.Get(&total, query, dominio.SituacaoDenunciaPendente, alvoTipo, alvoID)
+	return total, err
+}
+
+// ListarDenunciasDoUsuario retorna as denúncias feitas pelo usuário, das mais novas para as mais antigas.
+func (r *moderacaoRepositorioSqlx) ListarDenunciasDoUsuario(usuarioID int64) ([]dominio.Denuncia, error) {
+	var denuncias []dominio.Denuncia
+	err := r.db.Select(&denuncias, "SELECT * FROM denuncias WHERE denunciante_id = ? ORDER BY id DESC", usuarioID)
+	return denuncias, err
+}
+
+// Resolver encerra, com a situação informada, todas as denúncias pendentes do conteúdo da ação,
+// aplica a ação e a grava no registro de auditoria, numa única transação. As denúncias são
+// reivindicadas primeiro: sem nenhuma pendente (outro moderador já as resolveu), nada é aplicado
+// nem gravado e AcaoModeracao.Denuncias fica em 0. Ocultar exclui a avaliação ou o comentário, ou
+// esconde o perfil; suspender bloqueia o login do autor até SuspensoAte e encerra todas as sessões
+// dele. Retorna os denunciantes que ainda têm conta, ou ErrAlvoRemovido, desfazendo tudo, se o
+// conteúdo a ocultar já tiver sido apagado.
+func (r *moderacaoRepositorioSqlx) Resolver(a *dominio.AcaoModeracao, situacao string) ([]int64, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var encerradas []sql.NullInt64
+	query := `UPDATE denuncias SET situacao = ?, resultado = ?, data_resolucao = ?
+	          WHERE situacao = ? AND alvo_tipo = ? AND alvo_id = ?
+	          RETURNING denunciante_id`
+	err = tx.Select(&encerradas, query, situacao, a.Acao, a.DataCriacao, dominio.SituacaoDenunciaPendente, a.AlvoTipo, a.AlvoID)
+	if err != nil || len(encerradas) == 0 {
+		return nil, err
+	}
+	a.Denuncias = len(encerradas)
+	denunciantes := make([]int64, 0, len(encerradas))
+	for _, denunciante := range encerradas {
+		if denunciante.Valid {
+			denunciantes = append(denunciantes, denunciante.Int64)
+		}
+	}
+
+	switch a.Acao {
+	case dominio.AcaoModeracaoOcultar:
+		if err := ocultarAlvo(tx, a.AlvoTipo, a.AlvoID); err != nil {
+			return nil, err
+		}
+	case dominio.AcaoModeracaoSuspender:
+		if _, err := tx.Exec("UPDATE usuarios SET suspenso_ate = ? WHERE id = ?", a.SuspensoAte.UTC(), a.UsuarioAlvoID); err != nil {
+			return nil, err
+		}
+		query = "UPDATE sessoes SET revogada_em = ? WHERE usuario_id = ? AND revogada_em IS NULL"
+		if _, err := tx.Exec(query, time.Now().UTC(), a.UsuarioAlvoID); err != nil {
+			return nil, err
+		}
+	}
+
+	query = `INSERT INTO acoes_moderacao (moderador_id, acao, alvo_tipo, alvo_id, usuario_alvo_id, observacao,
+	              conteudo, suspenso_ate, denuncias, data_criacao)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	resultado, err := tx.Exec(query, a.ModeradorID, a.Acao, a.AlvoTipo, a.AlvoID, a.UsuarioAlvoID, a.Observacao,
+		a.Conteudo, a.SuspensoAte, a.Denuncias, a.DataCriacao)
+	if err != nil {
+		return nil, err
+	}
+	if a.ID, err = resultado.LastInsertId(); err != nil {
+		return nil, err
+	}
+	return denunciantes, tx.Commit()
+}
+
+// ocultarAlvo tira o conteúdo do ar: avaliações (que continuam no histórico) e comentários são
+// excluídos e perfis, escondidos.
+func ocultarAlvo(tx *sqlx.Tx, alvoTipo string, alvoID int64) error {
+	var linhas int64
+	switch alvoTipo {
+	case dominio.AlvoDenunciaAvaliacao:
+		excluida, err := excluirAvaliacao(tx, "a.id = ?", alvoID)
+		if err != nil {
+			return err
+		}
+		if excluida {
+			linhas = 1
+		}
+	default:
+		query := "UPDATE perfis SET oculto = 1 WHERE usuario_id = ? AND oculto = 0"
+		if alvoTipo == dominio.AlvoDenunciaComentario {
+			query = "DELETE FROM comentarios_avaliacoes WHERE id = ?"
+		}
+		resultado, err := tx.Exec(query, alvoID)
+		if err != nil {
+			return err
+		}
+		if linhas, err = resultado.RowsAffected(); err != nil {
+			return err
+		}
+	}
+	if linhas == 0 {
+		return ErrAlvoRemovido
+	}
+	return nil
+}
+
+// ListarAcoes retorna o registro de auditoria das mais novas para as mais antigas, a partir do
+// cursor antesDeID (0 para o início). Com usuarioAlvoID, só as ações contra esse usuário.
+func (r *moderacaoRepositorioSqlx) ListarAcoes(usuarioAlvoID, antesDeID int64, limite int) ([]dominio.AcaoModeracao, error) {
+	var acoes []dominio.AcaoModeracao
+	query := `SELECT * FROM acoes_moderacao
+	          WHERE (? = 0 OR usuario_alvo_id = ?) AND (? = 0 OR id < ?)
+	          ORDER BY id DESC LIMIT ?`
+	err := r.db.Select(&acoes, query, usuarioAlvoID, usuarioAlvoID, antesDeID, antesDeID, limite)
+	return acoes, err
+}
+
+// ListarAcoesContraUsuario retorna as ações que atingiram o conteúdo ou a conta do usuário
+// (todas menos os descartes), das mais novas para as mais antigas.
+func (r *moderacaoRepositorioSqlx) ListarAcoesContraUsuario(usuarioID int64) ([]dominio.AcaoModeracao, error) {
+	var acoes []dominio.AcaoModeracao
+	query := "SELECT * FROM acoes_moderacao WHERE usuario_alvo_id = ? AND acao != ? ORDER BY id DESC"
+	err := r.db.Select(&acoes, query, usuarioID, dominio.AcaoModeracaoDescartar)
+	return acoes, err
+}
@@ -0,0 +1,150 @@
+package repositorio
+
+import (
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/jmoiron/sqlx"
+)
+
+// NotificacaoRepositorio define a persistência das notificações e das preferências de cada tipo.
+type NotificacaoRepositorio interface {
+	Criar(notificacao *dominio.Notificacao) error
+	BuscarPorID(id int64) (*dominio.Notificacao, error)
+	Listar(usuarioID int64, apenasNaoLidas bool, antesDeID int64, limite int) ([]dominio.Notificacao, error)
+	ListarApos(aposID int64, limite int) ([]dominio.Notificacao, error)
+	ContarNaoLidas(usuarioID int64) (int, error)
+	MarcarLida(usuarioID, id int64, lida bool) (bool, error)
+	MarcarTodasLidas(usuarioID int64) (int64, error)
+	UltimoID() (int64, error)
+	ListarPorUsuarioID(usuarioID int64) ([]dominio.Notificacao, error)
+	BuscarPreferencias(usuarioID int64) (map[string]bool, error)
+	SalvarPreferencias(usuarioID int64, preferencias map[string]bool) error
+}
+
+type notificacaoRepositorioSqlx struct {
+	db *sqlx.DB
+}
+
+// NovaNotificacaoRepositorio cria uma nova instância do repositório de notificações.
+func NovaNotificacaoRepositorio(db *sqlx.DB) NotificacaoRepositorio {
+	return &notificacaoRepositorioSqlx{db: db}
+}
+
+// colunasNotificacao seleciona a notificação com o nome de quem a causou.
+const colunasNotificacao = `SELECT n.*, u.nome AS nome_ator FROM notificacoes n LEFT JOIN usuarios u ON u.id = n.ator_id`
+
+// Criar insere a notificação e preenche o ID gerado.
+func (r *notificacaoRepositorioSqlx) Criar(n *dominio.Notificacao) error {
+	n.DataCriacao = time.Now().UTC()
+	query := `INSERT INTO notificacoes (usuario_id, tipo, ator_id, filme_id, avaliacao_id, comentario_id, texto, data_criacao)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	resultado, err := r.db.Exec(query, n.UsuarioID, n.Tipo, n.AtorID, n.FilmeID, n.AvaliacaoID, n.ComentarioID, n.Texto, n.DataCriacao)
+	if err != nil {
+		return err
+	}
+	n.ID, err = resultado.LastInsertId()
+	return err
+}
+
+// BuscarPorID retorna a notificação com o nome de quem a causou.
+func (r *notificacaoRepositorioSqlx) BuscarPorID(id int64) (*dominio.Notificacao, error) {
+	var notificacao dominio.Notificacao
+	if err := r.db.Get(&notificacao, colunasNotificacao+" WHERE n.id = ?", id); err != nil {
+		return nil, err
+	}
+	return &notificacao, nil
+}
+
+// Listar retorna as notificações do usuário, das mais novas para as mais antigas, a partir do
+// cursor antesDeID (0 para o início).
+func (r *notificacaoRepositorioSqlx) Listar(usuarioID int64, apenasNaoLidas bool, antesDeID int64, limite int) ([]dominio.Notificacao, error) {
+	var notificacoes []dominio.Notificacao
+	query := colunasNotificacao + ` WHERE n.usuario_id = ? AND (? = 0 OR n.lida = 0) AND (? = 0 OR n.id < ?)
+	          ORDER BY n.id DESC LIMIT ?`
+	err := r.db.Select(&notificacoes, query, usuarioID, apenasNaoLidas, antesDeID, antesDeID, limite)
+	return notificacoes, err
+}
+
+// ListarApos retorna as notificações de todos os usuários criadas depois de aposID, das mais
+// antigas para as mais novas.
+func (r *notificacaoRepositorioSqlx) ListarApos(aposID int64, limite int) ([]dominio.Notificacao, error) {
+	var notificacoes []dominio.Notificacao
+	query := colunasNotificacao + " WHERE n.id > ? ORDER BY n.id LIMIT ?"
+	err := r.db.Select(&notificacoes, query, aposID, limite)
+	return notificacoes, err
+}
+
+// ContarNaoLidas conta as notificações que o usuário ainda não leu.
+func (r *notificacaoRepositorioSqlx) ContarNaoLidas(usuarioID int64) (int, error) {
+	var total int
+	err := r.db.Get(&total, "SELECT COUNT(*) FROM notificacoes WHERE usuario_id = ? AND lida = 0", usuarioID)
+	return total, err
+}
+
+// MarcarLida marca uma notificação do usuário como lida ou não lida. Retorna false se ela não existir.
+func (r *notificacaoRepositorioSqlx) MarcarLida(usuarioID, id int64, lida bool) (bool, error) {
+	resultado, err := r.db.Exec("UPDATE notificacoes SET lida = ? WHERE id = ? AND usuario_id = ?", lida, id, usuarioID)
+	if err != nil {
+		return false, err
+	}
+	linhas, err := resultado.RowsAffected()
+	return linhas > 0, err
+}
+
+// MarcarTodasLidas marca como lidas todas as notificações do usuário e retorna quantas mudaram.
+func (r *notificacaoRepositorioSqlx) MarcarTodasLidas(usuarioID int64) (int64, error) {
+	resultado, err := r.db.Exec("UPDATE notificacoes SET lida = 1 WHERE usuario_id = ? AND lida = 0", usuarioID)
+	if err != nil {
+		return 0, err
+	}
+	return resultado.RowsAffected()
+}
+
+// UltimoID retorna o ID da notificação mais recente (0 se não houver nenhuma).
+func (r *notificacaoRepositorioSqlx) UltimoID() (int64, error) {
+	var id int64
+	err := r.db.Get(&id, "SELECT COALESCE(MAX(id), 0) FROM notificacoes")
+	return id, err
+}
+
+// ListarPorUsuarioID retorna todas as notificações do usuário em ordem cronológica.
+func (r *notificacaoRepositorioSqlx) ListarPorUsuarioID(usuarioID int64) ([]dominio.Notificacao, error) {
+	var notificacoes []dominio.Notificacao
+	err := r.db.Select(&notificacoes, colunasNotificacao+" WHERE n.usuario_id = ? ORDER BY n.id", usuarioID)
+	return notificacoes, err
+}
+
+// BuscarPreferencias retorna os tipos que o usuário já configurou; os demais seguem o padrão.
+func (r *notificacaoRepositorioSqlx) BuscarPreferencias(usuarioID int64) (map[string]bool, error) {
+	var linhas []struct {
+		Tipo  string `db:"tipo"`
+		Ativa bool   `db:"ativa"`
+	}
+	if err := r.db.Select(&linhas, "SELECT tipo, ativa FROM preferencias_notificacoes WHERE usuario_id = ?", usuarioID); err != nil {
+		return nil, err
+	}
+	preferencias := make(map[string]bool, len(linhas))
+	for _, linha := range linhas {
+		preferencias[linha.Tipo] = linha.Ativa
+	}
+	return preferencias, nil
+}
+
+// SalvarPreferencias grava, numa única transação, os tipos informados.
+func (r *notificacaoRepositorioSqlx) SalvarPreferencias(usuarioID int64, preferencias map[string]bool) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO preferencias_notificacoes (usuario_id, tipo, ativa) VALUES (?, ?, ?)
+	          ON CONFLICT (usuario_id, tipo) DO UPDATE SET ativa = excluded.ativa`
+	for tipo, ativa := range preferencias {
+		if _, err := tx.Exec(query, usuarioID, tipo, ativa); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
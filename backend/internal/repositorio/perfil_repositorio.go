@@ -0,0 +1,108 @@
+package repositorio
+
+import (
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/jmoiron/sqlx"
+)
+
+// PerfilRepositorio define a persistência dos perfis públicos.
+type PerfilRepositorio interface {
+	BuscarPorUsuarioID(usuarioID int64) (*dominio.Perfil, error)
+	BuscarPorSlug(slug string) (*dominio.Perfil, error)
+	Criar(perfil *dominio.Perfil) (bool, error)
+	Atualizar(perfil *dominio.Perfil) (bool, error)
+	Ocultar(usuarioID int64) (bool, error)
+	Estatisticas(usuarioID int64) (*dominio.EstatisticasPerfil, error)
+}
+
+type perfilRepositorioSqlx struct {
+	db *sqlx.DB
+}
+
+// NovoPerfilRepositorio cria uma nova instância do repositório de perfis.
+func NovoPerfilRepositorio(db *sqlx.DB) PerfilRepositorio {
+	return &perfilRepositorioSqlx{db: db}
+}
+
+// BuscarPorUsuarioID retorna o perfil do usuário.
+func (r *perfilRepositorioSqlx) BuscarPorUsuarioID(usuarioID int64) (*dominio.Perfil, error) {
+	var perfil dominio.Perfil
+	if err := r.db.Get(&perfil, "SELECT * FROM perfis WHERE usuario_id = ?", usuarioID); err != nil {
+		return nil, err
+	}
+	return &perfil, nil
+}
+
+// BuscarPorSlug encontra um perfil pelo endereço, sem diferenciar maiúsculas. Contas com
+// exclusão agendada e perfis ocultados pela moderação não aparecem.
+func (r *perfilRepositorioSqlx) BuscarPorSlug(slug string) (*dominio.Perfil, error) {
+	var perfil dominio.Perfil
+	query := `SELECT p.* FROM perfis p JOIN usuarios u ON u.id = p.usuario_id
+	          WHERE p.slug = ? AND u.exclusao_agendada_em IS NULL AND p.oculto = 0`
+	if err := r.db.Get(&perfil, query, slug); err != nil {
+		return nil, err
+	}
+	return &perfil, nil
+}
+
+// Criar insere o perfil. Retorna false se o slug já estiver em uso (ou o usuário já tiver perfil).
+func (r *perfilRepositorioSqlx) Criar(p *dominio.Perfil) (bool, error) {
+	p.DataAtualizacao = time.Now().UTC()
+	query := `INSERT OR IGNORE INTO perfis (usuario_id, slug, mostrar_favoritos, mostrar_listas,
+	              mostrar_avaliacoes, mostrar_estatisticas, mostrar_diario, data_atualizacao)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	resultado, err := r.db.Exec(query, p.UsuarioID, p.Slug, p.MostrarFavoritos, p.MostrarListas,
+		p.MostrarAvaliacoes, p.MostrarEstatisticas, p.MostrarDiario, p.DataAtualizacao)
+	if err != nil {
+		return false, err
+	}
+	linhas, err := resultado.RowsAffected()
+	return linhas > 0, err
+}
+
+// Atualizar grava o slug e a privacidade das seções. Retorna false se o slug já for de outro usuário.
+func (r *perfilRepositorioSqlx) Atualizar(p *dominio.Perfil) (bool, error) {
+	p.DataAtualizacao = time.Now().UTC()
+	query := `UPDATE OR IGNORE perfis SET slug = ?, mostrar_favoritos = ?, mostrar_listas = ?,
+	              mostrar_avaliacoes = ?, mostrar_estatisticas = ?, mostrar_diario = ?, data_atualizacao = ?
+	          WHERE usuario_id = ?`
+	resultado, err := r.db.Exec(query, p.Slug, p.MostrarFavoritos, p.MostrarListas,
+		p.MostrarAvaliacoes, p.MostrarEstatisticas, p.MostrarDiario, p.DataAtualizacao, p.UsuarioID)
+	if err != nil {
+		return false, err
+	}
+	linhas, err := resultado.RowsAffected()
+	return linhas > 0, err
+}
+
+// Ocultar tira o perfil do ar por decisão da moderação. Retorna false se o usuário não tiver perfil.
+func (r *perfilRepositorioSqlx) Ocultar(usuarioID int64) (bool, error) {
+	resultado, err := r.db.Exec("UPDATE perfis SET oculto = 1 WHERE usuario_id = ?", usuarioID)
+	if err != nil {
+		return false, err
+	}
+	linhas, err := resultado.RowsAffected()
+	return linhas > 0, err
+}
+
+// Estatisticas conta favoritos, listas públicas, avaliações publicadas e filmes assistidos do usuário.
+func (r *perfilRepositorioSqlx) Estatisticas(usuarioID int64) (*dominio.EstatisticasPerfil, error) {
+	var estatisticas dominio.EstatisticasPerfil
+	query := `SELECT
+	              (SELECT COUNT(*) FROM itens_lista i JOIN listas l ON l.id = i.lista_id
+	                  WHERE l.usuario_id = ? AND l.tipo = ?) AS favoritos,
+	              (SELECT COUNT(*) FROM listas WHERE usuario_id = ? AND visibilidade = ?) AS listas_publicas,
+	              (SELECT COUNT(*) FROM avaliacoes WHERE usuario_id = ? AND situacao = ?) AS avaliacoes,
+	              (SELECT COALESCE(AVG(nota_meias) / 2.0, 0) FROM avaliacoes
+	                  WHERE usuario_id = ? AND situacao = ?) AS media_notas,
+	              (SELECT COUNT(DISTINCT filme_id) FROM diario WHERE usuario_id = ?) AS filmes_assistidos`
+	err := r.db.Get(&estatisticas, query, usuarioID, dominio.TipoListaFavoritos,
+		usuarioID, dominio.VisibilidadePublica, usuarioID, dominio.SituacaoPublicada,
+		usuarioID, dominio.SituacaoPublicada, usuarioID)
+	if err != nil {
+		return nil, err
+	}
+	return &estatisticas, nil
+}
@@ -0,0 +1,39 @@
+package repositorio
+
+import (
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/jmoiron/sqlx"
+)
+
+// QuizRepositorio define a persistência do histórico de perguntas do quiz.
+type QuizRepositorio interface {
+	Registrar(registro *dominio.RegistroQuiz) error
+	ListarPorUsuarioID(usuarioID int64) ([]dominio.RegistroQuiz, error)
+}
+
+type quizRepositorioSqlx struct {
+	db *sqlx.DB
+}
+
+// NovoQuizRepositorio cria uma nova instância do repositório do quiz.
+func NovoQuizRepositorio(db *sqlx.DB) QuizRepositorio {
+	return &quizRepositorioSqlx{db: db}
+}
+
+// Registrar grava uma pergunta apresentada ao usuário.
+func (r *quizRepositorioSqlx) Registrar(q *dominio.RegistroQuiz) error {
+	query := `INSERT INTO historico_quiz (usuario_id, filme_id, pergunta, resposta_correta, data_criacao)
+	          VALUES (?, ?, ?, ?, ?)`
+	_, err := r.db.Exec(query, q.UsuarioID, q.FilmeID, q.Pergunta, q.RespostaCorreta, time.Now().UTC())
+	return err
+}
+
+// ListarPorUsuarioID retorna o histórico do quiz do usuário em ordem cronológica.
+func (r *quizRepositorioSqlx) ListarPorUsuarioID(usuarioID int64) ([]dominio.RegistroQuiz, error) {
+	var historico []dominio.RegistroQuiz
+	query := "SELECT * FROM historico_quiz WHERE usuario_id = ? ORDER BY data_criacao"
+	err := r.db.Select(&historico, query, usuarioID)
+	return historico, err
+}
@@ -0,0 +1,73 @@
+package repositorio
+
+import (
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/jmoiron/sqlx"
+)
+
+// SeguidorRepositorio define a persistência de quem segue quem.
+type SeguidorRepositorio interface {
+	Seguir(seguidorID, seguidoID int64) (bool, error)
+	DeixarDeSeguir(seguidorID, seguidoID int64) (bool, error)
+	ListarSeguidores(usuarioID, antesDeID int64, limite int) ([]dominio.UsuarioConexao, error)
+	ListarSeguindo(usuarioID, antesDeID int64, limite int) ([]dominio.UsuarioConexao, error)
+}
+
+type seguidorRepositorioSqlx struct {
+	db *sqlx.DB
+}
+
+// NovoSeguidorRepositorio cria uma nova instância do repositório de seguidores.
+func NovoSeguidorRepositorio(db *sqlx.DB) SeguidorRepositorio {
+	return &seguidorRepositorioSqlx{db: db}
+}
+
+// Seguir registra que seguidorID passou a seguir seguidoID. Retorna false se já seguia.
+func (r *seguidorRepositorioSqlx) Seguir(seguidorID, seguidoID int64) (bool, error) {
+	query := "INSERT OR IGNORE INTO seguidores (seguidor_id, seguido_id, data_criacao) VALUES (?, ?, ?)"
+	resultado, err := r.db.Exec(query, seguidorID, seguidoID, time.Now().UTC())
+	if err != nil {
+		return false, err
+	}
+	linhas, err := resultado.RowsAffected()
+	return linhas > 0, err
+}
+
+// DeixarDeSeguir desfaz o vínculo. Retorna false se seguidorID não seguia seguidoID.
+func (r *seguidorRepositorioSqlx) DeixarDeSeguir(seguidorID, seguidoID int64) (bool, error) {
+	resultado, err := r.db.Exec("DELETE FROM seguidores WHERE seguidor_id = ? AND seguido_id = ?", seguidorID, seguidoID)
+	if err != nil {
+		return false, err
+	}
+	linhas, err := resultado.RowsAffected()
+	return linhas > 0, err
+}
+
+// ListarSeguidores retorna quem segue o usuário, dos mais recentes para os mais antigos, a
+// partir do cursor antesDeID (0 para o início). Limite negativo traz todos. Contas com exclusão
+// agendada e perfis ocultados pela moderação não aparecem.
+func (r *seguidorRepositorioSqlx) ListarSeguidores(usuarioID, antesDeID int64, limite int) ([]dominio.UsuarioConexao, error) {
+	return r.listar("seguido_id", "seguidor_id", usuarioID, antesDeID, limite)
+}
+
+// ListarSeguindo retorna quem o usuário segue, nas mesmas condições de ListarSeguidores.
+func (r *seguidorRepositorioSqlx) ListarSeguindo(usuarioID, antesDeID int64, limite int) ([]dominio.UsuarioConexao, error) {
+	return r.listar("seguidor_id", "seguido_id", usuarioID, antesDeID, limite)
+}
+
+// listar filtra os vínculos pela coluna do usuário consultado e devolve os dados do outro lado.
+// As colunas vêm de constantes internas, nunca da requisição.
+func (r *seguidorRepositorioSqlx) listar(coluna, outro string, usuarioID, antesDeID int64, limite int) ([]dominio.UsuarioConexao, error) {
+	var conexoes []dominio.UsuarioConexao
+	query := `SELECT s.id, u.id AS usuario_id, u.nome, p.slug, s.data_criacao
+	          FROM seguidores s
+	          JOIN usuarios u ON u.id = s.` + outro + `
+	          LEFT JOIN perfis p ON p.usuario_id = u.id
+	          WHERE s.` + coluna + ` = ? AND (? = 0 OR s.id < ?)
+	              AND u.exclusao_agendada_em IS NULL AND COALESCE(p.oculto, 0) = 0
+	          ORDER BY s.id DESC LIMIT ?`
+	err := r.db.Select(&conexoes, query, usuarioID, antesDeID, antesDeID, limite)
+	return conexoes, err
+}
@@ -0,0 +1,101 @@
+package repositorio
+
+import (
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/jmoiron/sqlx"
+)
+
+// SessaoRepositorio define a persistência das sessões de login.
+type SessaoRepositorio interface {
+	Criar(sessao *dominio.Sessao) error
+	BuscarPorID(id string) (*dominio.Sessao, error)
+	ListarAtivas(usuarioID int64) ([]dominio.Sessao, error)
+	Revogar(usuarioID int64, id string) (bool, error)
+	RevogarOutras(usuarioID int64, atual string) (int64, error)
+	RevogarTodas(usuarioID int64) (int64, error)
+	RegistrarAcesso(id string, intervalo time.Duration) error
+}
+
+type sessaoRepositorioSqlx struct {
+	db *sqlx.DB
+}
+
+// NovoSessaoRepositorio cria uma nova instância do repositório de sessões.
+func NovoSessaoRepositorio(db *sqlx.DB) SessaoRepositorio {
+	return &sessaoRepositorioSqlx{db: db}
+}
+
+// Criar grava uma nova sessão e remove as do usuário que expiraram há mais de 30 dias.
+func (r *sessaoRepositorioSqlx) Criar(s *dominio.Sessao) error {
+	agora := time.Now().UTC()
+	limpeza := "DELETE FROM sessoes WHERE usuario_id = ? AND expira_em < ?"
+	if _, err := r.db.Exec(limpeza, s.UsuarioID, agora.AddDate(0, 0, -30)); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO sessoes (id, usuario_id, dispositivo, ip, data_criacao, ultimo_acesso, expira_em)
+	          VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Exec(query, s.ID, s.UsuarioID, s.Dispositivo, s.IP, agora, agora, s.ExpiraEm.UTC())
+	return err
+}
+
+// BuscarPorID encontra uma sessão pelo seu identificador.
+func (r *sessaoRepositorioSqlx) BuscarPorID(id string) (*dominio.Sessao, error) {
+	var sessao dominio.Sessao
+	if err := r.db.Get(&sessao, "SELECT * FROM sessoes WHERE id = ?", id); err != nil {
+		return nil, err
+	}
+	return &sessao, nil
+}
+
+// ListarAtivas retorna as sessões não revogadas e não expiradas, da mais recente para a mais antiga.
+func (r *sessaoRepositorioSqlx) ListarAtivas(usuarioID int64) ([]dominio.Sessao, error) {
+	var sessoes []dominio.Sessao
+	query := `SELECT * FROM sessoes
+	          WHERE usuario_id = ? AND revogada_em IS NULL AND expira_em > ?
+	          ORDER BY ultimo_acesso DESC`
+	err := r.db.Select(&sessoes, query, usuarioID, time.Now().UTC())
+	return sessoes, err
+}
+
+// Revogar encerra uma sessão do usuário e informa se ela existia e estava ativa.
+func (r *sessaoRepositorioSqlx) Revogar(usuarioID int64, id string) (bool, error) {
+	query := "UPDATE sessoes SET revogada_em = ? WHERE id = ? AND usuario_id = ? AND revogada_em IS NULL"
+	resultado, err := r.db.Exec(query, time.Now().UTC(), id, usuarioID)
+	if err != nil {
+		return false, err
+	}
+	linhas, err := resultado.RowsAffected()
+	return linhas > 0, err
+}
+
+// RevogarOutras encerra todas as sessões ativas do usuário, exceto a atual.
+func (r *sessaoRepositorioSqlx) RevogarOutras(usuarioID int64, atual string) (int64, error) {
+	query := "UPDATE sessoes SET revogada_em = ? WHERE usuario_id = ? AND id != ? AND revogada_em IS NULL"
+	resultado, err := r.db.Exec(query, time.Now().UTC(), usuarioID, atual)
+	if err != nil {
+		return 0, err
+	}
+	return resultado.RowsAffected()
+}
+
+// RevogarTodas encerra todas as sessões ativas do usuário, como na suspensão da conta.
+func (r *sessaoRepositorioSqlx) RevogarTodas(usuarioID int64) (int64, error) {
+	query := "UPDATE sessoes SET revogada_em = ? WHERE usuario_id = ? AND revogada_em IS NULL"
+	resultado, err := r.db.Exec(query, time.Now().UTC(), usuarioID)
+	if err != nil {
+		return 0, err
+	}
+	return resultado.RowsAffected()
+}
+
+// RegistrarAcesso atualiza o último acesso, no máximo uma vez por intervalo, para
+// não gerar uma escrita no banco a cada requisição.
+func (r *sessaoRepositorioSqlx) RegistrarAcesso(id string, intervalo time.Duration) error {
+	agora := time.Now().UTC()
+	query := "UPDATE sessoes SET ultimo_acesso = ? WHERE id = ? AND ultimo_acesso < ?"
+	_, err := r.db.Exec(query, agora, id, agora.Add(-intervalo))
+	return err
+}
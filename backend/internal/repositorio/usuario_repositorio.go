@@ -1,6 +1,9 @@
 package repositorio
 
 import (
+	"database/sql"
+	"time"
+
 	"github.com/Andydev0/filmes-backend/internal/dominio"
 	"github.com/jmoiron/sqlx"
 )
@@ -9,6 +12,18 @@ import (
 type UsuarioRepositorio interface {
 	Salvar(usuario *dominio.Usuario) error
 	BuscarPorEmail(email string) (*dominio.Usuario, error)
+	BuscarPorID(id int64) (*dominio.Usuario, error)
+	DefinirSegredoTOTP(usuarioID int64, segredo string) error
+	AtivarTOTP(usuarioID int64) error
+	DesativarTOTP(usuarioID int64) error
+	RegistrarPassoTOTP(usuarioID, passo int64) (bool, error)
+	RegistrarFalhaTOTP(usuarioID int64, limite int, bloqueio time.Duration) error
+	AgendarExclusao(usuarioID int64, data time.Time) error
+	CancelarExclusao(usuarioID int64) error
+	ExcluirAgendados(ate time.Time) (int64, error)
+	Suspender(usuarioID int64, ate time.Time) error
+	CriarVerificacaoEmail(usuarioID int64, email, tokenHash string, expiraEm time.Time) error
+	ConfirmarEmail(tokenHash string, agora time.Time) (bool, error)
 }
 
 // usuarioRepositorioSqlx é a implementação da interface usando sqlx.
@@ -21,10 +36,14 @@ func NovoUsuarioRepositorio(db *sqlx.DB) UsuarioRepositorio {
 	return &usuarioRepositorioSqlx{db: db}
 }
 
-// Salvar insere um novo usuário no banco de dados.
+// Salvar insere um novo usuário no banco de dados e preenche o ID gerado.
 func (r *usuarioRepositorioSqlx) Salvar(usuario *dominio.Usuario) error {
 	query := "INSERT INTO usuarios (nome, email, senha_hash) VALUES (?, ?, ?)"
-	_, err := r.db.Exec(query, usuario.Nome, usuario.Email, usuario.SenhaHash)
+	resultado, err := r.db.Exec(query, usuario.Nome, usuario.Email, usuario.SenhaHash)
+	if err != nil {
+		return err
+	}
+	usuario.ID, err = resultado.LastInsertId()
 	return err
 }
 
@@ -38,3 +57,174 @@ func (r *usuarioRepositorioSqlx) BuscarPorEmail(email string) (*dominio.Usuario,
 	}
 	return &usuario, nil
 }
+
+// BuscarPorID encontra um usuário pelo seu ID.
+func (r *usuarioRepositorioSqlx) BuscarPorID(id int64) (*dominio.Usuario, error) {
+	var usuario dominio.Usuario
+	query := "SELECT * FROM usuarios WHERE id = ?"
+	err := r.db.Get(&usuario, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &usuario, nil
+}
+
+// DefinirSegredoTOTP grava o segredo de um cadastro de 2FA ainda não confirmado.
+func (r *usuarioRepositorioSqlx) DefinirSegredoTOTP(usuarioID int64, segredo string) error {
+	query := "UPDATE usuarios SET totp_segredo = ?, totp_ativo = 0, totp_ultimo_passo = 0 WHERE id = ?"
+	_, err := r.db.Exec(query, segredo, usuarioID)
+	return err
+}
+
+// AtivarTOTP marca o 2FA como ativo depois que o usuário confirmou o primeiro código.
+func (r *usuarioRepositorioSqlx) AtivarTOTP(usuarioID int64) error {
+	_, err := r.db.Exec("UPDATE usuarios SET totp_ativo = 1 WHERE id = ? AND totp_segredo != ''", usuarioID)
+	return err
+}
+
+// DesativarTOTP apaga o segredo e os códigos de recuperação do usuário.
+func (r *usuarioRepositorioSqlx) DesativarTOTP(usuarioID int64) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE usuarios SET totp_segredo = '', totp_ativo = 0, totp_ultimo_passo = 0,
+	          totp_falhas = 0, totp_bloqueado_ate = NULL WHERE id = ?`
+	if _, err := tx.Exec(query, usuarioID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM codigos_recuperacao WHERE usuario_id = ?", usuarioID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RegistrarPassoTOTP grava o passo de tempo de um código aceito. Retorna false se
+// um código do mesmo passo (ou posterior) já foi usado, o que caracteriza replay.
+func (r *usuarioRepositorioSqlx) RegistrarPassoTOTP(usuarioID, passo int64) (bool, error) {
+	query := `UPDATE usuarios SET totp_ultimo_passo = ?, totp_falhas = 0, totp_bloqueado_ate = NULL
+	          WHERE id = ? AND totp_ultimo_passo < ?`
+	resultado, err := r.db.Exec(query, passo, usuarioID, passo)
+	if err != nil {
+		return false, err
+	}
+	linhas, err := resultado.RowsAffected()
+	return linhas > 0, err
+}
+
+// RegistrarFalhaTOTP conta uma tentativa errada e bloqueia novas tentativas ao atingir o limite.
+func (r *usuarioRepositorioSqlx) RegistrarFalhaTOTP(usuarioID int64, limite int, bloqueio time.Duration) error {
+	query := `UPDATE usuarios SET
+	              totp_bloqueado_ate = CASE WHEN totp_falhas + 1 >= ? THEN ? ELSE totp_bloqueado_ate END,
+	              totp_falhas = CASE WHEN totp_falhas + 1 >= ? THEN 0 ELSE totp_falhas + 1 END
+	          WHERE id = ?`
+	_, err := r.db.Exec(query, limite, time.Now().Add(bloqueio).UTC(), limite, usuarioID)
+	return err
+}
+
+// AgendarExclusao marca a conta para ser apagada na data informada.
+func (r *usuarioRepositorioSqlx) AgendarExclusao(usuarioID int64, data time.Time) error {
+	_, err := r.db.Exec("UPDATE usuarios SET exclusao_agendada_em = ? WHERE id = ?", data.UTC(), usuarioID)
+	return err
+}
+
+// CancelarExclusao remove o agendamento de exclusão da conta, se houver.
+func (r *usuarioRepositorioSqlx) CancelarExclusao(usuarioID int64) error {
+	query := "UPDATE usuarios SET exclusao_agendada_em = NULL WHERE id = ? AND exclusao_agendada_em IS NOT NULL"
+	_, err := r.db.Exec(query, usuarioID)
+	return err
+}
+
+// Suspender bloqueia o login do usuário até a data informada.
+func (r *usuarioRepositorioSqlx) Suspender(usuarioID int64, ate time.Time) error {
+	_, err := r.db.Exec("UPDATE usuarios SET suspenso_ate = ? WHERE id = ?", ate.UTC(), usuarioID)
+	return err
+}
+
+// CriarVerificacaoEmail grava o token de confirmação do e-mail, substituindo os enviados antes.
+func (r *usuarioRepositorioSqlx) CriarVerificacaoEmail(usuarioID int64, email, tokenHash string, expiraEm time.Time) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM verificacoes_email WHERE usuario_id = ?", usuarioID); err != nil {
+		return err
+	}
+	query := "INSERT INTO verificacoes_email (token_hash, usuario_id, email, expira_em) VALUES (?, ?, ?, ?)"
+	if _, err := tx.Exec(query, tokenHash, usuarioID, email, expiraEm.UTC()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ConfirmarEmail consome o token e marca o e-mail da conta como verificado. Retorna false se o
+// token não existe, expirou ou foi enviado para um endereço que não é mais o da conta.
+func (r *usuarioRepositorioSqlx) ConfirmarEmail(tokenHash string, agora time.Time) (bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var verificacao struct {
+		UsuarioID int64     `db:"usuario_id"`
+		Email     string    `db:"email"`
+		ExpiraEm  time.Time `db:"expira_em"`
+	}
+	query := "SELECT usuario_id, email, expira_em FROM verificacoes_email WHERE token_hash = ?"
+	if err := tx.Get(&verificacao, query, tokenHash); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	// O token é de uso único, mesmo quando não confirma nada.
+	if _, err := tx.Exec("DELETE FROM verificacoes_email WHERE token_hash = ?", tokenHash); err != nil {
+		return false, err
+	}
+
+	confirmado := false
+	if agora.Before(verificacao.ExpiraEm) {
+		resultado, err := tx.Exec("UPDATE usuarios SET email_verificado = 1 WHERE id = ? AND email = ?", verificacao.UsuarioID, verificacao.Email)
+		if err != nil {
+			return false, err
+		}
+		linhas, err := resultado.RowsAffected()
+		if err != nil {
+			return false, err
+		}
+		confirmado = linhas > 0
+	}
+	return confirmado, tx.Commit()
+}
+
+// ExcluirAgendados apaga as contas cujo prazo de carência terminou. As chaves estrangeiras
+// removem os dados pessoais em cascata e anonimizam as avaliações; as edições retidas delas, que
+// ficariam sem autor, são apagadas antes.
+func (r *usuarioRepositorioSqlx) ExcluirAgendados(ate time.Time) (int64, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	const agendados = "SELECT id FROM usuarios WHERE exclusao_agendada_em IS NOT NULL AND exclusao_agendada_em <= ?"
+	query := "DELETE FROM edicoes_pendentes_avaliacoes WHERE avaliacao_id IN (SELECT id FROM avaliacoes WHERE usuario_id IN (" + agendados + "))"
+	if _, err := tx.Exec(query, ate.UTC()); err != nil {
+		return 0, err
+	}
+
+	resultado, err := tx.Exec("DELETE FROM usuarios WHERE id IN ("+agendados+")", ate.UTC())
+	if err != nil {
+		return 0, err
+	}
+	excluidas, err := resultado.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return excluidas, tx.Commit()
+}
@@ -5,9 +5,9 @@ import (
 	"errors"
 	"time"
 
+	"github.com/Andydev0/filmes-backend/internal/auth"
 	"github.com/Andydev0/filmes-backend/internal/dominio"
 	"github.com/Andydev0/filmes-backend/internal/repositorio"
-	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -15,8 +15,13 @@ import (
 var (
 	ErrEmailJaExiste        = errors.New("o e-mail fornecido já está em uso")
 	ErrCredenciaisInvalidas = errors.New("credenciais inválidas")
+	ErrDesafioInvalido      = errors.New("desafio de login inválido ou expirado")
+	ErrContaSuspensa        = errors.New("conta suspensa pela moderação")
 )
 
+// validadeDesafio2FA é o tempo para informar o código após acertar a senha.
+const validadeDesafio2FA = 5 * time.Minute
+
 // RegistroInput define os campos para o body do request de registro.
 type RegistroInput struct {
 	Nome  string `json:"nome" binding:"required"`
@@ -30,23 +35,44 @@ type LoginInput struct {
 	Senha string `json:"senha" binding:"required"`
 }
 
+// Login2FAInput define os campos do segundo passo do login com 2FA.
+type Login2FAInput struct {
+	Desafio string `json:"desafio" binding:"required"`
+	Codigo  string `json:"codigo" binding:"required"`
+}
+
+// RespostaLogin traz o token de acesso ou, quando a conta tem 2FA, o desafio
+// que deve ser enviado junto com o código em /auth/login/2fa.
+type RespostaLogin struct {
+	Token     string `json:"token,omitempty"`
+	Requer2FA bool   `json:"requer2fa,omitempty"`
+	Desafio   string `json:"desafio,omitempty"`
+}
+
 // AuthServico é a interface que define os contratos do nosso serviço de autenticação.
 type AuthServico interface {
 	Registrar(input RegistroInput) (*dominio.Usuario, error)
-	Login(input LoginInput) (string, error)
+	Login(input LoginInput, cliente InfoCliente) (*RespostaLogin, error)
+	ConcluirLogin2FA(input Login2FAInput, cliente InfoCliente) (*RespostaLogin, error)
+	IniciarSessao(usuario *dominio.Usuario, cliente InfoCliente) (*RespostaLogin, error)
+	ConexaoAtiva(sessaoID string, usuarioID int64) (bool, error)
 }
 
 // authServicoImpl é a implementação da interface AuthServico.
 type authServicoImpl struct {
-	repo      repositorio.UsuarioRepositorio
-	jwtSecret string
+	repo        repositorio.UsuarioRepositorio
+	chaves      *auth.ConjuntoChaves
+	doisFatores DoisFatoresServico
+	sessoes     SessaoServico
 }
 
 // NovoAuthServico cria a instância do serviço de autenticação com suas dependências.
-func NovoAuthServico(repo repositorio.UsuarioRepositorio, jwtSecret string) AuthServico {
+func NovoAuthServico(repo repositorio.UsuarioRepositorio, chaves *auth.ConjuntoChaves, doisFatores DoisFatoresServico, sessoes SessaoServico) AuthServico {
 	return &authServicoImpl{
-		repo:      repo,
-		jwtSecret: jwtSecret,
+		repo:        repo,
+		chaves:      chaves,
+		doisFatores: doisFatores,
+		sessoes:     sessoes,
 	}
 }
 
@@ -82,39 +108,119 @@ func (s *authServicoImpl) Registrar(input RegistroInput) (*dominio.Usuario, erro
 	return novoUsuario, nil
 }
 
-// Login executa a lógica de autenticação e retorna um token JWT.
-func (s *authServicoImpl) Login(input LoginInput) (string, error) {
+// Login executa a lógica de autenticação e retorna um token JWT
+// (ou um desafio, se a conta tiver 2FA ativo).
+func (s *authServicoImpl) Login(input LoginInput, cliente InfoCliente) (*RespostaLogin, error) {
 	// Busca o usuário pelo email.
 	usuario, err := s.repo.BuscarPorEmail(input.Email)
 	if err != nil {
 		// Retorna erro genérico se o usuário não for encontrado.
 		if err == sql.ErrNoRows {
-			return "", ErrCredenciaisInvalidas
+			return nil, ErrCredenciaisInvalidas
 		}
-		return "", err
+		return nil, err
 	}
 
 	// Compara a senha enviada com o hash salvo no banco.
 	err = bcrypt.CompareHashAndPassword([]byte(usuario.SenhaHash), []byte(input.Senha))
 	if err != nil {
 		// Se a senha não bate, retorna o mesmo erro genérico.
-		return "", ErrCredenciaisInvalidas
+		return nil, ErrCredenciaisInvalidas
+	}
+
+	return s.IniciarSessao(usuario, cliente)
+}
+
+// IniciarSessao conclui o primeiro fator de autenticação (senha ou provedor externo).
+// Sem 2FA, emite o token de acesso; com 2FA, emite apenas o desafio de curta duração.
+func (s *authServicoImpl) IniciarSessao(usuario *dominio.Usuario, cliente InfoCliente) (*RespostaLogin, error) {
+	if suspenso(usuario) {
+		return nil, ErrContaSuspensa
+	}
+	if usuario.TOTPAtivo {
+		desafio, err := s.chaves.EmitirDesafio2FA(usuario.ID, validadeDesafio2FA)
+		if err != nil {
+			return nil, err
+		}
+		return &RespostaLogin{Requer2FA: true, Desafio: desafio}, nil
+	}
+
+	return s.emitirToken(usuario.ID, cliente)
+}
+
+// ConcluirLogin2FA valida o desafio e o código (TOTP ou de recuperação) e emite o token de acesso.
+func (s *authServicoImpl) ConcluirLogin2FA(input Login2FAInput, cliente InfoCliente) (*RespostaLogin, error) {
+	claims, err := s.chaves.ValidarDesafio2FA(input.Desafio)
+	if err != nil {
+		return nil, ErrDesafioInvalido
+	}
+	usuarioID, err := claims.UsuarioID()
+	if err != nil {
+		return nil, ErrDesafioInvalido
+	}
+
+	usuario, err := s.repo.BuscarPorID(usuarioID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrDesafioInvalido
+		}
+		return nil, err
 	}
 
-	// Define as informações (claims) que irão no payload do token.
-	claims := jwt.MapClaims{
-		"sub": usuario.ID,                           // Subject (ID do usuário)
-		"exp": time.Now().Add(time.Hour * 8).Unix(), // Expiração do token
+	// O 2FA pode ter sido desativado entre os dois passos; nesse caso o desafio perde a validade.
+	if !usuario.TOTPAtivo {
+		return nil, ErrDesafioInvalido
+	}
+	if suspenso(usuario) {
+		return nil, ErrContaSuspensa
 	}
+	if err := s.doisFatores.VerificarCodigo(usuario, input.Codigo); err != nil {
+		return nil, err
+	}
+
+	return s.emitirToken(usuario.ID, cliente)
+}
 
-	// Cria o token com o método de assinatura e as claims.
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+// emitirToken registra a sessão do dispositivo e emite o token de acesso vinculado a ela.
+// O 'kid' no cabeçalho do token permite a rotação de chaves.
+func (s *authServicoImpl) emitirToken(usuarioID int64, cliente InfoCliente) (*RespostaLogin, error) {
+	// Entrar na conta durante o prazo de carência desfaz o pedido de exclusão.
+	if err := s.repo.CancelarExclusao(usuarioID); err != nil {
+		return nil, err
+	}
 
-	// Assina o token com a chave secreta e o retorna como uma string.
-	tokenString, err := token.SignedString([]byte(s.jwtSecret))
+	sessao, err := s.sessoes.Criar(usuarioID, cliente, time.Now().Add(s.chaves.Duracao))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return tokenString, nil
+	token, err := s.chaves.Emitir(usuarioID, sessao.ID, sessao.ExpiraEm)
+	if err != nil {
+		return nil, err
+	}
+	return &RespostaLogin{Token: token}, nil
+}
+
+// ConexaoAtiva é usada por conexões longas, como o stream de notificações, para confirmar
+// periodicamente que a sessão não foi revogada e que a conta não foi suspensa nem agendada
+// para exclusão desde que o token foi validado.
+func (s *authServicoImpl) ConexaoAtiva(sessaoID string, usuarioID int64) (bool, error) {
+	ativa, err := s.sessoes.SessaoAtiva(sessaoID, usuarioID)
+	if err != nil || !ativa {
+		return false, err
+	}
+
+	usuario, err := s.repo.BuscarPorID(usuarioID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return !suspenso(usuario) && usuario.ExclusaoAgendadaEm == nil, nil
+}
+
+// suspenso informa se a conta está com o login bloqueado por uma suspensão da moderação.
+func suspenso(usuario *dominio.Usuario) bool {
+	return usuario.SuspensoAte != nil && usuario.SuspensoAte.After(time.Now())
 }
@@ -0,0 +1,86 @@
+package servico_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/auth"
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cenarioAuth struct {
+	usuarioRepo repositorio.UsuarioRepositorio
+	sessaoRepo  repositorio.SessaoRepositorio
+	servico     servico.AuthServico
+	usuario     *dominio.Usuario
+	sessaoID    string
+}
+
+// novoCenarioAuth cadastra um usuário e abre uma sessão para ele.
+func novoCenarioAuth(t *testing.T) *cenarioAuth {
+	t.Helper()
+
+	db := novoBanco(t)
+	chave, err := auth.NovaChaveHMAC("teste", []byte("segredo-de-teste-com-tamanho-suficiente"))
+	require.NoError(t, err)
+	chaves, err := auth.NovoConjuntoChaves("cinehub", "cinehub-api", chave)
+	require.NoError(t, err)
+
+	c := &cenarioAuth{
+		usuarioRepo: repositorio.NovoUsuarioRepositorio(db),
+		sessaoRepo:  repositorio.NovoSessaoRepositorio(db),
+		usuario:     novoUsuario(t, db, "Ana", "ana@example.com"),
+	}
+	doisFatores := servico.NovoDoisFatoresServico(c.usuarioRepo, repositorio.NovoCodigoRecuperacaoRepositorio(db))
+	c.servico = servico.NovoAuthServico(c.usuarioRepo, chaves, doisFatores, servico.NovoSessaoServico(c.sessaoRepo))
+
+	resposta, err := c.servico.IniciarSessao(c.usuario, servico.InfoCliente{})
+	require.NoError(t, err)
+	claims, err := chaves.Validar(resposta.Token)
+	require.NoError(t, err)
+	c.sessaoID = claims.SessaoID
+	return c
+}
+
+func (c *cenarioAuth) conexaoAtiva(t *testing.T) bool {
+	t.Helper()
+
+	ativa, err := c.servico.ConexaoAtiva(c.sessaoID, c.usuario.ID)
+	require.NoError(t, err)
+	return ativa
+}
+
+func TestConexaoAtivaComSessaoValida(t *testing.T) {
+	c := novoCenarioAuth(t)
+	assert.True(t, c.conexaoAtiva(t))
+
+	ativa, err := c.servico.ConexaoAtiva(c.sessaoID, c.usuario.ID+1)
+	require.NoError(t, err)
+	assert.False(t, ativa)
+}
+
+func TestConexaoAtivaEncerraComSessaoRevogada(t *testing.T) {
+	c := novoCenarioAuth(t)
+
+	_, err := c.sessaoRepo.RevogarTodas(c.usuario.ID)
+	require.NoError(t, err)
+	assert.False(t, c.conexaoAtiva(t))
+}
+
+func TestConexaoAtivaEncerraComContaSuspensa(t *testing.T) {
+	c := novoCenarioAuth(t)
+
+	require.NoError(t, c.usuarioRepo.Suspender(c.usuario.ID, time.Now().Add(time.Hour)))
+	assert.False(t, c.conexaoAtiva(t))
+}
+
+func TestConexaoAtivaEncerraComExclusaoAgendada(t *testing.T) {
+	c := novoCenarioAuth(t)
+
+	require.NoError(t, c.usuarioRepo.AgendarExclusao(c.usuario.ID, time.Now().Add(24*time.Hour)))
+	assert.False(t, c.conexaoAtiva(t))
+}
@@ -1,38 +1,537 @@
 package servico
 
 import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
 	"github.com/Andydev0/filmes-backend/internal/dominio"
 	"github.com/Andydev0/filmes-backend/internal/repositorio"
 )
 
+// Define os erros que o serviço de avaliações pode retornar.
+var (
+	ErrAvaliacaoNaoEncontrada   = errors.New("avaliação não encontrada")
+	ErrDecadaInvalida           = errors.New("a década deve ser um ano terminado em zero, como 1990")
+	ErrOrdenacaoAvaliacoes      = errors.New("ordenação inválida; use recentes, uteis, nota_alta ou nota_baixa")
+	ErrReacaoPropria            = errors.New("não é possível reagir à própria avaliação")
+	ErrReacaoNaoEncontrada      = errors.New("você não reagiu a esta avaliação")
+	ErrAvaliacaoNaoPendente     = errors.New("a avaliação não está pendente de moderação")
+	ErrCursorAvaliacoesInvalido = errors.New("cursor inválido para esta consulta")
+	ErrFiltroNotaInvalido       = errors.New("a nota do filtro deve ir de 0.5 a 5, em meias estrelas")
+	ErrNotaInvalida             = errors.New("a nota deve ir de 0.5 a 5, em meias estrelas")
+)
+
+// ErroComentarioLongo indica um comentário acima do tamanho máximo configurado.
+type ErroComentarioLongo struct{ Maximo int }
+
+func (e *ErroComentarioLongo) Error() string {
+	return fmt.Sprintf("o comentário pode ter no máximo %d caracteres", e.Maximo)
+}
+
+// ErroAvaliacaoRejeitada indica uma avaliação recusada pelo filtro de conteúdo, com os motivos.
+type ErroAvaliacaoRejeitada struct{ Motivos []string }
+
+func (e *ErroAvaliacaoRejeitada) Error() string {
+	return "a avaliação foi recusada pelo filtro de conteúdo"
+}
+
+const (
+	// VotosMinimosRanking é quantas avaliações um filme precisa, por padrão, para entrar no ranking.
+	VotosMinimosRanking = 5
+
+	// LimitePadraoRanking e LimiteMaximoRanking definem o tamanho do ranking.
+	LimitePadraoRanking = 20
+	LimiteMaximoRanking = 100
+
+	// LimitePadraoAvaliacoes e LimiteMaximoAvaliacoes definem o tamanho das páginas de avaliações.
+	LimitePadraoAvaliacoes = 20
+	LimiteMaximoAvaliacoes = 100
+)
+
+// AvaliacaoInput traz a nota em estrelas, de 0.5 a 5 em meias estrelas; as notas inteiras de 1 a
+// 5 dos clientes anteriores às meias estrelas continuam valendo.
 type AvaliacaoInput struct {
-	Nota       int    `json:"nota" binding:"required,min=1,max=5"`
-	Comentario string `json:"comentario"`
+	Nota       float64 `json:"nota" binding:"required,min=0.5,max=5"`
+	Comentario string  `json:"comentario"`
+	Spoiler    bool    `json:"spoiler"`
+}
+
+// ReacaoInput define o tipo de reação a uma avaliação.
+type ReacaoInput struct {
+	Tipo string `json:"tipo" binding:"required,oneof=util curtida"`
+}
+
+// HistoricoAvaliacao é a avaliação atual (nula se excluída) com suas versões anteriores.
+type HistoricoAvaliacao struct {
+	Atual   *dominio.Avaliacao        `json:"atual"`
+	Versoes []dominio.VersaoAvaliacao `json:"versoes"`
+}
+
+// ConsultaAvaliacoes descreve uma página de GET /filmes/:id/avaliacoes. Sem MostrarSpoilers,
+// os comentários marcados como spoiler vêm vazios.
+type ConsultaAvaliacoes struct {
+	Ordenar         string
+	MostrarSpoilers bool
+	Cursor          string
+	Limite          int
+}
+
+// ConsultaRanking descreve um GET /ranking. Campos zerados usam os padrões ou não filtram.
+type ConsultaRanking struct {
+	GeneroID     int
+	Decada       int
+	VotosMinimos int
+	Limite       int
 }
 
 type AvaliacaoServico interface {
-	Criar(usuarioID, filmeID int64, input AvaliacaoInput) error
-	ListarPorFilme(filmeID int64) ([]dominio.AvaliacaoComUsuario, error)
+	Salvar(usuarioID, filmeID int64, input AvaliacaoInput) (*dominio.Avaliacao, bool, error)
+	BuscarDoUsuario(usuarioID, filmeID int64) (*dominio.Avaliacao, error)
+	Excluir(usuarioID, filmeID int64) error
+	ListarPorFilme(filmeID int64, consulta ConsultaAvaliacoes, leitorID int64) ([]dominio.AvaliacaoComUsuario, string, error)
+	Reagir(usuarioID, avaliacaoID int64, input ReacaoInput) error
+	RemoverReacao(usuarioID, avaliacaoID int64) error
+	Historico(avaliacaoID int64) (*HistoricoAvaliacao, error)
+	ListarPendentes() ([]dominio.AvaliacaoPendente, error)
+	Aprovar(avaliacaoID int64) error
+	Rejeitar(avaliacaoID int64) error
+	Estatisticas(filmeID, usuarioID int64) (*dominio.EstatisticasFilme, error)
+	Ranking(consulta ConsultaRanking) ([]dominio.ItemRanking, error)
 }
 
 type avaliacaoServicoImpl struct {
-	repo repositorio.AvaliacaoRepositorio
+	repo          repositorio.AvaliacaoRepositorio
+	atividadeRepo repositorio.AtividadeRepositorio
+	catalogo      CatalogoServico
+	config        *ConfigAvaliacoes
 }
 
-func NovaAvaliacaoServico(repo repositorio.AvaliacaoRepositorio) AvaliacaoServico {
-	return &avaliacaoServicoImpl{repo: repo}
+func NovaAvaliacaoServico(repo repositorio.AvaliacaoRepositorio, atividadeRepo repositorio.AtividadeRepositorio, catalogo CatalogoServico, config *ConfigAvaliacoes) AvaliacaoServico {
+	return &avaliacaoServicoImpl{repo: repo, atividadeRepo: atividadeRepo, catalogo: catalogo, config: config}
 }
 
-func (s *avaliacaoServicoImpl) Criar(usuarioID, filmeID int64, input AvaliacaoInput) error {
+// Salvar cria ou edita a avaliação do usuário para o filme. Retorna a avaliação gravada e se ela foi criada.
+// O comentário passa pelo filtro de conteúdo: se for retido, a avaliação nova fica pendente até a
+// moderação, e a edição de uma avaliação publicada fica guardada à parte, com a versão publicada
+// no ar; se for rejeitado, nada é gravado além da decisão do filtro.
+func (s *avaliacaoServicoImpl) Salvar(usuarioID, filmeID int64, input AvaliacaoInput) (*dominio.Avaliacao, bool, error) {
+	nota, ok := dominio.NotaDeEstrelas(input.Nota)
+	if !ok {
+		return nil, false, ErrNotaInvalida
+	}
+	if utf8.RuneCountInString(input.Comentario) > s.config.TamanhoMaximoComentario {
+		return nil, false, &ErroComentarioLongo{Maximo: s.config.TamanhoMaximoComentario}
+	}
+
+	resultado := s.config.Filtro.Analisar(input.Comentario)
+	decisao := &dominio.DecisaoFiltro{
+		UsuarioID:   &usuarioID,
+		FilmeID:     filmeID,
+		Acao:        resultado.Acao,
+		Motivos:     strings.Join(resultado.Motivos, ","),
+		Texto:       input.Comentario,
+		DataCriacao: time.Now().UTC(),
+	}
+	if resultado.Acao == dominio.AcaoFiltroRejeitar {
+		if err := s.repo.RegistrarDecisaoFiltro(decisao); err != nil {
+			return nil, false, err
+		}
+		return nil, false, &ErroAvaliacaoRejeitada{Motivos: resultado.Motivos}
+	}
+
 	avaliacao := &dominio.Avaliacao{
 		UsuarioID:  usuarioID,
 		FilmeID:    filmeID,
-		Nota:       input.Nota,
+		Nota:       nota,
 		Comentario: input.Comentario,
+		Spoiler:    input.Spoiler,
+		Situacao:   dominio.SituacaoPublicada,
+	}
+	if resultado.Acao == dominio.AcaoFiltroReter {
+		avaliacao.Situacao = dominio.SituacaoPendente
+
+		publicada, err := s.repo.BuscarPorFilmeDoUsuario(usuarioID, filmeID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, false, err
+		}
+		if publicada != nil && publicada.Situacao == dominio.SituacaoPublicada {
+			return s.reterEdicao(publicada, avaliacao, decisao)
+		}
+	}
+	criada, err := s.repo.Salvar(avaliacao)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if resultado.Acao == dominio.AcaoFiltroReter {
+		decisao.AvaliacaoID = &avaliacao.ID
+		if err := s.repo.RegistrarDecisaoFiltro(decisao); err != nil {
+			return nil, false, err
+		}
+	} else {
+		s.registrarAtividade(avaliacao)
+	}
+	return avaliacao, criada, nil
+}
+
+// reterEdicao guarda a edição retida de uma avaliação publicada sem alterar a versão no ar e
+// retorna a edição, pendente, para o autor.
+func (s *avaliacaoServicoImpl) reterEdicao(publicada, edicao *dominio.Avaliacao, decisao *dominio.DecisaoFiltro) (*dominio.Avaliacao, bool, error) {
+	agora := time.Now().UTC()
+	err := s.repo.SalvarEdicaoPendente(&dominio.EdicaoPendente{
+		AvaliacaoID: publicada.ID,
+		Nota:        edicao.Nota,
+		Comentario:  edicao.Comentario,
+		Spoiler:     edicao.Spoiler,
+		DataCriacao: agora,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	decisao.AvaliacaoID = &publicada.ID
+	if err := s.repo.RegistrarDecisaoFiltro(decisao); err != nil {
+		return nil, false, err
+	}
+
+	edicao.ID, edicao.DataCriacao, edicao.DataAtualizacao = publicada.ID, publicada.DataCriacao, agora
+	return edicao, false, nil
+}
+
+// BuscarDoUsuario retorna a avaliação que o usuário fez do filme.
+func (s *avaliacaoServicoImpl) BuscarDoUsuario(usuarioID, filmeID int64) (*dominio.Avaliacao, error) {
+	avaliacao, err := s.repo.BuscarPorFilmeDoUsuario(usuarioID, filmeID)
+	if err == sql.ErrNoRows {
+		return nil, ErrAvaliacaoNaoEncontrada
+	}
+	return avaliacao, err
+}
+
+// Excluir apaga a avaliação do usuário para o filme; ela continua no histórico de moderação.
+func (s *avaliacaoServicoImpl) Excluir(usuarioID, filmeID int64) error {
+	excluida, err := s.repo.Deletar(usuarioID, filmeID)
+	if err != nil {
+		return err
+	}
+	if !excluida {
+		return ErrAvaliacaoNaoEncontrada
+	}
+	return nil
+}
+
+// ListarPorFilme retorna uma página das avaliações publicadas do filme e o cursor opaco da
+// próxima ("" na última). Ordenar vazio lista as mais recentes primeiro; leitorID, quando
+// diferente de zero, marca a reação do usuário logado em cada avaliação.
+func (s *avaliacaoServicoImpl) ListarPorFilme(filmeID int64, consulta ConsultaAvaliacoes, leitorID int64) ([]dominio.AvaliacaoComUsuario, string, error) {
+	filtro := repositorio.FiltroAvaliacoes{
+		Ordenar: consulta.Ordenar,
+		Limite:  limiteAvaliacoes(consulta.Limite),
+	}
+	switch filtro.Ordenar {
+	case "":
+		filtro.Ordenar = repositorio.OrdenarAvaliacoesRecentes
+	case repositorio.OrdenarAvaliacoesRecentes, repositorio.OrdenarAvaliacoesUteis,
+		repositorio.OrdenarAvaliacoesNotaAlta, repositorio.OrdenarAvaliacoesNotaBaixa:
+	default:
+		return nil, "", ErrOrdenacaoAvaliacoes
+	}
+	if consulta.Cursor != "" {
+		apos, err := decodificarCursorAvaliacoes(consulta.Cursor, filtro.Ordenar)
+		if err != nil {
+			return nil, "", err
+		}
+		filtro.Apos = apos
+	}
+
+	avaliacoes, proximo, err := s.repo.BuscarPorFilmeID(filmeID, filtro, leitorID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !consulta.MostrarSpoilers {
+		for i := range avaliacoes {
+			if avaliacoes[i].Spoiler {
+				avaliacoes[i].Comentario = ""
+			}
+		}
+	}
+	if proximo == nil {
+		return avaliacoes, "", nil
+	}
+	return avaliacoes, codificarCursorAvaliacoes(*proximo, filtro.Ordenar), nil
+}
+
+// Reagir marca a avaliação de outro usuário como útil ou curtida. Cada usuário tem uma reação
+// por avaliação; reagir de novo troca o tipo.
+func (s *avaliacaoServicoImpl) Reagir(usuarioID, avaliacaoID int64, input ReacaoInput) error {
+	avaliacao, err := s.repo.BuscarPorID(avaliacaoID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrAvaliacaoNaoEncontrada
+		}
+		return err
+	}
+	if avaliacao.Situacao != dominio.SituacaoPublicada {
+		return ErrAvaliacaoNaoEncontrada
+	}
+	if avaliacao.UsuarioID == usuarioID {
+		return ErrReacaoPropria
+	}
+
+	return s.repo.Reagir(&dominio.ReacaoAvaliacao{
+		AvaliacaoID: avaliacaoID,
+		UsuarioID:   usuarioID,
+		Tipo:        input.Tipo,
+		DataCriacao: time.Now().UTC(),
+	})
+}
+
+// RemoverReacao desfaz a reação do usuário à avaliação.
+func (s *avaliacaoServicoImpl) RemoverReacao(usuarioID, avaliacaoID int64) error {
+	removida, err := s.repo.RemoverReacao(avaliacaoID, usuarioID)
+	if err != nil {
+		return err
+	}
+	if !removida {
+		return ErrReacaoNaoEncontrada
+	}
+	return nil
+}
+
+// Historico retorna, para a moderação, a avaliação e todas as suas versões anteriores.
+func (s *avaliacaoServicoImpl) Historico(avaliacaoID int64) (*HistoricoAvaliacao, error) {
+	atual, err := s.repo.BuscarPorID(avaliacaoID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	versoes, err := s.repo.ListarHistorico(avaliacaoID)
+	if err != nil {
+		return nil, err
+	}
+	if atual == nil && len(versoes) == 0 {
+		return nil, ErrAvaliacaoNaoEncontrada
+	}
+	if versoes == nil {
+		versoes = make([]dominio.VersaoAvaliacao, 0)
+	}
+	return &HistoricoAvaliacao{Atual: atual, Versoes: versoes}, nil
+}
+
+// ListarPendentes retorna, para a moderação, as avaliações retidas pelo filtro de conteúdo.
+func (s *avaliacaoServicoImpl) ListarPendentes() ([]dominio.AvaliacaoPendente, error) {
+	return s.repo.ListarPendentes()
+}
+
+// Aprovar publica uma avaliação retida pelo filtro ou a edição retida de uma avaliação publicada.
+func (s *avaliacaoServicoImpl) Aprovar(avaliacaoID int64) error {
+	avaliacao, err := s.buscarAvaliacao(avaliacaoID)
+	if err != nil {
+		return err
+	}
+
+	if avaliacao.Situacao != dominio.SituacaoPendente {
+		aplicada, err := s.repo.AplicarEdicaoPendente(avaliacaoID)
+		if err != nil {
+			return err
+		}
+		if !aplicada {
+			return ErrAvaliacaoNaoPendente
+		}
+		return nil
+	}
+
+	publicada, err := s.repo.Publicar(avaliacaoID)
+	if err != nil {
+		return err
+	}
+	if !publicada {
+		return ErrAvaliacaoNaoPendente
+	}
+	s.registrarAtividade(avaliacao)
+	return nil
+}
+
+// Rejeitar exclui uma avaliação retida que nunca foi publicada; ela continua no histórico de
+// moderação. A edição retida de uma avaliação publicada é apenas descartada, e a versão publicada
+// continua no ar com seus comentários e reações.
+func (s *avaliacaoServicoImpl) Rejeitar(avaliacaoID int64) error {
+	avaliacao, err := s.buscarAvaliacao(avaliacaoID)
+	if err != nil {
+		return err
+	}
+
+	if avaliacao.Situacao != dominio.SituacaoPendente {
+		descartada, err := s.repo.DescartarEdicaoPendente(avaliacaoID)
+		if err != nil {
+			return err
+		}
+		if !descartada {
+			return ErrAvaliacaoNaoPendente
+		}
+		return nil
+	}
+
+	excluida, err := s.repo.DeletarPorID(avaliacaoID)
+	if err != nil {
+		return err
+	}
+	if !excluida {
+		return ErrAvaliacaoNaoEncontrada
+	}
+	return nil
+}
+
+// registrarAtividade leva a avaliação publicada ao feed dos seguidores. Cada avaliação entra
+// uma única vez; as edições seguintes não geram novas atividades.
+func (s *avaliacaoServicoImpl) registrarAtividade(avaliacao *dominio.Avaliacao) {
+	registrarAtividade(s.atividadeRepo, &dominio.Atividade{
+		UsuarioID:   avaliacao.UsuarioID,
+		Tipo:        dominio.AtividadeAvaliacao,
+		FilmeID:     &avaliacao.FilmeID,
+		AvaliacaoID: &avaliacao.ID,
+	})
+}
+
+// buscarAvaliacao encontra uma avaliação de qualquer usuário para a moderação.
+func (s *avaliacaoServicoImpl) buscarAvaliacao(avaliacaoID int64) (*dominio.Avaliacao, error) {
+	avaliacao, err := s.repo.BuscarPorID(avaliacaoID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrAvaliacaoNaoEncontrada
+		}
+		return nil, err
+	}
+	return avaliacao, nil
+}
+
+// Estatisticas retorna o total, a média e a distribuição das notas do filme, com todas as notas
+// da escala. Com usuarioID diferente de zero, inclui a nota que o próprio usuário deu.
+func (s *avaliacaoServicoImpl) Estatisticas(filmeID, usuarioID int64) (*dominio.EstatisticasFilme, error) {
+	estatisticas, err := s.repo.BuscarEstatisticas(filmeID)
+	if err != nil {
+		return nil, err
+	}
+	estatisticas.Media = arredondarMedia(estatisticas.Media)
+
+	quantidades := make(map[dominio.Nota]int, len(estatisticas.Distribuicao))
+	for _, q := range estatisticas.Distribuicao {
+		quantidades[q.Nota] = q.Quantidade
+	}
+	estatisticas.Distribuicao = make([]dominio.QuantidadeNota, 0, dominio.NotaMaxima)
+	for nota := dominio.NotaMinima; nota <= dominio.NotaMaxima; nota++ {
+		estatisticas.Distribuicao = append(estatisticas.Distribuicao, dominio.QuantidadeNota{Nota: nota, Quantidade: quantidades[nota]})
+	}
+
+	if usuarioID != 0 {
+		avaliacao, err := s.repo.BuscarPorFilmeDoUsuario(usuarioID, filmeID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if avaliacao != nil {
+			estatisticas.MinhaNota = &avaliacao.Nota
+		}
+	}
+	return estatisticas, nil
+}
+
+// Ranking retorna os filmes mais bem avaliados pela comunidade, pela média bayesiana. Filmes
+// que ainda não estão no catálogo local têm título e pôster buscados na API externa.
+func (s *avaliacaoServicoImpl) Ranking(consulta ConsultaRanking) ([]dominio.ItemRanking, error) {
+	filtro := repositorio.FiltroRanking{
+		VotosMinimos: consulta.VotosMinimos,
+		GeneroID:     consulta.GeneroID,
+		Limite:       consulta.Limite,
+	}
+	if filtro.VotosMinimos <= 0 {
+		filtro.VotosMinimos = VotosMinimosRanking
+	}
+	if filtro.Limite <= 0 {
+		filtro.Limite = LimitePadraoRanking
+	} else if filtro.Limite > LimiteMaximoRanking {
+		filtro.Limite = LimiteMaximoRanking
+	}
+	if consulta.Decada != 0 {
+		if consulta.Decada < 0 || consulta.Decada%10 != 0 {
+			return nil, ErrDecadaInvalida
+		}
+		filtro.AnoInicial, filtro.AnoFinal = consulta.Decada, consulta.Decada+9
+	}
+
+	ranking, err := s.repo.ListarRanking(filtro)
+	if err != nil {
+		return nil, err
+	}
+
+	var semTitulo []int64
+	for _, item := range ranking {
+		if item.Titulo == "" {
+			semTitulo = append(semTitulo, item.FilmeID)
+		}
+	}
+	metadados, err := s.catalogo.Metadados(semTitulo)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range ranking {
+		item := &ranking[i]
+		item.Posicao = i + 1
+		item.Media = arredondarMedia(item.Media)
+		item.Pontuacao = arredondarMedia(item.Pontuacao)
+		if m, ok := metadados[item.FilmeID]; ok {
+			item.Titulo, item.CaminhoPoster, item.Ano = m.Titulo, m.CaminhoPoster, m.Ano
+		}
+	}
+	return ranking, nil
+}
+
+// limiteAvaliacoes aplica o tamanho padrão e o máximo às páginas de avaliações.
+func limiteAvaliacoes(limite int) int {
+	if limite <= 0 {
+		return LimitePadraoAvaliacoes
+	}
+	if limite > LimiteMaximoAvaliacoes {
+		return LimiteMaximoAvaliacoes
+	}
+	return limite
+}
+
+// codificarCursorAvaliacoes gera o cursor opaco "ordenação|id|chave|data" em base64 (URL). A
+// ordenação permite recusar um cursor usado com outra consulta.
+func codificarCursorAvaliacoes(cursor repositorio.CursorAvaliacoes, ordenar string) string {
+	texto := fmt.Sprintf("%s|%d|%s|%s", ordenar, cursor.ID, cursor.Chave, cursor.Data)
+	return base64.RawURLEncoding.EncodeToString([]byte(texto))
+}
+
+func decodificarCursorAvaliacoes(cursor, ordenar string) (*repositorio.CursorAvaliacoes, error) {
+	bruto, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrCursorAvaliacoesInvalido
+	}
+	partes := strings.SplitN(string(bruto), "|", 4)
+	if len(partes) != 4 || partes[0] != ordenar || partes[3] == "" {
+		return nil, ErrCursorAvaliacoesInvalido
+	}
+	id, err := strconv.ParseInt(partes[1], 10, 64)
+	if err != nil {
+		return nil, ErrCursorAvaliacoesInvalido
+	}
+	if ordenar != repositorio.OrdenarAvaliacoesRecentes {
+		// As demais ordenações são numéricas.
+		if _, err := strconv.ParseFloat(partes[2], 64); err != nil {
+			return nil, ErrCursorAvaliacoesInvalido
+		}
 	}
-	return s.repo.Salvar(avaliacao)
+	return &repositorio.CursorAvaliacoes{Chave: partes[2], Data: partes[3], ID: id}, nil
 }
 
-func (s *avaliacaoServicoImpl) ListarPorFilme(filmeID int64) ([]dominio.AvaliacaoComUsuario, error) {
-	return s.repo.BuscarPorFilmeID(filmeID)
+// arredondarMedia deixa uma média com duas casas decimais.
+func arredondarMedia(media float64) float64 {
+	return math.Round(media*100) / 100
 }
@@ -0,0 +1,214 @@
+package servico_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// novoServicoAvaliacoes cria o serviço com o filtro padrão, que retém comentários com links.
+func novoServicoAvaliacoes(db *sqlx.DB) (servico.AvaliacaoServico, repositorio.AvaliacaoRepositorio) {
+	repo := repositorio.NovaAvaliacaoRepositorio(db)
+	config := &servico.ConfigAvaliacoes{
+		TamanhoMaximoComentario: servico.TamanhoMaximoComentarioPadrao,
+		Filtro: servico.NovoFiltroConteudo(servico.ConfigFiltro{
+			AcaoPalavras: dominio.AcaoFiltroRejeitar,
+			AcaoLinks:    dominio.AcaoFiltroReter,
+			AcaoSpam:     dominio.AcaoFiltroReter,
+		}),
+	}
+	return servico.NovaAvaliacaoServico(repo, repositorio.NovaAtividadeRepositorio(db), nil, config), repo
+}
+
+// avaliacaoComReacao publica uma avaliação da autora e registra a reação de outra pessoa.
+func avaliacaoComReacao(t *testing.T, db *sqlx.DB, avaliacoes servico.AvaliacaoServico, repo repositorio.AvaliacaoRepositorio) (*dominio.Usuario, *dominio.Avaliacao) {
+	t.Helper()
+
+	autora := novoUsuario(t, db, "Ana", "ana@example.com")
+	leitora := novoUsuario(t, db, "Bia", "bia@example.com")
+
+	publicada, _, err := avaliacoes.Salvar(autora.ID, 603, servico.AvaliacaoInput{Nota: 4, Comentario: "Ótimo filme"})
+	require.NoError(t, err)
+	require.Equal(t, dominio.SituacaoPublicada, publicada.Situacao)
+	require.NoError(t, repo.Reagir(&dominio.ReacaoAvaliacao{
+		AvaliacaoID: publicada.ID, UsuarioID: leitora.ID, Tipo: "util", DataCriacao: time.Now().UTC(),
+	}))
+	return autora, publicada
+}
+
+func TestEdicaoRetidaMantemVersaoPublicada(t *testing.T) {
+	db := novoBanco(t)
+	avaliacoes, repo := novoServicoAvaliacoes(db)
+	autora, publicada := avaliacaoComReacao(t, db, avaliacoes, repo)
+
+	edicao, _, err := avaliacoes.Salvar(autora.ID, 603, servico.AvaliacaoInput{Nota: 2, Comentario: "veja em exemplo.com"})
+	require.NoError(t, err)
+	assert.Equal(t, dominio.SituacaoPendente, edicao.Situacao)
+	assert.Equal(t, publicada.ID, edicao.ID)
+
+	// A versão publicada continua nas listagens e nas estatísticas.
+	lista, _, err := avaliacoes.ListarPorFilme(603, servico.ConsultaAvaliacoes{}, 0)
+	require.NoError(t, err)
+	require.Len(t, lista, 1)
+	assert.Equal(t, "Ótimo filme", lista[0].Comentario)
+	assert.Equal(t, 1, lista[0].Uteis)
+
+	estatisticas, err := avaliacoes.Estatisticas(603, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 4.0, estatisticas.Media)
+
+	pendentes, err := avaliacoes.ListarPendentes()
+	require.NoError(t, err)
+	require.Len(t, pendentes, 1)
+	assert.True(t, pendentes[0].Edicao)
+	assert.Equal(t, "veja em exemplo.com", pendentes[0].Comentario)
+	assert.Equal(t, servico.MotivoLink, pendentes[0].Motivos)
+}
+
+func TestRejeitarEdicaoRetidaNaoApagaAvaliacao(t *testing.T) {
+	db := novoBanco(t)
+	avaliacoes, repo := novoServicoAvaliacoes(db)
+	autora, publicada := avaliacaoComReacao(t, db, avaliacoes, repo)
+
+	_, _, err := avaliacoes.Salvar(autora.ID, 603, servico.AvaliacaoInput{Nota: 2, Comentario: "veja em exemplo.com"})
+	require.NoError(t, err)
+	require.NoError(t, avaliacoes.Rejeitar(publicada.ID))
+
+	atual, err := avaliacoes.BuscarDoUsuario(autora.ID, 603)
+	require.NoError(t, err)
+	assert.Equal(t, "Ótimo filme", atual.Comentario)
+	assert.Equal(t, 4.0, atual.Nota.Estrelas())
+
+	lista, _, err := avaliacoes.ListarPorFilme(603, servico.ConsultaAvaliacoes{}, 0)
+	require.NoError(t, err)
+	require.Len(t, lista, 1)
+	assert.Equal(t, 1, lista[0].Uteis)
+
+	pendentes, err := avaliacoes.ListarPendentes()
+	require.NoError(t, err)
+	assert.Empty(t, pendentes)
+	assert.ErrorIs(t, avaliacoes.Rejeitar(publicada.ID), servico.ErrAvaliacaoNaoPendente)
+}
+
+func TestAprovarEdicaoRetidaPublicaEGuardaVersaoAnterior(t *testing.T) {
+	db := novoBanco(t)
+	avaliacoes, repo := novoServicoAvaliacoes(db)
+	autora, publicada := avaliacaoComReacao(t, db, avaliacoes, repo)
+
+	_, _, err := avaliacoes.Salvar(autora.ID, 603, servico.AvaliacaoInput{Nota: 2, Comentario: "veja em exemplo.com"})
+	require.NoError(t, err)
+	require.NoError(t, avaliacoes.Aprovar(publicada.ID))
+
+	historico, err := avaliacoes.Historico(publicada.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "veja em exemplo.com", historico.Atual.Comentario)
+	assert.Equal(t, dominio.SituacaoPublicada, historico.Atual.Situacao)
+	require.Len(t, historico.Versoes, 1)
+	assert.Equal(t, "Ótimo filme", historico.Versoes[0].Comentario)
+
+	estatisticas, err := avaliacoes.Estatisticas(603, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, estatisticas.Media)
+	assert.Equal(t, 1, estatisticas.Total)
+}
+
+func TestEdicaoAprovadaPeloFiltroDescartaEdicaoRetida(t *testing.T) {
+	db := novoBanco(t)
+	avaliacoes, repo := novoServicoAvaliacoes(db)
+	autora, publicada := avaliacaoComReacao(t, db, avaliacoes, repo)
+
+	_, _, err := avaliacoes.Salvar(autora.ID, 603, servico.AvaliacaoInput{Nota: 2, Comentario: "veja em exemplo.com"})
+	require.NoError(t, err)
+	_, _, err = avaliacoes.Salvar(autora.ID, 603, servico.AvaliacaoInput{Nota: 3, Comentario: "Revi e gostei menos"})
+	require.NoError(t, err)
+
+	pendentes, err := avaliacoes.ListarPendentes()
+	require.NoError(t, err)
+	assert.Empty(t, pendentes)
+	assert.ErrorIs(t, avaliacoes.Aprovar(publicada.ID), servico.ErrAvaliacaoNaoPendente)
+}
+
+func TestRejeitarAvaliacaoNuncaPublicadaAExclui(t *testing.T) {
+	db := novoBanco(t)
+	avaliacoes, _ := novoServicoAvaliacoes(db)
+	autora := novoUsuario(t, db, "Ana", "ana@example.com")
+
+	pendente, _, err := avaliacoes.Salvar(autora.ID, 603, servico.AvaliacaoInput{Nota: 5, Comentario: "veja em exemplo.com"})
+	require.NoError(t, err)
+	require.Equal(t, dominio.SituacaoPendente, pendente.Situacao)
+
+	require.NoError(t, avaliacoes.Rejeitar(pendente.ID))
+	_, err = avaliacoes.BuscarDoUsuario(autora.ID, 603)
+	assert.ErrorIs(t, err, servico.ErrAvaliacaoNaoEncontrada)
+}
+
+func TestSalvarRecusaNotaForaDaEscala(t *testing.T) {
+	db := novoBanco(t)
+	avaliacoes, _ := novoServicoAvaliacoes(db)
+	ana := novoUsuario(t, db, "Ana", "ana@example.com")
+
+	for _, nota := range []float64{0, 3.3, 5.5} {
+		_, _, err := avaliacoes.Salvar(ana.ID, 603, servico.AvaliacaoInput{Nota: nota})
+		assert.ErrorIs(t, err, servico.ErrNotaInvalida, "nota %v", nota)
+	}
+
+	avaliacao, _, err := avaliacoes.Salvar(ana.ID, 603, servico.AvaliacaoInput{Nota: 0.5})
+	require.NoError(t, err)
+	assert.Equal(t, dominio.NotaMinima, avaliacao.Nota)
+}
+
+func TestEstatisticasAgrupaPorMeiasEstrelas(t *testing.T) {
+	db := novoBanco(t)
+	avaliacoes, _ := novoServicoAvaliacoes(db)
+	for i, nota := range []float64{3.5, 3.5, 4} {
+		usuario := novoUsuario(t, db, "Usuário", fmt.Sprintf("usuario%d@example.com", i))
+		_, _, err := avaliacoes.Salvar(usuario.ID, 603, servico.AvaliacaoInput{Nota: nota})
+		require.NoError(t, err)
+	}
+
+	estatisticas, err := avaliacoes.Estatisticas(603, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, estatisticas.Total)
+	assert.Equal(t, 3.67, estatisticas.Media)
+	require.Len(t, estatisticas.Distribuicao, int(dominio.NotaMaxima))
+	assert.Equal(t, dominio.QuantidadeNota{Nota: 7, Quantidade: 2}, estatisticas.Distribuicao[6])
+	assert.Equal(t, dominio.QuantidadeNota{Nota: 8, Quantidade: 1}, estatisticas.Distribuicao[7])
+}
+
+// O cursor da ordenação por nota leva a chave da última avaliação da página; seguindo-o, todas
+// as avaliações aparecem uma vez, na ordem.
+func TestListarPorNotaSegueOCursor(t *testing.T) {
+	db := novoBanco(t)
+	avaliacoes, _ := novoServicoAvaliacoes(db)
+	for i, nota := range []float64{2.5, 4, 0.5, 4, 5} {
+		usuario := novoUsuario(t, db, "Usuário", fmt.Sprintf("usuario%d@example.com", i))
+		_, _, err := avaliacoes.Salvar(usuario.ID, 603, servico.AvaliacaoInput{Nota: nota})
+		require.NoError(t, err)
+	}
+
+	var notas []float64
+	consulta := servico.ConsultaAvaliacoes{Ordenar: repositorio.OrdenarAvaliacoesNotaAlta, Limite: 2}
+	for pagina := 0; pagina < 5; pagina++ {
+		lista, proximo, err := avaliacoes.ListarPorFilme(603, consulta, 0)
+		require.NoError(t, err)
+		for _, avaliacao := range lista {
+			notas = append(notas, avaliacao.Nota.Estrelas())
+		}
+		if proximo == "" {
+			break
+		}
+		consulta.Cursor = proximo
+	}
+	assert.Equal(t, []float64{5, 4, 4, 2.5, 0.5}, notas)
+
+	consulta.Ordenar = repositorio.OrdenarAvaliacoesNotaBaixa
+	_, _, err := avaliacoes.ListarPorFilme(603, consulta, 0)
+	assert.ErrorIs(t, err, servico.ErrCursorAvaliacoesInvalido)
+}
@@ -0,0 +1,60 @@
+package servico_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Andydev0/filmes-backend/internal/database"
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/jmoiron/sqlx"
+)
+
+// novoBanco abre um banco novo, com todas as migrações, em um diretório temporário do teste.
+func novoBanco(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	db, err := database.Abrir(filepath.Join(t.TempDir(), "teste.db"))
+	if err != nil {
+		t.Fatalf("falha ao abrir o banco de teste: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// novoUsuario cadastra um usuário com senha e retorna o registro salvo.
+func novoUsuario(t *testing.T, db *sqlx.DB, nome, email string) *dominio.Usuario {
+	t.Helper()
+
+	usuario := &dominio.Usuario{Nome: nome, Email: email, SenhaHash: "hash"}
+	if err := repositorio.NovoUsuarioRepositorio(db).Salvar(usuario); err != nil {
+		t.Fatalf("falha ao cadastrar o usuário de teste: %v", err)
+	}
+	return usuario
+}
+
+// catalogoFixo é um catálogo em memória: conhece apenas os filmes do mapa.
+type catalogoFixo map[int64]dominio.MetadadosFilme
+
+func (c catalogoFixo) Metadados(filmeIDs []int64) (map[int64]dominio.MetadadosFilme, error) {
+	metadados := make(map[int64]dominio.MetadadosFilme)
+	for _, id := range filmeIDs {
+		if m, ok := c[id]; ok {
+			metadados[id] = m
+		}
+	}
+	return metadados, nil
+}
+
+func (c catalogoFixo) Obter(filmeID int64) (*dominio.MetadadosFilme, error) {
+	m, ok := c[filmeID]
+	if !ok {
+		return nil, servico.ErrFilmeInexistente
+	}
+	return &m, nil
+}
+
+func (c catalogoFixo) Sincronizar(limite int) (int64, error) {
+	return 0, nil
+}
@@ -0,0 +1,171 @@
+package servico
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+)
+
+const (
+	// ValidadeMetadadosCatalogo é o tempo após o qual os metadados em cache são buscados de novo.
+	ValidadeMetadadosCatalogo = 30 * 24 * time.Hour
+
+	// IdadeSincronizacaoCatalogo é a idade a partir da qual a sincronização periódica
+	// volta a consultar a API externa para os filmes guardados em listas ou avaliados.
+	IdadeSincronizacaoCatalogo = 7 * 24 * time.Hour
+
+	// buscasSimultaneasCatalogo limita as requisições paralelas à API externa.
+	buscasSimultaneasCatalogo = 4
+)
+
+// Define os erros que o serviço de catálogo pode retornar.
+var (
+	ErrFilmeInexistente     = errors.New("filme não encontrado no catálogo")
+	ErrCatalogoIndisponivel = errors.New("não foi possível consultar o catálogo de filmes")
+)
+
+// CatalogoServico fornece os metadados dos filmes, consultando a API externa só quando o cache
+// não os tem ou eles estão vencidos.
+type CatalogoServico interface {
+	Metadados(filmeIDs []int64) (map[int64]dominio.MetadadosFilme, error)
+	Obter(filmeID int64) (*dominio.MetadadosFilme, error)
+	Sincronizar(limite int) (int64, error)
+}
+
+type catalogoServicoImpl struct {
+	repo         repositorio.CatalogoRepositorio
+	filmeServico FilmeServico
+}
+
+// NovoCatalogoServico cria o serviço de catálogo.
+func NovoCatalogoServico(repo repositorio.CatalogoRepositorio, filmeServico FilmeServico) CatalogoServico {
+	return &catalogoServicoImpl{repo: repo, filmeServico: filmeServico}
+}
+
+// Metadados retorna os metadados conhecidos dos filmes informados. Falhas da API externa não
+// são erros: o filme fica com os dados vencidos do cache ou simplesmente fora do mapa.
+func (s *catalogoServicoImpl) Metadados(filmeIDs []int64) (map[int64]dominio.MetadadosFilme, error) {
+	emCache, err := s.repo.BuscarPorIDs(filmeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	resultado := make(map[int64]dominio.MetadadosFilme, len(filmeIDs))
+	limite := time.Now().Add(-ValidadeMetadadosCatalogo)
+	for _, metadados := range emCache {
+		resultado[metadados.FilmeID] = metadados
+	}
+
+	var faltantes []int64
+	for _, id := range filmeIDs {
+		if metadados, ok := resultado[id]; !ok || metadados.AtualizadoEm.Before(limite) {
+			faltantes = append(faltantes, id)
+		}
+	}
+
+	atualizados, err := s.buscarNaAPI(faltantes)
+	if err != nil {
+		return nil, err
+	}
+	for id, metadados := range atualizados {
+		resultado[id] = metadados
+	}
+	return resultado, nil
+}
+
+// Obter retorna os metadados de um filme, validando que ele existe no catálogo. Retorna
+// ErrFilmeInexistente se a API externa não o conhece e ErrCatalogoIndisponivel se ela
+// falhar e não houver nada em cache.
+func (s *catalogoServicoImpl) Obter(filmeID int64) (*dominio.MetadadosFilme, error) {
+	emCache, err := s.repo.BuscarPorIDs([]int64{filmeID})
+	if err != nil {
+		return nil, err
+	}
+	if len(emCache) > 0 && emCache[0].AtualizadoEm.After(time.Now().Add(-ValidadeMetadadosCatalogo)) {
+		return &emCache[0], nil
+	}
+
+	metadados, err := s.filmeServico.BuscarMetadados(filmeID)
+	if err != nil {
+		log.Printf("Falha ao buscar os metadados do filme %d: %v", filmeID, err)
+		if len(emCache) > 0 {
+			return &emCache[0], nil
+		}
+		return nil, ErrCatalogoIndisponivel
+	}
+	if metadados == nil {
+		return nil, ErrFilmeInexistente
+	}
+
+	metadados.AtualizadoEm = time.Now().UTC()
+	if err := s.repo.Salvar(metadados); err != nil {
+		return nil, err
+	}
+	return metadados, nil
+}
+
+// Sincronizar atualiza até limite filmes guardados em listas ou avaliados cujos metadados
+// passaram de IdadeSincronizacaoCatalogo e copia título e pôster atualizados para os itens das
+// listas. Retorna quantos itens mudaram.
+func (s *catalogoServicoImpl) Sincronizar(limite int) (int64, error) {
+	ids, err := s.repo.ListarReferenciadosDesatualizados(time.Now().UTC().Add(-IdadeSincronizacaoCatalogo), limite)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := s.buscarNaAPI(ids); err != nil {
+		return 0, err
+	}
+	return s.repo.SincronizarItensListas()
+}
+
+// buscarNaAPI consulta a API externa em paralelo e grava no cache o que encontrar. Filmes
+// inexistentes ou com falha na consulta ficam fora do mapa; só erros de banco são retornados.
+func (s *catalogoServicoImpl) buscarNaAPI(filmeIDs []int64) (map[int64]dominio.MetadadosFilme, error) {
+	resultado := make(map[int64]dominio.MetadadosFilme, len(filmeIDs))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var errBanco error
+	fila := make(chan int64)
+	for i := 0; i < buscasSimultaneasCatalogo; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range fila {
+				metadados, err := s.filmeServico.BuscarMetadados(id)
+				if err != nil {
+					log.Printf("Falha ao buscar os metadados do filme %d: %v", id, err)
+					continue
+				}
+				if metadados == nil {
+					continue
+				}
+
+				metadados.AtualizadoEm = time.Now().UTC()
+				err = s.repo.Salvar(metadados)
+
+				mu.Lock()
+				if err != nil && errBanco == nil {
+					errBanco = fmt.Errorf("falha ao gravar os metadados do filme %d: %w", id, err)
+				}
+				resultado[id] = *metadados
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, id := range filmeIDs {
+		fila <- id
+	}
+	close(fila)
+	wg.Wait()
+
+	if errBanco != nil {
+		return nil, errBanco
+	}
+	return resultado, nil
+}
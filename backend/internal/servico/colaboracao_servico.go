@@ -0,0 +1,236 @@
+package servico
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+)
+
+// Define os erros que o serviço de colaboração pode retornar.
+var (
+	ErrListaNaoCompartilhavel = errors.New("listas embutidas não podem ter membros")
+	ErrConviteNaoEncontrado   = errors.New("convite não encontrado ou expirado")
+	ErrConvidadoNaoEncontrado = errors.New("nenhum usuário com este perfil")
+	ErrJaParticipaDaLista     = errors.New("este usuário já participa da lista")
+	ErrMembroNaoEncontrado    = errors.New("este usuário não é membro da lista")
+)
+
+// ValidadeConviteLista é por quanto tempo um convite pode ser aceito.
+const ValidadeConviteLista = 7 * 24 * time.Hour
+
+// CriarConviteInput define o papel oferecido e, opcionalmente, o perfil do usuário convidado.
+// Sem perfil, o convite é um link que qualquer pessoa com o código pode aceitar.
+type CriarConviteInput struct {
+	Papel  string `json:"papel" binding:"required,oneof=editor leitor"`
+	Perfil string `json:"perfil"`
+}
+
+// AlterarPapelInput define o novo papel de um membro.
+type AlterarPapelInput struct {
+	Papel string `json:"papel" binding:"required,oneof=editor leitor"`
+}
+
+// ColaboracaoServico define os membros e convites das listas colaborativas. Só o dono convida,
+// revoga convites e altera papéis; qualquer participante vê os membros e pode sair da lista.
+type ColaboracaoServico interface {
+	CriarConvite(usuarioID, listaID int64, input CriarConviteInput) (*dominio.ConviteLista, error)
+	ListarConvites(usuarioID, listaID int64) ([]dominio.ConviteLista, error)
+	RevogarConvite(usuarioID, listaID, conviteID int64) error
+	ListarConvitesRecebidos(usuarioID int64) ([]dominio.ConviteLista, error)
+	AceitarConvite(usuarioID int64, codigo string) (*dominio.Lista, error)
+	ListarMembros(usuarioID, listaID int64) ([]dominio.MembroLista, error)
+	AlterarPapel(usuarioID, listaID, membroID int64, input AlterarPapelInput) error
+	RemoverMembro(usuarioID, listaID, membroID int64) error
+}
+
+type colaboracaoServicoImpl struct {
+	listaServico ListaServico
+	repo         repositorio.ColaboracaoRepositorio
+	perfilRepo   repositorio.PerfilRepositorio
+}
+
+// NovaColaboracaoServico cria o serviço de colaboração em listas.
+func NovaColaboracaoServico(
+	listaServico ListaServico,
+	repo repositorio.ColaboracaoRepositorio,
+	perfilRepo repositorio.PerfilRepositorio,
+) ColaboracaoServico {
+	return &colaboracaoServicoImpl{listaServico: listaServico, repo: repo, perfilRepo: perfilRepo}
+}
+
+// CriarConvite gera um convite pessoal ou de link para a lista.
+func (s *colaboracaoServicoImpl) CriarConvite(usuarioID, listaID int64, input CriarConviteInput) (*dominio.ConviteLista, error) {
+	lista, err := s.listaDoDono(usuarioID, listaID)
+	if err != nil {
+		return nil, err
+	}
+	if lista.Tipo != dominio.TipoListaPersonalizada {
+		return nil, ErrListaNaoCompartilhavel
+	}
+
+	convite := &dominio.ConviteLista{
+		ListaID:   listaID,
+		Papel:     input.Papel,
+		CriadoPor: usuarioID,
+		ExpiraEm:  time.Now().UTC().Add(ValidadeConviteLista),
+	}
+
+	if input.Perfil != "" {
+		perfil, err := s.perfilRepo.BuscarPorSlug(input.Perfil)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, ErrConvidadoNaoEncontrado
+			}
+			return nil, err
+		}
+		if perfil.UsuarioID == usuarioID {
+			return nil, ErrJaParticipaDaLista
+		}
+		papel, err := s.repo.BuscarPapel(listaID, perfil.UsuarioID)
+		if err != nil {
+			return nil, err
+		}
+		if papel != "" {
+			return nil, ErrJaParticipaDaLista
+		}
+		convite.ConvidadoID = &perfil.UsuarioID
+	}
+
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return nil, err
+	}
+	convite.Codigo = hex.EncodeToString(bytes)
+
+	if err := s.repo.CriarConvite(convite); err != nil {
+		return nil, err
+	}
+	convite.TituloLista = lista.Titulo
+	return convite, nil
+}
+
+// ListarConvites retorna os convites ainda válidos da lista.
+func (s *colaboracaoServicoImpl) ListarConvites(usuarioID, listaID int64) ([]dominio.ConviteLista, error) {
+	if _, err := s.listaDoDono(usuarioID, listaID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListarConvitesDaLista(listaID)
+}
+
+// RevogarConvite invalida um convite antes que ele seja aceito.
+func (s *colaboracaoServicoImpl) RevogarConvite(usuarioID, listaID, conviteID int64) error {
+	if _, err := s.listaDoDono(usuarioID, listaID); err != nil {
+		return err
+	}
+	revogado, err := s.repo.RevogarConvite(listaID, conviteID)
+	if err != nil {
+		return err
+	}
+	if !revogado {
+		return ErrConviteNaoEncontrado
+	}
+	return nil
+}
+
+// ListarConvitesRecebidos retorna os convites pessoais pendentes do usuário.
+func (s *colaboracaoServicoImpl) ListarConvitesRecebidos(usuarioID int64) ([]dominio.ConviteLista, error) {
+	return s.repo.ListarConvitesRecebidos(usuarioID)
+}
+
+// AceitarConvite torna o usuário membro da lista. Convites pessoais só valem para o convidado, e
+// quem já participa da lista não troca de papel por um convite: isso cabe ao dono, em AlterarPapel.
+func (s *colaboracaoServicoImpl) AceitarConvite(usuarioID int64, codigo string) (*dominio.Lista, error) {
+	convite, err := s.repo.BuscarConvite(codigo)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrConviteNaoEncontrado
+		}
+		return nil, err
+	}
+	if convite.ConvidadoID != nil && *convite.ConvidadoID != usuarioID {
+		return nil, ErrConviteNaoEncontrado
+	}
+	if convite.CriadoPor == usuarioID {
+		return nil, ErrJaParticipaDaLista
+	}
+
+	aceito, err := s.repo.AceitarConvite(convite, usuarioID)
+	if err != nil {
+		return nil, err
+	}
+	if !aceito {
+		return nil, ErrJaParticipaDaLista
+	}
+	return s.listaServico.BuscarVisivel(usuarioID, convite.ListaID)
+}
+
+// ListarMembros retorna o dono e os membros da lista para quem participa dela.
+func (s *colaboracaoServicoImpl) ListarMembros(usuarioID, listaID int64) ([]dominio.MembroLista, error) {
+	lista, err := s.listaServico.BuscarVisivel(usuarioID, listaID)
+	if err != nil {
+		return nil, err
+	}
+	if lista.Papel == "" {
+		return nil, ErrListaNaoEncontrada
+	}
+	return s.repo.ListarMembros(listaID)
+}
+
+// AlterarPapel troca o papel de um membro da lista.
+func (s *colaboracaoServicoImpl) AlterarPapel(usuarioID, listaID, membroID int64, input AlterarPapelInput) error {
+	if _, err := s.listaDoDono(usuarioID, listaID); err != nil {
+		return err
+	}
+	alterado, err := s.repo.AlterarPapel(listaID, membroID, input.Papel)
+	if err != nil {
+		return err
+	}
+	if !alterado {
+		return ErrMembroNaoEncontrado
+	}
+	return nil
+}
+
+// RemoverMembro tira um membro da lista. O dono remove qualquer membro; os demais só a si mesmos.
+// Quando o dono remove alguém, os convites de link da lista são revogados junto.
+func (s *colaboracaoServicoImpl) RemoverMembro(usuarioID, listaID, membroID int64) error {
+	lista, err := s.listaServico.BuscarVisivel(usuarioID, listaID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case lista.Papel == "":
+		return ErrListaNaoEncontrada
+	case lista.Papel != dominio.PapelDono && membroID != usuarioID:
+		return ErrSemPermissaoLista
+	}
+
+	removido, err := s.repo.RemoverMembro(listaID, membroID, membroID != usuarioID)
+	if err != nil {
+		return err
+	}
+	if !removido {
+		return ErrMembroNaoEncontrado
+	}
+	return nil
+}
+
+// listaDoDono retorna a lista se o usuário for o dono dela.
+func (s *colaboracaoServicoImpl) listaDoDono(usuarioID, listaID int64) (*dominio.Lista, error) {
+	lista, err := s.listaServico.BuscarVisivel(usuarioID, listaID)
+	if err != nil {
+		return nil, err
+	}
+	switch lista.Papel {
+	case dominio.PapelDono:
+		return lista, nil
+	case "":
+		return nil, ErrListaNaoEncontrada
+	}
+	return nil, ErrSemPermissaoLista
+}
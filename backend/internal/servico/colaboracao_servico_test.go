@@ -0,0 +1,119 @@
+package servico_test
+
+import (
+	"testing"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cenarioColaboracao struct {
+	listas      servico.ListaServico
+	colaboracao servico.ColaboracaoServico
+	dono, bia   *dominio.Usuario
+	lista       *dominio.Lista
+}
+
+func novoCenarioColaboracao(t *testing.T) *cenarioColaboracao {
+	t.Helper()
+
+	db := novoBanco(t)
+	listas := servico.NovaListaServico(repositorio.NovoListaRepositorio(db), repositorio.NovoColaboracaoRepositorio(db),
+		repositorio.NovaAtividadeRepositorio(db), catalogoTeste)
+	c := &cenarioColaboracao{
+		listas:      listas,
+		colaboracao: servico.NovaColaboracaoServico(listas, repositorio.NovoColaboracaoRepositorio(db), repositorio.NovoPerfilRepositorio(db)),
+		dono:        novoUsuario(t, db, "Ana", "ana@example.com"),
+		bia:         novoUsuario(t, db, "Bia", "bia@example.com"),
+	}
+
+	lista, err := listas.Criar(c.dono.ID, servico.CriarListaInput{Titulo: "Ficção"})
+	require.NoError(t, err)
+	c.lista = lista
+	return c
+}
+
+func (c *cenarioColaboracao) link(t *testing.T, papel string) *dominio.ConviteLista {
+	t.Helper()
+
+	convite, err := c.colaboracao.CriarConvite(c.dono.ID, c.lista.ID, servico.CriarConviteInput{Papel: papel})
+	require.NoError(t, err)
+	return convite
+}
+
+func (c *cenarioColaboracao) papel(t *testing.T, usuarioID int64) string {
+	t.Helper()
+
+	lista, err := c.listas.BuscarVisivel(usuarioID, c.lista.ID)
+	require.NoError(t, err)
+	return lista.Papel
+}
+
+func TestAceitarConviteNaoAlteraPapelDeMembro(t *testing.T) {
+	c := novoCenarioColaboracao(t)
+
+	_, err := c.colaboracao.AceitarConvite(c.bia.ID, c.link(t, dominio.PapelLeitor).Codigo)
+	require.NoError(t, err)
+	assert.Equal(t, dominio.PapelLeitor, c.papel(t, c.bia.ID))
+
+	_, err = c.colaboracao.AceitarConvite(c.bia.ID, c.link(t, dominio.PapelEditor).Codigo)
+	assert.ErrorIs(t, err, servico.ErrJaParticipaDaLista)
+	assert.Equal(t, dominio.PapelLeitor, c.papel(t, c.bia.ID))
+}
+
+func TestRemoverMembroRevogaConvitesDeLink(t *testing.T) {
+	c := novoCenarioColaboracao(t)
+	convite := c.link(t, dominio.PapelEditor)
+
+	_, err := c.colaboracao.AceitarConvite(c.bia.ID, convite.Codigo)
+	require.NoError(t, err)
+	require.NoError(t, c.colaboracao.RemoverMembro(c.dono.ID, c.lista.ID, c.bia.ID))
+
+	_, err = c.colaboracao.AceitarConvite(c.bia.ID, convite.Codigo)
+	assert.ErrorIs(t, err, servico.ErrConviteNaoEncontrado)
+	convites, err := c.colaboracao.ListarConvites(c.dono.ID, c.lista.ID)
+	require.NoError(t, err)
+	assert.Empty(t, convites)
+}
+
+func TestSairDaListaMantemConvitesDeLink(t *testing.T) {
+	c := novoCenarioColaboracao(t)
+	convite := c.link(t, dominio.PapelEditor)
+
+	_, err := c.colaboracao.AceitarConvite(c.bia.ID, convite.Codigo)
+	require.NoError(t, err)
+	require.NoError(t, c.colaboracao.RemoverMembro(c.bia.ID, c.lista.ID, c.bia.ID))
+
+	convites, err := c.colaboracao.ListarConvites(c.dono.ID, c.lista.ID)
+	require.NoError(t, err)
+	assert.Len(t, convites, 1)
+}
+
+func TestRebaixarEditorRevogaConvitesDeLink(t *testing.T) {
+	c := novoCenarioColaboracao(t)
+	convite := c.link(t, dominio.PapelEditor)
+
+	_, err := c.colaboracao.AceitarConvite(c.bia.ID, convite.Codigo)
+	require.NoError(t, err)
+	require.NoError(t, c.colaboracao.AlterarPapel(c.dono.ID, c.lista.ID, c.bia.ID, servico.AlterarPapelInput{Papel: dominio.PapelLeitor}))
+
+	_, err = c.colaboracao.AceitarConvite(c.bia.ID, convite.Codigo)
+	assert.ErrorIs(t, err, servico.ErrConviteNaoEncontrado)
+	assert.Equal(t, dominio.PapelLeitor, c.papel(t, c.bia.ID))
+}
+
+func TestPromoverLeitorMantemConvitesDeLink(t *testing.T) {
+	c := novoCenarioColaboracao(t)
+	convite := c.link(t, dominio.PapelLeitor)
+
+	_, err := c.colaboracao.AceitarConvite(c.bia.ID, convite.Codigo)
+	require.NoError(t, err)
+	require.NoError(t, c.colaboracao.AlterarPapel(c.dono.ID, c.lista.ID, c.bia.ID, servico.AlterarPapelInput{Papel: dominio.PapelEditor}))
+
+	convites, err := c.colaboracao.ListarConvites(c.dono.ID, c.lista.ID)
+	require.NoError(t, err)
+	assert.Len(t, convites, 1)
+}
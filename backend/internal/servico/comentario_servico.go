@@ -0,0 +1,258 @@
+package servico
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+)
+
+// Define os erros que o serviço de comentários pode retornar.
+var (
+	ErrComentarioNaoEncontrado   = errors.New("comentário não encontrado")
+	ErrComentarioVazio           = errors.New("o comentário não pode ficar vazio")
+	ErrSemPermissaoComentario    = errors.New("você não pode alterar este comentário")
+	ErrCursorComentariosInvalido = errors.New("cursor de comentários inválido")
+)
+
+const (
+	// LimitePadraoComentarios e LimiteMaximoComentarios definem o tamanho da página de comentários.
+	LimitePadraoComentarios = 20
+	LimiteMaximoComentarios = 100
+)
+
+// ComentarioInput define o texto de um comentário e, para respostas, o comentário respondido.
+type ComentarioInput struct {
+	Texto     string `json:"texto" binding:"required,max=2000"`
+	RespostaA *int64 `json:"respostaA"`
+}
+
+// EditarComentarioInput define o novo texto de um comentário.
+type EditarComentarioInput struct {
+	Texto string `json:"texto" binding:"required,max=2000"`
+}
+
+// ComentarioServico define os comentários nas avaliações. Só o autor edita um comentário; apagar
+// também é permitido ao autor da avaliação e aos moderadores.
+type ComentarioServico interface {
+	Comentar(usuarioID, avaliacaoID int64, input ComentarioInput) (*dominio.ComentarioAvaliacao, error)
+	Listar(avaliacaoID int64, cursor string, limite int) ([]dominio.ComentarioAvaliacao, string, error)
+	Editar(usuarioID, comentarioID int64, input EditarComentarioInput) (*dominio.ComentarioAvaliacao, error)
+	Excluir(usuarioID, comentarioID int64) error
+}
+
+type comentarioServicoImpl struct {
+	repo          repositorio.ComentarioRepositorio
+	avaliacaoRepo repositorio.AvaliacaoRepositorio
+	usuarioRepo   repositorio.UsuarioRepositorio
+	notificacoes  NotificacaoServico
+}
+
+// NovoComentarioServico cria o serviço de comentários.
+func NovoComentarioServico(
+	repo repositorio.ComentarioRepositorio,
+	avaliacaoRepo repositorio.AvaliacaoRepositorio,
+	usuarioRepo repositorio.UsuarioRepositorio,
+	notificacoes NotificacaoServico,
+) ComentarioServico {
+	return &comentarioServicoImpl{repo: repo, avaliacaoRepo: avaliacaoRepo, usuarioRepo: usuarioRepo, notificacoes: notificacoes}
+}
+
+// Comentar publica um comentário na avaliação. Há um só nível de respostas: responder a uma
+// resposta vira uma resposta ao mesmo comentário de primeiro nível.
+func (s *comentarioServicoImpl) Comentar(usuarioID, avaliacaoID int64, input ComentarioInput) (*dominio.ComentarioAvaliacao, error) {
+	texto := strings.TrimSpace(input.Texto)
+	if texto == "" {
+		return nil, ErrComentarioVazio
+	}
+	avaliacao, err := s.buscarPublicada(avaliacaoID)
+	if err != nil {
+		return nil, err
+	}
+
+	agora := time.Now().UTC()
+	comentario := &dominio.ComentarioAvaliacao{
+		AvaliacaoID:     avaliacaoID,
+		UsuarioID:       &usuarioID,
+		Texto:           texto,
+		DataCriacao:     agora,
+		DataAtualizacao: agora,
+	}
+	var respondido *dominio.ComentarioAvaliacao
+	if input.RespostaA != nil {
+		respondido, err = s.buscar(*input.RespostaA)
+		if err != nil {
+			return nil, err
+		}
+		if respondido.AvaliacaoID != avaliacaoID {
+			return nil, ErrComentarioNaoEncontrado
+		}
+		comentario.RespostaA = &respondido.ID
+		if respondido.RespostaA != nil {
+			comentario.RespostaA = respondido.RespostaA
+		}
+	}
+
+	if err := s.repo.Criar(comentario); err != nil {
+		return nil, err
+	}
+	s.notificarComentario(avaliacao, respondido, comentario)
+	return s.repo.BuscarPorID(comentario.ID)
+}
+
+// notificarComentario avisa o autor do comentário respondido e o autor da avaliação, uma só vez
+// se forem a mesma pessoa.
+func (s *comentarioServicoImpl) notificarComentario(avaliacao *dominio.Avaliacao, respondido, comentario *dominio.ComentarioAvaliacao) {
+	novaNotificacao := func(usuarioID int64, tipo string) *dominio.Notificacao {
+		return &dominio.Notificacao{
+			UsuarioID:    usuarioID,
+			Tipo:         tipo,
+			AtorID:       comentario.UsuarioID,
+			FilmeID:      &avaliacao.FilmeID,
+			AvaliacaoID:  &avaliacao.ID,
+			ComentarioID: &comentario.ID,
+			Texto:        trechoNotificacao(comentario.Texto),
+		}
+	}
+	var respondidoID int64
+	if respondido != nil && respondido.UsuarioID != nil {
+		respondidoID = *respondido.UsuarioID
+		s.notificacoes.Notificar(novaNotificacao(respondidoID, dominio.NotificacaoResposta))
+	}
+	if avaliacao.UsuarioID != respondidoID {
+		s.notificacoes.Notificar(novaNotificacao(avaliacao.UsuarioID, dominio.NotificacaoComentario))
+	}
+}
+
+// Listar retorna uma página de comentários de primeiro nível, cada um com todas as suas respostas,
+// e o cursor da página seguinte (vazio na última).
+func (s *comentarioServicoImpl) Listar(avaliacaoID int64, cursor string, limite int) ([]dominio.ComentarioAvaliacao, string, error) {
+	if limite <= 0 {
+		limite = LimitePadraoComentarios
+	} else if limite > LimiteMaximoComentarios {
+		limite = LimiteMaximoComentarios
+	}
+	var aposID int64
+	if cursor != "" {
+		id, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, "", ErrCursorComentariosInvalido
+		}
+		aposID = id
+	}
+	if _, err := s.buscarPublicada(avaliacaoID); err != nil {
+		return nil, "", err
+	}
+
+	// Um comentário a mais indica se existe uma próxima página.
+	comentarios, err := s.repo.ListarPrimeiroNivel(avaliacaoID, aposID, limite+1)
+	if err != nil {
+		return nil, "", err
+	}
+	proximo := ""
+	if len(comentarios) > limite {
+		comentarios = comentarios[:limite]
+		proximo = strconv.FormatInt(comentarios[limite-1].ID, 10)
+	}
+
+	ids := make([]int64, 0, len(comentarios))
+	indices := make(map[int64]int, len(comentarios))
+	for i, comentario := range comentarios {
+		ids = append(ids, comentario.ID)
+		indices[comentario.ID] = i
+	}
+	respostas, err := s.repo.ListarRespostas(ids)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, resposta := range respostas {
+		i := indices[*resposta.RespostaA]
+		comentarios[i].Respostas = append(comentarios[i].Respostas, resposta)
+	}
+	return comentarios, proximo, nil
+}
+
+// Editar troca o texto de um comentário do próprio usuário.
+func (s *comentarioServicoImpl) Editar(usuarioID, comentarioID int64, input EditarComentarioInput) (*dominio.ComentarioAvaliacao, error) {
+	texto := strings.TrimSpace(input.Texto)
+	if texto == "" {
+		return nil, ErrComentarioVazio
+	}
+	comentario, err := s.buscar(comentarioID)
+	if err != nil {
+		return nil, err
+	}
+	if !escritoPor(comentario, usuarioID) {
+		return nil, ErrSemPermissaoComentario
+	}
+
+	agora := time.Now().UTC()
+	if err := s.repo.AtualizarTexto(comentarioID, texto, agora); err != nil {
+		return nil, err
+	}
+	comentario.Texto, comentario.DataAtualizacao = texto, agora
+	return comentario, nil
+}
+
+// Excluir apaga o comentário, junto com as respostas a ele. Podem apagar o autor do comentário,
+// o autor da avaliação e os moderadores.
+func (s *comentarioServicoImpl) Excluir(usuarioID, comentarioID int64) error {
+	comentario, err := s.buscar(comentarioID)
+	if err != nil {
+		return err
+	}
+
+	if !escritoPor(comentario, usuarioID) {
+		avaliacao, err := s.buscarAvaliacao(comentario.AvaliacaoID)
+		if err != nil {
+			return err
+		}
+		if avaliacao.UsuarioID != usuarioID {
+			usuario, err := s.usuarioRepo.BuscarPorID(usuarioID)
+			if err != nil {
+				return err
+			}
+			if usuario.Papel != dominio.PapelModerador {
+				return ErrSemPermissaoComentario
+			}
+		}
+	}
+	return s.repo.Deletar(comentarioID)
+}
+
+// buscar encontra o comentário, traduzindo a ausência para ErrComentarioNaoEncontrado.
+func (s *comentarioServicoImpl) buscar(comentarioID int64) (*dominio.ComentarioAvaliacao, error) {
+	comentario, err := s.repo.BuscarPorID(comentarioID)
+	if err == sql.ErrNoRows {
+		return nil, ErrComentarioNaoEncontrado
+	}
+	return comentario, err
+}
+
+// buscarPublicada é como buscarAvaliacao, mas trata as avaliações pendentes de moderação como
+// ausentes: elas ainda não podem ser lidas nem comentadas.
+func (s *comentarioServicoImpl) buscarPublicada(avaliacaoID int64) (*dominio.Avaliacao, error) {
+	avaliacao, err := s.buscarAvaliacao(avaliacaoID)
+	if err == nil && avaliacao.Situacao != dominio.SituacaoPublicada {
+		return nil, ErrAvaliacaoNaoEncontrada
+	}
+	return avaliacao, err
+}
+
+// buscarAvaliacao encontra a avaliação comentada, traduzindo a ausência para ErrAvaliacaoNaoEncontrada.
+func (s *comentarioServicoImpl) buscarAvaliacao(avaliacaoID int64) (*dominio.Avaliacao, error) {
+	avaliacao, err := s.avaliacaoRepo.BuscarPorID(avaliacaoID)
+	if err == sql.ErrNoRows {
+		return nil, ErrAvaliacaoNaoEncontrada
+	}
+	return avaliacao, err
+}
+
+// escritoPor informa se o comentário é do usuário; os de contas excluídas não são de ninguém.
+func escritoPor(comentario *dominio.ComentarioAvaliacao, usuarioID int64) bool {
+	return comentario.UsuarioID != nil && *comentario.UsuarioID == usuarioID
+}
@@ -0,0 +1,329 @@
+package servico
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// CarenciaExclusaoConta é o prazo entre o pedido de exclusão e a remoção definitiva.
+// Entrar novamente na conta dentro desse prazo cancela a exclusão.
+const CarenciaExclusaoConta = 30 * 24 * time.Hour
+
+// ExcluirContaInput exige reautenticação: a senha (quando a conta tem uma) e,
+// se o 2FA estiver ativo, um código válido.
+type ExcluirContaInput struct {
+	Senha  string `json:"senha"`
+	Codigo string `json:"codigo"`
+}
+
+// PerfilExportado reúne os dados cadastrais do usuário na exportação.
+type PerfilExportado struct {
+	ID                 int64                       `json:"id"`
+	Nome               string                      `json:"nome"`
+	Email              string                      `json:"email"`
+	DoisFatoresAtivo   bool                        `json:"doisFatoresAtivo"`
+	ExclusaoAgendadaEm *time.Time                  `json:"exclusaoAgendadaEm,omitempty"`
+	SuspensoAte        *time.Time                  `json:"suspensoAte,omitempty"`
+	PerfilPublico      *dominio.Perfil             `json:"perfilPublico,omitempty"`
+	Identidades        []dominio.IdentidadeExterna `json:"identidades"`
+	Sessoes            []dominio.Sessao            `json:"sessoes"`
+}
+
+// ExportacaoConta contém todos os dados pessoais guardados pela aplicação.
+type ExportacaoConta struct {
+	GeradoEm                 time.Time                     `json:"geradoEm"`
+	Perfil                   PerfilExportado               `json:"perfil"`
+	Favoritos                []dominio.FilmeFavorito       `json:"favoritos"`
+	Listas                   []dominio.ListaComItens       `json:"listas"`
+	ListasCompartilhadas     []dominio.Lista               `json:"listasCompartilhadas"` // Listas de outros usuários das quais é membro
+	Diario                   []dominio.EntradaDiario       `json:"diario"`
+	Avaliacoes               []dominio.Avaliacao           `json:"avaliacoes"`
+	HistoricoAvaliacoes      []dominio.VersaoAvaliacao     `json:"historicoAvaliacoes"` // Versões editadas ou excluídas
+	Reacoes                  []dominio.ReacaoAvaliacao     `json:"reacoes"`             // Dadas às avaliações de outras pessoas
+	Comentarios              []dominio.ComentarioAvaliacao `json:"comentarios"`
+	DecisoesFiltro           []dominio.DecisaoFiltro       `json:"decisoesFiltro"` // Avaliações retidas ou rejeitadas pelo filtro de conteúdo
+	Denuncias                []dominio.Denuncia            `json:"denuncias"`      // Feitas pelo usuário
+	Advertencias             []dominio.AcaoModeracao       `json:"advertencias"`   // Ações da moderação contra o conteúdo ou a conta
+	HistoricoQuiz            []dominio.RegistroQuiz        `json:"historicoQuiz"`
+	Importacoes              []dominio.Importacao          `json:"importacoes"`
+	Seguindo                 []dominio.UsuarioConexao      `json:"seguindo"`
+	Seguidores               []dominio.UsuarioConexao      `json:"seguidores"`
+	Atividades               []dominio.Atividade           `json:"atividades"` // Registradas para o feed dos seguidores
+	Notificacoes             []dominio.Notificacao         `json:"notificacoes"`
+	PreferenciasNotificacoes map[string]bool               `json:"preferenciasNotificacoes"` // Só os tipos desligados ou religados; os demais estão ativos
+}
+
+// ContaServico define a exportação dos dados pessoais e a exclusão da conta.
+type ContaServico interface {
+	Exportar(usuarioID int64) (*ExportacaoConta, error)
+	AgendarExclusao(usuarioID int64, input ExcluirContaInput) (time.Time, error)
+}
+
+type contaServicoImpl struct {
+	usuarioRepo     repositorio.UsuarioRepositorio
+	perfilRepo      repositorio.PerfilRepositorio
+	favoritoRepo    repositorio.FavoritoRepositorio
+	listaRepo       repositorio.ListaRepositorio
+	colaboracaoRepo repositorio.ColaboracaoRepositorio
+	diarioRepo      repositorio.DiarioRepositorio
+	avaliacaoRepo   repositorio.AvaliacaoRepositorio
+	comentarioRepo  repositorio.ComentarioRepositorio
+	moderacaoRepo   repositorio.ModeracaoRepositorio
+	quizRepo        repositorio.QuizRepositorio
+	importacaoRepo  repositorio.ImportacaoRepositorio
+	seguidorRepo    repositorio.SeguidorRepositorio
+	atividadeRepo   repositorio.AtividadeRepositorio
+	notificacaoRepo repositorio.NotificacaoRepositorio
+	identidadeRepo  repositorio.IdentidadeRepositorio
+	sessaoRepo      repositorio.SessaoRepositorio
+	doisFatores     DoisFatoresServico
+}
+
+// NovaContaServico cria o serviço de conta com os repositórios que guardam dados do usuário.
+func NovaContaServico(
+	usuarioRepo repositorio.UsuarioRepositorio,
+	perfilRepo repositorio.PerfilRepositorio,
+	favoritoRepo repositorio.FavoritoRepositorio,
+	listaRepo repositorio.ListaRepositorio,
+	colaboracaoRepo repositorio.ColaboracaoRepositorio,
+	diarioRepo repositorio.DiarioRepositorio,
+	avaliacaoRepo repositorio.AvaliacaoRepositorio,
+	comentarioRepo repositorio.ComentarioRepositorio,
+	moderacaoRepo repositorio.ModeracaoRepositorio,
+	quizRepo repositorio.QuizRepositorio,
+	importacaoRepo repositorio.ImportacaoRepositorio,
+	seguidorRepo repositorio.SeguidorRepositorio,
+	atividadeRepo repositorio.AtividadeRepositorio,
+	notificacaoRepo repositorio.NotificacaoRepositorio,
+	identidadeRepo repositorio.IdentidadeRepositorio,
+	sessaoRepo repositorio.SessaoRepositorio,
+	doisFatores DoisFatoresServico,
+) ContaServico {
+	return &contaServicoImpl{
+		usuarioRepo:     usuarioRepo,
+		perfilRepo:      perfilRepo,
+		favoritoRepo:    favoritoRepo,
+		listaRepo:       listaRepo,
+		colaboracaoRepo: colaboracaoRepo,
+		diarioRepo:      diarioRepo,
+		avaliacaoRepo:   avaliacaoRepo,
+		comentarioRepo:  comentarioRepo,
+		moderacaoRepo:   moderacaoRepo,
+		quizRepo:        quizRepo,
+		importacaoRepo:  importacaoRepo,
+		seguidorRepo:    seguidorRepo,
+		atividadeRepo:   atividadeRepo,
+		notificacaoRepo: notificacaoRepo,
+		identidadeRepo:  identidadeRepo,
+		sessaoRepo:      sessaoRepo,
+		doisFatores:     doisFatores,
+	}
+}
+
+// Exportar reúne o perfil, os favoritos, as listas (próprias e compartilhadas), o diário, as avaliações, as reações e os
+// comentários, as denúncias e advertências, o histórico do quiz, as importações, os seguidores,
+// as atividades e as notificações do usuário.
+// Segredos (hash da senha, segredo TOTP, códigos de recuperação) nunca são exportados.
+func (s *contaServicoImpl) Exportar(usuarioID int64) (*ExportacaoConta, error) {
+	usuario, err := s.usuarioRepo.BuscarPorID(usuarioID)
+	if err != nil {
+		return nil, err
+	}
+
+	exportacao := &ExportacaoConta{
+		GeradoEm: time.Now().UTC(),
+		Perfil: PerfilExportado{
+			ID:                 usuario.ID,
+			Nome:               usuario.Nome,
+			Email:              usuario.Email,
+			DoisFatoresAtivo:   usuario.TOTPAtivo,
+			ExclusaoAgendadaEm: usuario.ExclusaoAgendadaEm,
+			SuspensoAte:        usuario.SuspensoAte,
+		},
+	}
+
+	// O perfil público só existe depois que o usuário o consulta ou configura.
+	if exportacao.Perfil.PerfilPublico, err = s.perfilRepo.BuscarPorUsuarioID(usuarioID); err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if exportacao.Perfil.Identidades, err = s.identidadeRepo.ListarPorUsuarioID(usuarioID); err != nil {
+		return nil, err
+	}
+	if exportacao.Perfil.Sessoes, err = s.sessaoRepo.ListarAtivas(usuarioID); err != nil {
+		return nil, err
+	}
+	if exportacao.Favoritos, err = s.favoritoRepo.ListarPorUsuarioID(usuarioID); err != nil {
+		return nil, err
+	}
+	if exportacao.Listas, err = s.exportarListas(usuarioID); err != nil {
+		return nil, err
+	}
+	if exportacao.ListasCompartilhadas, err = s.colaboracaoRepo.ListarListasDoMembro(usuarioID); err != nil {
+		return nil, err
+	}
+	if exportacao.Diario, err = s.diarioRepo.ListarPorUsuarioID(usuarioID); err != nil {
+		return nil, err
+	}
+	if exportacao.Avaliacoes, err = s.avaliacaoRepo.ListarPorUsuarioID(usuarioID); err != nil {
+		return nil, err
+	}
+	if exportacao.HistoricoAvaliacoes, err = s.avaliacaoRepo.ListarHistoricoDoUsuario(usuarioID); err != nil {
+		return nil, err
+	}
+	if exportacao.Reacoes, err = s.avaliacaoRepo.ListarReacoesDoUsuario(usuarioID); err != nil {
+		return nil, err
+	}
+	if exportacao.Comentarios, err = s.comentarioRepo.ListarPorUsuarioID(usuarioID); err != nil {
+		return nil, err
+	}
+	if exportacao.DecisoesFiltro, err = s.avaliacaoRepo.ListarDecisoesFiltroDoUsuario(usuarioID); err != nil {
+		return nil, err
+	}
+	if exportacao.Denuncias, err = s.moderacaoRepo.ListarDenunciasDoUsuario(usuarioID); err != nil {
+		return nil, err
+	}
+	if exportacao.Advertencias, err = s.moderacaoRepo.ListarAcoesContraUsuario(usuarioID); err != nil {
+		return nil, err
+	}
+	for i := range exportacao.Advertencias {
+		exportacao.Advertencias[i].ModeradorID = nil
+	}
+	if exportacao.HistoricoQuiz, err = s.quizRepo.ListarPorUsuarioID(usuarioID); err != nil {
+		return nil, err
+	}
+	if exportacao.Importacoes, err = s.importacaoRepo.ListarPorUsuarioID(usuarioID); err != nil {
+		return nil, err
+	}
+	if exportacao.Seguindo, err = s.seguidorRepo.ListarSeguindo(usuarioID, 0, -1); err != nil {
+		return nil, err
+	}
+	if exportacao.Seguidores, err = s.seguidorRepo.ListarSeguidores(usuarioID, 0, -1); err != nil {
+		return nil, err
+	}
+	if exportacao.Atividades, err = s.atividadeRepo.ListarPorUsuarioID(usuarioID); err != nil {
+		return nil, err
+	}
+	if exportacao.Notificacoes, err = s.notificacaoRepo.ListarPorUsuarioID(usuarioID); err != nil {
+		return nil, err
+	}
+	if exportacao.PreferenciasNotificacoes, err = s.notificacaoRepo.BuscarPreferencias(usuarioID); err != nil {
+		return nil, err
+	}
+
+	// Seções vazias aparecem como listas vazias, e não como null, no arquivo exportado.
+	if exportacao.Perfil.Identidades == nil {
+		exportacao.Perfil.Identidades = make([]dominio.IdentidadeExterna, 0)
+	}
+	if exportacao.Perfil.Sessoes == nil {
+		exportacao.Perfil.Sessoes = make([]dominio.Sessao, 0)
+	}
+	if exportacao.Favoritos == nil {
+		exportacao.Favoritos = make([]dominio.FilmeFavorito, 0)
+	}
+	if exportacao.Listas == nil {
+		exportacao.Listas = make([]dominio.ListaComItens, 0)
+	}
+	if exportacao.ListasCompartilhadas == nil {
+		exportacao.ListasCompartilhadas = make([]dominio.Lista, 0)
+	}
+	if exportacao.Diario == nil {
+		exportacao.Diario = make([]dominio.EntradaDiario, 0)
+	}
+	if exportacao.Avaliacoes == nil {
+		exportacao.Avaliacoes = make([]dominio.Avaliacao, 0)
+	}
+	if exportacao.HistoricoAvaliacoes == nil {
+		exportacao.HistoricoAvaliacoes = make([]dominio.VersaoAvaliacao, 0)
+	}
+	if exportacao.Reacoes == nil {
+		exportacao.Reacoes = make([]dominio.ReacaoAvaliacao, 0)
+	}
+	if exportacao.Comentarios == nil {
+		exportacao.Comentarios = make([]dominio.ComentarioAvaliacao, 0)
+	}
+	if exportacao.DecisoesFiltro == nil {
+		exportacao.DecisoesFiltro = make([]dominio.DecisaoFiltro, 0)
+	}
+	if exportacao.Denuncias == nil {
+		exportacao.Denuncias = make([]dominio.Denuncia, 0)
+	}
+	if exportacao.Advertencias == nil {
+		exportacao.Advertencias = make([]dominio.AcaoModeracao, 0)
+	}
+	if exportacao.HistoricoQuiz == nil {
+		exportacao.HistoricoQuiz = make([]dominio.RegistroQuiz, 0)
+	}
+	if exportacao.Importacoes == nil {
+		exportacao.Importacoes = make([]dominio.Importacao, 0)
+	}
+	if exportacao.Seguindo == nil {
+		exportacao.Seguindo = make([]dominio.UsuarioConexao, 0)
+	}
+	if exportacao.Seguidores == nil {
+		exportacao.Seguidores = make([]dominio.UsuarioConexao, 0)
+	}
+	if exportacao.Atividades == nil {
+		exportacao.Atividades = make([]dominio.Atividade, 0)
+	}
+	if exportacao.Notificacoes == nil {
+		exportacao.Notificacoes = make([]dominio.Notificacao, 0)
+	}
+
+	return exportacao, nil
+}
+
+// exportarListas reúne as listas do usuário com seus itens.
+func (s *contaServicoImpl) exportarListas(usuarioID int64) ([]dominio.ListaComItens, error) {
+	listas, err := s.listaRepo.ListarPorUsuarioID(usuarioID)
+	if err != nil {
+		return nil, err
+	}
+
+	exportadas := make([]dominio.ListaComItens, 0, len(listas))
+	for _, lista := range listas {
+		itens, err := s.listaRepo.ListarItens(lista.ID)
+		if err != nil {
+			return nil, err
+		}
+		if itens == nil {
+			itens = make([]dominio.ItemLista, 0)
+		}
+		exportadas = append(exportadas, dominio.ListaComItens{Lista: lista, Itens: itens})
+	}
+	return exportadas, nil
+}
+
+// AgendarExclusao confirma a identidade do usuário, agenda a remoção da conta para o fim
+// do prazo de carência e encerra todas as sessões. Retorna a data da exclusão definitiva.
+func (s *contaServicoImpl) AgendarExclusao(usuarioID int64, input ExcluirContaInput) (time.Time, error) {
+	usuario, err := s.usuarioRepo.BuscarPorID(usuarioID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// Contas criadas via provedor externo não têm senha; nelas vale o 2FA, se ativo.
+	if usuario.SenhaHash != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(usuario.SenhaHash), []byte(input.Senha)); err != nil {
+			return time.Time{}, ErrCredenciaisInvalidas
+		}
+	}
+	if usuario.TOTPAtivo {
+		if err := s.doisFatores.VerificarCodigo(usuario, input.Codigo); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	data := time.Now().Add(CarenciaExclusaoConta).UTC()
+	if err := s.usuarioRepo.AgendarExclusao(usuarioID, data); err != nil {
+		return time.Time{}, err
+	}
+	// Sem sessão a preservar, todas são encerradas, inclusive a da requisição atual.
+	if _, err := s.sessaoRepo.RevogarOutras(usuarioID, ""); err != nil {
+		return time.Time{}, err
+	}
+	return data, nil
+}
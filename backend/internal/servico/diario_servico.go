@@ -0,0 +1,166 @@
+package servico
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+)
+
+// Define os erros que o serviço do diário pode retornar.
+var (
+	ErrDataAssistidoInvalida  = errors.New("a data deve estar no formato AAAA-MM-DD e não pode estar no futuro")
+	ErrAvaliacaoNaoVinculavel = errors.New("a avaliação informada não existe ou é de outro filme")
+	ErrEntradaDiarioNaoExiste = errors.New("entrada do diário não encontrada")
+)
+
+// formatoDataDiario é o layout das datas do diário (AAAA-MM-DD).
+const formatoDataDiario = "2006-01-02"
+
+// RegistrarDiarioInput define os campos de uma nova entrada no diário.
+type RegistrarDiarioInput struct {
+	FilmeID       int64  `json:"filmeId" binding:"required"` // Título e pôster vêm do catálogo
+	DataAssistido string `json:"dataAssistido"`              // AAAA-MM-DD; se ausente, usa a data de hoje
+	Revisto       *bool  `json:"revisto"`                    // Se ausente, é verdadeiro quando o filme já está no diário antes dessa data
+	AvaliacaoID   *int64 `json:"avaliacaoId"`                // Avaliação do próprio usuário para o mesmo filme
+}
+
+// MesDiario agrupa as entradas do diário de um mês.
+type MesDiario struct {
+	Mes      int                     `json:"mes"`
+	Entradas []dominio.EntradaDiario `json:"entradas"`
+}
+
+// DiarioAno é a resposta do diário de um ano, com os meses do mais recente para o mais antigo.
+type DiarioAno struct {
+	Ano   int         `json:"ano"`
+	Total int         `json:"total"`
+	Meses []MesDiario `json:"meses"`
+}
+
+// DiarioServico define o registro e a consulta do diário de filmes assistidos.
+type DiarioServico interface {
+	Registrar(usuarioID int64, input RegistrarDiarioInput) (*dominio.EntradaDiario, error)
+	ListarAno(usuarioID int64, ano int) (*DiarioAno, error)
+	Remover(usuarioID, entradaID int64) error
+}
+
+type diarioServicoImpl struct {
+	repo          repositorio.DiarioRepositorio
+	avaliacaoRepo repositorio.AvaliacaoRepositorio
+	atividadeRepo repositorio.AtividadeRepositorio
+	catalogo      CatalogoServico
+}
+
+// NovoDiarioServico cria o serviço do diário.
+func NovoDiarioServico(repo repositorio.DiarioRepositorio, avaliacaoRepo repositorio.AvaliacaoRepositorio, atividadeRepo repositorio.AtividadeRepositorio, catalogo CatalogoServico) DiarioServico {
+	return &diarioServicoImpl{repo: repo, avaliacaoRepo: avaliacaoRepo, atividadeRepo: atividadeRepo, catalogo: catalogo}
+}
+
+// Registrar adiciona uma entrada ao diário. O filme sai da lista "para assistir".
+func (s *diarioServicoImpl) Registrar(usuarioID int64, input RegistrarDiarioInput) (*dominio.EntradaDiario, error) {
+	hoje := time.Now().Format(formatoDataDiario)
+
+	dataAssistido := input.DataAssistido
+	if dataAssistido == "" {
+		dataAssistido = hoje
+	}
+	// A comparação como texto funciona porque o formato AAAA-MM-DD ordena cronologicamente.
+	if _, err := time.Parse(formatoDataDiario, dataAssistido); err != nil || dataAssistido > hoje {
+		return nil, ErrDataAssistidoInvalida
+	}
+
+	if input.AvaliacaoID != nil {
+		avaliacao, err := s.avaliacaoRepo.BuscarDoUsuario(usuarioID, *input.AvaliacaoID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, ErrAvaliacaoNaoVinculavel
+			}
+			return nil, err
+		}
+		if avaliacao.FilmeID != input.FilmeID {
+			return nil, ErrAvaliacaoNaoVinculavel
+		}
+	}
+
+	// Confirma que o filme existe no catálogo e usa os dados de lá.
+	metadados, err := s.catalogo.Obter(input.FilmeID)
+	if err != nil {
+		return nil, err
+	}
+
+	entrada := &dominio.EntradaDiario{
+		UsuarioID:     usuarioID,
+		FilmeID:       input.FilmeID,
+		Titulo:        metadados.Titulo,
+		CaminhoPoster: metadados.CaminhoPoster,
+		DataAssistido: dataAssistido,
+		AvaliacaoID:   input.AvaliacaoID,
+	}
+	if input.Revisto != nil {
+		entrada.Revisto = *input.Revisto
+	} else {
+		jaAssistido, err := s.repo.JaAssistido(usuarioID, input.FilmeID, dataAssistido)
+		if err != nil {
+			return nil, err
+		}
+		entrada.Revisto = jaAssistido
+	}
+
+	if err := s.repo.Criar(entrada); err != nil {
+		return nil, err
+	}
+	registrarAtividade(s.atividadeRepo, &dominio.Atividade{
+		UsuarioID:     usuarioID,
+		Tipo:          dominio.AtividadeDiario,
+		FilmeID:       &entrada.FilmeID,
+		Titulo:        entrada.Titulo,
+		CaminhoPoster: entrada.CaminhoPoster,
+		DiarioID:      &entrada.ID,
+	})
+	return entrada, nil
+}
+
+// ListarAno retorna as entradas do ano agrupadas por mês.
+func (s *diarioServicoImpl) ListarAno(usuarioID int64, ano int) (*DiarioAno, error) {
+	inicio := fmt.Sprintf("%04d-01-01", ano)
+	fim := fmt.Sprintf("%04d-01-01", ano+1)
+
+	entradas, err := s.repo.ListarPorPeriodo(usuarioID, inicio, fim)
+	if err != nil {
+		return nil, err
+	}
+
+	diario := &DiarioAno{Ano: ano, Total: len(entradas), Meses: make([]MesDiario, 0)}
+	for _, entrada := range entradas {
+		// As entradas chegam ordenadas por data, então cada mês forma um bloco contínuo.
+		data, err := time.Parse(formatoDataDiario, entrada.DataAssistido)
+		if err != nil {
+			return nil, err
+		}
+		mes := int(data.Month())
+
+		if n := len(diario.Meses); n == 0 || diario.Meses[n-1].Mes != mes {
+			diario.Meses = append(diario.Meses, MesDiario{Mes: mes})
+		}
+		atual := &diario.Meses[len(diario.Meses)-1]
+		atual.Entradas = append(atual.Entradas, entrada)
+	}
+
+	return diario, nil
+}
+
+// Remover apaga uma entrada do diário do usuário.
+func (s *diarioServicoImpl) Remover(usuarioID, entradaID int64) error {
+	removida, err := s.repo.Deletar(usuarioID, entradaID)
+	if err != nil {
+		return err
+	}
+	if !removida {
+		return ErrEntradaDiarioNaoExiste
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package servico_test
+
+import (
+	"testing"
+
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistrarDiarioUsaDadosDoCatalogo(t *testing.T) {
+	db := novoBanco(t)
+	ana := novoUsuario(t, db, "Ana", "ana@example.com")
+	diario := servico.NovoDiarioServico(repositorio.NovoDiarioRepositorio(db), repositorio.NovaAvaliacaoRepositorio(db),
+		repositorio.NovaAtividadeRepositorio(db), catalogoTeste)
+
+	entrada, err := diario.Registrar(ana.ID, servico.RegistrarDiarioInput{FilmeID: 603, DataAssistido: "2024-03-10"})
+	require.NoError(t, err)
+	assert.Equal(t, "Matrix", entrada.Titulo)
+	assert.Equal(t, "/matrix.jpg", entrada.CaminhoPoster)
+
+	_, err = diario.Registrar(ana.ID, servico.RegistrarDiarioInput{FilmeID: 999, DataAssistido: "2024-03-10"})
+	assert.ErrorIs(t, err, servico.ErrFilmeInexistente)
+}
+
+func TestRegistrarDiarioMarcaRevistoPelasDatasAnteriores(t *testing.T) {
+	db := novoBanco(t)
+	ana := novoUsuario(t, db, "Ana", "ana@example.com")
+	diario := servico.NovoDiarioServico(repositorio.NovoDiarioRepositorio(db), repositorio.NovaAvaliacaoRepositorio(db),
+		repositorio.NovaAtividadeRepositorio(db), catalogoTeste)
+
+	segunda, err := diario.Registrar(ana.ID, servico.RegistrarDiarioInput{FilmeID: 603, DataAssistido: "2024-05-01"})
+	require.NoError(t, err)
+	assert.False(t, segunda.Revisto)
+
+	// Uma sessão anterior registrada depois não conta como revisão.
+	primeira, err := diario.Registrar(ana.ID, servico.RegistrarDiarioInput{FilmeID: 603, DataAssistido: "2024-03-10"})
+	require.NoError(t, err)
+	assert.False(t, primeira.Revisto)
+
+	terceira, err := diario.Registrar(ana.ID, servico.RegistrarDiarioInput{FilmeID: 603, DataAssistido: "2024-06-01"})
+	require.NoError(t, err)
+	assert.True(t, terceira.Revisto)
+}
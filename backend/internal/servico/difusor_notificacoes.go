@@ -0,0 +1,151 @@
+package servico
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+)
+
+const (
+	// capacidadeAssinatura é quantas notificações esperam por uma conexão lenta antes de serem
+	// descartadas; quem perdeu alguma a recupera pela listagem ou ao reconectar.
+	capacidadeAssinatura = 32
+
+	// IntervaloDifusorBancoPadrao é de quanto em quanto tempo o difusor "banco" procura
+	// notificações novas.
+	IntervaloDifusorBancoPadrao = time.Second
+
+	// loteDifusorBanco limita as notificações lidas a cada consulta do difusor "banco".
+	loteDifusorBanco = 500
+)
+
+// DifusorNotificacoes entrega as notificações novas às conexões abertas (SSE) dos
+// destinatários. Publicar não pode bloquear quem cria a notificação.
+type DifusorNotificacoes interface {
+	Publicar(notificacao dominio.Notificacao)
+	Assinar(usuarioID int64) (<-chan dominio.Notificacao, func())
+}
+
+type difusorLocal struct {
+	mu         sync.Mutex
+	assinantes map[int64]map[chan dominio.Notificacao]struct{}
+}
+
+// NovoDifusorLocal cria o difusor padrão, que só alcança as conexões abertas neste processo.
+// Serve quando há uma única instância do servidor.
+func NovoDifusorLocal() DifusorNotificacoes {
+	return &difusorLocal{assinantes: make(map[int64]map[chan dominio.Notificacao]struct{})}
+}
+
+// Publicar envia a notificação a todas as conexões do destinatário. Uma conexão com a fila
+// cheia perde a notificação em vez de atrasar as outras.
+func (d *difusorLocal) Publicar(notificacao dominio.Notificacao) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for canal := range d.assinantes[notificacao.UsuarioID] {
+		select {
+		case canal <- notificacao:
+		default:
+		}
+	}
+}
+
+// Assinar abre uma fila para as notificações do usuário. A função retornada a encerra e deve
+// ser chamada quando a conexão fechar.
+func (d *difusorLocal) Assinar(usuarioID int64) (<-chan dominio.Notificacao, func()) {
+	canal := make(chan dominio.Notificacao, capacidadeAssinatura)
+	d.mu.Lock()
+	if d.assinantes[usuarioID] == nil {
+		d.assinantes[usuarioID] = make(map[chan dominio.Notificacao]struct{})
+	}
+	d.assinantes[usuarioID][canal] = struct{}{}
+	d.mu.Unlock()
+
+	var encerrar sync.Once
+	return canal, func() {
+		encerrar.Do(func() {
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			delete(d.assinantes[usuarioID], canal)
+			if len(d.assinantes[usuarioID]) == 0 {
+				delete(d.assinantes, usuarioID)
+			}
+			close(canal)
+		})
+	}
+}
+
+type difusorBanco struct {
+	local DifusorNotificacoes
+	repo  repositorio.NotificacaoRepositorio
+}
+
+// NovoDifusorBanco cria um difusor para várias instâncias do servidor com o mesmo banco: cada
+// instância procura, a cada intervalo, as notificações gravadas por qualquer uma delas e as
+// entrega às suas próprias conexões. Só as notificações criadas depois da partida são entregues.
+func NovoDifusorBanco(repo repositorio.NotificacaoRepositorio, intervalo time.Duration) (DifusorNotificacoes, error) {
+	ultimoID, err := repo.UltimoID()
+	if err != nil {
+		return nil, err
+	}
+	d := &difusorBanco{local: NovoDifusorLocal(), repo: repo}
+	go d.acompanhar(ultimoID, intervalo)
+	return d, nil
+}
+
+// Publicar não faz nada: a notificação já está no banco e chega a todas as instâncias,
+// inclusive esta, pela consulta periódica.
+func (d *difusorBanco) Publicar(dominio.Notificacao) {}
+
+// Assinar abre uma fila para as notificações do usuário nesta instância.
+func (d *difusorBanco) Assinar(usuarioID int64) (<-chan dominio.Notificacao, func()) {
+	return d.local.Assinar(usuarioID)
+}
+
+// acompanhar entrega as notificações novas a cada intervalo, sem esperar enquanto houver
+// lotes cheios.
+func (d *difusorBanco) acompanhar(ultimoID int64, intervalo time.Duration) {
+	for {
+		notificacoes, err := d.repo.ListarApos(ultimoID, loteDifusorBanco)
+		if err != nil {
+			log.Printf("Falha ao buscar notificações novas: %v", err)
+		}
+		for _, notificacao := range notificacoes {
+			d.local.Publicar(notificacao)
+			ultimoID = notificacao.ID
+		}
+		if len(notificacoes) < loteDifusorBanco {
+			time.Sleep(intervalo)
+		}
+	}
+}
+
+// CarregarDifusorDoAmbiente escolhe o difusor de notificações pelas variáveis de ambiente:
+//
+//   - NOTIFICACOES_DIFUSOR: local (padrão, uma única instância) ou banco (várias instâncias
+//     com o mesmo banco de dados)
+//   - NOTIFICACOES_INTERVALO: intervalo entre as consultas do difusor banco, como "500ms" (padrão 1s)
+func CarregarDifusorDoAmbiente(repo repositorio.NotificacaoRepositorio) (DifusorNotificacoes, error) {
+	switch tipo := strings.ToLower(strings.TrimSpace(os.Getenv("NOTIFICACOES_DIFUSOR"))); tipo {
+	case "", "local":
+		return NovoDifusorLocal(), nil
+	case "banco":
+		intervalo := IntervaloDifusorBancoPadrao
+		if valor := os.Getenv("NOTIFICACOES_INTERVALO"); valor != "" {
+			var err error
+			intervalo, err = time.ParseDuration(valor)
+			if err != nil || intervalo <= 0 {
+				return nil, fmt.Errorf("NOTIFICACOES_INTERVALO deve ser uma duração positiva, como 500ms: %s", valor)
+			}
+		}
+		return NovoDifusorBanco(repo, intervalo)
+	default:
+		return nil, fmt.Errorf("NOTIFICACOES_DIFUSOR deve ser local ou banco: %s", tipo)
+	}
+}
@@ -0,0 +1,273 @@
+package servico
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/auth"
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Define os erros que o serviço de dois fatores pode retornar.
+var (
+	ErrDoisFatoresJaAtivo     = errors.New("a autenticação em dois fatores já está ativa")
+	ErrDoisFatoresInativo     = errors.New("a autenticação em dois fatores não está ativa")
+	ErrDoisFatoresNaoIniciado = errors.New("inicie o cadastro da autenticação em dois fatores antes de confirmar")
+	ErrCodigoInvalido         = errors.New("código de verificação inválido")
+	ErrMuitasTentativas       = errors.New("muitas tentativas inválidas; tente novamente mais tarde")
+)
+
+const (
+	emissorTOTP                  = "CineHub"
+	quantidadeCodigosRecuperacao = 10
+	limiteFalhas2FA              = 5
+	bloqueioFalhas2FA            = 15 * time.Minute
+)
+
+// alfabetoCodigosRecuperacao evita caracteres ambíguos como 0/o e 1/l.
+const alfabetoCodigosRecuperacao = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// ConfiguracaoDoisFatores é retornada no início do cadastro para ser exibida como QR code.
+type ConfiguracaoDoisFatores struct {
+	Segredo string `json:"segredo"`
+	URI     string `json:"uri"`
+}
+
+// StatusDoisFatores resume a situação do 2FA na conta do usuário.
+type StatusDoisFatores struct {
+	Ativo            bool `json:"ativo"`
+	CodigosRestantes int  `json:"codigosRestantes"`
+}
+
+// CodigoDoisFatoresInput define o corpo com um código TOTP ou de recuperação.
+type CodigoDoisFatoresInput struct {
+	Codigo string `json:"codigo" binding:"required"`
+}
+
+// DesativarDoisFatoresInput exige reautenticação: a senha (quando a conta tem uma) e um código válido.
+type DesativarDoisFatoresInput struct {
+	Senha  string `json:"senha"`
+	Codigo string `json:"codigo" binding:"required"`
+}
+
+// DoisFatoresServico define o cadastro e a verificação da autenticação em dois fatores.
+type DoisFatoresServico interface {
+	Status(usuarioID int64) (*StatusDoisFatores, error)
+	IniciarCadastro(usuarioID int64) (*ConfiguracaoDoisFatores, error)
+	ConfirmarCadastro(usuarioID int64, input CodigoDoisFatoresInput) ([]string, error)
+	Desativar(usuarioID int64, input DesativarDoisFatoresInput) error
+	VerificarCodigo(usuario *dominio.Usuario, codigo string) error
+}
+
+type doisFatoresServicoImpl struct {
+	usuarioRepo repositorio.UsuarioRepositorio
+	codigoRepo  repositorio.CodigoRecuperacaoRepositorio
+}
+
+// NovoDoisFatoresServico cria o serviço de autenticação em dois fatores.
+func NovoDoisFatoresServico(usuarioRepo repositorio.UsuarioRepositorio, codigoRepo repositorio.CodigoRecuperacaoRepositorio) DoisFatoresServico {
+	return &doisFatoresServicoImpl{usuarioRepo: usuarioRepo, codigoRepo: codigoRepo}
+}
+
+// Status informa se o 2FA está ativo e quantos códigos de recuperação restam.
+func (s *doisFatoresServicoImpl) Status(usuarioID int64) (*StatusDoisFatores, error) {
+	usuario, err := s.usuarioRepo.BuscarPorID(usuarioID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &StatusDoisFatores{Ativo: usuario.TOTPAtivo}
+	if usuario.TOTPAtivo {
+		if status.CodigosRestantes, err = s.codigoRepo.ContarDisponiveis(usuarioID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// IniciarCadastro gera um novo segredo, que só passa a valer após a confirmação.
+func (s *doisFatoresServicoImpl) IniciarCadastro(usuarioID int64) (*ConfiguracaoDoisFatores, error) {
+	usuario, err := s.usuarioRepo.BuscarPorID(usuarioID)
+	if err != nil {
+		return nil, err
+	}
+	if usuario.TOTPAtivo {
+		return nil, ErrDoisFatoresJaAtivo
+	}
+
+	segredo, err := auth.GerarSegredoTOTP()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.usuarioRepo.DefinirSegredoTOTP(usuarioID, segredo); err != nil {
+		return nil, err
+	}
+
+	return &ConfiguracaoDoisFatores{
+		Segredo: segredo,
+		URI:     auth.URIProvisionamentoTOTP(emissorTOTP, usuario.Email, segredo),
+	}, nil
+}
+
+// ConfirmarCadastro ativa o 2FA com o primeiro código do aplicativo e retorna os
+// códigos de recuperação, que são exibidos uma única vez.
+func (s *doisFatoresServicoImpl) ConfirmarCadastro(usuarioID int64, input CodigoDoisFatoresInput) ([]string, error) {
+	usuario, err := s.usuarioRepo.BuscarPorID(usuarioID)
+	if err != nil {
+		return nil, err
+	}
+	if usuario.TOTPAtivo {
+		return nil, ErrDoisFatoresJaAtivo
+	}
+	if usuario.TOTPSegredo == "" {
+		return nil, ErrDoisFatoresNaoIniciado
+	}
+	// A confirmação conta as falhas como o login; sem o bloqueio, daria para testar códigos à vontade.
+	if bloqueado2FA(usuario) {
+		return nil, ErrMuitasTentativas
+	}
+
+	if err := s.verificarTOTP(usuario, input.Codigo); err != nil {
+		return nil, err
+	}
+
+	codigos, hashes, err := gerarCodigosRecuperacao()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.codigoRepo.Substituir(usuarioID, hashes); err != nil {
+		return nil, err
+	}
+	if err := s.usuarioRepo.AtivarTOTP(usuarioID); err != nil {
+		return nil, err
+	}
+
+	return codigos, nil
+}
+
+// Desativar remove o 2FA após confirmar a senha (se houver) e um código válido.
+func (s *doisFatoresServicoImpl) Desativar(usuarioID int64, input DesativarDoisFatoresInput) error {
+	usuario, err := s.usuarioRepo.BuscarPorID(usuarioID)
+	if err != nil {
+		return err
+	}
+	if !usuario.TOTPAtivo {
+		return ErrDoisFatoresInativo
+	}
+
+	// Contas criadas via provedor externo não têm senha; nelas basta o código.
+	if usuario.SenhaHash != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(usuario.SenhaHash), []byte(input.Senha)); err != nil {
+			return ErrCredenciaisInvalidas
+		}
+	}
+
+	if err := s.VerificarCodigo(usuario, input.Codigo); err != nil {
+		return err
+	}
+
+	return s.usuarioRepo.DesativarTOTP(usuarioID)
+}
+
+// VerificarCodigo aceita um código TOTP ou um código de recuperação ainda não usado.
+// Erros consecutivos bloqueiam novas tentativas por um período.
+func (s *doisFatoresServicoImpl) VerificarCodigo(usuario *dominio.Usuario, codigo string) error {
+	if bloqueado2FA(usuario) {
+		return ErrMuitasTentativas
+	}
+
+	codigo = strings.TrimSpace(codigo)
+	if ehCodigoTOTP(codigo) {
+		return s.verificarTOTP(usuario, codigo)
+	}
+
+	usado, err := s.codigoRepo.Usar(usuario.ID, hashCodigoRecuperacao(codigo))
+	if err != nil {
+		return err
+	}
+	if !usado {
+		return s.registrarFalha(usuario.ID)
+	}
+	return nil
+}
+
+// verificarTOTP valida o código e registra o passo usado, rejeitando reutilizações.
+func (s *doisFatoresServicoImpl) verificarTOTP(usuario *dominio.Usuario, codigo string) error {
+	passo, valido := auth.ValidarCodigoTOTP(usuario.TOTPSegredo, codigo, time.Now())
+	if !valido {
+		return s.registrarFalha(usuario.ID)
+	}
+
+	inedito, err := s.usuarioRepo.RegistrarPassoTOTP(usuario.ID, passo)
+	if err != nil {
+		return err
+	}
+	if !inedito {
+		return ErrCodigoInvalido
+	}
+	return nil
+}
+
+// bloqueado2FA indica se as falhas consecutivas ainda impedem novas tentativas.
+func bloqueado2FA(usuario *dominio.Usuario) bool {
+	return usuario.TOTPBloqueadoAte != nil && time.Now().Before(*usuario.TOTPBloqueadoAte)
+}
+
+func (s *doisFatoresServicoImpl) registrarFalha(usuarioID int64) error {
+	if err := s.usuarioRepo.RegistrarFalhaTOTP(usuarioID, limiteFalhas2FA, bloqueioFalhas2FA); err != nil {
+		return err
+	}
+	return ErrCodigoInvalido
+}
+
+func ehCodigoTOTP(codigo string) bool {
+	if len(codigo) != 6 {
+		return false
+	}
+	for _, c := range codigo {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// gerarCodigosRecuperacao cria os códigos no formato "xxxxx-xxxxx" e seus hashes.
+func gerarCodigosRecuperacao() ([]string, []string, error) {
+	codigos := make([]string, 0, quantidadeCodigosRecuperacao)
+	hashes := make([]string, 0, quantidadeCodigosRecuperacao)
+
+	for i := 0; i < quantidadeCodigosRecuperacao; i++ {
+		aleatorio := make([]byte, 10)
+		if _, err := rand.Read(aleatorio); err != nil {
+			return nil, nil, err
+		}
+
+		var codigo strings.Builder
+		for j, b := range aleatorio {
+			if j == 5 {
+				codigo.WriteByte('-')
+			}
+			codigo.WriteByte(alfabetoCodigosRecuperacao[int(b)%len(alfabetoCodigosRecuperacao)])
+		}
+
+		codigos = append(codigos, codigo.String())
+		hashes = append(hashes, hashCodigoRecuperacao(codigo.String()))
+	}
+
+	return codigos, hashes, nil
+}
+
+// hashCodigoRecuperacao normaliza o código (sem hífen e em minúsculas) e calcula o SHA-256.
+// Os códigos são aleatórios e longos, então um hash rápido é suficiente e permite a busca direta.
+func hashCodigoRecuperacao(codigo string) string {
+	normalizado := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(codigo), "-", ""))
+	soma := sha256.Sum256([]byte(normalizado))
+	return hex.EncodeToString(soma[:])
+}
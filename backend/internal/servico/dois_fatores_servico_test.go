@@ -0,0 +1,33 @@
+package servico_test
+
+import (
+	"testing"
+
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmarCadastroBloqueiaAposFalhas(t *testing.T) {
+	db := novoBanco(t)
+	ana := novoUsuario(t, db, "Ana", "ana@example.com")
+	usuarios := repositorio.NovoUsuarioRepositorio(db)
+	doisFatores := servico.NovoDoisFatoresServico(usuarios, repositorio.NovoCodigoRecuperacaoRepositorio(db))
+
+	_, err := doisFatores.IniciarCadastro(ana.ID)
+	require.NoError(t, err)
+
+	// Um código com letras nunca é um TOTP válido.
+	for i := 0; i < 5; i++ {
+		_, err := doisFatores.ConfirmarCadastro(ana.ID, servico.CodigoDoisFatoresInput{Codigo: "abcdef"})
+		require.ErrorIs(t, err, servico.ErrCodigoInvalido)
+	}
+	_, err = doisFatores.ConfirmarCadastro(ana.ID, servico.CodigoDoisFatoresInput{Codigo: "abcdef"})
+	assert.ErrorIs(t, err, servico.ErrMuitasTentativas)
+
+	usuario, err := usuarios.BuscarPorID(ana.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, usuario.TOTPBloqueadoAte)
+	assert.False(t, usuario.TOTPAtivo)
+}
@@ -0,0 +1,98 @@
+package servico
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+// EnviadorEmail entrega mensagens de texto simples aos usuários.
+type EnviadorEmail interface {
+	Enviar(para, assunto, corpo string) error
+}
+
+// ConfigEmail reúne o enviador e o endereço da página do frontend que confirma o e-mail.
+type ConfigEmail struct {
+	Enviador EnviadorEmail
+
+	// URLVerificacao recebe o token no parâmetro 'token' e o envia a POST /v1/auth/email/confirmar.
+	URLVerificacao string
+}
+
+// URLVerificacaoEmailPadrao é a página de confirmação do frontend em desenvolvimento.
+const URLVerificacaoEmailPadrao = "http://localhost:5173/verificar-email"
+
+// enviadorEmailLog apenas registra a mensagem no log; serve ao desenvolvimento, sem servidor SMTP.
+type enviadorEmailLog struct{}
+
+// NovoEnviadorEmailLog cria o enviador que escreve as mensagens no log em vez de enviá-las.
+func NovoEnviadorEmailLog() EnviadorEmail {
+	return enviadorEmailLog{}
+}
+
+func (enviadorEmailLog) Enviar(para, assunto, corpo string) error {
+	log.Printf("E-mail para %s (%s):\n%s", para, assunto, corpo)
+	return nil
+}
+
+// enviadorEmailSMTP envia as mensagens por um servidor SMTP com autenticação PLAIN.
+type enviadorEmailSMTP struct {
+	endereco  string
+	auth      smtp.Auth
+	remetente string
+}
+
+// NovoEnviadorEmailSMTP cria o enviador SMTP. Sem usuário, o servidor é usado sem autenticação.
+func NovoEnviadorEmailSMTP(host, porta, usuario, senha, remetente string) EnviadorEmail {
+	enviador := &enviadorEmailSMTP{endereco: net.JoinHostPort(host, porta), remetente: remetente}
+	if usuario != "" {
+		enviador.auth = smtp.PlainAuth("", usuario, senha, host)
+	}
+	return enviador
+}
+
+func (e *enviadorEmailSMTP) Enviar(para, assunto, corpo string) error {
+	// Quebras de linha no destinatário injetariam cabeçalhos na mensagem.
+	if strings.ContainsAny(para, "\r\n") {
+		return fmt.Errorf("destinatário inválido: %q", para)
+	}
+	mensagem := "From: " + e.remetente + "\r\n" +
+		"To: " + para + "\r\n" +
+		"Subject: " + mime.QEncoding.Encode("utf-8", assunto) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + strings.ReplaceAll(corpo, "\n", "\r\n")
+	return smtp.SendMail(e.endereco, e.auth, e.remetente, []string{para}, []byte(mensagem))
+}
+
+// CarregarConfigEmailDoAmbiente escolhe o enviador de e-mails pelas variáveis de ambiente:
+//
+//   - SMTP_HOST, SMTP_PORTA (padrão 587), SMTP_USUARIO e SMTP_SENHA: servidor de envio; sem
+//     SMTP_HOST, as mensagens vão apenas para o log
+//   - EMAIL_REMETENTE: endereço do remetente, obrigatório com SMTP_HOST
+//   - EMAIL_VERIFICACAO_URL: página do frontend que confirma o e-mail (padrão URLVerificacaoEmailPadrao)
+func CarregarConfigEmailDoAmbiente() (*ConfigEmail, error) {
+	config := &ConfigEmail{Enviador: NovoEnviadorEmailLog(), URLVerificacao: URLVerificacaoEmailPadrao}
+	if valor := os.Getenv("EMAIL_VERIFICACAO_URL"); valor != "" {
+		config.URLVerificacao = valor
+	}
+
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return config, nil
+	}
+	remetente := os.Getenv("EMAIL_REMETENTE")
+	if remetente == "" {
+		return nil, fmt.Errorf("EMAIL_REMETENTE é obrigatório quando SMTP_HOST está definido")
+	}
+	porta := os.Getenv("SMTP_PORTA")
+	if porta == "" {
+		porta = "587"
+	}
+	config.Enviador = NovoEnviadorEmailSMTP(host, porta, os.Getenv("SMTP_USUARIO"), os.Getenv("SMTP_SENHA"), remetente)
+	return config, nil
+}
@@ -0,0 +1,244 @@
+package servico
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+)
+
+// Escopos aceitos pela exportação; o de lista é informado como "lista:<id>".
+const (
+	EscopoExportacaoFavoritos  = "favoritos"
+	EscopoExportacaoAvaliacoes = "avaliacoes"
+	EscopoExportacaoDiario     = "diario"
+	EscopoExportacaoLista      = "lista"
+)
+
+// tamanhoPaginaExportacao é quantos registros são lidos e enriquecidos de cada vez.
+const tamanhoPaginaExportacao = 200
+
+// ErrEscopoExportacaoInvalido indica um escopo de exportação desconhecido ou mal formado.
+var ErrEscopoExportacaoInvalido = errors.New("escopo inválido; use favoritos, avaliacoes, diario ou lista:<id>")
+
+// EscopoExportacao identifica o que será exportado, já validado para o usuário.
+type EscopoExportacao struct {
+	Tipo      string
+	UsuarioID int64
+	ListaID   int64 // Preenchido para listas e favoritos
+}
+
+// Nome retorna o identificador do escopo usado no nome do arquivo (ex.: "diario", "lista-12").
+func (e *EscopoExportacao) Nome() string {
+	if e.Tipo == EscopoExportacaoLista {
+		return fmt.Sprintf("lista-%d", e.ListaID)
+	}
+	return e.Tipo
+}
+
+// ItemExportado é uma linha da exportação, com os metadados do catálogo. Os campos
+// específicos de cada escopo ficam vazios nos demais.
+type ItemExportado struct {
+	FilmeID       int64        `json:"filmeId"`
+	Titulo        string       `json:"titulo"`
+	Ano           int          `json:"ano,omitempty"`
+	IMDbID        string       `json:"imdbId,omitempty"`
+	Diretor       string       `json:"diretor,omitempty"`
+	Posicao       int          `json:"posicao,omitempty"`
+	Nota          dominio.Nota `json:"nota,omitempty"`
+	Comentario    string       `json:"comentario,omitempty"`
+	DataAssistido string       `json:"dataAssistido,omitempty"`
+	Revisto       *bool        `json:"revisto,omitempty"`
+	Data          time.Time    `json:"data"` // Quando o filme foi adicionado, avaliado ou registrado
+}
+
+// ExportacaoServico define a exportação de favoritos, listas, diário e avaliações em páginas,
+// para que arquivos grandes sejam enviados aos poucos, sem montá-los inteiros na memória.
+type ExportacaoServico interface {
+	ResolverEscopo(usuarioID int64, escopo string) (*EscopoExportacao, error)
+	Percorrer(escopo *EscopoExportacao, consumir func(pagina []ItemExportado) error) error
+}
+
+type exportacaoServicoImpl struct {
+	listaServico  ListaServico
+	catalogo      CatalogoServico
+	listaRepo     repositorio.ListaRepositorio
+	avaliacaoRepo repositorio.AvaliacaoRepositorio
+	diarioRepo    repositorio.DiarioRepositorio
+}
+
+// NovaExportacaoServico cria o serviço de exportação.
+func NovaExportacaoServico(
+	listaServico ListaServico,
+	catalogo CatalogoServico,
+	listaRepo repositorio.ListaRepositorio,
+	avaliacaoRepo repositorio.AvaliacaoRepositorio,
+	diarioRepo repositorio.DiarioRepositorio,
+) ExportacaoServico {
+	return &exportacaoServicoImpl{
+		listaServico:  listaServico,
+		catalogo:      catalogo,
+		listaRepo:     listaRepo,
+		avaliacaoRepo: avaliacaoRepo,
+		diarioRepo:    diarioRepo,
+	}
+}
+
+// ResolverEscopo interpreta o escopo pedido e verifica o acesso antes de a exportação começar.
+// Listas de outros usuários só podem ser exportadas se forem públicas.
+func (s *exportacaoServicoImpl) ResolverEscopo(usuarioID int64, escopo string) (*EscopoExportacao, error) {
+	resolvido := &EscopoExportacao{Tipo: escopo, UsuarioID: usuarioID}
+
+	switch {
+	case escopo == EscopoExportacaoAvaliacoes, escopo == EscopoExportacaoDiario:
+		return resolvido, nil
+
+	case escopo == EscopoExportacaoFavoritos:
+		listaID, err := s.listaRepo.BuscarIDEmbutida(usuarioID, dominio.TipoListaFavoritos)
+		if err != nil {
+			return nil, err
+		}
+		resolvido.ListaID = listaID
+		return resolvido, nil
+
+	case strings.HasPrefix(escopo, EscopoExportacaoLista+":"):
+		listaID, err := strconv.ParseInt(strings.TrimPrefix(escopo, EscopoExportacaoLista+":"), 10, 64)
+		if err != nil {
+			return nil, ErrEscopoExportacaoInvalido
+		}
+		if _, err := s.listaServico.BuscarVisivel(usuarioID, listaID); err != nil {
+			return nil, err
+		}
+		resolvido.Tipo = EscopoExportacaoLista
+		resolvido.ListaID = listaID
+		return resolvido, nil
+	}
+
+	return nil, ErrEscopoExportacaoInvalido
+}
+
+// Percorrer lê o escopo em páginas, completa cada página com os metadados do catálogo e a
+// entrega a consumir. Um erro de consumir interrompe a exportação e é retornado.
+func (s *exportacaoServicoImpl) Percorrer(escopo *EscopoExportacao, consumir func(pagina []ItemExportado) error) error {
+	switch escopo.Tipo {
+	case EscopoExportacaoFavoritos, EscopoExportacaoLista:
+		return s.percorrerLista(escopo.ListaID, consumir)
+	case EscopoExportacaoAvaliacoes:
+		return s.percorrerAvaliacoes(escopo.UsuarioID, consumir)
+	case EscopoExportacaoDiario:
+		return s.percorrerDiario(escopo.UsuarioID, consumir)
+	}
+	return ErrEscopoExportacaoInvalido
+}
+
+func (s *exportacaoServicoImpl) percorrerLista(listaID int64, consumir func([]ItemExportado) error) error {
+	aposPosicao := 0
+	for {
+		itens, err := s.listaRepo.ListarItensApos(listaID, aposPosicao, tamanhoPaginaExportacao)
+		if err != nil || len(itens) == 0 {
+			return err
+		}
+
+		pagina := make([]ItemExportado, 0, len(itens))
+		for _, item := range itens {
+			pagina = append(pagina, ItemExportado{
+				FilmeID: item.FilmeID,
+				Titulo:  item.Titulo,
+				Posicao: item.Posicao,
+				Data:    item.DataAdicionado,
+			})
+		}
+		if err := s.entregar(pagina, consumir); err != nil {
+			return err
+		}
+		aposPosicao = itens[len(itens)-1].Posicao
+	}
+}
+
+func (s *exportacaoServicoImpl) percorrerAvaliacoes(usuarioID int64, consumir func([]ItemExportado) error) error {
+	var aposID int64
+	for {
+		avaliacoes, err := s.avaliacaoRepo.ListarPorUsuarioApos(usuarioID, aposID, tamanhoPaginaExportacao)
+		if err != nil || len(avaliacoes) == 0 {
+			return err
+		}
+
+		// As avaliações não guardam o título; ele vem dos metadados do catálogo.
+		pagina := make([]ItemExportado, 0, len(avaliacoes))
+		for _, avaliacao := range avaliacoes {
+			pagina = append(pagina, ItemExportado{
+				FilmeID:    avaliacao.FilmeID,
+				Nota:       avaliacao.Nota,
+				Comentario: avaliacao.Comentario,
+				Data:       avaliacao.DataCriacao,
+			})
+		}
+		if err := s.entregar(pagina, consumir); err != nil {
+			return err
+		}
+		aposID = avaliacoes[len(avaliacoes)-1].ID
+	}
+}
+
+func (s *exportacaoServicoImpl) percorrerDiario(usuarioID int64, consumir func([]ItemExportado) error) error {
+	var aposData string
+	var aposID int64
+	for {
+		entradas, err := s.diarioRepo.ListarComNotaApos(usuarioID, aposData, aposID, tamanhoPaginaExportacao)
+		if err != nil || len(entradas) == 0 {
+			return err
+		}
+
+		pagina := make([]ItemExportado, 0, len(entradas))
+		for _, entrada := range entradas {
+			revisto := entrada.Revisto
+			item := ItemExportado{
+				FilmeID:       entrada.FilmeID,
+				Titulo:        entrada.Titulo,
+				DataAssistido: entrada.DataAssistido,
+				Revisto:       &revisto,
+				Data:          entrada.DataCriacao,
+			}
+			if entrada.Nota != nil {
+				item.Nota = *entrada.Nota
+			}
+			pagina = append(pagina, item)
+		}
+		if err := s.entregar(pagina, consumir); err != nil {
+			return err
+		}
+		ultima := entradas[len(entradas)-1]
+		aposData, aposID = ultima.DataAssistido, ultima.ID
+	}
+}
+
+// entregar completa a página com ano, ID do IMDb e diretor antes de passá-la adiante.
+func (s *exportacaoServicoImpl) entregar(pagina []ItemExportado, consumir func([]ItemExportado) error) error {
+	ids := make([]int64, 0, len(pagina))
+	for _, item := range pagina {
+		ids = append(ids, item.FilmeID)
+	}
+
+	metadados, err := s.catalogo.Metadados(ids)
+	if err != nil {
+		return err
+	}
+	for i := range pagina {
+		m, ok := metadados[pagina[i].FilmeID]
+		if !ok {
+			continue
+		}
+		pagina[i].Ano = m.Ano
+		pagina[i].IMDbID = m.IMDbID
+		pagina[i].Diretor = m.Diretor
+		if pagina[i].Titulo == "" {
+			pagina[i].Titulo = m.Titulo
+		}
+	}
+
+	return consumir(pagina)
+}
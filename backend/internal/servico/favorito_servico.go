@@ -1,59 +1,360 @@
 package servico
 
 import (
+	"database/sql"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/Andydev0/filmes-backend/internal/dominio"
 	"github.com/Andydev0/filmes-backend/internal/repositorio"
 )
 
-var ErrFavoritoJaExiste = errors.New("este filme já está na lista de favoritos")
+// Define os erros que o serviço de favoritos pode retornar.
+var (
+	ErrFavoritoJaExiste        = errors.New("este filme já está na lista de favoritos")
+	ErrFavoritoNaoEncontrado   = errors.New("este filme não está na lista de favoritos")
+	ErrAnotacaoMuitoLonga      = fmt.Errorf("a anotação deve ter no máximo %d caracteres", TamanhoMaximoAnotacao)
+	ErrTagsInvalidas           = fmt.Errorf("use no máximo %d tags de até %d caracteres", QuantidadeMaximaTags, TamanhoMaximoTag)
+	ErrOrdenacaoFavoritos      = errors.New("ordenação inválida; use data, titulo, ano ou nota")
+	ErrCursorFavoritosInvalido = errors.New("cursor inválido para esta consulta")
+)
+
+const (
+	TamanhoMaximoAnotacao = 2000
+	QuantidadeMaximaTags  = 20
+	TamanhoMaximoTag      = 30
 
+	// LimitePadraoFavoritos e LimiteMaximoFavoritos definem o tamanho das páginas de GET /favoritos.
+	LimitePadraoFavoritos = 50
+	LimiteMaximoFavoritos = 100
+)
+
+// AdicionarFavoritoInput identifica o filme a favoritar. Título, pôster, ano e gêneros vêm
+// do catálogo, não do cliente.
 type AdicionarFavoritoInput struct {
-	FilmeID       int64  `json:"filmeId" binding:"required"`
-	Titulo        string `json:"titulo" binding:"required"`
-	CaminhoPoster string `json:"caminhoPoster"`
+	FilmeID  int64    `json:"filmeId" binding:"required"`
+	Anotacao string   `json:"anotacao"`
+	Tags     []string `json:"tags"`
+}
+
+// Ações aceitas em POST /favoritos/lote.
+const (
+	AcaoLoteAdicionar = "adicionar"
+	AcaoLoteRemover   = "remover"
+)
+
+// Resultados de cada operação de um lote.
+const (
+	ResultadoLoteCriado        = "criado"
+	ResultadoLoteJaExistia     = "ja_existia"
+	ResultadoLoteRemovido      = "removido"
+	ResultadoLoteNaoEncontrado = "nao_encontrado" // Filme inexistente no catálogo ou fora dos favoritos
+)
+
+// OperacaoLoteFavoritos é uma adição ou remoção de POST /favoritos/lote.
+type OperacaoLoteFavoritos struct {
+	Acao     string   `json:"acao" binding:"required,oneof=adicionar remover"`
+	FilmeID  int64    `json:"filmeId" binding:"required"`
+	Anotacao string   `json:"anotacao"`
+	Tags     []string `json:"tags"`
+}
+
+// LoteFavoritosInput traz as operações de um lote, aplicadas na ordem em que aparecem.
+type LoteFavoritosInput struct {
+	Operacoes []OperacaoLoteFavoritos `json:"operacoes" binding:"required,min=1,max=100,dive"`
+}
+
+// ResultadoLoteFavoritos informa o que aconteceu com uma operação do lote.
+type ResultadoLoteFavoritos struct {
+	Acao      string `json:"acao"`
+	FilmeID   int64  `json:"filmeId"`
+	Resultado string `json:"resultado"`
+}
+
+// AtualizarFavoritoInput traz os campos editáveis de um favorito; os omitidos não mudam.
+type AtualizarFavoritoInput struct {
+	Anotacao *string   `json:"anotacao"`
+	Tags     *[]string `json:"tags"`
+}
+
+// ConsultaFavoritos descreve uma página de GET /favoritos. Ordenar vazio mantém a ordem manual;
+// sem limite e sem cursor, a consulta traz todos os favoritos.
+type ConsultaFavoritos struct {
+	Ordenar     string
+	Decrescente bool
+	Tag         string
+	GeneroID    int
+	Busca       string
+	Cursor      string
+	Limite      int
 }
 
 type FavoritoServico interface {
 	AdicionarFavorito(usuarioID int64, input AdicionarFavoritoInput) error
-	ListarFavoritos(usuarioID int64) ([]dominio.FilmeFavorito, error)
+	AplicarLote(usuarioID int64, input LoteFavoritosInput) ([]ResultadoLoteFavoritos, error)
+	PesquisarFavoritos(usuarioID int64, consulta ConsultaFavoritos) ([]dominio.FilmeFavorito, string, error)
+	AtualizarFavorito(usuarioID, filmeID int64, input AtualizarFavoritoInput) (*dominio.FilmeFavorito, error)
 	RemoverFavorito(usuarioID, filmeID int64) error
 }
 
 type favoritoServicoImpl struct {
-	repo repositorio.FavoritoRepositorio
+	repo          repositorio.FavoritoRepositorio
+	atividadeRepo repositorio.AtividadeRepositorio
+	catalogo      CatalogoServico
 }
 
-func NovoFavoritoServico(repo repositorio.FavoritoRepositorio) FavoritoServico {
-	return &favoritoServicoImpl{repo: repo}
+func NovoFavoritoServico(repo repositorio.FavoritoRepositorio, atividadeRepo repositorio.AtividadeRepositorio, catalogo CatalogoServico) FavoritoServico {
+	return &favoritoServicoImpl{repo: repo, atividadeRepo: atividadeRepo, catalogo: catalogo}
 }
 
 // Lógica de AdicionarFavorito atualizada
 func (s *favoritoServicoImpl) AdicionarFavorito(usuarioID int64, input AdicionarFavoritoInput) error {
-	// 1. Verifica se o favorito já existe antes de tentar inserir.
-	existe, err := s.repo.VerificarExistencia(usuarioID, input.FilmeID)
+	if utf8.RuneCountInString(input.Anotacao) > TamanhoMaximoAnotacao {
+		return ErrAnotacaoMuitoLonga
+	}
+	tags, err := normalizarTags(input.Tags)
 	if err != nil {
-		return err // Retorna erro de banco de dados
+		return err
 	}
-	if existe {
-		return ErrFavoritoJaExiste // Retorna nosso erro customizado
+
+	// Confirma que o filme existe no catálogo e usa os dados de lá.
+	metadados, err := s.catalogo.Obter(input.FilmeID)
+	if err != nil {
+		return err
 	}
 
-	// 2. Se não existir, cria e salva o novo favorito.
 	favorito := &dominio.FilmeFavorito{
 		UsuarioID:     usuarioID,
 		FilmeID:       input.FilmeID,
-		Titulo:        input.Titulo,
-		CaminhoPoster: input.CaminhoPoster,
+		Titulo:        metadados.Titulo,
+		CaminhoPoster: metadados.CaminhoPoster,
+		Anotacao:      input.Anotacao,
+		Tags:          tags,
+	}
+	inserido, err := s.repo.Salvar(favorito)
+	if err != nil {
+		return err
+	}
+	if !inserido {
+		return ErrFavoritoJaExiste
+	}
+	s.registrarAtividade(favorito)
+	return nil
+}
+
+// AplicarLote executa as adições e remoções do lote em uma única transação e informa o
+// resultado de cada uma. Filmes inexistentes no catálogo não impedem as demais operações.
+func (s *favoritoServicoImpl) AplicarLote(usuarioID int64, input LoteFavoritosInput) ([]ResultadoLoteFavoritos, error) {
+	resultados := make([]ResultadoLoteFavoritos, len(input.Operacoes))
+	tags := make([][]string, len(input.Operacoes))
+	for i, operacao := range input.Operacoes {
+		resultados[i] = ResultadoLoteFavoritos{Acao: operacao.Acao, FilmeID: operacao.FilmeID}
+		if operacao.Acao != AcaoLoteAdicionar {
+			continue
+		}
+		if utf8.RuneCountInString(operacao.Anotacao) > TamanhoMaximoAnotacao {
+			return nil, ErrAnotacaoMuitoLonga
+		}
+		var err error
+		if tags[i], err = normalizarTags(operacao.Tags); err != nil {
+			return nil, err
+		}
+	}
+
+	// As consultas ao catálogo ficam fora da transação, que só guarda o que já foi validado.
+	catalogo := make(map[int64]*dominio.MetadadosFilme)
+	var operacoes []repositorio.OperacaoFavorito
+	var indices []int
+	for i, operacao := range input.Operacoes {
+		favorito := dominio.FilmeFavorito{UsuarioID: usuarioID, FilmeID: operacao.FilmeID}
+		if operacao.Acao == AcaoLoteAdicionar {
+			metadados, consultado := catalogo[operacao.FilmeID]
+			if !consultado {
+				var err error
+				metadados, err = s.catalogo.Obter(operacao.FilmeID)
+				if err != nil && err != ErrFilmeInexistente {
+					return nil, err
+				}
+				catalogo[operacao.FilmeID] = metadados
+			}
+			if metadados == nil {
+				resultados[i].Resultado = ResultadoLoteNaoEncontrado
+				continue
+			}
+			favorito.Titulo = metadados.Titulo
+			favorito.CaminhoPoster = metadados.CaminhoPoster
+			favorito.Anotacao = operacao.Anotacao
+			favorito.Tags = tags[i]
+		}
+		operacoes = append(operacoes, repositorio.OperacaoFavorito{
+			Remover:  operacao.Acao == AcaoLoteRemover,
+			Favorito: favorito,
+		})
+		indices = append(indices, i)
+	}
+
+	aplicadas, err := s.repo.AplicarLote(usuarioID, operacoes)
+	if err != nil {
+		return nil, err
+	}
+	for j, aplicada := range aplicadas {
+		resultado := &resultados[indices[j]]
+		switch {
+		case resultado.Acao == AcaoLoteAdicionar && aplicada:
+			resultado.Resultado = ResultadoLoteCriado
+			s.registrarAtividade(&operacoes[j].Favorito)
+		case resultado.Acao == AcaoLoteAdicionar:
+			resultado.Resultado = ResultadoLoteJaExistia
+		case aplicada:
+			resultado.Resultado = ResultadoLoteRemovido
+		default:
+			resultado.Resultado = ResultadoLoteNaoEncontrado
+		}
+	}
+	return resultados, nil
+}
+
+// PesquisarFavoritos retorna uma página de favoritos e o cursor opaco da próxima ("" na última).
+func (s *favoritoServicoImpl) PesquisarFavoritos(usuarioID int64, consulta ConsultaFavoritos) ([]dominio.FilmeFavorito, string, error) {
+	filtro := repositorio.FiltroFavoritos{
+		Ordenar:     consulta.Ordenar,
+		Decrescente: consulta.Decrescente,
+		Tag:         strings.ToLower(strings.TrimSpace(consulta.Tag)),
+		GeneroID:    consulta.GeneroID,
+		Busca:       strings.TrimSpace(consulta.Busca),
+		Limite:      consulta.Limite,
+	}
+	switch filtro.Ordenar {
+	case "":
+		filtro.Ordenar = repositorio.OrdenarFavoritosPosicao
+	case repositorio.OrdenarFavoritosData, repositorio.OrdenarFavoritosTitulo,
+		repositorio.OrdenarFavoritosAno, repositorio.OrdenarFavoritosNota:
+	default:
+		return nil, "", ErrOrdenacaoFavoritos
+	}
+	// Clientes anteriores à paginação pedem a lista sem limite nem cursor e esperam todos.
+	if filtro.Limite <= 0 && consulta.Cursor != "" {
+		filtro.Limite = LimitePadraoFavoritos
+	} else if filtro.Limite > LimiteMaximoFavoritos {
+		filtro.Limite = LimiteMaximoFavoritos
+	}
+
+	if consulta.Cursor != "" {
+		apos, err := decodificarCursorFavoritos(consulta.Cursor, filtro)
+		if err != nil {
+			return nil, "", err
+		}
+		filtro.Apos = apos
+	}
+
+	favoritos, proximo, err := s.repo.Pesquisar(usuarioID, filtro)
+	if err != nil {
+		return nil, "", err
 	}
-	return s.repo.Salvar(favorito)
+	if proximo == nil {
+		return favoritos, "", nil
+	}
+	return favoritos, codificarCursorFavoritos(*proximo, filtro), nil
 }
 
-func (s *favoritoServicoImpl) ListarFavoritos(usuarioID int64) ([]dominio.FilmeFavorito, error) {
-	return s.repo.ListarPorUsuarioID(usuarioID)
+// AtualizarFavorito altera a anotação e/ou as tags do favorito e o retorna atualizado.
+func (s *favoritoServicoImpl) AtualizarFavorito(usuarioID, filmeID int64, input AtualizarFavoritoInput) (*dominio.FilmeFavorito, error) {
+	if input.Anotacao != nil && utf8.RuneCountInString(*input.Anotacao) > TamanhoMaximoAnotacao {
+		return nil, ErrAnotacaoMuitoLonga
+	}
+	var tags []string
+	if input.Tags != nil {
+		var err error
+		if tags, err = normalizarTags(*input.Tags); err != nil {
+			return nil, err
+		}
+		// Uma lista vazia apaga as tags; nil significaria "não alterar".
+		if tags == nil {
+			tags = make([]string, 0)
+		}
+	}
+
+	encontrado, err := s.repo.AtualizarDetalhes(usuarioID, filmeID, input.Anotacao, tags)
+	if err != nil {
+		return nil, err
+	}
+	if !encontrado {
+		return nil, ErrFavoritoNaoEncontrado
+	}
+
+	favorito, err := s.repo.Buscar(usuarioID, filmeID)
+	if err == sql.ErrNoRows {
+		return nil, ErrFavoritoNaoEncontrado
+	}
+	return favorito, err
 }
 
 func (s *favoritoServicoImpl) RemoverFavorito(usuarioID, filmeID int64) error {
 	return s.repo.Deletar(usuarioID, filmeID)
 }
+
+// registrarAtividade leva o novo favorito ao feed dos seguidores.
+func (s *favoritoServicoImpl) registrarAtividade(favorito *dominio.FilmeFavorito) {
+	registrarAtividade(s.atividadeRepo, &dominio.Atividade{
+		UsuarioID:     favorito.UsuarioID,
+		Tipo:          dominio.AtividadeFavorito,
+		FilmeID:       &favorito.FilmeID,
+		Titulo:        favorito.Titulo,
+		CaminhoPoster: favorito.CaminhoPoster,
+	})
+}
+
+// normalizarTags deixa as tags em minúsculas, sem espaços nas pontas e sem repetições.
+func normalizarTags(tags []string) ([]string, error) {
+	var normalizadas []string
+	vistas := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || vistas[tag] {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > TamanhoMaximoTag {
+			return nil, ErrTagsInvalidas
+		}
+		vistas[tag] = true
+		normalizadas = append(normalizadas, tag)
+	}
+	if len(normalizadas) > QuantidadeMaximaTags {
+		return nil, ErrTagsInvalidas
+	}
+	return normalizadas, nil
+}
+
+// codificarCursorFavoritos gera o cursor opaco "ordenação|direção|id|chave" em base64 (URL).
+// A ordenação e a direção permitem recusar um cursor usado com outra consulta.
+func codificarCursorFavoritos(cursor repositorio.CursorFavoritos, filtro repositorio.FiltroFavoritos) string {
+	texto := fmt.Sprintf("%s|%t|%d|%s", filtro.Ordenar, filtro.Decrescente, cursor.ID, cursor.Chave)
+	return base64.RawURLEncoding.EncodeToString([]byte(texto))
+}
+
+func decodificarCursorFavoritos(cursor string, filtro repositorio.FiltroFavoritos) (*repositorio.CursorFavoritos, error) {
+	bruto, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrCursorFavoritosInvalido
+	}
+	// A chave vem por último porque pode conter "|" (títulos, por exemplo).
+	partes := strings.SplitN(string(bruto), "|", 4)
+	if len(partes) != 4 || partes[0] != filtro.Ordenar || partes[1] != strconv.FormatBool(filtro.Decrescente) {
+		return nil, ErrCursorFavoritosInvalido
+	}
+	id, err := strconv.ParseInt(partes[2], 10, 64)
+	if err != nil {
+		return nil, ErrCursorFavoritosInvalido
+	}
+	if filtro.Ordenar != repositorio.OrdenarFavoritosData && filtro.Ordenar != repositorio.OrdenarFavoritosTitulo {
+		// As demais ordenações são numéricas.
+		if _, err := strconv.ParseFloat(partes[3], 64); err != nil {
+			return nil, ErrCursorFavoritosInvalido
+		}
+	}
+	return &repositorio.CursorFavoritos{Chave: partes[3], ID: id}, nil
+}
@@ -0,0 +1,46 @@
+package servico_test
+
+import (
+	"testing"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var catalogoFavoritos = catalogoFixo{
+	603: {FilmeID: 603, Titulo: "Matrix"},
+	604: {FilmeID: 604, Titulo: "Matrix Reloaded"},
+	605: {FilmeID: 605, Titulo: "Matrix Revolutions"},
+}
+
+func TestPesquisarFavoritosSemLimiteTrazTodos(t *testing.T) {
+	db := novoBanco(t)
+	ana := novoUsuario(t, db, "Ana", "ana@example.com")
+	favoritos := servico.NovoFavoritoServico(repositorio.NovoFavoritoRepositorio(db), repositorio.NovaAtividadeRepositorio(db), catalogoFavoritos)
+	for _, filmeID := range []int64{603, 604, 605} {
+		require.NoError(t, favoritos.AdicionarFavorito(ana.ID, servico.AdicionarFavoritoInput{FilmeID: filmeID}))
+	}
+
+	todos, proximo, err := favoritos.PesquisarFavoritos(ana.ID, servico.ConsultaFavoritos{})
+	require.NoError(t, err)
+	assert.Len(t, todos, 3)
+	assert.Empty(t, proximo)
+
+	var paginas [][]dominio.FilmeFavorito
+	consulta := servico.ConsultaFavoritos{Limite: 2}
+	for {
+		pagina, proximo, err := favoritos.PesquisarFavoritos(ana.ID, consulta)
+		require.NoError(t, err)
+		paginas = append(paginas, pagina)
+		if proximo == "" {
+			break
+		}
+		consulta.Cursor = proximo
+	}
+	require.Len(t, paginas, 2)
+	assert.Len(t, paginas[0], 2)
+	assert.Len(t, paginas[1], 1)
+}
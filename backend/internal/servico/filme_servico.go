@@ -2,6 +2,7 @@ package servico
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/rand"
@@ -25,6 +26,9 @@ type FilmeServico interface {
 	BuscarDetalhes(filmeID int64) (*dominio.DetalhesFilmeCompleto, error)
 	ListarGeneros() ([]dominio.Genero, error)
 	BuscarFilmeAleatorio(generoID, ano string) (*dominio.Filme, error)
+	BuscarPorIMDbID(imdbID string) (*dominio.Filme, error)
+	BuscarPorTituloEAno(titulo string, ano int) (*dominio.Filme, error)
+	BuscarMetadados(filmeID int64) (*dominio.MetadadosFilme, error)
 }
 
 type tmdbService struct {
@@ -270,3 +274,129 @@ func (s *tmdbService) BuscarFilmesPorGenero(generoID string) ([]dominio.Filme, e
 
 	return filmes, nil
 }
+
+// BuscarPorIMDbID encontra o filme correspondente a um ID do IMDb (ex.: "tt0137523").
+// Retorna nil, sem erro, quando o catálogo não conhece o ID.
+func (s *tmdbService) BuscarPorIMDbID(imdbID string) (*dominio.Filme, error) {
+	queryParams := url.Values{}
+	queryParams.Add("api_key", s.apiKey)
+	queryParams.Add("language", "pt-BR")
+	queryParams.Add("external_source", "imdb_id")
+
+	urlBusca := "https://api.themoviedb.org/3/find/" + url.PathEscape(imdbID) + "?" + queryParams.Encode()
+	var resposta dominio.RespostaFindTMDB
+	if err := s.buscarJSON(urlBusca, &resposta); err != nil {
+		return nil, err
+	}
+
+	if len(resposta.Filmes) == 0 {
+		return nil, nil
+	}
+	return converterFilmeTMDB(resposta.Filmes[0]), nil
+}
+
+// BuscarPorTituloEAno retorna o resultado mais relevante da busca pelo título entre os filmes
+// lançados no ano informado (0 busca em qualquer ano). Retorna nil, sem erro, se nada for encontrado.
+func (s *tmdbService) BuscarPorTituloEAno(titulo string, ano int) (*dominio.Filme, error) {
+	queryParams := url.Values{}
+	queryParams.Add("api_key", s.apiKey)
+	queryParams.Add("language", "pt-BR")
+	queryParams.Add("query", titulo)
+	if ano > 0 {
+		queryParams.Add("primary_release_year", strconv.Itoa(ano))
+	}
+
+	var resposta dominio.RespostaBuscaTMDB
+	if err := s.buscarJSON("https://api.themoviedb.org/3/search/movie?"+queryParams.Encode(), &resposta); err != nil {
+		return nil, err
+	}
+
+	if len(resposta.Resultados) == 0 {
+		return nil, nil
+	}
+	return converterFilmeTMDB(resposta.Resultados[0]), nil
+}
+
+// BuscarMetadados busca o ano, os gêneros, o ID do IMDb e o diretor de um filme em uma única requisição.
+// Retorna nil, sem erro, quando o filme não existe no catálogo.
+func (s *tmdbService) BuscarMetadados(filmeID int64) (*dominio.MetadadosFilme, error) {
+	queryParams := url.Values{}
+	queryParams.Add("api_key", s.apiKey)
+	queryParams.Add("language", "pt-BR")
+	queryParams.Add("append_to_response", "credits")
+
+	urlBusca := fmt.Sprintf("https://api.themoviedb.org/3/movie/%d?%s", filmeID, queryParams.Encode())
+	var detalhes dominio.DetalhesCatalogoTMDB
+	if err := s.buscarJSON(urlBusca, &detalhes); err != nil {
+		if err == errFilmeInexistenteTMDB {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	filme := converterFilmeTMDB(detalhes.TMDBMovieResult)
+	metadados := &dominio.MetadadosFilme{
+		FilmeID:       filmeID,
+		Titulo:        filme.Titulo,
+		IMDbID:        detalhes.IMDbID,
+		CaminhoPoster: filme.CaminhoPoster,
+		Generos:       detalhes.Generos,
+	}
+	if len(detalhes.DataLancamento) >= 4 {
+		metadados.Ano, _ = strconv.Atoi(detalhes.DataLancamento[:4])
+	}
+	for _, membro := range detalhes.Creditos.Equipe {
+		if membro.Job == "Director" {
+			metadados.Diretor = membro.Nome
+			break
+		}
+	}
+	return metadados, nil
+}
+
+// errFilmeInexistenteTMDB indica que a API externa respondeu 404 para o filme pedido.
+var errFilmeInexistenteTMDB = errors.New("filme inexistente no TMDB")
+
+// buscarJSON faz um GET na API do TMDB e decodifica a resposta em destino.
+func (s *tmdbService) buscarJSON(urlBusca string, destino interface{}) error {
+	if s.apiKey == "" {
+		return fmt.Errorf("a chave da API do TMDB não foi configurada")
+	}
+
+	resposta, err := s.clienteHttp.Get(urlBusca)
+	if err != nil {
+		// O erro de url.Error repete a URL, que contém a chave da API; guardamos só a causa.
+		if erroURL, ok := err.(*url.Error); ok {
+			err = erroURL.Err
+		}
+		return fmt.Errorf("falha ao realizar a requisição para o TMDB: %w", err)
+	}
+	defer resposta.Body.Close()
+
+	if resposta.StatusCode == http.StatusNotFound {
+		return errFilmeInexistenteTMDB
+	}
+	if resposta.StatusCode != http.StatusOK {
+		return fmt.Errorf("a API do TMDB retornou um status inesperado: %s", resposta.Status)
+	}
+
+	if err := json.NewDecoder(resposta.Body).Decode(destino); err != nil {
+		return fmt.Errorf("falha ao decodificar o JSON da resposta: %w", err)
+	}
+	return nil
+}
+
+// converterFilmeTMDB traduz um resultado da API externa para o nosso modelo de filme.
+func converterFilmeTMDB(filmeTMDB dominio.TMDBMovieResult) *dominio.Filme {
+	filme := &dominio.Filme{
+		ID:             filmeTMDB.ID,
+		Titulo:         filmeTMDB.Titulo,
+		Sinopse:        filmeTMDB.Sinopse,
+		DataLancamento: filmeTMDB.DataLancamento,
+		NotaMedia:      filmeTMDB.NotaMedia,
+	}
+	if filmeTMDB.CaminhoPoster != "" {
+		filme.CaminhoPoster = "https://image.tmdb.org/t/p/w500" + filmeTMDB.CaminhoPoster
+	}
+	return filme
+}
@@ -0,0 +1,283 @@
+package servico
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+)
+
+// Códigos dos motivos que o filtro de conteúdo registra em cada decisão.
+const (
+	MotivoPalavraBloqueada    = "palavra_bloqueada"
+	MotivoLink                = "link"
+	MotivoCaracteresRepetidos = "caracteres_repetidos"
+	MotivoMaiusculas          = "maiusculas"
+	MotivoPalavrasRepetidas   = "palavras_repetidas"
+)
+
+// TamanhoMaximoComentarioPadrao é o limite, em caracteres, do comentário de uma avaliação.
+const TamanhoMaximoComentarioPadrao = 5000
+
+// ResultadoFiltro é o que o filtro decidiu sobre um texto (aprovar, reter ou rejeitar) e por quê.
+type ResultadoFiltro struct {
+	Acao    string
+	Motivos []string
+}
+
+// FiltroConteudo analisa o comentário de uma avaliação antes que ela seja publicada.
+type FiltroConteudo interface {
+	Analisar(texto string) ResultadoFiltro
+}
+
+// ConfigFiltro define as palavras bloqueadas e a ação tomada em cada tipo de problema. Uma ação
+// "aprovar" desliga a verificação correspondente.
+type ConfigFiltro struct {
+	PalavrasBloqueadas []string
+	AcaoPalavras       string
+	AcaoLinks          string
+	AcaoSpam           string
+}
+
+// ConfigAvaliacoes reúne o limite de tamanho e o filtro aplicados aos comentários das avaliações.
+type ConfigAvaliacoes struct {
+	TamanhoMaximoComentario int
+	Filtro                  FiltroConteudo
+}
+
+type filtroPadrao struct {
+	config     ConfigFiltro
+	bloqueadas map[string]bool
+}
+
+// NovoFiltroConteudo cria o filtro padrão: palavras bloqueadas (comparadas sem acentos, sem
+// leetspeak e sem letras repetidas), links e heurísticas de spam.
+func NovoFiltroConteudo(config ConfigFiltro) FiltroConteudo {
+	bloqueadas := make(map[string]bool, len(config.PalavrasBloqueadas))
+	for _, palavra := range config.PalavrasBloqueadas {
+		if normalizada := strings.Join(palavrasNormalizadas(palavra), ""); normalizada != "" {
+			bloqueadas[normalizada] = true
+		}
+	}
+	return &filtroPadrao{config: config, bloqueadas: bloqueadas}
+}
+
+var (
+	// padraoLink reconhece URLs e domínios soltos, como "exemplo.com.br".
+	padraoLink = regexp.MustCompile(`(?i)(https?://|www\.|\b[a-z0-9-]+\.(com|net|org|br|io|ly|me|xyz|info|biz|site|online|gg)\b)`)
+
+	// semLeetspeak desfaz as trocas de letras por números e símbolos antes da comparação com as
+	// palavras bloqueadas.
+	semLeetspeak = strings.NewReplacer(
+		"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s",
+	)
+)
+
+// Analisar retorna a ação mais severa entre as verificações que encontraram problemas.
+func (f *filtroPadrao) Analisar(texto string) ResultadoFiltro {
+	resultado := ResultadoFiltro{Acao: dominio.AcaoFiltroAprovar}
+	if strings.TrimSpace(texto) == "" {
+		return resultado
+	}
+	registrar := func(acao, motivo string) {
+		if acao == dominio.AcaoFiltroAprovar {
+			return
+		}
+		resultado.Motivos = append(resultado.Motivos, motivo)
+		if severidadeAcao(acao) > severidadeAcao(resultado.Acao) {
+			resultado.Acao = acao
+		}
+	}
+
+	if len(f.bloqueadas) > 0 && f.temPalavraBloqueada(texto) {
+		registrar(f.config.AcaoPalavras, MotivoPalavraBloqueada)
+	}
+	if padraoLink.MatchString(texto) {
+		registrar(f.config.AcaoLinks, MotivoLink)
+	}
+	if temSequenciaRepetida(texto, 8) {
+		registrar(f.config.AcaoSpam, MotivoCaracteresRepetidos)
+	}
+	if emMaiusculas(texto) {
+		registrar(f.config.AcaoSpam, MotivoMaiusculas)
+	}
+	if palavrasRepetidas(texto) {
+		registrar(f.config.AcaoSpam, MotivoPalavrasRepetidas)
+	}
+	return resultado
+}
+
+// temPalavraBloqueada procura as palavras bloqueadas no texto normalizado, inclusive quando
+// soletradas com separadores ("m.e.r.d.a").
+func (f *filtroPadrao) temPalavraBloqueada(texto string) bool {
+	palavras := palavrasNormalizadas(texto)
+	var soletrada strings.Builder
+	for _, palavra := range palavras {
+		if f.bloqueadas[palavra] {
+			return true
+		}
+		if len(palavra) == 1 {
+			soletrada.WriteString(palavra)
+			if f.bloqueadas[colapsarRepetidas(soletrada.String())] {
+				return true
+			}
+			continue
+		}
+		soletrada.Reset()
+	}
+	return false
+}
+
+// palavrasNormalizadas quebra o texto em palavras minúsculas, sem acentos, sem leetspeak e sem
+// letras repetidas em sequência ("pééééssimo" vira "pesimo").
+func palavrasNormalizadas(texto string) []string {
+	texto = semLeetspeak.Replace(semAcentos.Replace(strings.ToLower(texto)))
+	palavras := strings.FieldsFunc(texto, func(r rune) bool { return !unicode.IsLetter(r) })
+	for i, palavra := range palavras {
+		palavras[i] = colapsarRepetidas(palavra)
+	}
+	return palavras
+}
+
+// colapsarRepetidas deixa uma só letra onde a mesma letra aparece várias vezes seguidas.
+func colapsarRepetidas(palavra string) string {
+	var b strings.Builder
+	var anterior rune
+	for i, r := range palavra {
+		if i > 0 && r == anterior {
+			continue
+		}
+		b.WriteRune(r)
+		anterior = r
+	}
+	return b.String()
+}
+
+// temSequenciaRepetida indica se o mesmo caractere (fora espaços) aparece minimo vezes seguidas.
+func temSequenciaRepetida(texto string, minimo int) bool {
+	var anterior rune
+	seguidas := 0
+	for _, r := range texto {
+		if r == anterior && !unicode.IsSpace(r) {
+			seguidas++
+			if seguidas >= minimo {
+				return true
+			}
+			continue
+		}
+		anterior, seguidas = r, 1
+	}
+	return false
+}
+
+// emMaiusculas indica um texto de tamanho razoável escrito quase todo em letras maiúsculas.
+func emMaiusculas(texto string) bool {
+	letras, maiusculas := 0, 0
+	for _, r := range texto {
+		if unicode.IsLetter(r) {
+			letras++
+			if unicode.IsUpper(r) {
+				maiusculas++
+			}
+		}
+	}
+	return letras >= 20 && maiusculas*10 > letras*7
+}
+
+// palavrasRepetidas indica um texto longo formado por poucas palavras diferentes repetidas.
+func palavrasRepetidas(texto string) bool {
+	palavras := strings.Fields(strings.ToLower(texto))
+	if len(palavras) < 10 {
+		return false
+	}
+	distintas := make(map[string]bool, len(palavras))
+	for _, palavra := range palavras {
+		distintas[palavra] = true
+	}
+	return len(distintas)*10 < len(palavras)*3
+}
+
+// severidadeAcao ordena as ações do filtro: rejeitar prevalece sobre reter, que prevalece sobre aprovar.
+func severidadeAcao(acao string) int {
+	switch acao {
+	case dominio.AcaoFiltroRejeitar:
+		return 2
+	case dominio.AcaoFiltroReter:
+		return 1
+	}
+	return 0
+}
+
+// CarregarConfigAvaliacoesDoAmbiente monta o limite e o filtro das avaliações a partir das
+// variáveis de ambiente:
+//
+//   - AVALIACAO_TAMANHO_MAXIMO: caracteres permitidos no comentário (padrão 5000)
+//   - FILTRO_PALAVRAS_BLOQUEADAS_ARQUIVO: arquivo com uma palavra por linha ('#' inicia comentário)
+//   - FILTRO_ACAO_PALAVRAS, FILTRO_ACAO_LINKS e FILTRO_ACAO_SPAM: aprovar, reter ou rejeitar
+//     (padrões rejeitar, reter e reter)
+func CarregarConfigAvaliacoesDoAmbiente() (*ConfigAvaliacoes, error) {
+	config := &ConfigAvaliacoes{TamanhoMaximoComentario: TamanhoMaximoComentarioPadrao}
+	if valor := os.Getenv("AVALIACAO_TAMANHO_MAXIMO"); valor != "" {
+		tamanho, err := strconv.Atoi(valor)
+		if err != nil || tamanho <= 0 {
+			return nil, fmt.Errorf("AVALIACAO_TAMANHO_MAXIMO deve ser um número positivo: %s", valor)
+		}
+		config.TamanhoMaximoComentario = tamanho
+	}
+
+	filtro := ConfigFiltro{}
+	acoes := []struct {
+		variavel, padrao string
+		destino          *string
+	}{
+		{"FILTRO_ACAO_PALAVRAS", dominio.AcaoFiltroRejeitar, &filtro.AcaoPalavras},
+		{"FILTRO_ACAO_LINKS", dominio.AcaoFiltroReter, &filtro.AcaoLinks},
+		{"FILTRO_ACAO_SPAM", dominio.AcaoFiltroReter, &filtro.AcaoSpam},
+	}
+	for _, acao := range acoes {
+		valor := strings.ToLower(strings.TrimSpace(os.Getenv(acao.variavel)))
+		if valor == "" {
+			valor = acao.padrao
+		}
+		if valor != dominio.AcaoFiltroAprovar && valor != dominio.AcaoFiltroReter && valor != dominio.AcaoFiltroRejeitar {
+			return nil, fmt.Errorf("%s deve ser aprovar, reter ou rejeitar: %s", acao.variavel, valor)
+		}
+		*acao.destino = valor
+	}
+
+	if arquivo := os.Getenv("FILTRO_PALAVRAS_BLOQUEADAS_ARQUIVO"); arquivo != "" {
+		palavras, err := lerPalavrasBloqueadas(arquivo)
+		if err != nil {
+			return nil, err
+		}
+		filtro.PalavrasBloqueadas = palavras
+	}
+
+	config.Filtro = NovoFiltroConteudo(filtro)
+	return config, nil
+}
+
+// lerPalavrasBloqueadas lê o arquivo de palavras bloqueadas, ignorando linhas vazias e comentários.
+func lerPalavrasBloqueadas(arquivo string) ([]string, error) {
+	f, err := os.Open(arquivo)
+	if err != nil {
+		return nil, fmt.Errorf("não foi possível ler as palavras bloqueadas em %s: %w", arquivo, err)
+	}
+	defer f.Close()
+
+	var palavras []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		linha := strings.TrimSpace(scanner.Text())
+		if linha == "" || strings.HasPrefix(linha, "#") {
+			continue
+		}
+		palavras = append(palavras, linha)
+	}
+	return palavras, scanner.Err()
+}
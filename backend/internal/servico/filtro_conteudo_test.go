@@ -0,0 +1,54 @@
+package servico_test
+
+import (
+	"testing"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/stretchr/testify/assert"
+)
+
+func novoFiltro() servico.FiltroConteudo {
+	return servico.NovoFiltroConteudo(servico.ConfigFiltro{
+		PalavrasBloqueadas: []string{"Porcaria"},
+		AcaoPalavras:       dominio.AcaoFiltroRejeitar,
+		AcaoLinks:          dominio.AcaoFiltroReter,
+		AcaoSpam:           dominio.AcaoFiltroReter,
+	})
+}
+
+func TestFiltroNormalizaPalavrasBloqueadas(t *testing.T) {
+	filtro := novoFiltro()
+
+	for _, texto := range []string{
+		"que porcaria de filme",
+		"que PORCARIA de filme",
+		"que p0rc4r1a de filme",
+		"que porcaaaaria de filme",
+		"que pórcária de filme",
+		"que p.o.r.c.a.r.i.a de filme",
+	} {
+		resultado := filtro.Analisar(texto)
+		assert.Equal(t, dominio.AcaoFiltroRejeitar, resultado.Acao, texto)
+		assert.Equal(t, []string{servico.MotivoPalavraBloqueada}, resultado.Motivos, texto)
+	}
+}
+
+func TestFiltroNaoBloqueiaPalavrasParecidas(t *testing.T) {
+	resultado := novoFiltro().Analisar("A porca e a carroça roubam a cena")
+	assert.Equal(t, dominio.AcaoFiltroAprovar, resultado.Acao)
+	assert.Empty(t, resultado.Motivos)
+}
+
+func TestFiltroRetemLinksESpam(t *testing.T) {
+	filtro := novoFiltro()
+
+	assert.Equal(t, []string{servico.MotivoLink}, filtro.Analisar("assista em www.exemplo.com").Motivos)
+	assert.Equal(t, []string{servico.MotivoCaracteresRepetidos}, filtro.Analisar("incrível!!!!!!!!!!").Motivos)
+	assert.Equal(t, []string{servico.MotivoMaiusculas}, filtro.Analisar("ESTE FILME É SIMPLESMENTE MARAVILHOSO").Motivos)
+	assert.Equal(t, []string{servico.MotivoPalavrasRepetidas}, filtro.Analisar("bom bom bom bom bom bom bom bom bom bom").Motivos)
+
+	resultado := filtro.Analisar("Porcaria, veja exemplo.com")
+	assert.Equal(t, dominio.AcaoFiltroRejeitar, resultado.Acao)
+	assert.Equal(t, []string{servico.MotivoPalavraBloqueada, servico.MotivoLink}, resultado.Motivos)
+}
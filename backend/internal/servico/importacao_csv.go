@@ -0,0 +1,188 @@
+package servico
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+)
+
+// Destinos de uma linha importada, na ordem em que são processados: a lista "para assistir"
+// vem antes do diário para que os filmes já vistos saiam dela, como no registro manual.
+const (
+	destinoAssistir = iota
+	destinoDiario
+	destinoAvaliacao
+)
+
+// linhaImportacao é uma linha de um CSV já interpretada, pronta para ser associada ao catálogo.
+type linhaImportacao struct {
+	arquivo string
+	linha   int
+	destino int
+	titulo  string
+	ano     int
+	imdbID  string
+	nota    dominio.Nota // Já convertida para meias estrelas
+	data    string       // AAAA-MM-DD; vazia quando o arquivo não informa
+
+	// motivoIgnorada, quando preenchido, faz a linha virar pendência sem consultar o catálogo.
+	motivoIgnorada string
+}
+
+// Tipos de título do IMDb aceitos como filme (comparados em minúsculas e sem espaços).
+var tiposFilmeIMDb = map[string]bool{"movie": true, "tvmovie": true, "video": true}
+
+// lerArquivoImportacao identifica o formato do CSV pelo cabeçalho e pelo nome do arquivo e
+// interpreta suas linhas. Aceita ratings.csv, watched.csv e watchlist.csv do Letterboxd e
+// a exportação de notas do IMDb.
+func lerArquivoImportacao(nome string, conteudo io.Reader) ([]linhaImportacao, error) {
+	leitor := csv.NewReader(conteudo)
+	leitor.FieldsPerRecord = -1
+	leitor.LazyQuotes = true
+
+	cabecalho, err := leitor.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s não tem cabeçalho", ErrArquivoImportacaoInvalido, nome)
+	}
+	colunas := make(map[string]int, len(cabecalho))
+	for i, coluna := range cabecalho {
+		if i == 0 {
+			coluna = strings.TrimPrefix(coluna, "\ufeff") // BOM de arquivos salvos no Excel
+		}
+		colunas[strings.TrimSpace(coluna)] = i
+	}
+
+	var interpretar func(campo func(string) string) linhaImportacao
+	nomeMinusculo := strings.ToLower(nome)
+	_, temURILetterboxd := colunas["Letterboxd URI"]
+	_, temNota := colunas["Rating"]
+	_, temConstIMDb := colunas["Const"]
+	_, temNotaIMDb := colunas["Your Rating"]
+
+	switch {
+	case temConstIMDb && temNotaIMDb:
+		interpretar = interpretarNotaIMDb
+	case temURILetterboxd && temNota:
+		interpretar = interpretarNotaLetterboxd
+	case temURILetterboxd && strings.Contains(nomeMinusculo, "watchlist"):
+		interpretar = interpretarAssistirLetterboxd
+	case temURILetterboxd && strings.Contains(nomeMinusculo, "watched"):
+		interpretar = interpretarAssistidoLetterboxd
+	default:
+		return nil, fmt.Errorf("%w: formato de %s não reconhecido", ErrArquivoImportacaoInvalido, nome)
+	}
+
+	var linhas []linhaImportacao
+	for {
+		registro, err := leitor.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrArquivoImportacaoInvalido, nome, err)
+		}
+
+		if strings.TrimSpace(strings.Join(registro, "")) == "" {
+			continue
+		}
+		campo := func(coluna string) string {
+			if i, ok := colunas[coluna]; ok && i < len(registro) {
+				return strings.TrimSpace(registro[i])
+			}
+			return ""
+		}
+
+		linha := interpretar(campo)
+		linha.arquivo = nome
+		linha.linha, _ = leitor.FieldPos(0)
+		linhas = append(linhas, linha)
+	}
+	return linhas, nil
+}
+
+func interpretarNotaLetterboxd(campo func(string) string) linhaImportacao {
+	linha := linhaImportacao{destino: destinoAvaliacao, titulo: campo("Name"), ano: lerAno(campo("Year"))}
+	nota, ok := converterNota(campo("Rating"), 5)
+	if !ok {
+		linha.motivoIgnorada = "nota inválida"
+	}
+	linha.nota = nota
+	return linha
+}
+
+func interpretarAssistidoLetterboxd(campo func(string) string) linhaImportacao {
+	linha := linhaImportacao{
+		destino: destinoDiario,
+		titulo:  campo("Name"),
+		ano:     lerAno(campo("Year")),
+		data:    lerData(campo("Date")),
+	}
+	// Sem a data, a entrada do diário ficaria com a data da importação.
+	if linha.data == "" {
+		linha.motivoIgnorada = "data em que o filme foi assistido ausente ou inválida"
+	}
+	return linha
+}
+
+func interpretarAssistirLetterboxd(campo func(string) string) linhaImportacao {
+	return linhaImportacao{destino: destinoAssistir, titulo: campo("Name"), ano: lerAno(campo("Year"))}
+}
+
+func interpretarNotaIMDb(campo func(string) string) linhaImportacao {
+	linha := linhaImportacao{
+		destino: destinoAvaliacao,
+		titulo:  campo("Title"),
+		ano:     lerAno(campo("Year")),
+		imdbID:  campo("Const"),
+	}
+
+	tipo := campo("Title Type")
+	if tipo != "" && !tiposFilmeIMDb[strings.ToLower(strings.ReplaceAll(tipo, " ", ""))] {
+		linha.motivoIgnorada = fmt.Sprintf("não é um filme (%s)", tipo)
+		return linha
+	}
+
+	nota, ok := converterNota(campo("Your Rating"), 10)
+	if !ok {
+		linha.motivoIgnorada = "nota inválida"
+	}
+	linha.nota = nota
+	return linha
+}
+
+// converterNota leva uma nota da escala de origem (5 estrelas no Letterboxd, 10 pontos no IMDb)
+// para a nossa escala em meias estrelas, arredondando para a meia estrela mais próxima.
+func converterNota(valor string, escala float64) (dominio.Nota, bool) {
+	nota, err := strconv.ParseFloat(valor, 64)
+	if err != nil || nota <= 0 || nota > escala {
+		return 0, false
+	}
+	meias := dominio.Nota(math.Round(nota / escala * float64(dominio.NotaMaxima)))
+	if meias < dominio.NotaMinima {
+		meias = dominio.NotaMinima
+	}
+	return meias, true
+}
+
+// lerAno retorna o ano informado ou 0 se ele estiver ausente ou inválido.
+func lerAno(valor string) int {
+	ano, err := strconv.Atoi(valor)
+	if err != nil || ano < 1800 || ano > 9999 {
+		return 0
+	}
+	return ano
+}
+
+// lerData retorna a data no formato do diário ou vazio se ela for inválida.
+func lerData(valor string) string {
+	if _, err := time.Parse(formatoDataDiario, valor); err != nil {
+		return ""
+	}
+	return valor
+}
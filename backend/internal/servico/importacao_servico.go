@@ -0,0 +1,335 @@
+package servico
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+)
+
+// Define os erros que o serviço de importação pode retornar.
+var (
+	ErrArquivoImportacaoInvalido = errors.New("arquivo de importação inválido")
+	ErrImportacaoVazia           = errors.New("os arquivos enviados não têm linhas para importar")
+	ErrImportacaoMuitoGrande     = errors.New("os arquivos enviados excedem o limite de linhas por importação")
+	ErrImportacaoEmAndamento     = errors.New("já existe uma importação em andamento")
+	ErrImportacaoNaoEncontrada   = errors.New("importação não encontrada")
+)
+
+const (
+	// LimiteLinhasImportacao é o máximo de linhas, somando todos os arquivos, de uma importação.
+	LimiteLinhasImportacao = 20000
+
+	// intervaloProgressoImportacao define a cada quantas linhas o progresso é gravado.
+	intervaloProgressoImportacao = 25
+)
+
+// ArquivoImportacao é um dos CSVs enviados pelo usuário.
+type ArquivoImportacao struct {
+	Nome     string
+	Conteudo io.Reader
+}
+
+// ImportacaoDetalhada é a importação com as linhas que não puderam ser importadas.
+type ImportacaoDetalhada struct {
+	dominio.Importacao
+	Pendencias []dominio.PendenciaImportacao `json:"pendencias"`
+}
+
+// ImportacaoServico define a importação de histórico a partir de exportações do Letterboxd e do IMDb.
+type ImportacaoServico interface {
+	Iniciar(usuarioID int64, arquivos []ArquivoImportacao) (*dominio.Importacao, error)
+	Listar(usuarioID int64) ([]dominio.Importacao, error)
+	Buscar(usuarioID, importacaoID int64) (*ImportacaoDetalhada, error)
+}
+
+type importacaoServicoImpl struct {
+	repo          repositorio.ImportacaoRepositorio
+	filmeServico  FilmeServico
+	avaliacaoRepo repositorio.AvaliacaoRepositorio
+	diarioRepo    repositorio.DiarioRepositorio
+	listaRepo     repositorio.ListaRepositorio
+}
+
+// NovaImportacaoServico cria o serviço de importação.
+func NovaImportacaoServico(
+	repo repositorio.ImportacaoRepositorio,
+	filmeServico FilmeServico,
+	avaliacaoRepo repositorio.AvaliacaoRepositorio,
+	diarioRepo repositorio.DiarioRepositorio,
+	listaRepo repositorio.ListaRepositorio,
+) ImportacaoServico {
+	return &importacaoServicoImpl{
+		repo:          repo,
+		filmeServico:  filmeServico,
+		avaliacaoRepo: avaliacaoRepo,
+		diarioRepo:    diarioRepo,
+		listaRepo:     listaRepo,
+	}
+}
+
+// Iniciar lê os arquivos, registra a importação e a processa em segundo plano.
+// Erros de formato são retornados de imediato; o andamento é consultado por Buscar.
+func (s *importacaoServicoImpl) Iniciar(usuarioID int64, arquivos []ArquivoImportacao) (*dominio.Importacao, error) {
+	var linhas []linhaImportacao
+	for _, arquivo := range arquivos {
+		linhasArquivo, err := lerArquivoImportacao(arquivo.Nome, arquivo.Conteudo)
+		if err != nil {
+			return nil, err
+		}
+		linhas = append(linhas, linhasArquivo...)
+	}
+	if len(linhas) == 0 {
+		return nil, ErrImportacaoVazia
+	}
+	if len(linhas) > LimiteLinhasImportacao {
+		return nil, ErrImportacaoMuitoGrande
+	}
+	sort.SliceStable(linhas, func(i, j int) bool { return linhas[i].destino < linhas[j].destino })
+
+	importacao := &dominio.Importacao{
+		UsuarioID:   usuarioID,
+		Status:      dominio.StatusImportacaoPendente,
+		TotalLinhas: len(linhas),
+	}
+	criada, err := s.repo.Criar(importacao)
+	if err != nil {
+		return nil, err
+	}
+	if !criada {
+		return nil, ErrImportacaoEmAndamento
+	}
+
+	// O processamento recebe uma cópia, para não compartilhar a struct devolvida ao chamador.
+	go s.processar(*importacao, linhas)
+	return importacao, nil
+}
+
+// Listar retorna as importações do usuário, das mais recentes para as mais antigas.
+func (s *importacaoServicoImpl) Listar(usuarioID int64) ([]dominio.Importacao, error) {
+	return s.repo.ListarPorUsuarioID(usuarioID)
+}
+
+// Buscar retorna o andamento de uma importação e as linhas que não foram importadas.
+func (s *importacaoServicoImpl) Buscar(usuarioID, importacaoID int64) (*ImportacaoDetalhada, error) {
+	importacao, err := s.repo.BuscarDoUsuario(usuarioID, importacaoID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrImportacaoNaoEncontrada
+		}
+		return nil, err
+	}
+
+	pendencias, err := s.repo.ListarPendencias(importacaoID)
+	if err != nil {
+		return nil, err
+	}
+	if pendencias == nil {
+		pendencias = make([]dominio.PendenciaImportacao, 0)
+	}
+	return &ImportacaoDetalhada{Importacao: *importacao, Pendencias: pendencias}, nil
+}
+
+// resultadoLinha indica o que aconteceu com uma linha processada.
+type resultadoLinha int
+
+const (
+	linhaImportada resultadoLinha = iota
+	linhaJaExistente
+	linhaPendente
+)
+
+// processar importa as linhas uma a uma, gravando o progresso periodicamente. Reimportar os
+// mesmos arquivos não duplica nada: as linhas já presentes contam como "já existentes".
+func (s *importacaoServicoImpl) processar(importacao dominio.Importacao, linhas []linhaImportacao) {
+	// Um pânico no meio do processamento não pode deixar a importação em andamento para sempre,
+	// o que impediria o usuário de importar de novo.
+	defer func() {
+		if causa := recover(); causa != nil {
+			s.falhar(&importacao, fmt.Errorf("pânico: %v", causa))
+		}
+	}()
+
+	importacao.Status = dominio.StatusImportacaoProcessando
+	if err := s.repo.Atualizar(&importacao); err != nil {
+		s.falhar(&importacao, err)
+		return
+	}
+
+	// Os mesmos filmes costumam aparecer em vários arquivos; o cache evita repetir as buscas.
+	cache := make(map[string]*dominio.Filme)
+	for i, linha := range linhas {
+		resultado, motivo, err := s.importarLinha(importacao.UsuarioID, linha, cache)
+		if err != nil {
+			s.falhar(&importacao, err)
+			return
+		}
+
+		switch resultado {
+		case linhaImportada:
+			importacao.Importadas++
+		case linhaJaExistente:
+			importacao.JaExistentes++
+		case linhaPendente:
+			importacao.NaoEncontradas++
+			pendencia := &dominio.PendenciaImportacao{
+				ImportacaoID: importacao.ID,
+				Arquivo:      linha.arquivo,
+				Linha:        linha.linha,
+				Titulo:       linha.titulo,
+				Ano:          linha.ano,
+				IMDbID:       linha.imdbID,
+				Motivo:       motivo,
+			}
+			if err := s.repo.AdicionarPendencia(pendencia); err != nil {
+				s.falhar(&importacao, err)
+				return
+			}
+		}
+
+		importacao.Processadas = i + 1
+		if importacao.Processadas%intervaloProgressoImportacao == 0 {
+			if err := s.repo.Atualizar(&importacao); err != nil {
+				s.falhar(&importacao, err)
+				return
+			}
+		}
+	}
+
+	agora := time.Now().UTC()
+	importacao.Status = dominio.StatusImportacaoConcluida
+	importacao.DataConclusao = &agora
+	if err := s.repo.Atualizar(&importacao); err != nil {
+		log.Printf("Falha ao concluir a importação %d: %v", importacao.ID, err)
+	}
+}
+
+// importarLinha associa a linha a um filme do catálogo e grava no destino correspondente.
+// Erros de banco interrompem a importação; problemas da própria linha viram pendências.
+func (s *importacaoServicoImpl) importarLinha(usuarioID int64, linha linhaImportacao, cache map[string]*dominio.Filme) (resultadoLinha, string, error) {
+	if linha.motivoIgnorada != "" {
+		return linhaPendente, linha.motivoIgnorada, nil
+	}
+
+	filme, err := s.encontrarFilme(linha, cache)
+	if err != nil {
+		log.Printf("Falha ao consultar o catálogo durante a importação: %v", err)
+		return linhaPendente, "falha ao consultar o catálogo; tente importar o arquivo novamente", nil
+	}
+	if filme == nil {
+		return linhaPendente, "filme não encontrado no catálogo", nil
+	}
+	filmeID := int64(filme.ID)
+
+	switch linha.destino {
+	case destinoAvaliacao:
+		alterada, err := s.avaliacaoRepo.SalvarNota(usuarioID, filmeID, linha.nota)
+		if err != nil {
+			return 0, "", err
+		}
+		if !alterada {
+			return linhaJaExistente, "", nil
+		}
+
+	case destinoDiario:
+		data := linha.data
+		// Uma entrada do mesmo filme na mesma data é a desta linha, de uma importação anterior.
+		existente, err := s.diarioRepo.AssistidoEm(usuarioID, filmeID, data)
+		if err != nil {
+			return 0, "", err
+		}
+		if existente {
+			return linhaJaExistente, "", nil
+		}
+		revisto, err := s.diarioRepo.JaAssistido(usuarioID, filmeID, data)
+		if err != nil {
+			return 0, "", err
+		}
+		entrada := &dominio.EntradaDiario{
+			UsuarioID:     usuarioID,
+			FilmeID:       filmeID,
+			Titulo:        filme.Titulo,
+			CaminhoPoster: filme.CaminhoPoster,
+			DataAssistido: data,
+			Revisto:       revisto,
+		}
+		if err := s.diarioRepo.Criar(entrada); err != nil {
+			return 0, "", err
+		}
+
+	case destinoAssistir:
+		// Filmes que já estão no diário não voltam para a lista "para assistir" ao reimportar.
+		jaAssistido, err := s.diarioRepo.JaAssistido(usuarioID, filmeID, "")
+		if err != nil {
+			return 0, "", err
+		}
+		if jaAssistido {
+			return linhaJaExistente, "", nil
+		}
+		listaID, err := s.listaRepo.BuscarIDEmbutida(usuarioID, dominio.TipoListaAssistir)
+		if err != nil {
+			return 0, "", err
+		}
+		versao, err := s.listaRepo.AdicionarItem(&dominio.ItemLista{
+			ListaID:       listaID,
+			FilmeID:       filmeID,
+			Titulo:        filme.Titulo,
+			CaminhoPoster: filme.CaminhoPoster,
+			AdicionadoPor: &usuarioID,
+		}, 0)
+		if err != nil {
+			return 0, "", err
+		}
+		if versao == 0 {
+			return linhaJaExistente, "", nil
+		}
+	}
+
+	return linhaImportada, "", nil
+}
+
+// encontrarFilme busca o filme pelo ID do IMDb, quando houver, e senão pelo título e ano.
+func (s *importacaoServicoImpl) encontrarFilme(linha linhaImportacao, cache map[string]*dominio.Filme) (*dominio.Filme, error) {
+	chave := fmt.Sprintf("titulo:%s|%d", strings.ToLower(linha.titulo), linha.ano)
+	if linha.imdbID != "" {
+		chave = "imdb:" + linha.imdbID
+	}
+	if filme, ok := cache[chave]; ok {
+		return filme, nil
+	}
+
+	var filme *dominio.Filme
+	var err error
+	if linha.imdbID != "" {
+		filme, err = s.filmeServico.BuscarPorIMDbID(linha.imdbID)
+	}
+	if err == nil && filme == nil && linha.titulo != "" {
+		filme, err = s.filmeServico.BuscarPorTituloEAno(linha.titulo, linha.ano)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cache[chave] = filme
+	return filme, nil
+}
+
+// falhar encerra a importação com erro, mantendo o que já foi gravado.
+func (s *importacaoServicoImpl) falhar(importacao *dominio.Importacao, causa error) {
+	log.Printf("Importação %d interrompida: %v", importacao.ID, causa)
+
+	agora := time.Now().UTC()
+	importacao.Status = dominio.StatusImportacaoFalhou
+	importacao.Erro = "falha interna ao gravar os dados importados"
+	importacao.DataConclusao = &agora
+	if err := s.repo.Atualizar(importacao); err != nil {
+		log.Printf("Falha ao registrar o erro da importação %d: %v", importacao.ID, err)
+	}
+}
@@ -0,0 +1,102 @@
+package servico_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// filmesPorTitulo encontra Matrix pelo título; os demais métodos do catálogo não são usados.
+type filmesPorTitulo struct {
+	servico.FilmeServico
+	bloquear chan struct{}
+	panico   bool
+}
+
+func (f *filmesPorTitulo) BuscarPorTituloEAno(titulo string, ano int) (*dominio.Filme, error) {
+	if f.bloquear != nil {
+		<-f.bloquear
+	}
+	if f.panico {
+		panic("falha inesperada")
+	}
+	return &dominio.Filme{ID: 603, Titulo: "Matrix", CaminhoPoster: "/matrix.jpg"}, nil
+}
+
+func novoServicoImportacao(db *sqlx.DB, filmes servico.FilmeServico) servico.ImportacaoServico {
+	return servico.NovaImportacaoServico(repositorio.NovaImportacaoRepositorio(db), filmes,
+		repositorio.NovaAvaliacaoRepositorio(db), repositorio.NovoDiarioRepositorio(db), repositorio.NovoListaRepositorio(db))
+}
+
+func watched(conteudo string) []servico.ArquivoImportacao {
+	return []servico.ArquivoImportacao{{Nome: "watched.csv", Conteudo: strings.NewReader(conteudo)}}
+}
+
+// aguardarImportacao espera a importação sair do andamento e a retorna.
+func aguardarImportacao(t *testing.T, importacoes servico.ImportacaoServico, usuarioID, id int64) *servico.ImportacaoDetalhada {
+	t.Helper()
+
+	var importacao *servico.ImportacaoDetalhada
+	require.Eventually(t, func() bool {
+		var err error
+		importacao, err = importacoes.Buscar(usuarioID, id)
+		require.NoError(t, err)
+		return importacao.Status == dominio.StatusImportacaoConcluida || importacao.Status == dominio.StatusImportacaoFalhou
+	}, 5*time.Second, 10*time.Millisecond)
+	return importacao
+}
+
+func TestImportacaoIgnoraAssistidosSemData(t *testing.T) {
+	db := novoBanco(t)
+	ana := novoUsuario(t, db, "Ana", "ana@example.com")
+	importacoes := novoServicoImportacao(db, &filmesPorTitulo{})
+
+	importacao, err := importacoes.Iniciar(ana.ID, watched("Date,Name,Year,Letterboxd URI\n,The Matrix,1999,https://boxd.it/1\n"))
+	require.NoError(t, err)
+
+	detalhada := aguardarImportacao(t, importacoes, ana.ID, importacao.ID)
+	assert.Equal(t, dominio.StatusImportacaoConcluida, detalhada.Status)
+	assert.Zero(t, detalhada.Importadas)
+	assert.Equal(t, 1, detalhada.NaoEncontradas)
+	require.Len(t, detalhada.Pendencias, 1)
+	assert.Contains(t, detalhada.Pendencias[0].Motivo, "data")
+
+	assistido, err := repositorio.NovoDiarioRepositorio(db).JaAssistido(ana.ID, 603, "")
+	require.NoError(t, err)
+	assert.False(t, assistido)
+}
+
+func TestImportacaoUmaPorVez(t *testing.T) {
+	db := novoBanco(t)
+	ana := novoUsuario(t, db, "Ana", "ana@example.com")
+	filmes := &filmesPorTitulo{bloquear: make(chan struct{})}
+	importacoes := novoServicoImportacao(db, filmes)
+	const arquivo = "Date,Name,Year,Letterboxd URI\n2024-01-02,The Matrix,1999,https://boxd.it/1\n"
+
+	primeira, err := importacoes.Iniciar(ana.ID, watched(arquivo))
+	require.NoError(t, err)
+	_, err = importacoes.Iniciar(ana.ID, watched(arquivo))
+	assert.ErrorIs(t, err, servico.ErrImportacaoEmAndamento)
+
+	close(filmes.bloquear)
+	assert.Equal(t, dominio.StatusImportacaoConcluida, aguardarImportacao(t, importacoes, ana.ID, primeira.ID).Status)
+	_, err = importacoes.Iniciar(ana.ID, watched(arquivo))
+	assert.NoError(t, err)
+}
+
+func TestImportacaoComPanicoFalha(t *testing.T) {
+	db := novoBanco(t)
+	ana := novoUsuario(t, db, "Ana", "ana@example.com")
+	importacoes := novoServicoImportacao(db, &filmesPorTitulo{panico: true})
+
+	importacao, err := importacoes.Iniciar(ana.ID, watched("Date,Name,Year,Letterboxd URI\n2024-01-02,The Matrix,1999,https://boxd.it/1\n"))
+	require.NoError(t, err)
+	assert.Equal(t, dominio.StatusImportacaoFalhou, aguardarImportacao(t, importacoes, ana.ID, importacao.ID).Status)
+}
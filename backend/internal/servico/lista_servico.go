@@ -0,0 +1,413 @@
+package servico
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+)
+
+// Define os erros que o serviço de listas pode retornar.
+var (
+	ErrListaNaoEncontrada  = errors.New("lista não encontrada")
+	ErrListaEmbutida       = errors.New("listas embutidas não podem ser renomeadas nem excluídas")
+	ErrItemJaNaLista       = errors.New("este filme já está na lista")
+	ErrItemNaoEncontrado   = errors.New("este filme não está na lista")
+	ErrTituloListaInvalido = errors.New("o título da lista não pode ficar vazio")
+	ErrSemPermissaoLista   = errors.New("seu papel nesta lista não permite esta alteração")
+	ErrListaDesatualizada  = errors.New("a lista foi alterada por outra pessoa; recarregue-a e tente novamente")
+	ErrOrdemListaInvalida  = errors.New("a nova ordem deve conter cada filme da lista exatamente uma vez")
+	ErrListaFavoritos      = errors.New("use /favoritos para adicionar filmes aos favoritos")
+)
+
+// CriarListaInput define os campos para criar uma lista.
+type CriarListaInput struct {
+	Titulo       string `json:"titulo" binding:"required,max=100"`
+	Descricao    string `json:"descricao" binding:"max=1000"`
+	Visibilidade string `json:"visibilidade" binding:"omitempty,oneof=privada nao_listada publica"`
+	Ranqueada    bool   `json:"ranqueada"`
+}
+
+// AtualizarListaInput define os campos editáveis de uma lista; os ausentes não mudam.
+type AtualizarListaInput struct {
+	Titulo       *string `json:"titulo" binding:"omitempty,max=100"`
+	Descricao    *string `json:"descricao" binding:"omitempty,max=1000"`
+	Visibilidade *string `json:"visibilidade" binding:"omitempty,oneof=privada nao_listada publica"`
+	Ranqueada    *bool   `json:"ranqueada"`
+}
+
+// AdicionarItemListaInput define o filme a ser incluído em uma lista e, opcionalmente, a posição
+// (a partir de 1) em que ele entra; sem ela, o filme vai para o fim. Título e pôster vêm do
+// catálogo, não do cliente.
+type AdicionarItemListaInput struct {
+	FilmeID int64 `json:"filmeId" binding:"required"`
+	Posicao int   `json:"posicao" binding:"omitempty,min=1"`
+}
+
+// MoverItemListaInput define a nova posição (a partir de 1) de um filme na lista.
+type MoverItemListaInput struct {
+	Posicao int `json:"posicao" binding:"required,min=1"`
+}
+
+// DefinirOrdemListaInput traz todos os filmes da lista na nova ordem.
+type DefinirOrdemListaInput struct {
+	Filmes []int64 `json:"filmes" binding:"required"`
+}
+
+// ListaServico define a criação e a organização das listas de filmes.
+type ListaServico interface {
+	Criar(usuarioID int64, input CriarListaInput) (*dominio.Lista, error)
+	Listar(usuarioID int64) ([]dominio.Lista, error)
+	Buscar(usuarioID, listaID int64) (*dominio.ListaComItens, error)
+	BuscarVisivel(usuarioID, listaID int64) (*dominio.Lista, error)
+	BuscarCompartilhada(slug string) (*dominio.ListaComItens, error)
+	Atualizar(usuarioID, listaID int64, versao int, input AtualizarListaInput) (*dominio.Lista, error)
+	Excluir(usuarioID, listaID int64) error
+	AdicionarItem(usuarioID, listaID int64, versao int, input AdicionarItemListaInput) (*dominio.ItemLista, int, error)
+	RemoverItem(usuarioID, listaID, filmeID int64, versao int) (int, error)
+	MoverItem(usuarioID, listaID, filmeID int64, versao int, input MoverItemListaInput) (int, error)
+	DefinirOrdem(usuarioID, listaID int64, versao int, input DefinirOrdemListaInput) (int, error)
+	IDEmbutida(usuarioID int64, tipo string) (int64, error)
+}
+
+type listaServicoImpl struct {
+	repo            repositorio.ListaRepositorio
+	colaboracaoRepo repositorio.ColaboracaoRepositorio
+	atividadeRepo   repositorio.AtividadeRepositorio
+	catalogo        CatalogoServico
+}
+
+// NovaListaServico cria o serviço de listas.
+func NovaListaServico(repo repositorio.ListaRepositorio, colaboracaoRepo repositorio.ColaboracaoRepositorio, atividadeRepo repositorio.AtividadeRepositorio, catalogo CatalogoServico) ListaServico {
+	return &listaServicoImpl{repo: repo, colaboracaoRepo: colaboracaoRepo, atividadeRepo: atividadeRepo, catalogo: catalogo}
+}
+
+// Criar cria uma lista personalizada, privada por padrão.
+func (s *listaServicoImpl) Criar(usuarioID int64, input CriarListaInput) (*dominio.Lista, error) {
+	titulo := strings.TrimSpace(input.Titulo)
+	if titulo == "" {
+		return nil, ErrTituloListaInvalido
+	}
+
+	lista := &dominio.Lista{
+		UsuarioID:    usuarioID,
+		Tipo:         dominio.TipoListaPersonalizada,
+		Titulo:       titulo,
+		Descricao:    strings.TrimSpace(input.Descricao),
+		Visibilidade: input.Visibilidade,
+		Ranqueada:    input.Ranqueada,
+	}
+	if lista.Visibilidade == "" {
+		lista.Visibilidade = dominio.VisibilidadePrivada
+	}
+	if err := prepararCompartilhamento(lista); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Criar(lista); err != nil {
+		return nil, err
+	}
+	registrarAtividade(s.atividadeRepo, &dominio.Atividade{
+		UsuarioID: usuarioID,
+		Tipo:      dominio.AtividadeLista,
+		ListaID:   &lista.ID,
+	})
+	lista.Papel = dominio.PapelDono
+	return lista, nil
+}
+
+// Listar retorna as listas do usuário, incluindo as embutidas (como a de favoritos), seguidas
+// das listas de outros usuários das quais ele é membro.
+func (s *listaServicoImpl) Listar(usuarioID int64) ([]dominio.Lista, error) {
+	if err := s.repo.GarantirEmbutidas(usuarioID); err != nil {
+		return nil, err
+	}
+	listas, err := s.repo.ListarPorUsuarioID(usuarioID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range listas {
+		listas[i].Papel = dominio.PapelDono
+	}
+
+	compartilhadas, err := s.colaboracaoRepo.ListarListasDoMembro(usuarioID)
+	if err != nil {
+		return nil, err
+	}
+	return append(listas, compartilhadas...), nil
+}
+
+// Buscar retorna a lista com seus itens.
+func (s *listaServicoImpl) Buscar(usuarioID, listaID int64) (*dominio.ListaComItens, error) {
+	lista, err := s.BuscarVisivel(usuarioID, listaID)
+	if err != nil {
+		return nil, err
+	}
+	return s.comItens(lista)
+}
+
+// BuscarCompartilhada retorna, sem exigir login, a lista do link de compartilhamento.
+// Listas que voltaram a ser privadas deixam de ser acessíveis pelo link.
+func (s *listaServicoImpl) BuscarCompartilhada(slug string) (*dominio.ListaComItens, error) {
+	lista, err := s.repo.BuscarPorSlug(slug)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrListaNaoEncontrada
+		}
+		return nil, err
+	}
+	if lista.Visibilidade == dominio.VisibilidadePrivada {
+		return nil, ErrListaNaoEncontrada
+	}
+	return s.comItens(lista)
+}
+
+// comItens completa a lista com seus filmes, na ordem manual.
+func (s *listaServicoImpl) comItens(lista *dominio.Lista) (*dominio.ListaComItens, error) {
+	itens, err := s.repo.ListarItens(lista.ID)
+	if err != nil {
+		return nil, err
+	}
+	if itens == nil {
+		itens = make([]dominio.ItemLista, 0)
+	}
+
+	lista.QuantidadeItens = len(itens)
+	return &dominio.ListaComItens{Lista: *lista, Itens: itens}, nil
+}
+
+// BuscarVisivel retorna a lista, sem os itens, se o usuário puder vê-la. Listas de outros
+// usuários só são visíveis se públicas ou se ele for membro delas.
+func (s *listaServicoImpl) BuscarVisivel(usuarioID, listaID int64) (*dominio.Lista, error) {
+	lista, err := s.buscarLista(listaID)
+	if err != nil {
+		return nil, err
+	}
+	papel, err := s.papel(usuarioID, lista)
+	if err != nil {
+		return nil, err
+	}
+	if papel == "" && lista.Visibilidade != dominio.VisibilidadePublica {
+		return nil, ErrListaNaoEncontrada
+	}
+	lista.Papel = papel
+	return lista, nil
+}
+
+// Atualizar altera título, descrição e visibilidade. O título das listas embutidas é fixo.
+// Com versao diferente de 0, a alteração é recusada se a lista tiver mudado desde então.
+func (s *listaServicoImpl) Atualizar(usuarioID, listaID int64, versao int, input AtualizarListaInput) (*dominio.Lista, error) {
+	lista, err := s.buscarComPapel(usuarioID, listaID, dominio.PapelDono)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Titulo != nil {
+		titulo := strings.TrimSpace(*input.Titulo)
+		if titulo == "" {
+			return nil, ErrTituloListaInvalido
+		}
+		if lista.Tipo != dominio.TipoListaPersonalizada && titulo != lista.Titulo {
+			return nil, ErrListaEmbutida
+		}
+		lista.Titulo = titulo
+	}
+	if input.Descricao != nil {
+		lista.Descricao = strings.TrimSpace(*input.Descricao)
+	}
+	if input.Visibilidade != nil {
+		lista.Visibilidade = *input.Visibilidade
+	}
+	if input.Ranqueada != nil {
+		lista.Ranqueada = *input.Ranqueada
+	}
+	if err := prepararCompartilhamento(lista); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Atualizar(lista, versao); err != nil {
+		return nil, traduzirErroVersao(err)
+	}
+	lista.Papel = dominio.PapelDono
+	return lista, nil
+}
+
+// Excluir apaga uma lista personalizada e todos os seus itens.
+func (s *listaServicoImpl) Excluir(usuarioID, listaID int64) error {
+	lista, err := s.buscarComPapel(usuarioID, listaID, dominio.PapelDono)
+	if err != nil {
+		return err
+	}
+	if lista.Tipo != dominio.TipoListaPersonalizada {
+		return ErrListaEmbutida
+	}
+	return s.repo.Deletar(listaID)
+}
+
+// AdicionarItem inclui o filme na posição pedida ou no fim da lista, registrando quem o adicionou, e retorna a nova
+// versão da lista. Dono e editores podem alterar os itens; o mesmo vale para RemoverItem e MoverItem.
+// Os favoritos têm anotações e tags e só recebem filmes por /favoritos.
+func (s *listaServicoImpl) AdicionarItem(usuarioID, listaID int64, versao int, input AdicionarItemListaInput) (*dominio.ItemLista, int, error) {
+	lista, err := s.buscarComPapel(usuarioID, listaID, dominio.PapelDono, dominio.PapelEditor)
+	if err != nil {
+		return nil, 0, err
+	}
+	if lista.Tipo == dominio.TipoListaFavoritos {
+		return nil, 0, ErrListaFavoritos
+	}
+
+	// Confirma que o filme existe no catálogo e usa os dados de lá, como nos favoritos.
+	metadados, err := s.catalogo.Obter(input.FilmeID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	item := &dominio.ItemLista{
+		ListaID:       listaID,
+		FilmeID:       input.FilmeID,
+		Titulo:        metadados.Titulo,
+		CaminhoPoster: metadados.CaminhoPoster,
+		Posicao:       input.Posicao,
+		AdicionadoPor: &usuarioID,
+	}
+	novaVersao, err := s.repo.AdicionarItem(item, versao)
+	if err != nil {
+		return nil, 0, traduzirErroVersao(err)
+	}
+	if novaVersao == 0 {
+		return nil, 0, ErrItemJaNaLista
+	}
+
+	registrarAtividade(s.atividadeRepo, &dominio.Atividade{
+		UsuarioID:     usuarioID,
+		Tipo:          dominio.AtividadeLista,
+		FilmeID:       &item.FilmeID,
+		Titulo:        item.Titulo,
+		CaminhoPoster: item.CaminhoPoster,
+		ListaID:       &listaID,
+	})
+	return item, novaVersao, nil
+}
+
+// RemoverItem tira o filme da lista e retorna a nova versão da lista.
+func (s *listaServicoImpl) RemoverItem(usuarioID, listaID, filmeID int64, versao int) (int, error) {
+	if _, err := s.buscarComPapel(usuarioID, listaID, dominio.PapelDono, dominio.PapelEditor); err != nil {
+		return 0, err
+	}
+
+	novaVersao, err := s.repo.RemoverItem(listaID, filmeID, versao)
+	if err != nil {
+		return 0, traduzirErroVersao(err)
+	}
+	if novaVersao == 0 {
+		return 0, ErrItemNaoEncontrado
+	}
+	return novaVersao, nil
+}
+
+// MoverItem altera a posição do filme na ordem manual e retorna a nova versão da lista.
+func (s *listaServicoImpl) MoverItem(usuarioID, listaID, filmeID int64, versao int, input MoverItemListaInput) (int, error) {
+	if _, err := s.buscarComPapel(usuarioID, listaID, dominio.PapelDono, dominio.PapelEditor); err != nil {
+		return 0, err
+	}
+
+	novaVersao, err := s.repo.MoverItem(listaID, filmeID, input.Posicao, versao)
+	if err != nil {
+		return 0, traduzirErroVersao(err)
+	}
+	if novaVersao == 0 {
+		return 0, ErrItemNaoEncontrado
+	}
+	return novaVersao, nil
+}
+
+// DefinirOrdem reordena a lista inteira de uma vez e retorna a nova versão da lista.
+func (s *listaServicoImpl) DefinirOrdem(usuarioID, listaID int64, versao int, input DefinirOrdemListaInput) (int, error) {
+	if _, err := s.buscarComPapel(usuarioID, listaID, dominio.PapelDono, dominio.PapelEditor); err != nil {
+		return 0, err
+	}
+
+	novaVersao, err := s.repo.DefinirOrdem(listaID, input.Filmes, versao)
+	if err == repositorio.ErrOrdemDivergente {
+		return 0, ErrOrdemListaInvalida
+	}
+	if err != nil {
+		return 0, traduzirErroVersao(err)
+	}
+	return novaVersao, nil
+}
+
+// IDEmbutida retorna o ID de uma lista embutida do usuário (como a "para assistir").
+func (s *listaServicoImpl) IDEmbutida(usuarioID int64, tipo string) (int64, error) {
+	return s.repo.BuscarIDEmbutida(usuarioID, tipo)
+}
+
+func (s *listaServicoImpl) buscarLista(listaID int64) (*dominio.Lista, error) {
+	lista, err := s.repo.BuscarPorID(listaID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrListaNaoEncontrada
+		}
+		return nil, err
+	}
+	return lista, nil
+}
+
+// papel retorna o papel do usuário na lista, ou "" se ele não for o dono nem membro dela.
+func (s *listaServicoImpl) papel(usuarioID int64, lista *dominio.Lista) (string, error) {
+	if lista.UsuarioID == usuarioID {
+		return dominio.PapelDono, nil
+	}
+	return s.colaboracaoRepo.BuscarPapel(lista.ID, usuarioID)
+}
+
+// buscarComPapel retorna a lista se o usuário tiver um dos papéis informados. Listas de que ele
+// não participa são tratadas como inexistentes, para não revelar quais IDs existem.
+func (s *listaServicoImpl) buscarComPapel(usuarioID, listaID int64, papeis ...string) (*dominio.Lista, error) {
+	lista, err := s.buscarLista(listaID)
+	if err != nil {
+		return nil, err
+	}
+	papel, err := s.papel(usuarioID, lista)
+	if err != nil {
+		return nil, err
+	}
+	if papel == "" {
+		return nil, ErrListaNaoEncontrada
+	}
+	for _, permitido := range papeis {
+		if papel == permitido {
+			lista.Papel = papel
+			return lista, nil
+		}
+	}
+	return nil, ErrSemPermissaoLista
+}
+
+// traduzirErroVersao converte o conflito de versão do repositório no erro do serviço.
+func traduzirErroVersao(err error) error {
+	if err == repositorio.ErrVersaoDesatualizada {
+		return ErrListaDesatualizada
+	}
+	return err
+}
+
+// prepararCompartilhamento gera o link de compartilhamento na primeira vez que a lista deixa de
+// ser privada. O identificador é aleatório, para que listas não listadas não possam ser adivinhadas.
+func prepararCompartilhamento(lista *dominio.Lista) error {
+	if lista.Visibilidade == dominio.VisibilidadePrivada || lista.SlugCompartilhamento != nil {
+		return nil
+	}
+
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return err
+	}
+	slug := hex.EncodeToString(bytes)
+	lista.SlugCompartilhamento = &slug
+	return nil
+}
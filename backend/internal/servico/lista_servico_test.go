@@ -0,0 +1,88 @@
+package servico_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var catalogoTeste = catalogoFixo{
+	603: {FilmeID: 603, Titulo: "Matrix", CaminhoPoster: "/matrix.jpg", Ano: 1999},
+}
+
+func TestAdicionarItemUsaDadosDoCatalogo(t *testing.T) {
+	db := novoBanco(t)
+	ana := novoUsuario(t, db, "Ana", "ana@example.com")
+	listas := servico.NovaListaServico(repositorio.NovoListaRepositorio(db), repositorio.NovoColaboracaoRepositorio(db),
+		repositorio.NovaAtividadeRepositorio(db), catalogoTeste)
+
+	lista, err := listas.Criar(ana.ID, servico.CriarListaInput{Titulo: "Ficção"})
+	require.NoError(t, err)
+
+	item, _, err := listas.AdicionarItem(ana.ID, lista.ID, 0, servico.AdicionarItemListaInput{FilmeID: 603})
+	require.NoError(t, err)
+	assert.Equal(t, "Matrix", item.Titulo)
+	assert.Equal(t, "/matrix.jpg", item.CaminhoPoster)
+
+	_, _, err = listas.AdicionarItem(ana.ID, lista.ID, 0, servico.AdicionarItemListaInput{FilmeID: 999})
+	assert.ErrorIs(t, err, servico.ErrFilmeInexistente)
+}
+
+func TestAdicionarItemRecusaListaDeFavoritos(t *testing.T) {
+	db := novoBanco(t)
+	ana := novoUsuario(t, db, "Ana", "ana@example.com")
+	listas := servico.NovaListaServico(repositorio.NovoListaRepositorio(db), repositorio.NovoColaboracaoRepositorio(db),
+		repositorio.NovaAtividadeRepositorio(db), catalogoTeste)
+
+	_, err := listas.Listar(ana.ID)
+	require.NoError(t, err)
+	favoritosID, err := listas.IDEmbutida(ana.ID, dominio.TipoListaFavoritos)
+	require.NoError(t, err)
+
+	_, _, err = listas.AdicionarItem(ana.ID, favoritosID, 0, servico.AdicionarItemListaInput{FilmeID: 603})
+	assert.ErrorIs(t, err, servico.ErrListaFavoritos)
+}
+
+func TestListaCompartilhadaSomeComODono(t *testing.T) {
+	db := novoBanco(t)
+	ana := novoUsuario(t, db, "Ana", "ana@example.com")
+	listas := servico.NovaListaServico(repositorio.NovoListaRepositorio(db), repositorio.NovoColaboracaoRepositorio(db),
+		repositorio.NovaAtividadeRepositorio(db), catalogoTeste)
+	usuarioRepo := repositorio.NovoUsuarioRepositorio(db)
+	perfilRepo := repositorio.NovoPerfilRepositorio(db)
+	perfis := servico.NovoPerfilServico(perfilRepo, usuarioRepo, repositorio.NovoListaRepositorio(db),
+		repositorio.NovaAvaliacaoRepositorio(db), catalogoTeste)
+
+	lista, err := listas.Criar(ana.ID, servico.CriarListaInput{Titulo: "Ficção"})
+	require.NoError(t, err)
+	visibilidade := dominio.VisibilidadeNaoListada
+	lista, err = listas.Atualizar(ana.ID, lista.ID, 0, servico.AtualizarListaInput{Visibilidade: &visibilidade})
+	require.NoError(t, err)
+	require.NotNil(t, lista.SlugCompartilhamento)
+	slug := *lista.SlugCompartilhamento
+
+	// Sem perfil criado, o link continua valendo.
+	_, err = listas.BuscarCompartilhada(slug)
+	require.NoError(t, err)
+
+	require.NoError(t, usuarioRepo.AgendarExclusao(ana.ID, time.Now().Add(24*time.Hour)))
+	_, err = listas.BuscarCompartilhada(slug)
+	assert.ErrorIs(t, err, servico.ErrListaNaoEncontrada)
+
+	require.NoError(t, usuarioRepo.CancelarExclusao(ana.ID))
+	_, err = perfis.Buscar(ana.ID)
+	require.NoError(t, err)
+	_, err = listas.BuscarCompartilhada(slug)
+	require.NoError(t, err)
+
+	ocultado, err := perfilRepo.Ocultar(ana.ID)
+	require.NoError(t, err)
+	require.True(t, ocultado)
+	_, err = listas.BuscarCompartilhada(slug)
+	assert.ErrorIs(t, err, servico.ErrListaNaoEncontrada)
+}
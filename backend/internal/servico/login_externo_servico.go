@@ -0,0 +1,294 @@
+package servico
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/auth"
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+)
+
+// Define os erros que o serviço de login externo pode retornar.
+var (
+	ErrProvedorDesconhecido    = errors.New("provedor de login desconhecido")
+	ErrEstadoInvalido          = errors.New("login expirado ou já concluído; inicie novamente")
+	ErrEmailNaoVerificado      = errors.New("o provedor não confirmou que o e-mail foi verificado")
+	ErrIdentidadeNaoEncontrada = errors.New("identidade externa não encontrada")
+	ErrUltimoMetodoLogin       = errors.New("não é possível remover o único método de login da conta")
+	ErrContaJaExiste           = errors.New("já existe uma conta com este e-mail ainda não verificado; confirme o e-mail ou entre com ela e vincule o provedor nas configurações da conta")
+	ErrIdentidadeEmUso         = errors.New("esta conta do provedor já está vinculada a outro usuário")
+)
+
+// ValidadeLoginExterno é o tempo que o usuário tem para concluir o login no provedor.
+const ValidadeLoginExterno = 10 * time.Minute
+
+// LoginExternoIniciado traz a URL do provedor e o state que o handler guarda no cookie do
+// navegador que iniciou o fluxo.
+type LoginExternoIniciado struct {
+	URL    string
+	Estado string
+}
+
+// ConcluirLoginExternoInput define os campos recebidos no callback do provedor.
+// EstadoNavegador vem do cookie gravado no início do fluxo, não do corpo da requisição.
+type ConcluirLoginExternoInput struct {
+	Codigo          string `json:"codigo" binding:"required"`
+	Estado          string `json:"estado" binding:"required"`
+	EstadoNavegador string `json:"-"`
+}
+
+// LoginExternoServico define o login social via OpenID Connect.
+type LoginExternoServico interface {
+	Iniciar(provedor string) (*LoginExternoIniciado, error)
+	Concluir(provedor string, input ConcluirLoginExternoInput, cliente InfoCliente) (*RespostaLogin, error)
+	IniciarVinculo(provedor string, usuarioID int64) (*LoginExternoIniciado, error)
+	ConcluirVinculo(provedor string, usuarioID int64, input ConcluirLoginExternoInput) (*dominio.IdentidadeExterna, error)
+	ListarIdentidades(usuarioID int64) ([]dominio.IdentidadeExterna, error)
+	Desvincular(usuarioID, identidadeID int64) error
+}
+
+type loginExternoServicoImpl struct {
+	provedores     map[string]*auth.ProvedorOIDC
+	identidadeRepo repositorio.IdentidadeRepositorio
+	usuarioRepo    repositorio.UsuarioRepositorio
+	authServico    AuthServico
+}
+
+// NovoLoginExternoServico cria o serviço de login externo com os provedores configurados.
+func NovoLoginExternoServico(provedores map[string]*auth.ProvedorOIDC, identidadeRepo repositorio.IdentidadeRepositorio, usuarioRepo repositorio.UsuarioRepositorio, authServico AuthServico) LoginExternoServico {
+	return &loginExternoServicoImpl{
+		provedores:     provedores,
+		identidadeRepo: identidadeRepo,
+		usuarioRepo:    usuarioRepo,
+		authServico:    authServico,
+	}
+}
+
+// Iniciar começa um login pelo provedor e retorna a URL de autorização.
+func (s *loginExternoServicoImpl) Iniciar(nomeProvedor string) (*LoginExternoIniciado, error) {
+	return s.iniciar(nomeProvedor, nil)
+}
+
+// IniciarVinculo começa o fluxo que vincula um novo provedor à conta já autenticada.
+func (s *loginExternoServicoImpl) IniciarVinculo(nomeProvedor string, usuarioID int64) (*LoginExternoIniciado, error) {
+	return s.iniciar(nomeProvedor, &usuarioID)
+}
+
+// iniciar gera state, nonce e PKCE, guarda-os e monta a URL de autorização do provedor.
+func (s *loginExternoServicoImpl) iniciar(nomeProvedor string, usuarioID *int64) (*LoginExternoIniciado, error) {
+	provedor, ok := s.provedores[nomeProvedor]
+	if !ok {
+		return nil, ErrProvedorDesconhecido
+	}
+
+	estado, err := auth.TokenAleatorio(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := auth.TokenAleatorio(32)
+	if err != nil {
+		return nil, err
+	}
+	verificador, desafio, err := auth.NovoVerificadorPKCE()
+	if err != nil {
+		return nil, err
+	}
+
+	// Monta a URL antes de gravar, para não guardar logins de um provedor indisponível.
+	urlAutorizacao, err := provedor.URLAutorizacao(estado, nonce, desafio)
+	if err != nil {
+		return nil, err
+	}
+
+	pendente := &dominio.LoginExternoPendente{
+		Estado:          estado,
+		Provedor:        nomeProvedor,
+		Nonce:           nonce,
+		VerificadorPKCE: verificador,
+		ExpiraEm:        time.Now().Add(ValidadeLoginExterno),
+		UsuarioID:       usuarioID,
+	}
+	if err := s.identidadeRepo.SalvarLoginPendente(pendente); err != nil {
+		return nil, err
+	}
+
+	return &LoginExternoIniciado{URL: urlAutorizacao, Estado: estado}, nil
+}
+
+// Concluir valida o retorno do provedor, encontra (ou cria) o usuário e emite o token
+// da aplicação. Contas com 2FA recebem o desafio do segundo passo, como no login por senha.
+func (s *loginExternoServicoImpl) Concluir(nomeProvedor string, input ConcluirLoginExternoInput, cliente InfoCliente) (*RespostaLogin, error) {
+	pendente, identidade, err := s.concluir(nomeProvedor, input)
+	if err != nil {
+		return nil, err
+	}
+	// Um vínculo iniciado por uma conta não pode ser usado como login.
+	if pendente.UsuarioID != nil {
+		return nil, ErrEstadoInvalido
+	}
+
+	usuarioID, err := s.localizarOuCriarUsuario(nomeProvedor, identidade)
+	if err != nil {
+		return nil, err
+	}
+
+	usuario, err := s.usuarioRepo.BuscarPorID(usuarioID)
+	if err != nil {
+		return nil, err
+	}
+	return s.authServico.IniciarSessao(usuario, cliente)
+}
+
+// ConcluirVinculo valida o retorno do provedor e vincula a identidade à conta que iniciou o fluxo.
+// Vincular de novo a mesma identidade à mesma conta não é erro.
+func (s *loginExternoServicoImpl) ConcluirVinculo(nomeProvedor string, usuarioID int64, input ConcluirLoginExternoInput) (*dominio.IdentidadeExterna, error) {
+	pendente, identidade, err := s.concluir(nomeProvedor, input)
+	if err != nil {
+		return nil, err
+	}
+	if pendente.UsuarioID == nil || *pendente.UsuarioID != usuarioID {
+		return nil, ErrEstadoInvalido
+	}
+
+	vinculada, err := s.identidadeRepo.BuscarPorProvedor(nomeProvedor, identidade.Sujeito)
+	if err == nil {
+		if vinculada.UsuarioID != usuarioID {
+			return nil, ErrIdentidadeEmUso
+		}
+		return vinculada, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	novaIdentidade := &dominio.IdentidadeExterna{
+		UsuarioID: usuarioID,
+		Provedor:  nomeProvedor,
+		Sujeito:   identidade.Sujeito,
+		Email:     identidade.Email,
+	}
+	if err := s.identidadeRepo.Vincular(novaIdentidade); err != nil {
+		return nil, err
+	}
+	return novaIdentidade, nil
+}
+
+// concluir confere se o callback veio do mesmo navegador que iniciou o fluxo, consome o state
+// e troca o código pela identidade validada.
+func (s *loginExternoServicoImpl) concluir(nomeProvedor string, input ConcluirLoginExternoInput) (*dominio.LoginExternoPendente, *auth.IdentidadeOIDC, error) {
+	provedor, ok := s.provedores[nomeProvedor]
+	if !ok {
+		return nil, nil, ErrProvedorDesconhecido
+	}
+
+	// Sem o cookie do início, um callback preparado por outra pessoa logaria a vítima na conta dela.
+	if input.EstadoNavegador == "" || subtle.ConstantTimeCompare([]byte(input.EstadoNavegador), []byte(input.Estado)) != 1 {
+		return nil, nil, ErrEstadoInvalido
+	}
+
+	// O state é consumido mesmo se algo falhar depois, impedindo reutilização.
+	pendente, err := s.identidadeRepo.ConsumirLoginPendente(input.Estado)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, ErrEstadoInvalido
+		}
+		return nil, nil, err
+	}
+	if pendente.Provedor != nomeProvedor || time.Now().After(pendente.ExpiraEm) {
+		return nil, nil, ErrEstadoInvalido
+	}
+
+	identidade, err := provedor.TrocarCodigo(input.Codigo, pendente.VerificadorPKCE, pendente.Nonce)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pendente, identidade, nil
+}
+
+// localizarOuCriarUsuario encontra a conta pela identidade já vinculada, vincula a identidade à
+// conta com o mesmo e-mail ou cria uma conta nova. O vínculo pelo e-mail exige que os dois lados
+// o tenham provado: o provedor (email_verified) e a conta local (link de confirmação). Sem isso,
+// quem cadastrou antes o endereço de outra pessoa passaria a dividir a conta dela; nesse caso o
+// dono confirma o e-mail ou entra com a senha e vincula o provedor pela sessão (IniciarVinculo).
+func (s *loginExternoServicoImpl) localizarOuCriarUsuario(nomeProvedor string, identidade *auth.IdentidadeOIDC) (int64, error) {
+	vinculada, err := s.identidadeRepo.BuscarPorProvedor(nomeProvedor, identidade.Sujeito)
+	if err == nil {
+		return vinculada.UsuarioID, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	// Sem e-mail verificado não há como provar que a conta pertence à mesma pessoa.
+	if identidade.Email == "" || !identidade.EmailVerificado {
+		return 0, ErrEmailNaoVerificado
+	}
+
+	novaIdentidade := &dominio.IdentidadeExterna{
+		Provedor: nomeProvedor,
+		Sujeito:  identidade.Sujeito,
+		Email:    identidade.Email,
+	}
+
+	existente, err := s.usuarioRepo.BuscarPorEmail(identidade.Email)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	if existente != nil {
+		if !existente.EmailVerificado {
+			return 0, ErrContaJaExiste
+		}
+		novaIdentidade.UsuarioID = existente.ID
+		if err := s.identidadeRepo.Vincular(novaIdentidade); err != nil {
+			return 0, err
+		}
+		return existente.ID, nil
+	}
+
+	nome := strings.TrimSpace(identidade.Nome)
+	if nome == "" {
+		nome, _, _ = strings.Cut(identidade.Email, "@")
+	}
+
+	// Contas criadas pelo provedor não têm senha; o hash vazio nunca confere no login por senha.
+	// O e-mail já chega verificado pelo provedor.
+	usuario := &dominio.Usuario{Nome: nome, Email: identidade.Email, EmailVerificado: true}
+	if err := s.identidadeRepo.CriarUsuarioComIdentidade(usuario, novaIdentidade); err != nil {
+		return 0, err
+	}
+	return usuario.ID, nil
+}
+
+// ListarIdentidades retorna os provedores vinculados à conta do usuário.
+func (s *loginExternoServicoImpl) ListarIdentidades(usuarioID int64) ([]dominio.IdentidadeExterna, error) {
+	return s.identidadeRepo.ListarPorUsuarioID(usuarioID)
+}
+
+// Desvincular remove uma identidade externa, desde que a conta continue com outra forma de login.
+func (s *loginExternoServicoImpl) Desvincular(usuarioID, identidadeID int64) error {
+	usuario, err := s.usuarioRepo.BuscarPorID(usuarioID)
+	if err != nil {
+		return err
+	}
+
+	identidades, err := s.identidadeRepo.ListarPorUsuarioID(usuarioID)
+	if err != nil {
+		return err
+	}
+	if usuario.SenhaHash == "" && len(identidades) <= 1 {
+		return ErrUltimoMetodoLogin
+	}
+
+	removida, err := s.identidadeRepo.Desvincular(usuarioID, identidadeID)
+	if err != nil {
+		return err
+	}
+	if !removida {
+		return ErrIdentidadeNaoEncontrada
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package servico_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/Andydev0/filmes-backend/internal/auth"
+	"github.com/Andydev0/filmes-backend/internal/auth/oidcteste"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cenarioLoginExterno struct {
+	db       *sqlx.DB
+	chaves   *auth.ConjuntoChaves
+	provedor *oidcteste.Provedor
+	servico  servico.LoginExternoServico
+}
+
+func novoCenarioLoginExterno(t *testing.T) *cenarioLoginExterno {
+	t.Helper()
+
+	db := novoBanco(t)
+	chave, err := auth.NovaChaveHMAC("teste", []byte("segredo-de-teste-com-tamanho-suficiente"))
+	require.NoError(t, err)
+	chaves, err := auth.NovoConjuntoChaves("cinehub", "cinehub-api", chave)
+	require.NoError(t, err)
+
+	provedor := oidcteste.Novo(t, "cinehub")
+	provedores := map[string]*auth.ProvedorOIDC{
+		"teste": auth.NovoProvedorOIDC("teste", provedor.Emissor(), "cinehub", "segredo", "http://localhost:5173/callback"),
+	}
+
+	usuarioRepo := repositorio.NovoUsuarioRepositorio(db)
+	doisFatores := servico.NovoDoisFatoresServico(usuarioRepo, repositorio.NovoCodigoRecuperacaoRepositorio(db))
+	sessoes := servico.NovoSessaoServico(repositorio.NovoSessaoRepositorio(db))
+	authServico := servico.NovoAuthServico(usuarioRepo, chaves, doisFatores, sessoes)
+
+	return &cenarioLoginExterno{
+		db:       db,
+		chaves:   chaves,
+		provedor: provedor,
+		servico:  servico.NovoLoginExternoServico(provedores, repositorio.NovoIdentidadeRepositorio(db), usuarioRepo, authServico),
+	}
+}
+
+// autorizar simula o navegador no provedor: lê state, nonce e desafio da URL de autorização
+// e retorna o input do callback com o cookie do mesmo navegador.
+func (c *cenarioLoginExterno) autorizar(t *testing.T, iniciado *servico.LoginExternoIniciado, sujeito, email string) servico.ConcluirLoginExternoInput {
+	t.Helper()
+
+	destino, err := url.Parse(iniciado.URL)
+	require.NoError(t, err)
+	parametros := destino.Query()
+	require.Equal(t, iniciado.Estado, parametros.Get("state"))
+
+	codigo := c.provedor.Autorizar(oidcteste.Autorizacao{
+		Sujeito: sujeito, Email: email, EmailVerificado: true, Nome: "Ana",
+		Nonce: parametros.Get("nonce"), DesafioPKCE: parametros.Get("code_challenge"),
+	})
+	return servico.ConcluirLoginExternoInput{Codigo: codigo, Estado: iniciado.Estado, EstadoNavegador: iniciado.Estado}
+}
+
+func (c *cenarioLoginExterno) usuarioDoToken(t *testing.T, resposta *servico.RespostaLogin) int64 {
+	t.Helper()
+
+	claims, err := c.chaves.Validar(resposta.Token)
+	require.NoError(t, err)
+	usuarioID, err := claims.UsuarioID()
+	require.NoError(t, err)
+	return usuarioID
+}
+
+func TestLoginExternoCriaContaEReconheceIdentidade(t *testing.T) {
+	c := novoCenarioLoginExterno(t)
+
+	iniciado, err := c.servico.Iniciar("teste")
+	require.NoError(t, err)
+	resposta, err := c.servico.Concluir("teste", c.autorizar(t, iniciado, "sub-1", "ana@example.com"), servico.InfoCliente{})
+	require.NoError(t, err)
+	primeiro := c.usuarioDoToken(t, resposta)
+
+	iniciado, err = c.servico.Iniciar("teste")
+	require.NoError(t, err)
+	resposta, err = c.servico.Concluir("teste", c.autorizar(t, iniciado, "sub-1", "ana@example.com"), servico.InfoCliente{})
+	require.NoError(t, err)
+	assert.Equal(t, primeiro, c.usuarioDoToken(t, resposta))
+
+	identidades, err := c.servico.ListarIdentidades(primeiro)
+	require.NoError(t, err)
+	assert.Len(t, identidades, 1)
+}
+
+func TestLoginExternoExigeStateDoMesmoNavegador(t *testing.T) {
+	c := novoCenarioLoginExterno(t)
+
+	iniciado, err := c.servico.Iniciar("teste")
+	require.NoError(t, err)
+	input := c.autorizar(t, iniciado, "sub-1", "ana@example.com")
+
+	semCookie := input
+	semCookie.EstadoNavegador = ""
+	_, err = c.servico.Concluir("teste", semCookie, servico.InfoCliente{})
+	assert.ErrorIs(t, err, servico.ErrEstadoInvalido)
+
+	outroNavegador, err := c.servico.Iniciar("teste")
+	require.NoError(t, err)
+	input.EstadoNavegador = outroNavegador.Estado
+	_, err = c.servico.Concluir("teste", input, servico.InfoCliente{})
+	assert.ErrorIs(t, err, servico.ErrEstadoInvalido)
+}
+
+func TestLoginExternoNaoReutilizaState(t *testing.T) {
+	c := novoCenarioLoginExterno(t)
+
+	iniciado, err := c.servico.Iniciar("teste")
+	require.NoError(t, err)
+	_, err = c.servico.Concluir("teste", c.autorizar(t, iniciado, "sub-1", "ana@example.com"), servico.InfoCliente{})
+	require.NoError(t, err)
+
+	_, err = c.servico.Concluir("teste", c.autorizar(t, iniciado, "sub-1", "ana@example.com"), servico.InfoCliente{})
+	assert.ErrorIs(t, err, servico.ErrEstadoInvalido)
+}
+
+func TestLoginExternoRejeitaNonceDivergente(t *testing.T) {
+	c := novoCenarioLoginExterno(t)
+	c.provedor.Nonce = "nonce-de-outro-login"
+
+	iniciado, err := c.servico.Iniciar("teste")
+	require.NoError(t, err)
+	_, err = c.servico.Concluir("teste", c.autorizar(t, iniciado, "sub-1", "ana@example.com"), servico.InfoCliente{})
+	assert.ErrorIs(t, err, auth.ErrNonceInvalido)
+}
+
+func TestLoginExternoNaoEntraEmContaComEmailNaoVerificado(t *testing.T) {
+	c := novoCenarioLoginExterno(t)
+	existente := novoUsuario(t, c.db, "Ana", "ana@example.com")
+
+	iniciado, err := c.servico.Iniciar("teste")
+	require.NoError(t, err)
+	_, err = c.servico.Concluir("teste", c.autorizar(t, iniciado, "sub-1", "ana@example.com"), servico.InfoCliente{})
+	assert.ErrorIs(t, err, servico.ErrContaJaExiste)
+
+	identidades, err := c.servico.ListarIdentidades(existente.ID)
+	require.NoError(t, err)
+	assert.Empty(t, identidades)
+}
+
+func TestLoginExternoVinculaContaComEmailVerificado(t *testing.T) {
+	c := novoCenarioLoginExterno(t)
+	existente := novoUsuario(t, c.db, "Ana", "ana@example.com")
+	verificacao, caixa := novoServicoVerificacaoEmail(c.db)
+	require.NoError(t, verificacao.Solicitar(existente.ID))
+	require.NoError(t, verificacao.Confirmar(caixa.token(t)))
+
+	iniciado, err := c.servico.Iniciar("teste")
+	require.NoError(t, err)
+	resposta, err := c.servico.Concluir("teste", c.autorizar(t, iniciado, "sub-1", "ana@example.com"), servico.InfoCliente{})
+	require.NoError(t, err)
+	assert.Equal(t, existente.ID, c.usuarioDoToken(t, resposta))
+
+	identidades, err := c.servico.ListarIdentidades(existente.ID)
+	require.NoError(t, err)
+	require.Len(t, identidades, 1)
+	assert.Equal(t, "sub-1", identidades[0].Sujeito)
+}
+
+func TestVinculoPelaSessaoPermiteLoginPeloProvedor(t *testing.T) {
+	c := novoCenarioLoginExterno(t)
+	existente := novoUsuario(t, c.db, "Ana", "ana@example.com")
+
+	iniciado, err := c.servico.IniciarVinculo("teste", existente.ID)
+	require.NoError(t, err)
+	input := c.autorizar(t, iniciado, "sub-1", "ana@example.com")
+
+	// O state de um vínculo não serve como login.
+	_, err = c.servico.Concluir("teste", input, servico.InfoCliente{})
+	assert.ErrorIs(t, err, servico.ErrEstadoInvalido)
+
+	iniciado, err = c.servico.IniciarVinculo("teste", existente.ID)
+	require.NoError(t, err)
+	identidade, err := c.servico.ConcluirVinculo("teste", existente.ID, c.autorizar(t, iniciado, "sub-1", "ana@example.com"))
+	require.NoError(t, err)
+	assert.Equal(t, existente.ID, identidade.UsuarioID)
+
+	iniciado, err = c.servico.Iniciar("teste")
+	require.NoError(t, err)
+	resposta, err := c.servico.Concluir("teste", c.autorizar(t, iniciado, "sub-1", "ana@example.com"), servico.InfoCliente{})
+	require.NoError(t, err)
+	assert.Equal(t, existente.ID, c.usuarioDoToken(t, resposta))
+}
+
+func TestVinculoExigeAMesmaContaEIdentidadeLivre(t *testing.T) {
+	c := novoCenarioLoginExterno(t)
+	ana := novoUsuario(t, c.db, "Ana", "ana@example.com")
+	bia := novoUsuario(t, c.db, "Bia", "bia@example.com")
+
+	iniciado, err := c.servico.IniciarVinculo("teste", ana.ID)
+	require.NoError(t, err)
+	_, err = c.servico.ConcluirVinculo("teste", bia.ID, c.autorizar(t, iniciado, "sub-1", "ana@example.com"))
+	assert.ErrorIs(t, err, servico.ErrEstadoInvalido)
+
+	iniciado, err = c.servico.IniciarVinculo("teste", ana.ID)
+	require.NoError(t, err)
+	_, err = c.servico.ConcluirVinculo("teste", ana.ID, c.autorizar(t, iniciado, "sub-1", "ana@example.com"))
+	require.NoError(t, err)
+
+	iniciado, err = c.servico.IniciarVinculo("teste", bia.ID)
+	require.NoError(t, err)
+	_, err = c.servico.ConcluirVinculo("teste", bia.ID, c.autorizar(t, iniciado, "sub-1", "ana@example.com"))
+	assert.ErrorIs(t, err, servico.ErrIdentidadeEmUso)
+}
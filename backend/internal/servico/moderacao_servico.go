@@ -0,0 +1,432 @@
+package servico
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+)
+
+// Define os erros que o serviço de moderação pode retornar.
+var (
+	ErrTipoDenunciaInvalido    = errors.New("tipo de conteúdo inválido; use avaliacao, comentario ou perfil")
+	ErrConteudoNaoEncontrado   = errors.New("conteúdo não encontrado")
+	ErrDenunciaPropria         = errors.New("não é possível denunciar o próprio conteúdo")
+	ErrDenunciaDuplicada       = errors.New("você já denunciou este conteúdo e a denúncia ainda está em análise")
+	ErrSemDenunciasPendentes   = errors.New("não há denúncias pendentes para este conteúdo")
+	ErrConteudoSemAutor        = errors.New("o autor deste conteúdo excluiu a conta")
+	ErrCursorAuditoriaInvalido = errors.New("cursor do registro de moderação inválido")
+)
+
+const (
+	// DiasSuspensaoPadrao é a duração da suspensão quando o moderador não informa os dias.
+	DiasSuspensaoPadrao = 7
+
+	// LimitePadraoAuditoria e LimiteMaximoAuditoria definem o tamanho da página do registro de moderação.
+	LimitePadraoAuditoria = 50
+	LimiteMaximoAuditoria = 200
+)
+
+// DenunciaInput define o conteúdo denunciado e o motivo. Perfis são identificados pelo ID do dono.
+type DenunciaInput struct {
+	Tipo     string `json:"tipo" binding:"required,oneof=avaliacao comentario perfil"`
+	AlvoID   int64  `json:"alvoId" binding:"required,min=1"`
+	Motivo   string `json:"motivo" binding:"required,oneof=spam ofensivo assedio spoiler outro"`
+	Detalhes string `json:"detalhes" binding:"max=1000"`
+}
+
+// ResolverDenunciasInput define a ação do moderador sobre um conteúdo denunciado. Dias só vale
+// para a suspensão.
+type ResolverDenunciasInput struct {
+	Acao       string `json:"acao" binding:"required,oneof=descartar ocultar advertir suspender"`
+	Observacao string `json:"observacao" binding:"max=1000"`
+	Dias       int    `json:"dias" binding:"min=0,max=365"`
+}
+
+// GrupoDenuncias reúne, na fila da moderação, as denúncias pendentes de um mesmo conteúdo.
+type GrupoDenuncias struct {
+	Tipo             string             `json:"tipo"`
+	AlvoID           int64              `json:"alvoId"`
+	UsuarioAlvoID    *int64             `json:"usuarioAlvoId"` // Autor do conteúdo
+	Conteudo         string             `json:"conteudo"`
+	ConteudoRemovido bool               `json:"conteudoRemovido"` // O autor apagou o conteúdo depois das denúncias
+	Quantidade       int                `json:"quantidade"`
+	Motivos          []string           `json:"motivos"`
+	PrimeiraEm       time.Time          `json:"primeiraEm"`
+	UltimaEm         time.Time          `json:"ultimaEm"`
+	Denuncias        []dominio.Denuncia `json:"denuncias"`
+}
+
+// ModeracaoServico define as denúncias dos usuários e as ações da moderação sobre elas.
+type ModeracaoServico interface {
+	Denunciar(usuarioID int64, input DenunciaInput) (*dominio.Denuncia, error)
+	ListarMinhasDenuncias(usuarioID int64) ([]dominio.Denuncia, error)
+	ListarAdvertencias(usuarioID int64) ([]dominio.AcaoModeracao, error)
+	Fila() ([]GrupoDenuncias, error)
+	Resolver(moderadorID int64, tipo string, alvoID int64, input ResolverDenunciasInput) (*dominio.AcaoModeracao, error)
+	Auditoria(usuarioAlvoID int64, cursor string, limite int) ([]dominio.AcaoModeracao, string, error)
+}
+
+type moderacaoServicoImpl struct {
+	repo           repositorio.ModeracaoRepositorio
+	avaliacaoRepo  repositorio.AvaliacaoRepositorio
+	comentarioRepo repositorio.ComentarioRepositorio
+	perfilRepo     repositorio.PerfilRepositorio
+	usuarioRepo    repositorio.UsuarioRepositorio
+	notificacoes   NotificacaoServico
+}
+
+// NovoModeracaoServico cria o serviço de moderação.
+func NovoModeracaoServico(
+	repo repositorio.ModeracaoRepositorio,
+	avaliacaoRepo repositorio.AvaliacaoRepositorio,
+	comentarioRepo repositorio.ComentarioRepositorio,
+	perfilRepo repositorio.PerfilRepositorio,
+	usuarioRepo repositorio.UsuarioRepositorio,
+	notificacoes NotificacaoServico,
+) ModeracaoServico {
+	return &moderacaoServicoImpl{
+		repo:           repo,
+		avaliacaoRepo:  avaliacaoRepo,
+		comentarioRepo: comentarioRepo,
+		perfilRepo:     perfilRepo,
+		usuarioRepo:    usuarioRepo,
+		notificacoes:   notificacoes,
+	}
+}
+
+// alvoDenuncia é o conteúdo denunciado: o autor (0 se a conta foi excluída) e o texto exibido
+// para a moderação.
+type alvoDenuncia struct {
+	autorID  int64
+	conteudo string
+}
+
+// Denunciar registra a denúncia de um conteúdo de outra pessoa. Enquanto ela estiver pendente,
+// o mesmo usuário não pode denunciar o conteúdo de novo.
+func (s *moderacaoServicoImpl) Denunciar(usuarioID int64, input DenunciaInput) (*dominio.Denuncia, error) {
+	alvo, err := s.buscarAlvo(input.Tipo, input.AlvoID)
+	if err != nil {
+		return nil, err
+	}
+	if alvo.autorID == usuarioID {
+		return nil, ErrDenunciaPropria
+	}
+
+	denuncia := &dominio.Denuncia{
+		DenuncianteID: &usuarioID,
+		AlvoTipo:      input.Tipo,
+		AlvoID:        input.AlvoID,
+		Motivo:        input.Motivo,
+		Detalhes:      input.Detalhes,
+		Situacao:      dominio.SituacaoDenunciaPendente,
+		DataCriacao:   time.Now().UTC(),
+	}
+	criada, err := s.repo.CriarDenuncia(denuncia)
+	if err != nil {
+		return nil, err
+	}
+	if !criada {
+		return nil, ErrDenunciaDuplicada
+	}
+	return denuncia, nil
+}
+
+// ListarMinhasDenuncias retorna as denúncias do usuário com a situação e o resultado de cada uma.
+func (s *moderacaoServicoImpl) ListarMinhasDenuncias(usuarioID int64) ([]dominio.Denuncia, error) {
+	return s.repo.ListarDenunciasDoUsuario(usuarioID)
+}
+
+// ListarAdvertencias retorna as ações da moderação contra o conteúdo ou a conta do usuário, sem
+// identificar o moderador.
+func (s *moderacaoServicoImpl) ListarAdvertencias(usuarioID int64) ([]dominio.AcaoModeracao, error) {
+	acoes, err := s.repo.ListarAcoesContraUsuario(usuarioID)
+	for i := range acoes {
+		acoes[i].ModeradorID = nil
+	}
+	return acoes, err
+}
+
+// Fila agrupa as denúncias pendentes por conteúdo, com os mais denunciados primeiro e, entre
+// eles, os que esperam há mais tempo.
+func (s *moderacaoServicoImpl) Fila() ([]GrupoDenuncias, error) {
+	denuncias, err := s.repo.ListarDenunciasPendentes()
+	if err != nil {
+		return nil, err
+	}
+
+	var fila []GrupoDenuncias
+	for _, denuncia := range denuncias {
+		n := len(fila)
+		if n == 0 || fila[n-1].Tipo != denuncia.AlvoTipo || fila[n-1].AlvoID != denuncia.AlvoID {
+			fila = append(fila, GrupoDenuncias{
+				Tipo:       denuncia.AlvoTipo,
+				AlvoID:     denuncia.AlvoID,
+				Motivos:    make([]string, 0, 1),
+				PrimeiraEm: denuncia.DataCriacao,
+			})
+			n++
+		}
+		grupo := &fila[n-1]
+		grupo.Quantidade++
+		grupo.UltimaEm = denuncia.DataCriacao
+		grupo.Denuncias = append(grupo.Denuncias, denuncia)
+		if !contem(grupo.Motivos, denuncia.Motivo) {
+			grupo.Motivos = append(grupo.Motivos, denuncia.Motivo)
+		}
+	}
+
+	for i := range fila {
+		grupo := &fila[i]
+		alvo, err := s.buscarAlvo(grupo.Tipo, grupo.AlvoID)
+		switch {
+		case err == ErrConteudoNaoEncontrado:
+			grupo.ConteudoRemovido = true
+		case err != nil:
+			return nil, err
+		default:
+			grupo.Conteudo = alvo.conteudo
+			if alvo.autorID != 0 {
+				autorID := alvo.autorID
+				grupo.UsuarioAlvoID = &autorID
+			}
+		}
+	}
+
+	sort.SliceStable(fila, func(i, j int) bool {
+		if fila[i].Quantidade != fila[j].Quantidade {
+			return fila[i].Quantidade > fila[j].Quantidade
+		}
+		return fila[i].PrimeiraEm.Before(fila[j].PrimeiraEm)
+	})
+	return fila, nil
+}
+
+// Resolver aplica a ação do moderador ao conteúdo denunciado e encerra todas as denúncias
+// pendentes dele. Descartar não altera nada; ocultar remove a avaliação ou o comentário (a
+// avaliação continua no histórico) ou tira o perfil do ar; advertir só fica registrado para o
+// autor; suspender bloqueia o login do autor e encerra as sessões dele.
+func (s *moderacaoServicoImpl) Resolver(moderadorID int64, tipo string, alvoID int64, input ResolverDenunciasInput) (*dominio.AcaoModeracao, error) {
+	if tipo != dominio.AlvoDenunciaAvaliacao && tipo != dominio.AlvoDenunciaComentario && tipo != dominio.AlvoDenunciaPerfil {
+		return nil, ErrTipoDenunciaInvalido
+	}
+	// A contagem só antecipa o erro; quem garante que cada denúncia é resolvida uma única vez é
+	// o repositório, que as reivindica antes de aplicar a ação.
+	pendentes, err := s.repo.ContarDenunciasPendentes(tipo, alvoID)
+	if err != nil {
+		return nil, err
+	}
+	if pendentes == 0 {
+		return nil, ErrSemDenunciasPendentes
+	}
+
+	agora := time.Now().UTC()
+	acao := &dominio.AcaoModeracao{
+		ModeradorID: &moderadorID,
+		Acao:        input.Acao,
+		AlvoTipo:    tipo,
+		AlvoID:      alvoID,
+		Observacao:  input.Observacao,
+		DataCriacao: agora,
+	}
+
+	// Conteúdo já apagado pelo autor só pode ter as denúncias descartadas.
+	alvo, err := s.buscarAlvo(tipo, alvoID)
+	if err != nil && !(err == ErrConteudoNaoEncontrado && input.Acao == dominio.AcaoModeracaoDescartar) {
+		return nil, err
+	}
+	if alvo != nil {
+		acao.Conteudo = alvo.conteudo
+		if alvo.autorID != 0 {
+			autorID := alvo.autorID
+			acao.UsuarioAlvoID = &autorID
+		}
+	}
+
+	switch input.Acao {
+	case dominio.AcaoModeracaoAdvertir, dominio.AcaoModeracaoSuspender:
+		if acao.UsuarioAlvoID == nil {
+			return nil, ErrConteudoSemAutor
+		}
+		if input.Acao == dominio.AcaoModeracaoSuspender {
+			dias := input.Dias
+			if dias == 0 {
+				dias = DiasSuspensaoPadrao
+			}
+			ate := agora.AddDate(0, 0, dias)
+			acao.SuspensoAte = &ate
+		}
+	}
+
+	situacao := dominio.SituacaoDenunciaProcedente
+	if input.Acao == dominio.AcaoModeracaoDescartar {
+		situacao = dominio.SituacaoDenunciaDescartada
+	}
+	// O repositório reivindica as denúncias, aplica a ação e grava a auditoria numa única transação.
+	denunciantes, err := s.repo.Resolver(acao, situacao)
+	if err != nil {
+		if err == repositorio.ErrAlvoRemovido {
+			return nil, ErrConteudoNaoEncontrado
+		}
+		return nil, err
+	}
+	if acao.Denuncias == 0 {
+		// Outro moderador resolveu as mesmas denúncias ao mesmo tempo.
+		return nil, ErrSemDenunciasPendentes
+	}
+	s.notificarAutor(acao)
+	s.notificarDenunciantes(acao, denunciantes)
+	return acao, nil
+}
+
+// notificarAutor avisa o autor do conteúdo denunciado sobre a ação tomada. A notificação não
+// aponta para o conteúdo, que pode ter sido ocultado, e não revela quem o denunciou.
+func (s *moderacaoServicoImpl) notificarAutor(acao *dominio.AcaoModeracao) {
+	if acao.UsuarioAlvoID == nil || acao.Acao == dominio.AcaoModeracaoDescartar {
+		return
+	}
+	var texto string
+	switch acao.Acao {
+	case dominio.AcaoModeracaoOcultar:
+		texto = "Um conteúdo seu foi ocultado pela moderação."
+	case dominio.AcaoModeracaoAdvertir:
+		texto = "Você recebeu uma advertência da moderação."
+	case dominio.AcaoModeracaoSuspender:
+		texto = "Sua conta foi suspensa pela moderação até " + acao.SuspensoAte.Format("02/01/2006 15:04") + " (UTC)."
+	}
+	if acao.Observacao != "" {
+		texto += " " + acao.Observacao
+	}
+	s.notificacoes.Notificar(&dominio.Notificacao{
+		UsuarioID: *acao.UsuarioAlvoID,
+		Tipo:      dominio.NotificacaoModeracao,
+		Texto:     texto,
+	})
+}
+
+// notificarDenunciantes conta a cada denunciante o resultado da denúncia, inclusive quando ela é
+// descartada. O texto não identifica o moderador nem repete a observação, que é dirigida ao autor.
+func (s *moderacaoServicoImpl) notificarDenunciantes(acao *dominio.AcaoModeracao, denunciantes []int64) {
+	var conteudo string
+	switch acao.AlvoTipo {
+	case dominio.AlvoDenunciaAvaliacao:
+		conteudo = "uma avaliação"
+	case dominio.AlvoDenunciaComentario:
+		conteudo = "um comentário"
+	case dominio.AlvoDenunciaPerfil:
+		conteudo = "um perfil"
+	}
+	var resultado string
+	switch acao.Acao {
+	case dominio.AcaoModeracaoDescartar:
+		resultado = "foi analisada e descartada: o conteúdo não viola as regras da comunidade."
+	case dominio.AcaoModeracaoOcultar:
+		resultado = "foi aceita e o conteúdo foi ocultado."
+	case dominio.AcaoModeracaoAdvertir:
+		resultado = "foi aceita e o autor recebeu uma advertência."
+	case dominio.AcaoModeracaoSuspender:
+		resultado = "foi aceita e o autor foi suspenso."
+	}
+	for _, denuncianteID := range denunciantes {
+		s.notificacoes.Notificar(&dominio.Notificacao{
+			UsuarioID: denuncianteID,
+			Tipo:      dominio.NotificacaoModeracao,
+			Texto:     "Sua denúncia de " + conteudo + " " + resultado,
+		})
+	}
+}
+
+// Auditoria retorna uma página do registro de ações da moderação, das mais novas para as mais
+// antigas, e o cursor da página seguinte (vazio na última). Com usuarioAlvoID, só as ações
+// contra esse usuário.
+func (s *moderacaoServicoImpl) Auditoria(usuarioAlvoID int64, cursor string, limite int) ([]dominio.AcaoModeracao, string, error) {
+	if limite <= 0 {
+		limite = LimitePadraoAuditoria
+	} else if limite > LimiteMaximoAuditoria {
+		limite = LimiteMaximoAuditoria
+	}
+	var antesDeID int64
+	if cursor != "" {
+		id, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, "", ErrCursorAuditoriaInvalido
+		}
+		antesDeID = id
+	}
+
+	// Uma ação a mais indica se existe uma próxima página.
+	acoes, err := s.repo.ListarAcoes(usuarioAlvoID, antesDeID, limite+1)
+	if err != nil {
+		return nil, "", err
+	}
+	proximo := ""
+	if len(acoes) > limite {
+		acoes = acoes[:limite]
+		proximo = strconv.FormatInt(acoes[limite-1].ID, 10)
+	}
+	return acoes, proximo, nil
+}
+
+// buscarAlvo encontra o conteúdo denunciado. Avaliações pendentes de moderação e perfis já
+// ocultados contam como não encontrados.
+func (s *moderacaoServicoImpl) buscarAlvo(tipo string, alvoID int64) (*alvoDenuncia, error) {
+	switch tipo {
+	case dominio.AlvoDenunciaAvaliacao:
+		avaliacao, err := s.avaliacaoRepo.BuscarPorID(alvoID)
+		if err == sql.ErrNoRows || (err == nil && avaliacao.Situacao != dominio.SituacaoPublicada) {
+			return nil, ErrConteudoNaoEncontrado
+		}
+		if err != nil {
+			return nil, err
+		}
+		conteudo := fmt.Sprintf("Nota %g", avaliacao.Nota.Estrelas())
+		if avaliacao.Comentario != "" {
+			conteudo += ": " + avaliacao.Comentario
+		}
+		return &alvoDenuncia{autorID: avaliacao.UsuarioID, conteudo: conteudo}, nil
+
+	case dominio.AlvoDenunciaComentario:
+		comentario, err := s.comentarioRepo.BuscarPorID(alvoID)
+		if err == sql.ErrNoRows {
+			return nil, ErrConteudoNaoEncontrado
+		}
+		if err != nil {
+			return nil, err
+		}
+		alvo := &alvoDenuncia{conteudo: comentario.Texto}
+		if comentario.UsuarioID != nil {
+			alvo.autorID = *comentario.UsuarioID
+		}
+		return alvo, nil
+
+	case dominio.AlvoDenunciaPerfil:
+		perfil, err := s.perfilRepo.BuscarPorUsuarioID(alvoID)
+		if err == sql.ErrNoRows || (err == nil && perfil.Oculto) {
+			return nil, ErrConteudoNaoEncontrado
+		}
+		if err != nil {
+			return nil, err
+		}
+		usuario, err := s.usuarioRepo.BuscarPorID(alvoID)
+		if err != nil {
+			return nil, err
+		}
+		return &alvoDenuncia{autorID: alvoID, conteudo: fmt.Sprintf("%s (/%s)", usuario.Nome, perfil.Slug)}, nil
+	}
+	return nil, ErrTipoDenunciaInvalido
+}
+
+// contem informa se o valor está na lista.
+func contem(lista []string, valor string) bool {
+	for _, item := range lista {
+		if item == valor {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package servico_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cenarioModeracao struct {
+	db            *sqlx.DB
+	repo          repositorio.ModeracaoRepositorio
+	avaliacaoRepo repositorio.AvaliacaoRepositorio
+	usuarioRepo   repositorio.UsuarioRepositorio
+	servico       servico.ModeracaoServico
+	notificacoes  servico.NotificacaoServico
+	autora        *dominio.Usuario
+	moderadora    *dominio.Usuario
+	denunciante   *dominio.Usuario
+	avaliacao     *dominio.Avaliacao
+}
+
+// novoCenarioModeracao publica uma avaliação da autora e a deixa denunciada por outra pessoa.
+func novoCenarioModeracao(t *testing.T) *cenarioModeracao {
+	t.Helper()
+
+	db := novoBanco(t)
+	c := &cenarioModeracao{
+		db:            db,
+		repo:          repositorio.NovoModeracaoRepositorio(db),
+		avaliacaoRepo: repositorio.NovaAvaliacaoRepositorio(db),
+		usuarioRepo:   repositorio.NovoUsuarioRepositorio(db),
+		autora:        novoUsuario(t, db, "Ana", "ana@example.com"),
+		moderadora:    novoUsuario(t, db, "Mod", "mod@example.com"),
+	}
+	c.notificacoes = servico.NovaNotificacaoServico(repositorio.NovaNotificacaoRepositorio(db), servico.NovoDifusorLocal())
+	c.servico = servico.NovoModeracaoServico(c.repo, c.avaliacaoRepo, repositorio.NovoComentarioRepositorio(db),
+		repositorio.NovoPerfilRepositorio(db), c.usuarioRepo, c.notificacoes)
+
+	c.avaliacao = &dominio.Avaliacao{UsuarioID: c.autora.ID, FilmeID: 603, Nota: 1, Comentario: "spam spam"}
+	_, err := c.avaliacaoRepo.Salvar(c.avaliacao)
+	require.NoError(t, err)
+
+	c.denunciante = novoUsuario(t, db, "Bia", "bia@example.com")
+	_, err = c.servico.Denunciar(c.denunciante.ID, servico.DenunciaInput{
+		Tipo: dominio.AlvoDenunciaAvaliacao, AlvoID: c.avaliacao.ID, Motivo: "spam",
+	})
+	require.NoError(t, err)
+	return c
+}
+
+func (c *cenarioModeracao) resolver(acao string) (*dominio.AcaoModeracao, error) {
+	return c.servico.Resolver(c.moderadora.ID, dominio.AlvoDenunciaAvaliacao, c.avaliacao.ID, servico.ResolverDenunciasInput{Acao: acao})
+}
+
+func (c *cenarioModeracao) pendentes(t *testing.T) int {
+	t.Helper()
+
+	pendentes, err := c.repo.ContarDenunciasPendentes(dominio.AlvoDenunciaAvaliacao, c.avaliacao.ID)
+	require.NoError(t, err)
+	return pendentes
+}
+
+func TestResolverOcultaConteudoERegistraAuditoria(t *testing.T) {
+	c := novoCenarioModeracao(t)
+
+	acao, err := c.resolver(dominio.AcaoModeracaoOcultar)
+	require.NoError(t, err)
+	assert.Equal(t, 1, acao.Denuncias)
+	assert.Equal(t, c.autora.ID, *acao.UsuarioAlvoID)
+	assert.Zero(t, c.pendentes(t))
+
+	_, err = c.avaliacaoRepo.BuscarPorID(c.avaliacao.ID)
+	assert.Error(t, err, "a avaliação ocultada deve ter sido excluída")
+
+	acoes, _, err := c.servico.Auditoria(0, "", 0)
+	require.NoError(t, err)
+	require.Len(t, acoes, 1)
+	assert.Equal(t, acao.ID, acoes[0].ID)
+}
+
+// notificacoesDe retorna as notificações do usuário, das mais novas para as mais antigas.
+func (c *cenarioModeracao) notificacoesDe(t *testing.T, usuarioID int64) []dominio.Notificacao {
+	t.Helper()
+
+	notificacoes, _, _, err := c.notificacoes.Listar(usuarioID, servico.ConsultaNotificacoes{})
+	require.NoError(t, err)
+	return notificacoes
+}
+
+func TestResolverAvisaDenuncianteDoResultado(t *testing.T) {
+	casos := []struct {
+		acao      string
+		resultado string
+		autora    int
+	}{
+		{dominio.AcaoModeracaoDescartar, "descartada", 0},
+		{dominio.AcaoModeracaoOcultar, "ocultado", 1},
+		{dominio.AcaoModeracaoAdvertir, "advertência", 1},
+	}
+	for _, caso := range casos {
+		t.Run(caso.acao, func(t *testing.T) {
+			c := novoCenarioModeracao(t)
+
+			_, err := c.servico.Resolver(c.moderadora.ID, dominio.AlvoDenunciaAvaliacao, c.avaliacao.ID,
+				servico.ResolverDenunciasInput{Acao: caso.acao, Observacao: "decidido por Mod"})
+			require.NoError(t, err)
+
+			notificacoes := c.notificacoesDe(t, c.denunciante.ID)
+			require.Len(t, notificacoes, 1)
+			assert.Equal(t, dominio.NotificacaoModeracao, notificacoes[0].Tipo)
+			assert.Contains(t, notificacoes[0].Texto, "avaliação")
+			assert.Contains(t, notificacoes[0].Texto, caso.resultado)
+			// Nada identifica quem moderou.
+			assert.Nil(t, notificacoes[0].AtorID)
+			assert.NotContains(t, notificacoes[0].Texto, "Mod")
+
+			assert.Len(t, c.notificacoesDe(t, c.autora.ID), caso.autora)
+		})
+	}
+}
+
+func TestResolverSuspendeAutorEEncerraSessoes(t *testing.T) {
+	c := novoCenarioModeracao(t)
+	sessoes := repositorio.NovoSessaoRepositorio(c.db)
+	sessao, err := servico.NovoSessaoServico(sessoes).Criar(c.autora.ID, servico.InfoCliente{}, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	acao, err := c.resolver(dominio.AcaoModeracaoSuspender)
+	require.NoError(t, err)
+	require.NotNil(t, acao.SuspensoAte)
+
+	autora, err := c.usuarioRepo.BuscarPorID(c.autora.ID)
+	require.NoError(t, err)
+	require.NotNil(t, autora.SuspensoAte)
+	assert.WithinDuration(t, *acao.SuspensoAte, *autora.SuspensoAte, 0)
+
+	encerrada, err := sessoes.BuscarPorID(sessao.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, encerrada.RevogadaEm)
+}
+
+func TestResolverNaoRepeteAcaoJaResolvida(t *testing.T) {
+	c := novoCenarioModeracao(t)
+
+	_, err := c.resolver(dominio.AcaoModeracaoAdvertir)
+	require.NoError(t, err)
+	_, err = c.resolver(dominio.AcaoModeracaoSuspender)
+	assert.ErrorIs(t, err, servico.ErrSemDenunciasPendentes)
+
+	autora, err := c.usuarioRepo.BuscarPorID(c.autora.ID)
+	require.NoError(t, err)
+	assert.Nil(t, autora.SuspensoAte)
+	acoes, _, err := c.servico.Auditoria(0, "", 0)
+	require.NoError(t, err)
+	assert.Len(t, acoes, 1)
+}
+
+// Se o conteúdo some entre a leitura e a ação, nada é gravado e as denúncias continuam pendentes.
+func TestResolverDesfazTudoSeOConteudoSumiu(t *testing.T) {
+	c := novoCenarioModeracao(t)
+
+	acao := &dominio.AcaoModeracao{
+		ModeradorID: &c.moderadora.ID, Acao: dominio.AcaoModeracaoOcultar,
+		AlvoTipo: dominio.AlvoDenunciaAvaliacao, AlvoID: c.avaliacao.ID, DataCriacao: time.Now().UTC(),
+	}
+	_, err := c.avaliacaoRepo.DeletarPorID(c.avaliacao.ID)
+	require.NoError(t, err)
+
+	_, err = c.repo.Resolver(acao, dominio.SituacaoDenunciaProcedente)
+	assert.ErrorIs(t, err, repositorio.ErrAlvoRemovido)
+	assert.Equal(t, 1, c.pendentes(t))
+	acoes, _, err := c.servico.Auditoria(0, "", 0)
+	require.NoError(t, err)
+	assert.Empty(t, acoes)
+}
@@ -0,0 +1,213 @@
+package servico
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+)
+
+// Define os erros que o serviço de notificações pode retornar.
+var (
+	ErrNotificacaoNaoEncontrada   = errors.New("notificação não encontrada")
+	ErrTipoNotificacaoInvalido    = errors.New("tipo de notificação inválido; use seguidor, comentario, resposta ou moderacao")
+	ErrCursorNotificacoesInvalido = errors.New("cursor de notificações inválido")
+)
+
+const (
+	// LimitePadraoNotificacoes e LimiteMaximoNotificacoes definem o tamanho da página de notificações.
+	LimitePadraoNotificacoes = 20
+	LimiteMaximoNotificacoes = 100
+
+	// LimiteReenvioNotificacoes é quantas notificações perdidas o stream reenvia ao reconectar;
+	// as mais antigas ficam só na listagem.
+	LimiteReenvioNotificacoes = 100
+
+	// tamanhoTrechoNotificacao é quantos caracteres do comentário vão no texto da notificação.
+	tamanhoTrechoNotificacao = 140
+)
+
+// tiposNotificacao são os tipos que o usuário pode ligar e desligar, todos ativos por padrão.
+var tiposNotificacao = []string{
+	dominio.NotificacaoSeguidor,
+	dominio.NotificacaoComentario,
+	dominio.NotificacaoResposta,
+	dominio.NotificacaoModeracao,
+}
+
+// ConsultaNotificacoes descreve uma página de GET /notificacoes.
+type ConsultaNotificacoes struct {
+	ApenasNaoLidas bool
+	Cursor         string
+	Limite         int
+}
+
+// NotificacaoServico define a criação, a leitura e a entrega em tempo real das notificações.
+type NotificacaoServico interface {
+	Notificar(notificacao *dominio.Notificacao)
+	Listar(usuarioID int64, consulta ConsultaNotificacoes) ([]dominio.Notificacao, string, int, error)
+	MarcarLida(usuarioID, notificacaoID int64, lida bool) error
+	MarcarTodasLidas(usuarioID int64) (int64, error)
+	Preferencias(usuarioID int64) (map[string]bool, error)
+	AtualizarPreferencias(usuarioID int64, preferencias map[string]bool) (map[string]bool, error)
+	Assinar(usuarioID, ultimoID int64) ([]dominio.Notificacao, <-chan dominio.Notificacao, func(), error)
+}
+
+type notificacaoServicoImpl struct {
+	repo    repositorio.NotificacaoRepositorio
+	difusor DifusorNotificacoes
+}
+
+// NovaNotificacaoServico cria o serviço de notificações com o difusor que alcança as conexões abertas.
+func NovaNotificacaoServico(repo repositorio.NotificacaoRepositorio, difusor DifusorNotificacoes) NotificacaoServico {
+	return &notificacaoServicoImpl{repo: repo, difusor: difusor}
+}
+
+// Notificar grava a notificação, se o destinatário não desligou o tipo, e a entrega às conexões
+// abertas. Quem chama já concluiu a ação que gerou a notificação, então uma falha aqui só fica
+// no log. Ninguém é notificado das próprias ações.
+func (s *notificacaoServicoImpl) Notificar(n *dominio.Notificacao) {
+	if n.UsuarioID == 0 || (n.AtorID != nil && *n.AtorID == n.UsuarioID) {
+		return
+	}
+	preferencias, err := s.Preferencias(n.UsuarioID)
+	if err != nil {
+		log.Printf("Falha ao consultar as preferências de notificação do usuário %d: %v", n.UsuarioID, err)
+		return
+	}
+	if !preferencias[n.Tipo] {
+		return
+	}
+	if err := s.repo.Criar(n); err != nil {
+		log.Printf("Falha ao criar a notificação %s para o usuário %d: %v", n.Tipo, n.UsuarioID, err)
+		return
+	}
+
+	// Relida para levar o nome de quem a causou.
+	gravada, err := s.repo.BuscarPorID(n.ID)
+	if err != nil {
+		log.Printf("Falha ao buscar a notificação %d: %v", n.ID, err)
+		return
+	}
+	s.difusor.Publicar(*gravada)
+}
+
+// Listar retorna uma página das notificações do usuário, das mais novas para as mais antigas, o
+// cursor da próxima ("" na última) e o total de não lidas.
+func (s *notificacaoServicoImpl) Listar(usuarioID int64, consulta ConsultaNotificacoes) ([]dominio.Notificacao, string, int, error) {
+	limite := consulta.Limite
+	if limite <= 0 {
+		limite = LimitePadraoNotificacoes
+	} else if limite > LimiteMaximoNotificacoes {
+		limite = LimiteMaximoNotificacoes
+	}
+	antesDeID, err := decodificarCursorID(consulta.Cursor, ErrCursorNotificacoesInvalido)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	notificacoes, err := s.repo.Listar(usuarioID, consulta.ApenasNaoLidas, antesDeID, limite+1)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	proximo := ""
+	if len(notificacoes) > limite {
+		notificacoes = notificacoes[:limite]
+		proximo = strconv.FormatInt(notificacoes[limite-1].ID, 10)
+	}
+	naoLidas, err := s.repo.ContarNaoLidas(usuarioID)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return notificacoes, proximo, naoLidas, nil
+}
+
+// MarcarLida marca uma notificação do usuário como lida ou de novo como não lida.
+func (s *notificacaoServicoImpl) MarcarLida(usuarioID, notificacaoID int64, lida bool) error {
+	marcada, err := s.repo.MarcarLida(usuarioID, notificacaoID, lida)
+	if err != nil {
+		return err
+	}
+	if !marcada {
+		return ErrNotificacaoNaoEncontrada
+	}
+	return nil
+}
+
+// MarcarTodasLidas marca todas as notificações do usuário como lidas e retorna quantas mudaram.
+func (s *notificacaoServicoImpl) MarcarTodasLidas(usuarioID int64) (int64, error) {
+	return s.repo.MarcarTodasLidas(usuarioID)
+}
+
+// Preferencias retorna, para cada tipo de notificação, se ele está ativo.
+func (s *notificacaoServicoImpl) Preferencias(usuarioID int64) (map[string]bool, error) {
+	gravadas, err := s.repo.BuscarPreferencias(usuarioID)
+	if err != nil {
+		return nil, err
+	}
+	preferencias := make(map[string]bool, len(tiposNotificacao))
+	for _, tipo := range tiposNotificacao {
+		ativa, configurada := gravadas[tipo]
+		preferencias[tipo] = ativa || !configurada
+	}
+	return preferencias, nil
+}
+
+// AtualizarPreferencias liga ou desliga os tipos informados; os omitidos não mudam. Desligar um
+// tipo não apaga as notificações que já existem.
+func (s *notificacaoServicoImpl) AtualizarPreferencias(usuarioID int64, preferencias map[string]bool) (map[string]bool, error) {
+	for tipo := range preferencias {
+		if !tipoNotificacaoValido(tipo) {
+			return nil, ErrTipoNotificacaoInvalido
+		}
+	}
+	if err := s.repo.SalvarPreferencias(usuarioID, preferencias); err != nil {
+		return nil, err
+	}
+	return s.Preferencias(usuarioID)
+}
+
+// Assinar abre a entrega em tempo real das notificações do usuário. Com ultimoID, também
+// retorna, das mais antigas para as mais novas, as notificações criadas depois dele, para quem
+// reconecta. A assinatura é aberta antes da consulta, então uma notificação pode vir nos dois;
+// quem consome descarta os IDs repetidos. A função retornada encerra a assinatura.
+func (s *notificacaoServicoImpl) Assinar(usuarioID, ultimoID int64) ([]dominio.Notificacao, <-chan dominio.Notificacao, func(), error) {
+	canal, encerrar := s.difusor.Assinar(usuarioID)
+	if ultimoID <= 0 {
+		return nil, canal, encerrar, nil
+	}
+	recentes, err := s.repo.Listar(usuarioID, false, 0, LimiteReenvioNotificacoes)
+	if err != nil {
+		encerrar()
+		return nil, nil, nil, err
+	}
+	var perdidas []dominio.Notificacao
+	for i := len(recentes) - 1; i >= 0; i-- {
+		if recentes[i].ID > ultimoID {
+			perdidas = append(perdidas, recentes[i])
+		}
+	}
+	return perdidas, canal, encerrar, nil
+}
+
+// tipoNotificacaoValido indica se o tipo está entre os configuráveis.
+func tipoNotificacaoValido(tipo string) bool {
+	for _, valido := range tiposNotificacao {
+		if tipo == valido {
+			return true
+		}
+	}
+	return false
+}
+
+// trechoNotificacao resume o texto de um comentário para a notificação.
+func trechoNotificacao(texto string) string {
+	texto = strings.Join(strings.Fields(texto), " ")
+	if runas := []rune(texto); len(runas) > tamanhoTrechoNotificacao {
+		return string(runas[:tamanhoTrechoNotificacao-1]) + "…"
+	}
+	return texto
+}
@@ -0,0 +1,418 @@
+package servico
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+)
+
+// Define os erros que o serviço de perfis pode retornar.
+var (
+	ErrPerfilNaoEncontrado = errors.New("perfil não encontrado")
+	ErrSlugInvalido        = errors.New("o endereço do perfil deve ter de 3 a 30 caracteres entre letras minúsculas, números e hífens")
+	ErrSlugEmUso           = errors.New("este endereço de perfil já está em uso")
+)
+
+const (
+	// Quantos favoritos e avaliações recentes aparecem no perfil público.
+	quantidadeFavoritosPerfil  = 20
+	quantidadeAvaliacoesPerfil = 10
+
+	// tentativasSlugPerfil limita os sufixos numéricos testados ao gerar o slug a partir do nome.
+	tentativasSlugPerfil = 20
+)
+
+// padraoSlugPerfil aceita palavras de letras minúsculas e números separadas por um hífen.
+var padraoSlugPerfil = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// semAcentos troca as letras acentuadas mais comuns pela letra sem acento, para gerar o slug e
+// no filtro de conteúdo.
+var semAcentos = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// AtualizarPerfilInput traz o endereço e a privacidade das seções; os campos omitidos não mudam.
+type AtualizarPerfilInput struct {
+	Slug                *string `json:"slug"`
+	MostrarFavoritos    *bool   `json:"mostrarFavoritos"`
+	MostrarListas       *bool   `json:"mostrarListas"`
+	MostrarAvaliacoes   *bool   `json:"mostrarAvaliacoes"`
+	MostrarEstatisticas *bool   `json:"mostrarEstatisticas"`
+	MostrarDiario       *bool   `json:"mostrarDiario"`
+}
+
+// AvaliacaoPerfil é uma avaliação recente exibida no perfil público.
+type AvaliacaoPerfil struct {
+	FilmeID       int64        `json:"filmeId"`
+	Titulo        string       `json:"titulo"`
+	CaminhoPoster string       `json:"caminhoPoster"`
+	Nota          dominio.Nota `json:"nota"`
+	Comentario    string       `json:"comentario"` // Vazio quando é spoiler e o visitante não pediu para vê-lo
+	Spoiler       bool         `json:"spoiler"`
+	DataCriacao   time.Time    `json:"dataCriacao"`
+}
+
+// PerfilPublico é o que qualquer pessoa vê em /perfis/:slug. As seções que o dono
+// escondeu vêm como null.
+type PerfilPublico struct {
+	UsuarioID    int64                       `json:"usuarioId"` // Usado para denunciar o perfil
+	Nome         string                      `json:"nome"`
+	Slug         string                      `json:"slug"`
+	Favoritos    []dominio.ItemLista         `json:"favoritos"`
+	Listas       []dominio.Lista             `json:"listas"`
+	Avaliacoes   []AvaliacaoPerfil           `json:"avaliacoesRecentes"`
+	Estatisticas *dominio.EstatisticasPerfil `json:"estatisticas"`
+}
+
+// ConsultaAvaliacoesUsuario descreve uma página de GET /usuarios/:id/avaliacoes. Nota e ano
+// (de lançamento do filme) zerados não filtram.
+type ConsultaAvaliacoesUsuario struct {
+	Nota            float64
+	Ano             int
+	MostrarSpoilers bool
+	Cursor          string
+	Limite          int
+}
+
+// PerfilServico define a configuração e a consulta dos perfis públicos.
+type PerfilServico interface {
+	Buscar(usuarioID int64) (*dominio.Perfil, error)
+	Atualizar(usuarioID int64, input AtualizarPerfilInput) (*dominio.Perfil, error)
+	BuscarPublico(slug string, mostrarSpoilers bool) (*PerfilPublico, error)
+	ListarAvaliacoes(usuarioID int64, consulta ConsultaAvaliacoesUsuario, leitorID int64) ([]dominio.AvaliacaoComFilme, string, error)
+}
+
+type perfilServicoImpl struct {
+	repo          repositorio.PerfilRepositorio
+	usuarioRepo   repositorio.UsuarioRepositorio
+	listaRepo     repositorio.ListaRepositorio
+	avaliacaoRepo repositorio.AvaliacaoRepositorio
+	catalogo      CatalogoServico
+}
+
+// NovoPerfilServico cria o serviço de perfis.
+func NovoPerfilServico(
+	repo repositorio.PerfilRepositorio,
+	usuarioRepo repositorio.UsuarioRepositorio,
+	listaRepo repositorio.ListaRepositorio,
+	avaliacaoRepo repositorio.AvaliacaoRepositorio,
+	catalogo CatalogoServico,
+) PerfilServico {
+	return &perfilServicoImpl{
+		repo:          repo,
+		usuarioRepo:   usuarioRepo,
+		listaRepo:     listaRepo,
+		avaliacaoRepo: avaliacaoRepo,
+		catalogo:      catalogo,
+	}
+}
+
+// Buscar retorna as configurações do perfil do usuário, criando-o na primeira consulta.
+func (s *perfilServicoImpl) Buscar(usuarioID int64) (*dominio.Perfil, error) {
+	return s.garantir(usuarioID)
+}
+
+// Atualizar altera o endereço e a privacidade das seções do perfil.
+func (s *perfilServicoImpl) Atualizar(usuarioID int64, input AtualizarPerfilInput) (*dominio.Perfil, error) {
+	perfil, err := s.garantir(usuarioID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Slug != nil {
+		slug := strings.ToLower(strings.TrimSpace(*input.Slug))
+		if len(slug) < 3 || len(slug) > 30 || !padraoSlugPerfil.MatchString(slug) {
+			return nil, ErrSlugInvalido
+		}
+		perfil.Slug = slug
+	}
+	if input.MostrarFavoritos != nil {
+		perfil.MostrarFavoritos = *input.MostrarFavoritos
+	}
+	if input.MostrarListas != nil {
+		perfil.MostrarListas = *input.MostrarListas
+	}
+	if input.MostrarAvaliacoes != nil {
+		perfil.MostrarAvaliacoes = *input.MostrarAvaliacoes
+	}
+	if input.MostrarEstatisticas != nil {
+		perfil.MostrarEstatisticas = *input.MostrarEstatisticas
+	}
+	if input.MostrarDiario != nil {
+		perfil.MostrarDiario = *input.MostrarDiario
+	}
+
+	atualizado, err := s.repo.Atualizar(perfil)
+	if err != nil {
+		return nil, err
+	}
+	if !atualizado {
+		return nil, ErrSlugEmUso
+	}
+	return perfil, nil
+}
+
+// BuscarPublico monta o perfil público com as seções que o dono escolheu mostrar.
+// Nunca inclui o email nem itens privados.
+func (s *perfilServicoImpl) BuscarPublico(slug string, mostrarSpoilers bool) (*PerfilPublico, error) {
+	perfil, err := s.repo.BuscarPorSlug(slug)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPerfilNaoEncontrado
+		}
+		return nil, err
+	}
+	usuario, err := s.usuarioRepo.BuscarPorID(perfil.UsuarioID)
+	if err != nil {
+		return nil, err
+	}
+
+	publico := &PerfilPublico{UsuarioID: usuario.ID, Nome: usuario.Nome, Slug: perfil.Slug}
+
+	if perfil.MostrarFavoritos {
+		listaID, err := s.listaRepo.BuscarIDEmbutida(perfil.UsuarioID, dominio.TipoListaFavoritos)
+		if err != nil {
+			return nil, err
+		}
+		if publico.Favoritos, err = s.listaRepo.ListarItensApos(listaID, 0, quantidadeFavoritosPerfil); err != nil {
+			return nil, err
+		}
+		if publico.Favoritos == nil {
+			publico.Favoritos = make([]dominio.ItemLista, 0)
+		}
+	}
+
+	if perfil.MostrarListas {
+		listas, err := s.listaRepo.ListarPorUsuarioID(perfil.UsuarioID)
+		if err != nil {
+			return nil, err
+		}
+		publico.Listas = make([]dominio.Lista, 0)
+		for _, lista := range listas {
+			if lista.Visibilidade == dominio.VisibilidadePublica {
+				publico.Listas = append(publico.Listas, lista)
+			}
+		}
+	}
+
+	if perfil.MostrarAvaliacoes {
+		if publico.Avaliacoes, err = s.avaliacoesRecentes(perfil.UsuarioID, mostrarSpoilers); err != nil {
+			return nil, err
+		}
+	}
+
+	if perfil.MostrarEstatisticas {
+		if publico.Estatisticas, err = s.repo.Estatisticas(perfil.UsuarioID); err != nil {
+			return nil, err
+		}
+		if !perfil.MostrarDiario {
+			publico.Estatisticas.FilmesAssistidos = nil
+		}
+	}
+
+	return publico, nil
+}
+
+// ListarAvaliacoes retorna uma página do histórico de avaliações publicadas do usuário e o
+// cursor opaco da próxima ("" na última). Segue a privacidade do perfil: se o dono escondeu as
+// avaliações, o perfil foi ocultado ou a conta vai ser excluída, só o próprio dono (leitorID)
+// consegue ver.
+func (s *perfilServicoImpl) ListarAvaliacoes(usuarioID int64, consulta ConsultaAvaliacoesUsuario, leitorID int64) ([]dominio.AvaliacaoComFilme, string, error) {
+	var nota dominio.Nota
+	if consulta.Nota != 0 {
+		var ok bool
+		if nota, ok = dominio.NotaDeEstrelas(consulta.Nota); !ok {
+			return nil, "", ErrFiltroNotaInvalido
+		}
+	}
+	if usuarioID != leitorID {
+		visivel, err := s.avaliacoesVisiveis(usuarioID)
+		if err != nil {
+			return nil, "", err
+		}
+		if !visivel {
+			return nil, "", ErrPerfilNaoEncontrado
+		}
+	}
+
+	filtro := repositorio.FiltroAvaliacoesUsuario{
+		Nota:   nota,
+		Ano:    consulta.Ano,
+		Limite: limiteAvaliacoes(consulta.Limite),
+	}
+	if consulta.Cursor != "" {
+		apos, err := decodificarCursorAvaliacoes(consulta.Cursor, repositorio.OrdenarAvaliacoesRecentes)
+		if err != nil {
+			return nil, "", err
+		}
+		filtro.Apos = apos
+	}
+
+	avaliacoes, proximo, err := s.avaliacaoRepo.ListarComFilmesDoUsuario(usuarioID, filtro)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var semTitulo []int64
+	for _, avaliacao := range avaliacoes {
+		if avaliacao.Titulo == "" {
+			semTitulo = append(semTitulo, avaliacao.FilmeID)
+		}
+	}
+	metadados, err := s.catalogo.Metadados(semTitulo)
+	if err != nil {
+		return nil, "", err
+	}
+	for i := range avaliacoes {
+		avaliacao := &avaliacoes[i]
+		if m, ok := metadados[avaliacao.FilmeID]; ok {
+			avaliacao.Titulo, avaliacao.CaminhoPoster, avaliacao.Ano = m.Titulo, m.CaminhoPoster, m.Ano
+		}
+		if avaliacao.Spoiler && !consulta.MostrarSpoilers {
+			avaliacao.Comentario = ""
+		}
+	}
+
+	if proximo == nil {
+		return avaliacoes, "", nil
+	}
+	return avaliacoes, codificarCursorAvaliacoes(*proximo, repositorio.OrdenarAvaliacoesRecentes), nil
+}
+
+// avaliacoesVisiveis indica se as avaliações do usuário aparecem para outras pessoas. Quem ainda
+// não configurou o perfil usa o padrão, que as mostra.
+func (s *perfilServicoImpl) avaliacoesVisiveis(usuarioID int64) (bool, error) {
+	usuario, err := s.usuarioRepo.BuscarPorID(usuarioID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if usuario.ExclusaoAgendadaEm != nil {
+		return false, nil
+	}
+
+	perfil, err := s.repo.BuscarPorUsuarioID(usuarioID)
+	if err == sql.ErrNoRows {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return perfil.MostrarAvaliacoes && !perfil.Oculto, nil
+}
+
+// avaliacoesRecentes retorna as últimas avaliações com título e pôster do catálogo. Sem
+// mostrarSpoilers, os comentários marcados como spoiler vêm vazios.
+func (s *perfilServicoImpl) avaliacoesRecentes(usuarioID int64, mostrarSpoilers bool) ([]AvaliacaoPerfil, error) {
+	avaliacoes, err := s.avaliacaoRepo.ListarRecentesDoUsuario(usuarioID, quantidadeAvaliacoesPerfil)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(avaliacoes))
+	for _, avaliacao := range avaliacoes {
+		ids = append(ids, avaliacao.FilmeID)
+	}
+	metadados, err := s.catalogo.Metadados(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	recentes := make([]AvaliacaoPerfil, 0, len(avaliacoes))
+	for _, avaliacao := range avaliacoes {
+		comentario := avaliacao.Comentario
+		if avaliacao.Spoiler && !mostrarSpoilers {
+			comentario = ""
+		}
+		recentes = append(recentes, AvaliacaoPerfil{
+			FilmeID:       avaliacao.FilmeID,
+			Titulo:        metadados[avaliacao.FilmeID].Titulo,
+			CaminhoPoster: metadados[avaliacao.FilmeID].CaminhoPoster,
+			Nota:          avaliacao.Nota,
+			Comentario:    comentario,
+			Spoiler:       avaliacao.Spoiler,
+			DataCriacao:   avaliacao.DataCriacao,
+		})
+	}
+	return recentes, nil
+}
+
+// garantir retorna o perfil do usuário, criando-o com um slug derivado do nome se ainda não existir.
+// Os favoritos começam ocultos, porque antes dos perfis eles eram sempre privados.
+func (s *perfilServicoImpl) garantir(usuarioID int64) (*dominio.Perfil, error) {
+	perfil, err := s.repo.BuscarPorUsuarioID(usuarioID)
+	if err != sql.ErrNoRows {
+		return perfil, err
+	}
+
+	usuario, err := s.usuarioRepo.BuscarPorID(usuarioID)
+	if err != nil {
+		return nil, err
+	}
+
+	base := slugDoNome(usuario.Nome)
+	for tentativa := 1; tentativa <= tentativasSlugPerfil+1; tentativa++ {
+		perfil = &dominio.Perfil{
+			UsuarioID:           usuarioID,
+			Slug:                base,
+			MostrarListas:       true,
+			MostrarAvaliacoes:   true,
+			MostrarEstatisticas: true,
+		}
+		switch {
+		case tentativa > tentativasSlugPerfil:
+			// O ID é único entre os usuários; só colide se alguém já escolheu exatamente esse slug.
+			perfil.Slug = fmt.Sprintf("%s-%d", base, usuarioID)
+		case tentativa > 1:
+			perfil.Slug = fmt.Sprintf("%s-%d", base, tentativa)
+		}
+
+		criado, err := s.repo.Criar(perfil)
+		if err != nil {
+			return nil, err
+		}
+		if criado {
+			return perfil, nil
+		}
+
+		// Uma requisição simultânea pode ter criado o perfil do mesmo usuário.
+		existente, err := s.repo.BuscarPorUsuarioID(usuarioID)
+		if err != sql.ErrNoRows {
+			return existente, err
+		}
+	}
+	return nil, ErrSlugEmUso
+}
+
+// slugDoNome converte o nome em um slug válido, como "joao-da-silva" para "João da Silva".
+func slugDoNome(nome string) string {
+	palavras := strings.FieldsFunc(semAcentos.Replace(strings.ToLower(nome)), func(r rune) bool {
+		return (r < 'a' || r > 'z') && (r < '0' || r > '9')
+	})
+
+	// Deixa espaço para o sufixo numérico dentro do limite de 30 caracteres.
+	slug := strings.Join(palavras, "-")
+	if len(slug) > 20 {
+		slug = slug[:20]
+		if i := strings.LastIndex(slug, "-"); i >= 3 {
+			slug = slug[:i]
+		}
+	}
+	if len(slug) < 3 {
+		return "usuario"
+	}
+	return slug
+}
@@ -0,0 +1,77 @@
+package servico_test
+
+import (
+	"testing"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMostrarDiarioControlaPerfilEFeed(t *testing.T) {
+	db := novoBanco(t)
+	ana := novoUsuario(t, db, "Ana", "ana@example.com")
+	bia := novoUsuario(t, db, "Bia", "bia@example.com")
+	perfilRepo := repositorio.NovoPerfilRepositorio(db)
+	usuarioRepo := repositorio.NovoUsuarioRepositorio(db)
+	atividadeRepo := repositorio.NovaAtividadeRepositorio(db)
+	perfis := servico.NovoPerfilServico(perfilRepo, usuarioRepo, repositorio.NovoListaRepositorio(db),
+		repositorio.NovaAvaliacaoRepositorio(db), catalogoTeste)
+	notificacoes := servico.NovaNotificacaoServico(repositorio.NovaNotificacaoRepositorio(db), servico.NovoDifusorLocal())
+	seguidores := servico.NovoSeguidorServico(repositorio.NovoSeguidorRepositorio(db), atividadeRepo, usuarioRepo,
+		perfilRepo, catalogoTeste, notificacoes)
+	diario := servico.NovoDiarioServico(repositorio.NovoDiarioRepositorio(db), repositorio.NovaAvaliacaoRepositorio(db),
+		atividadeRepo, catalogoTeste)
+
+	perfil, err := perfis.Buscar(ana.ID)
+	require.NoError(t, err)
+	require.NoError(t, seguidores.Seguir(bia.ID, ana.ID))
+	_, err = diario.Registrar(ana.ID, servico.RegistrarDiarioInput{FilmeID: 603, DataAssistido: "2024-01-02"})
+	require.NoError(t, err)
+
+	visivel := func() (*int, []dominio.ItemFeed) {
+		publico, err := perfis.BuscarPublico(perfil.Slug, false)
+		require.NoError(t, err)
+		require.NotNil(t, publico.Estatisticas)
+		feed, _, err := seguidores.Feed(bia.ID, servico.ConsultaFeed{})
+		require.NoError(t, err)
+		return publico.Estatisticas.FilmesAssistidos, feed
+	}
+
+	assistidos, feed := visivel()
+	assert.Nil(t, assistidos)
+	assert.Empty(t, feed)
+
+	mostrar := true
+	_, err = perfis.Atualizar(ana.ID, servico.AtualizarPerfilInput{MostrarDiario: &mostrar})
+	require.NoError(t, err)
+	assistidos, feed = visivel()
+	require.NotNil(t, assistidos)
+	assert.Equal(t, 1, *assistidos)
+	require.Len(t, feed, 1)
+	assert.Equal(t, dominio.AtividadeDiario, feed[0].Tipo)
+}
+
+func TestEstatisticasDoPerfilIgnoramAvaliacoesRetidas(t *testing.T) {
+	db := novoBanco(t)
+	ana := novoUsuario(t, db, "Ana", "ana@example.com")
+	perfis := servico.NovoPerfilServico(repositorio.NovoPerfilRepositorio(db), repositorio.NovoUsuarioRepositorio(db),
+		repositorio.NovoListaRepositorio(db), repositorio.NovaAvaliacaoRepositorio(db), catalogoTeste)
+	avaliacoes, _ := novoServicoAvaliacoes(db)
+
+	_, _, err := avaliacoes.Salvar(ana.ID, 603, servico.AvaliacaoInput{Nota: 4, Comentario: "Ótimo filme"})
+	require.NoError(t, err)
+	retida, _, err := avaliacoes.Salvar(ana.ID, 604, servico.AvaliacaoInput{Nota: 1, Comentario: "veja em exemplo.com"})
+	require.NoError(t, err)
+	require.Equal(t, dominio.SituacaoPendente, retida.Situacao)
+
+	perfil, err := perfis.Buscar(ana.ID)
+	require.NoError(t, err)
+	publico, err := perfis.BuscarPublico(perfil.Slug, false)
+	require.NoError(t, err)
+	require.NotNil(t, publico.Estatisticas)
+	assert.Equal(t, 1, publico.Estatisticas.Avaliacoes)
+	assert.Equal(t, 4.0, publico.Estatisticas.MediaNotas)
+}
@@ -45,6 +45,7 @@ type QuizServico interface {
 type quizServicoImpl struct {
 	apiKey       string                         // Chave da API TMDB
 	favoritoRepo repositorio.FavoritoRepositorio // Repositório de filmes favoritos
+	quizRepo     repositorio.QuizRepositorio     // Repositório do histórico de perguntas
 	filmeServico FilmeServico                   // Serviço para buscar informações de filmes
 	historicoQuiz map[int64][]int64             // Cache para evitar repetição de perguntas (usuarioID -> filmeIDs já usados)
 }
@@ -55,14 +56,16 @@ type quizServicoImpl struct {
 // Parâmetros:
 //   - apiKey: Chave de API para o serviço TMDB
 //   - favoritoRepo: Repositório para acessar os filmes favoritos dos usuários
+//   - quizRepo: Repositório onde as perguntas apresentadas são registradas
 //   - filmeServico: Serviço para buscar detalhes de filmes
 //
 // Retorno:
 //   - Uma implementação da interface QuizServico
-func NovoQuizServico(apiKey string, favoritoRepo repositorio.FavoritoRepositorio, filmeServico FilmeServico) QuizServico {
+func NovoQuizServico(apiKey string, favoritoRepo repositorio.FavoritoRepositorio, quizRepo repositorio.QuizRepositorio, filmeServico FilmeServico) QuizServico {
 	return &quizServicoImpl{
 		apiKey:       apiKey,
 		favoritoRepo: favoritoRepo,
+		quizRepo:     quizRepo,
 		filmeServico: filmeServico,
 		historicoQuiz: make(map[int64][]int64), // Inicializa o mapa de histórico vazio
 	}
@@ -125,19 +128,45 @@ func (s *quizServicoImpl) GerarPergunta(usuarioID int64) (*dominio.PerguntaQuiz,
 	tipoPergunta := rand.Intn(4) + 1
 
 	// Gera a pergunta de acordo com o tipo escolhido
+	var pergunta *dominio.PerguntaQuiz
 	switch tipoPergunta {
 	case 1:
-		return s.gerarPerguntaAno(detalhesFilmeCorreto)
+		pergunta, err = s.gerarPerguntaAno(detalhesFilmeCorreto)
 	case 2:
-		return s.gerarPerguntaDiretor(detalhesFilmeCorreto)
+		pergunta, err = s.gerarPerguntaDiretor(detalhesFilmeCorreto)
 	case 3:
-		return s.gerarPerguntaAtor(detalhesFilmeCorreto)
+		pergunta, err = s.gerarPerguntaAtor(detalhesFilmeCorreto)
 	case 4:
-		return s.gerarPerguntaGenero(detalhesFilmeCorreto)
+		pergunta, err = s.gerarPerguntaGenero(detalhesFilmeCorreto)
 	default:
 		// Fallback para pergunta de ano (mais simples e sempre disponível)
-		return s.gerarPerguntaAno(detalhesFilmeCorreto)
+		pergunta, err = s.gerarPerguntaAno(detalhesFilmeCorreto)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Registra a pergunta no histórico persistente do usuário (incluído na exportação de dados)
+	if err := s.registrarHistorico(usuarioID, filmeCorretoFavorito.FilmeID, pergunta); err != nil {
+		return nil, err
+	}
+	return pergunta, nil
+}
+
+// registrarHistorico grava a pergunta apresentada junto com o texto da resposta correta.
+func (s *quizServicoImpl) registrarHistorico(usuarioID, filmeID int64, pergunta *dominio.PerguntaQuiz) error {
+	registro := &dominio.RegistroQuiz{
+		UsuarioID: usuarioID,
+		FilmeID:   filmeID,
+		Pergunta:  pergunta.Pergunta,
+	}
+	for _, opcao := range pergunta.Opcoes {
+		if opcao.ID == pergunta.RespostaCorretaID {
+			registro.RespostaCorreta = opcao.Texto
+			break
+		}
+	}
+	return s.quizRepo.Registrar(registro)
 }
 
 // gerarPerguntaAno gera uma pergunta sobre o ano de lançamento do filme.
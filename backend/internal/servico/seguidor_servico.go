@@ -0,0 +1,250 @@
+package servico
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+)
+
+// Define os erros que o serviço de seguidores pode retornar.
+var (
+	ErrSeguirASiMesmo           = errors.New("você não pode seguir a si mesmo")
+	ErrJaSegue                  = errors.New("você já segue este usuário")
+	ErrNaoSegue                 = errors.New("você não segue este usuário")
+	ErrCursorSeguidoresInvalido = errors.New("cursor de seguidores inválido")
+	ErrCursorFeedInvalido       = errors.New("cursor do feed inválido")
+)
+
+const (
+	// LimitePadraoSeguidores e LimiteMaximoSeguidores definem o tamanho da página das listas de
+	// seguidores e de seguidos.
+	LimitePadraoSeguidores = 50
+	LimiteMaximoSeguidores = 200
+
+	// LimitePadraoFeed e LimiteMaximoFeed definem o tamanho da página do feed.
+	LimitePadraoFeed = 20
+	LimiteMaximoFeed = 100
+)
+
+// ConsultaFeed descreve uma página de GET /feed.
+type ConsultaFeed struct {
+	MostrarSpoilers bool
+	Cursor          string
+	Limite          int
+}
+
+// SeguidorServico define quem segue quem e o feed com a atividade dos usuários seguidos.
+type SeguidorServico interface {
+	Seguir(seguidorID, seguidoID int64) error
+	DeixarDeSeguir(seguidorID, seguidoID int64) error
+	ListarSeguidores(usuarioID int64, cursor string, limite int, leitorID int64) ([]dominio.UsuarioConexao, string, error)
+	ListarSeguindo(usuarioID int64, cursor string, limite int, leitorID int64) ([]dominio.UsuarioConexao, string, error)
+	Feed(usuarioID int64, consulta ConsultaFeed) ([]dominio.ItemFeed, string, error)
+}
+
+type seguidorServicoImpl struct {
+	repo          repositorio.SeguidorRepositorio
+	atividadeRepo repositorio.AtividadeRepositorio
+	usuarioRepo   repositorio.UsuarioRepositorio
+	perfilRepo    repositorio.PerfilRepositorio
+	catalogo      CatalogoServico
+	notificacoes  NotificacaoServico
+}
+
+// NovoSeguidorServico cria o serviço de seguidores e do feed.
+func NovoSeguidorServico(
+	repo repositorio.SeguidorRepositorio,
+	atividadeRepo repositorio.AtividadeRepositorio,
+	usuarioRepo repositorio.UsuarioRepositorio,
+	perfilRepo repositorio.PerfilRepositorio,
+	catalogo CatalogoServico,
+	notificacoes NotificacaoServico,
+) SeguidorServico {
+	return &seguidorServicoImpl{
+		repo:          repo,
+		atividadeRepo: atividadeRepo,
+		usuarioRepo:   usuarioRepo,
+		perfilRepo:    perfilRepo,
+		catalogo:      catalogo,
+		notificacoes:  notificacoes,
+	}
+}
+
+// Seguir passa a mostrar a atividade de seguidoID no feed de seguidorID.
+func (s *seguidorServicoImpl) Seguir(seguidorID, seguidoID int64) error {
+	if seguidorID == seguidoID {
+		return ErrSeguirASiMesmo
+	}
+	if err := s.garantirVisivel(seguidoID); err != nil {
+		return err
+	}
+	seguiu, err := s.repo.Seguir(seguidorID, seguidoID)
+	if err != nil {
+		return err
+	}
+	if !seguiu {
+		return ErrJaSegue
+	}
+	s.notificacoes.Notificar(&dominio.Notificacao{
+		UsuarioID: seguidoID,
+		Tipo:      dominio.NotificacaoSeguidor,
+		AtorID:    &seguidorID,
+	})
+	return nil
+}
+
+// DeixarDeSeguir desfaz o vínculo. Funciona mesmo que o seguido tenha sido ocultado depois.
+func (s *seguidorServicoImpl) DeixarDeSeguir(seguidorID, seguidoID int64) error {
+	removido, err := s.repo.DeixarDeSeguir(seguidorID, seguidoID)
+	if err != nil {
+		return err
+	}
+	if !removido {
+		return ErrNaoSegue
+	}
+	return nil
+}
+
+// ListarSeguidores retorna uma página de quem segue o usuário e o cursor da próxima ("" na
+// última). O perfil precisa estar visível, exceto para o próprio dono (leitorID).
+func (s *seguidorServicoImpl) ListarSeguidores(usuarioID int64, cursor string, limite int, leitorID int64) ([]dominio.UsuarioConexao, string, error) {
+	return s.listar(s.repo.ListarSeguidores, usuarioID, cursor, limite, leitorID)
+}
+
+// ListarSeguindo retorna uma página de quem o usuário segue, nas mesmas condições de ListarSeguidores.
+func (s *seguidorServicoImpl) ListarSeguindo(usuarioID int64, cursor string, limite int, leitorID int64) ([]dominio.UsuarioConexao, string, error) {
+	return s.listar(s.repo.ListarSeguindo, usuarioID, cursor, limite, leitorID)
+}
+
+func (s *seguidorServicoImpl) listar(
+	buscar func(usuarioID, antesDeID int64, limite int) ([]dominio.UsuarioConexao, error),
+	usuarioID int64, cursor string, limite int, leitorID int64,
+) ([]dominio.UsuarioConexao, string, error) {
+	if usuarioID != leitorID {
+		if err := s.garantirVisivel(usuarioID); err != nil {
+			return nil, "", err
+		}
+	}
+	if limite <= 0 {
+		limite = LimitePadraoSeguidores
+	} else if limite > LimiteMaximoSeguidores {
+		limite = LimiteMaximoSeguidores
+	}
+	antesDeID, err := decodificarCursorID(cursor, ErrCursorSeguidoresInvalido)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Um vínculo a mais indica se existe uma próxima página.
+	conexoes, err := buscar(usuarioID, antesDeID, limite+1)
+	if err != nil {
+		return nil, "", err
+	}
+	proximo := ""
+	if len(conexoes) > limite {
+		conexoes = conexoes[:limite]
+		proximo = strconv.FormatInt(conexoes[limite-1].ID, 10)
+	}
+	return conexoes, proximo, nil
+}
+
+// Feed retorna uma página da atividade dos usuários seguidos, das mais novas para as mais
+// antigas, e o cursor da próxima ("" na última). Sem MostrarSpoilers, os comentários marcados
+// como spoiler vêm vazios.
+func (s *seguidorServicoImpl) Feed(usuarioID int64, consulta ConsultaFeed) ([]dominio.ItemFeed, string, error) {
+	limite := consulta.Limite
+	if limite <= 0 {
+		limite = LimitePadraoFeed
+	} else if limite > LimiteMaximoFeed {
+		limite = LimiteMaximoFeed
+	}
+	antesDeID, err := decodificarCursorID(consulta.Cursor, ErrCursorFeedInvalido)
+	if err != nil {
+		return nil, "", err
+	}
+
+	itens, err := s.atividadeRepo.ListarFeed(usuarioID, antesDeID, limite+1)
+	if err != nil {
+		return nil, "", err
+	}
+	proximo := ""
+	if len(itens) > limite {
+		itens = itens[:limite]
+		proximo = strconv.FormatInt(itens[limite-1].ID, 10)
+	}
+
+	// As avaliações não guardam o título do filme, que vem do catálogo.
+	var semTitulo []int64
+	for _, item := range itens {
+		if item.Titulo == "" && item.FilmeID != nil {
+			semTitulo = append(semTitulo, *item.FilmeID)
+		}
+	}
+	metadados, err := s.catalogo.Metadados(semTitulo)
+	if err != nil {
+		return nil, "", err
+	}
+	for i := range itens {
+		item := &itens[i]
+		if item.Titulo == "" && item.FilmeID != nil {
+			if m, ok := metadados[*item.FilmeID]; ok {
+				item.Titulo, item.CaminhoPoster = m.Titulo, m.CaminhoPoster
+			}
+		}
+		if item.Spoiler && !consulta.MostrarSpoilers {
+			item.Comentario = ""
+		}
+	}
+	return itens, proximo, nil
+}
+
+// garantirVisivel confere se o usuário existe, não vai ser excluído e não teve o perfil
+// ocultado pela moderação.
+func (s *seguidorServicoImpl) garantirVisivel(usuarioID int64) error {
+	usuario, err := s.usuarioRepo.BuscarPorID(usuarioID)
+	if err == sql.ErrNoRows {
+		return ErrPerfilNaoEncontrado
+	}
+	if err != nil {
+		return err
+	}
+	if usuario.ExclusaoAgendadaEm != nil {
+		return ErrPerfilNaoEncontrado
+	}
+
+	perfil, err := s.perfilRepo.BuscarPorUsuarioID(usuarioID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if perfil.Oculto {
+		return ErrPerfilNaoEncontrado
+	}
+	return nil
+}
+
+// decodificarCursorID lê um cursor formado apenas pelo ID do último item da página anterior.
+func decodificarCursorID(cursor string, errInvalido error) (int64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(cursor, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, errInvalido
+	}
+	return id, nil
+}
+
+// registrarAtividade grava uma atividade para o feed dos seguidores. A ação do usuário já foi
+// concluída, então uma falha aqui só fica no log.
+func registrarAtividade(repo repositorio.AtividadeRepositorio, atividade *dominio.Atividade) {
+	if err := repo.Registrar(atividade); err != nil {
+		log.Printf("Falha ao registrar a atividade %s do usuário %d: %v", atividade.Tipo, atividade.UsuarioID, err)
+	}
+}
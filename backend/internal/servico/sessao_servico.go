@@ -0,0 +1,121 @@
+package servico
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/auth"
+	"github.com/Andydev0/filmes-backend/internal/dominio"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+)
+
+// ErrSessaoNaoEncontrada é retornado ao revogar uma sessão inexistente ou de outro usuário.
+var ErrSessaoNaoEncontrada = errors.New("sessão não encontrada")
+
+const (
+	// intervaloUltimoAcesso limita a frequência com que o último acesso é gravado.
+	intervaloUltimoAcesso = time.Minute
+
+	// tamanhoMaximoDispositivo evita guardar user-agents arbitrariamente longos.
+	tamanhoMaximoDispositivo = 255
+)
+
+// InfoCliente identifica o dispositivo de onde partiu o login.
+type InfoCliente struct {
+	Dispositivo string
+	IP          string
+}
+
+// SessaoServico define o registro e a revogação das sessões de login.
+type SessaoServico interface {
+	Criar(usuarioID int64, cliente InfoCliente, expiraEm time.Time) (*dominio.Sessao, error)
+	Listar(usuarioID int64, sessaoAtual string) ([]dominio.Sessao, error)
+	Revogar(usuarioID int64, sessaoID string) error
+	RevogarOutras(usuarioID int64, sessaoAtual string) (int64, error)
+	SessaoAtiva(sessaoID string, usuarioID int64) (bool, error)
+}
+
+type sessaoServicoImpl struct {
+	repo repositorio.SessaoRepositorio
+}
+
+// NovoSessaoServico cria o serviço de sessões.
+func NovoSessaoServico(repo repositorio.SessaoRepositorio) SessaoServico {
+	return &sessaoServicoImpl{repo: repo}
+}
+
+// Criar registra uma sessão para um login que acabou de ser concluído.
+func (s *sessaoServicoImpl) Criar(usuarioID int64, cliente InfoCliente, expiraEm time.Time) (*dominio.Sessao, error) {
+	id, err := auth.TokenAleatorio(18)
+	if err != nil {
+		return nil, err
+	}
+
+	dispositivo := cliente.Dispositivo
+	if len(dispositivo) > tamanhoMaximoDispositivo {
+		dispositivo = dispositivo[:tamanhoMaximoDispositivo]
+	}
+
+	sessao := &dominio.Sessao{
+		ID:          id,
+		UsuarioID:   usuarioID,
+		Dispositivo: dispositivo,
+		IP:          cliente.IP,
+		ExpiraEm:    expiraEm,
+	}
+	if err := s.repo.Criar(sessao); err != nil {
+		return nil, err
+	}
+	return sessao, nil
+}
+
+// Listar retorna as sessões ativas do usuário, marcando a da requisição atual.
+func (s *sessaoServicoImpl) Listar(usuarioID int64, sessaoAtual string) ([]dominio.Sessao, error) {
+	sessoes, err := s.repo.ListarAtivas(usuarioID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessoes {
+		sessoes[i].Atual = sessoes[i].ID == sessaoAtual
+	}
+	return sessoes, nil
+}
+
+// Revogar encerra uma sessão específica do usuário.
+func (s *sessaoServicoImpl) Revogar(usuarioID int64, sessaoID string) error {
+	revogada, err := s.repo.Revogar(usuarioID, sessaoID)
+	if err != nil {
+		return err
+	}
+	if !revogada {
+		return ErrSessaoNaoEncontrada
+	}
+	return nil
+}
+
+// RevogarOutras implementa o "sair de todos os outros dispositivos".
+func (s *sessaoServicoImpl) RevogarOutras(usuarioID int64, sessaoAtual string) (int64, error) {
+	return s.repo.RevogarOutras(usuarioID, sessaoAtual)
+}
+
+// SessaoAtiva é usada pelo AuthMiddleware: confirma que a sessão do token
+// pertence ao usuário e não foi revogada, registrando o acesso.
+func (s *sessaoServicoImpl) SessaoAtiva(sessaoID string, usuarioID int64) (bool, error) {
+	sessao, err := s.repo.BuscarPorID(sessaoID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if sessao.UsuarioID != usuarioID || sessao.RevogadaEm != nil || time.Now().After(sessao.ExpiraEm) {
+		return false, nil
+	}
+
+	if err := s.repo.RegistrarAcesso(sessaoID, intervaloUltimoAcesso); err != nil {
+		return false, err
+	}
+	return true, nil
+}
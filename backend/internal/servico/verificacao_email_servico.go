@@ -0,0 +1,89 @@
+package servico
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/Andydev0/filmes-backend/internal/auth"
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+)
+
+// Define os erros que o serviço de verificação de e-mail pode retornar.
+var (
+	ErrEmailJaVerificado        = errors.New("o e-mail da conta já foi verificado")
+	ErrTokenVerificacaoInvalido = errors.New("link de verificação inválido ou expirado")
+)
+
+// ValidadeVerificacaoEmail é o prazo para usar o link enviado ao e-mail.
+const ValidadeVerificacaoEmail = 24 * time.Hour
+
+// VerificacaoEmailServico confirma que o dono da conta tem acesso ao e-mail cadastrado.
+type VerificacaoEmailServico interface {
+	Solicitar(usuarioID int64) error
+	Confirmar(token string) error
+}
+
+type verificacaoEmailServicoImpl struct {
+	usuarioRepo repositorio.UsuarioRepositorio
+	config      *ConfigEmail
+}
+
+// NovaVerificacaoEmailServico cria a instância do serviço de verificação de e-mail.
+func NovaVerificacaoEmailServico(usuarioRepo repositorio.UsuarioRepositorio, config *ConfigEmail) VerificacaoEmailServico {
+	return &verificacaoEmailServicoImpl{usuarioRepo: usuarioRepo, config: config}
+}
+
+// Solicitar envia ao e-mail da conta um link de uso único. Um novo pedido invalida o link anterior.
+func (s *verificacaoEmailServicoImpl) Solicitar(usuarioID int64) error {
+	usuario, err := s.usuarioRepo.BuscarPorID(usuarioID)
+	if err != nil {
+		return err
+	}
+	if usuario.EmailVerificado {
+		return ErrEmailJaVerificado
+	}
+
+	token, err := auth.TokenAleatorio(32)
+	if err != nil {
+		return err
+	}
+	// Só o hash fica no banco; o token vai apenas no e-mail.
+	if err := s.usuarioRepo.CriarVerificacaoEmail(usuario.ID, usuario.Email, hashTokenVerificacao(token), time.Now().Add(ValidadeVerificacaoEmail)); err != nil {
+		return err
+	}
+
+	link, err := url.Parse(s.config.URLVerificacao)
+	if err != nil {
+		return err
+	}
+	parametros := link.Query()
+	parametros.Set("token", token)
+	link.RawQuery = parametros.Encode()
+
+	corpo := "Olá, " + usuario.Nome + "!\n\n" +
+		"Para confirmar o e-mail da sua conta no CineHub, acesse o link abaixo em até 24 horas:\n\n" +
+		link.String() + "\n\n" +
+		"Se você não pediu esta confirmação, ignore esta mensagem."
+	return s.config.Enviador.Enviar(usuario.Email, "Confirme seu e-mail no CineHub", corpo)
+}
+
+// Confirmar consome o token do link e marca o e-mail da conta como verificado.
+func (s *verificacaoEmailServicoImpl) Confirmar(token string) error {
+	confirmado, err := s.usuarioRepo.ConfirmarEmail(hashTokenVerificacao(token), time.Now())
+	if err != nil {
+		return err
+	}
+	if !confirmado {
+		return ErrTokenVerificacaoInvalido
+	}
+	return nil
+}
+
+// hashTokenVerificacao calcula o SHA-256 do token; por ser aleatório e longo, um hash rápido basta.
+func hashTokenVerificacao(token string) string {
+	soma := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(soma[:])
+}
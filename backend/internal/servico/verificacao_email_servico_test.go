@@ -0,0 +1,89 @@
+package servico_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Andydev0/filmes-backend/internal/repositorio"
+	"github.com/Andydev0/filmes-backend/internal/servico"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// caixaDeEntrada guarda os e-mails em vez de enviá-los.
+type caixaDeEntrada struct {
+	para   []string
+	corpos []string
+}
+
+func (c *caixaDeEntrada) Enviar(para, assunto, corpo string) error {
+	c.para = append(c.para, para)
+	c.corpos = append(c.corpos, corpo)
+	return nil
+}
+
+// token extrai o token do link do último e-mail recebido.
+func (c *caixaDeEntrada) token(t *testing.T) string {
+	t.Helper()
+
+	require.NotEmpty(t, c.corpos)
+	for _, linha := range strings.Split(c.corpos[len(c.corpos)-1], "\n") {
+		if strings.HasPrefix(linha, "http") {
+			link, err := url.Parse(linha)
+			require.NoError(t, err)
+			return link.Query().Get("token")
+		}
+	}
+	t.Fatal("e-mail sem link de confirmação")
+	return ""
+}
+
+func novoServicoVerificacaoEmail(db *sqlx.DB) (servico.VerificacaoEmailServico, *caixaDeEntrada) {
+	caixa := &caixaDeEntrada{}
+	config := &servico.ConfigEmail{Enviador: caixa, URLVerificacao: "http://localhost:5173/verificar-email"}
+	return servico.NovaVerificacaoEmailServico(repositorio.NovoUsuarioRepositorio(db), config), caixa
+}
+
+func TestVerificacaoEmailConfirmaComTokenDeUsoUnico(t *testing.T) {
+	db := novoBanco(t)
+	ana := novoUsuario(t, db, "Ana", "ana@example.com")
+	verificacao, caixa := novoServicoVerificacaoEmail(db)
+
+	require.NoError(t, verificacao.Solicitar(ana.ID))
+	require.Equal(t, []string{"ana@example.com"}, caixa.para)
+	token := caixa.token(t)
+
+	// Um novo pedido invalida o link anterior.
+	require.NoError(t, verificacao.Solicitar(ana.ID))
+	assert.ErrorIs(t, verificacao.Confirmar(token), servico.ErrTokenVerificacaoInvalido)
+
+	token = caixa.token(t)
+	require.NoError(t, verificacao.Confirmar(token))
+	assert.ErrorIs(t, verificacao.Confirmar(token), servico.ErrTokenVerificacaoInvalido)
+
+	usuario, err := repositorio.NovoUsuarioRepositorio(db).BuscarPorID(ana.ID)
+	require.NoError(t, err)
+	assert.True(t, usuario.EmailVerificado)
+	assert.ErrorIs(t, verificacao.Solicitar(ana.ID), servico.ErrEmailJaVerificado)
+}
+
+func TestVerificacaoEmailExpiraETrocaDeEndereco(t *testing.T) {
+	db := novoBanco(t)
+	ana := novoUsuario(t, db, "Ana", "ana@example.com")
+	verificacao, caixa := novoServicoVerificacaoEmail(db)
+
+	require.NoError(t, verificacao.Solicitar(ana.ID))
+	db.MustExec("UPDATE verificacoes_email SET expira_em = datetime('now', '-1 minute')")
+	assert.ErrorIs(t, verificacao.Confirmar(caixa.token(t)), servico.ErrTokenVerificacaoInvalido)
+
+	// O link confirma apenas o endereço para o qual foi enviado.
+	require.NoError(t, verificacao.Solicitar(ana.ID))
+	db.MustExec("UPDATE usuarios SET email = 'outra@example.com' WHERE id = ?", ana.ID)
+	assert.ErrorIs(t, verificacao.Confirmar(caixa.token(t)), servico.ErrTokenVerificacaoInvalido)
+
+	usuario, err := repositorio.NovoUsuarioRepositorio(db).BuscarPorID(ana.ID)
+	require.NoError(t, err)
+	assert.False(t, usuario.EmailVerificado)
+}
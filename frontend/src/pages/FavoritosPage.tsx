@@ -15,26 +15,32 @@ const FavoritosPage: React.FC = () => {
   const [carregando, setCarregando] = useState(true);
   const [erro, setErro] = useState<string | null>(null);
 
-  const buscarFavoritos = () => {
+  // A API devolve os favoritos em páginas; o cabeçalho X-Proximo-Cursor indica a próxima.
+  const buscarFavoritos = async () => {
     setCarregando(true);
-    api.get('/favoritos')
-      .then(response => {
-        const filmesMapeados = response.data.map((fav: FilmeFavoritoResponse) => ({
-          id: fav.filmeId,
-          titulo: fav.titulo,
-          caminhoPoster: fav.caminhoPoster,
-          sinopse: '',
-          dataLancamento: '',
-          notaMedia: 0,
-        }));
-        setFavoritos(filmesMapeados);
-      })
-      .catch(() => {
-        setErro('Falha ao carregar seus favoritos. Tente fazer login novamente.');
-      })
-      .finally(() => {
-        setCarregando(false);
-      });
+    try {
+      const todos: FilmeFavoritoResponse[] = [];
+      let cursor: string | undefined;
+      do {
+        const response = await api.get('/favoritos', { params: { limite: 100, cursor } });
+        todos.push(...response.data);
+        cursor = response.headers['x-proximo-cursor'];
+      } while (cursor);
+
+      const filmesMapeados = todos.map((fav: FilmeFavoritoResponse) => ({
+        id: fav.filmeId,
+        titulo: fav.titulo,
+        caminhoPoster: fav.caminhoPoster,
+        sinopse: '',
+        dataLancamento: '',
+        notaMedia: 0,
+      }));
+      setFavoritos(filmesMapeados);
+    } catch {
+      setErro('Falha ao carregar seus favoritos. Tente fazer login novamente.');
+    } finally {
+      setCarregando(false);
+    }
   };
 
   useEffect(() => {